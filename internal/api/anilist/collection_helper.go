@@ -1,6 +1,11 @@
 package anilist
 
-import "time"
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"math"
+	"time"
+)
 
 type (
 	MediaListEntry = AnimeCollection_MediaListCollection_Lists_Entries
@@ -145,6 +150,42 @@ func FuzzyDateToString(d IFuzzyDate) string {
 	return fuzzyDateToString(d.GetYear(), d.GetMonth(), d.GetDay())
 }
 
+// ToFuzzyDateInput returns the date as a mutation input, or nil if it has no year.
+func ToFuzzyDateInput(d IFuzzyDate) *FuzzyDateInput {
+	if d == nil || d.GetYear() == nil || *d.GetYear() <= 0 {
+		return nil
+	}
+	return &FuzzyDateInput{
+		Year:  d.GetYear(),
+		Month: d.GetMonth(),
+		Day:   d.GetDay(),
+	}
+}
+
+// FuzzyDateInputKey returns the date as "YYYY-MM-DD" so that dates can be compared, or an empty string if it is nil.
+func FuzzyDateInputKey(d *FuzzyDateInput) string {
+	if d == nil {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", lo.FromPtr(d.Year), lo.FromPtr(d.Month), lo.FromPtr(d.Day))
+}
+
+// RoundedScore returns the score of a list entry rounded to an integer, or 0 if it is not set.
+func RoundedScore(s *float64) int {
+	if s == nil {
+		return 0
+	}
+	return int(math.Round(*s))
+}
+
+// StatusOrPlanning returns the status of a list entry, or MediaListStatusPlanning if it is not set.
+func StatusOrPlanning(s *MediaListStatus) MediaListStatus {
+	if s == nil {
+		return MediaListStatusPlanning
+	}
+	return *s
+}
+
 func ToEntryStartDate(d *AnimeCollection_MediaListCollection_Lists_Entries_StartedAt) string {
 	if d == nil {
 		return ""
@@ -0,0 +1,127 @@
+package anilist
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/samber/lo"
+	"seanime/internal/util/limiter"
+	"sync"
+)
+
+// completeAnimeChunkSize is the number of complete media fetched by a single AniList request.
+// It is lower than for base media because relations make the query more complex.
+const completeAnimeChunkSize = 20
+
+// AnimeCollectionWithRelationsBuilder builds the collection with relations for platforms whose lists do not come from AniList.
+// Complete media are fetched from AniList in batches and cached in memory.
+type AnimeCollectionWithRelationsBuilder struct {
+	logger  *zerolog.Logger
+	limiter *limiter.Limiter
+	mu      sync.Mutex
+	media   map[int]*CompleteAnime
+	// fetchFunc fetches complete media in bulk, it is replaced in tests
+	fetchFunc func(ids []int) (map[int]*CompleteAnime, error)
+}
+
+func NewAnimeCollectionWithRelationsBuilder(limiter *limiter.Limiter, logger *zerolog.Logger) *AnimeCollectionWithRelationsBuilder {
+	return &AnimeCollectionWithRelationsBuilder{
+		logger:    logger,
+		limiter:   limiter,
+		media:     make(map[int]*CompleteAnime),
+		fetchFunc: FetchCompleteAnimeMap,
+	}
+}
+
+// Build returns the collection with the complete media of each entry.
+// The client is used to fetch media one by one when a batch fails.
+// Entries whose media cannot be fetched are omitted.
+func (b *AnimeCollectionWithRelationsBuilder) Build(anilistClient AnilistClient, collection *AnimeCollection) *AnimeCollectionWithRelations {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ret := &AnimeCollectionWithRelations{
+		MediaListCollection: &AnimeCollectionWithRelations_MediaListCollection{
+			Lists: make([]*AnimeCollectionWithRelations_MediaListCollection_Lists, 0),
+		},
+	}
+	if collection == nil || collection.MediaListCollection == nil {
+		return ret
+	}
+
+	b.fetchMissing(anilistClient, lo.Map(collection.GetAllAnime(), func(m *BaseAnime, _ int) int {
+		return m.GetID()
+	}))
+
+	for _, list := range collection.MediaListCollection.Lists {
+		retList := &AnimeCollectionWithRelations_MediaListCollection_Lists{
+			Status:       list.Status,
+			Name:         list.Name,
+			IsCustomList: list.IsCustomList,
+			Entries:      make([]*AnimeCollectionWithRelations_MediaListCollection_Lists_Entries, 0, len(list.Entries)),
+		}
+		for _, entry := range list.Entries {
+			media, found := b.media[entry.GetMedia().GetID()]
+			if !found {
+				continue
+			}
+			retEntry := &AnimeCollectionWithRelations_MediaListCollection_Lists_Entries{
+				ID:       entry.ID,
+				Score:    entry.Score,
+				Progress: entry.Progress,
+				Status:   entry.Status,
+				Notes:    entry.Notes,
+				Repeat:   entry.Repeat,
+				Private:  entry.Private,
+				Media:    media,
+			}
+			if entry.StartedAt != nil {
+				retEntry.StartedAt = &AnimeCollectionWithRelations_MediaListCollection_Lists_Entries_StartedAt{
+					Year:  entry.StartedAt.Year,
+					Month: entry.StartedAt.Month,
+					Day:   entry.StartedAt.Day,
+				}
+			}
+			if entry.CompletedAt != nil {
+				retEntry.CompletedAt = &AnimeCollectionWithRelations_MediaListCollection_Lists_Entries_CompletedAt{
+					Year:  entry.CompletedAt.Year,
+					Month: entry.CompletedAt.Month,
+					Day:   entry.CompletedAt.Day,
+				}
+			}
+			retList.Entries = append(retList.Entries, retEntry)
+		}
+		ret.MediaListCollection.Lists = append(ret.MediaListCollection.Lists, retList)
+	}
+
+	return ret
+}
+
+// fetchMissing fetches the media that are not cached yet.
+func (b *AnimeCollectionWithRelationsBuilder) fetchMissing(anilistClient AnilistClient, ids []int) {
+	toFetch := lo.Filter(lo.Uniq(ids), func(id int, _ int) bool {
+		_, found := b.media[id]
+		return !found
+	})
+
+	for _, chunk := range lo.Chunk(toFetch, completeAnimeChunkSize) {
+		b.limiter.Wait()
+		res, err := b.fetchFunc(chunk)
+		if err != nil {
+			// A single missing media fails the whole request, fetch the chunk one by one
+			b.logger.Debug().Err(err).Msg("anilist: Failed to fetch complete anime in bulk, fetching one by one")
+			res = make(map[int]*CompleteAnime)
+			for _, id := range chunk {
+				b.limiter.Wait()
+				media, err := anilistClient.CompleteAnimeByID(context.Background(), &id)
+				if err != nil || media.GetMedia() == nil {
+					b.logger.Error().Err(err).Int("mediaId", id).Msg("anilist: Failed to fetch complete anime")
+					continue
+				}
+				res[id] = media.GetMedia()
+			}
+		}
+		for id, media := range res {
+			b.media[id] = media
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package anilist
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seanime/internal/util"
+	"seanime/internal/util/limiter"
+	"testing"
+	"time"
+)
+
+func TestAnimeCollectionWithRelationsBuilder(t *testing.T) {
+	collection := &AnimeCollection{
+		MediaListCollection: &AnimeCollection_MediaListCollection{
+			Lists: []*AnimeCollection_MediaListCollection_Lists{
+				{
+					Entries: make([]*AnimeCollection_MediaListCollection_Lists_Entries, 0),
+				},
+			},
+		},
+	}
+	for id := 1; id <= 45; id++ {
+		progress := id
+		collection.MediaListCollection.Lists[0].Entries = append(collection.MediaListCollection.Lists[0].Entries, &AnimeCollection_MediaListCollection_Lists_Entries{
+			ID:       id,
+			Progress: &progress,
+			Media:    &BaseAnime{ID: id},
+		})
+	}
+
+	builder := NewAnimeCollectionWithRelationsBuilder(limiter.NewLimiter(time.Millisecond, 100), util.NewLogger())
+
+	requests := 0
+	builder.fetchFunc = func(ids []int) (map[int]*CompleteAnime, error) {
+		requests++
+		ret := make(map[int]*CompleteAnime)
+		for _, id := range ids {
+			// Media 45 is not on AniList
+			if id == 45 {
+				continue
+			}
+			ret[id] = &CompleteAnime{ID: id}
+		}
+		return ret, nil
+	}
+
+	ret := builder.Build(nil, collection)
+	require.Len(t, ret.MediaListCollection.Lists, 1)
+	entries := ret.MediaListCollection.Lists[0].Entries
+	require.Len(t, entries, 44)
+	assert.Equal(t, 10, entries[9].GetMedia().GetID())
+	assert.Equal(t, 10, *entries[9].Progress)
+	// The media are fetched in batches
+	assert.Equal(t, 3, requests)
+
+	// Only the missing media is fetched again
+	builder.Build(nil, collection)
+	assert.Equal(t, 4, requests)
+}
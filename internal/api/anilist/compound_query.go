@@ -5,6 +5,7 @@ import (
 	"github.com/goccy/go-json"
 	"seanime/internal/util"
 	"strconv"
+	"strings"
 )
 
 func FetchBaseAnimeMap(ids []int) (ret map[int]*BaseAnime, err error) {
@@ -48,6 +49,132 @@ func FetchBaseAnimeMap(ids []int) (ret map[int]*BaseAnime, err error) {
 	return ret, nil
 }
 
+// FetchBaseAnimeMapByMalIDs returns the AniList media matching the given MyAnimeList IDs.
+// The returned map is keyed by MyAnimeList ID, IDs that have no match on AniList are omitted.
+func FetchBaseAnimeMapByMalIDs(malIds []int) (ret map[int]*BaseAnime, err error) {
+
+	query := fmt.Sprintf(CompoundBaseAnimeDocument, newCompoundMalIdQuery(malIds, "ANIME", "baseAnime"))
+
+	var res map[string]*BaseAnime
+	if err = doCompoundQuery(query, &res); err != nil {
+		return nil, err
+	}
+
+	ret = make(map[int]*BaseAnime)
+	for k, v := range res {
+		if v == nil {
+			continue
+		}
+		id, err := strconv.Atoi(k[1:])
+		if err != nil {
+			return nil, err
+		}
+		ret[id] = v
+	}
+
+	return ret, nil
+}
+
+// FetchBaseMangaMapByMalIDs returns the AniList media matching the given MyAnimeList IDs.
+// The returned map is keyed by MyAnimeList ID, IDs that have no match on AniList are omitted.
+func FetchBaseMangaMapByMalIDs(malIds []int) (ret map[int]*BaseManga, err error) {
+
+	query := fmt.Sprintf(CompoundBaseMangaDocument, newCompoundMalIdQuery(malIds, "MANGA", "baseManga"))
+
+	var res map[string]*BaseManga
+	if err = doCompoundQuery(query, &res); err != nil {
+		return nil, err
+	}
+
+	ret = make(map[int]*BaseManga)
+	for k, v := range res {
+		if v == nil {
+			continue
+		}
+		id, err := strconv.Atoi(k[1:])
+		if err != nil {
+			return nil, err
+		}
+		ret[id] = v
+	}
+
+	return ret, nil
+}
+
+// FetchCompleteAnimeMap returns the complete media, including relations, matching the given AniList IDs.
+func FetchCompleteAnimeMap(ids []int) (ret map[int]*CompleteAnime, err error) {
+
+	query := "query CompoundCompleteAnime {\n" + newCompoundIdQuery(ids, "completeAnime") + "\n}\n" + completeAnimeFragments
+
+	var res map[string]*CompleteAnime
+	if err = doCompoundQuery(query, &res); err != nil {
+		return nil, err
+	}
+
+	ret = make(map[int]*CompleteAnime)
+	for k, v := range res {
+		if v == nil {
+			continue
+		}
+		id, err := strconv.Atoi(k[1:])
+		if err != nil {
+			return nil, err
+		}
+		ret[id] = v
+	}
+
+	return ret, nil
+}
+
+// completeAnimeFragments are the fragments of the generated CompleteAnimeByID query.
+var completeAnimeFragments = CompleteAnimeByIDDocument[strings.Index(CompleteAnimeByIDDocument, "fragment completeAnime"):]
+
+func doCompoundQuery(query string, res interface{}) error {
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": nil,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := customQuery(requestBody, util.NewLogger())
+	if err != nil {
+		return err
+	}
+
+	dataB, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(dataB, res)
+}
+
+func newCompoundMalIdQuery(malIds []int, mediaType string, fragment string) string {
+	var query string
+	for _, id := range malIds {
+		query += fmt.Sprintf(`
+		t%d: Media(idMal: %d, type: %s) {
+			...%s
+		}
+		`, id, id, mediaType, fragment)
+	}
+	return query
+}
+
+func newCompoundIdQuery(ids []int, fragment string) string {
+	var query string
+	for _, id := range ids {
+		query += fmt.Sprintf(`
+		t%d: Media(id: %d) {
+			...%s
+		}
+		`, id, id, fragment)
+	}
+	return query
+}
+
 func newCompoundQuery(ids []int) string {
 	var query string
 	for _, id := range ids {
@@ -113,3 +240,47 @@ fragment baseAnime on Media {
 		episode
 	}
 }`
+
+const CompoundBaseMangaDocument = `query CompoundQueryTest {
+%s
+}
+fragment baseManga on Media {
+	id
+	idMal
+	siteUrl
+	status(version: 2)
+	season
+	type
+	format
+	bannerImage
+	chapters
+	volumes
+	synonyms
+	isAdult
+	countryOfOrigin
+	meanScore
+	description
+	genres
+	title {
+		userPreferred
+		romaji
+		english
+		native
+	}
+	coverImage {
+		extraLarge
+		large
+		medium
+		color
+	}
+	startDate {
+		year
+		month
+		day
+	}
+	endDate {
+		year
+		month
+		day
+	}
+}`
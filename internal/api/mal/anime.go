@@ -50,7 +50,10 @@ type (
 			Status             MediaListStatus `json:"status"`
 			IsRewatching       bool            `json:"is_rewatching"`
 			NumEpisodesWatched int             `json:"num_episodes_watched"`
+			NumTimesRewatched  int             `json:"num_times_rewatched"`
 			Score              int             `json:"score"`
+			StartDate          string          `json:"start_date"`
+			FinishDate         string          `json:"finish_date"`
 			UpdatedAt          string          `json:"updated_at"`
 		} `json:"list_status"`
 	}
@@ -80,22 +83,29 @@ func (w *Wrapper) GetAnimeDetails(mId int) (*BasicAnime, error) {
 func (w *Wrapper) GetAnimeCollection() ([]*AnimeListEntry, error) {
 	w.logger.Debug().Msg("mal: Getting anime collection")
 
-	reqUrl := fmt.Sprintf("%s/users/@me/animelist?fields=list_status&limit=1000", ApiBaseURL)
+	reqUrl := fmt.Sprintf("%s/users/@me/animelist?fields=list_status&limit=1000&nsfw=true", ApiBaseURL)
 
 	type response struct {
-		Data []*AnimeListEntry `json:"data"`
+		Data   []*AnimeListEntry `json:"data"`
+		Paging Paging            `json:"paging"`
 	}
 
-	var data response
-	err := w.doQuery("GET", reqUrl, nil, "application/json", &data)
-	if err != nil {
-		w.logger.Error().Err(err).Msg("mal: Failed to get anime collection")
-		return nil, err
+	ret := make([]*AnimeListEntry, 0)
+	// Follow the pagination until the whole list is fetched
+	for reqUrl != "" {
+		var data response
+		err := w.doQuery("GET", reqUrl, nil, "application/json", &data)
+		if err != nil {
+			w.logger.Error().Err(err).Msg("mal: Failed to get anime collection")
+			return nil, err
+		}
+		ret = append(ret, data.Data...)
+		reqUrl = data.Paging.Next
 	}
 
 	w.logger.Info().Msg("mal: Fetched anime collection")
 
-	return data.Data, nil
+	return ret, nil
 }
 
 type AnimeListProgressParams struct {
@@ -138,6 +148,8 @@ type AnimeListStatusParams struct {
 	IsRewatching       *bool
 	NumEpisodesWatched *int
 	Score              *int
+	StartDate          *string // YYYY-MM-DD
+	FinishDate         *string // YYYY-MM-DD
 }
 
 func (w *Wrapper) UpdateAnimeListStatus(opts *AnimeListStatusParams, mId int) error {
@@ -159,6 +171,12 @@ func (w *Wrapper) UpdateAnimeListStatus(opts *AnimeListStatusParams, mId int) er
 	if opts.Score != nil {
 		urlData.Set("score", fmt.Sprintf("%d", *opts.Score))
 	}
+	if opts.StartDate != nil {
+		urlData.Set("start_date", *opts.StartDate)
+	}
+	if opts.FinishDate != nil {
+		urlData.Set("finish_date", *opts.FinishDate)
+	}
 	encodedData := urlData.Encode()
 
 	err := w.doMutation("PATCH", reqUrl, encodedData)
@@ -49,7 +49,10 @@ type (
 			IsRereading     bool            `json:"is_rereading"`
 			NumVolumesRead  int             `json:"num_volumes_read"`
 			NumChaptersRead int             `json:"num_chapters_read"`
+			NumTimesReread  int             `json:"num_times_reread"`
 			Score           int             `json:"score"`
+			StartDate       string          `json:"start_date"`
+			FinishDate      string          `json:"finish_date"`
 			UpdatedAt       string          `json:"updated_at"`
 		} `json:"list_status"`
 	}
@@ -79,22 +82,29 @@ func (w *Wrapper) GetMangaDetails(mId int) (*BasicManga, error) {
 func (w *Wrapper) GetMangaCollection() ([]*MangaListEntry, error) {
 	w.logger.Debug().Msg("mal: Getting manga collection")
 
-	reqUrl := fmt.Sprintf("%s/users/@me/mangalist?fields=list_status&limit=1000", ApiBaseURL)
+	reqUrl := fmt.Sprintf("%s/users/@me/mangalist?fields=list_status&limit=1000&nsfw=true", ApiBaseURL)
 
 	type response struct {
-		Data []*MangaListEntry `json:"data"`
+		Data   []*MangaListEntry `json:"data"`
+		Paging Paging            `json:"paging"`
 	}
 
-	var data response
-	err := w.doQuery("GET", reqUrl, nil, "application/json", &data)
-	if err != nil {
-		w.logger.Error().Err(err).Msg("mal: Failed to get manga collection")
-		return nil, err
+	ret := make([]*MangaListEntry, 0)
+	// Follow the pagination until the whole list is fetched
+	for reqUrl != "" {
+		var data response
+		err := w.doQuery("GET", reqUrl, nil, "application/json", &data)
+		if err != nil {
+			w.logger.Error().Err(err).Msg("mal: Failed to get manga collection")
+			return nil, err
+		}
+		ret = append(ret, data.Data...)
+		reqUrl = data.Paging.Next
 	}
 
 	w.logger.Info().Msg("mal: Fetched manga collection")
 
-	return data.Data, nil
+	return ret, nil
 }
 
 type MangaListProgressParams struct {
@@ -137,6 +147,8 @@ type MangaListStatusParams struct {
 	IsRereading     *bool
 	NumChaptersRead *int
 	Score           *int
+	StartDate       *string // YYYY-MM-DD
+	FinishDate      *string // YYYY-MM-DD
 }
 
 func (w *Wrapper) UpdateMangaListStatus(opts *MangaListStatusParams, mId int) error {
@@ -158,6 +170,12 @@ func (w *Wrapper) UpdateMangaListStatus(opts *MangaListStatusParams, mId int) er
 	if opts.Score != nil {
 		urlData.Set("score", fmt.Sprintf("%d", *opts.Score))
 	}
+	if opts.StartDate != nil {
+		urlData.Set("start_date", *opts.StartDate)
+	}
+	if opts.FinishDate != nil {
+		urlData.Set("finish_date", *opts.FinishDate)
+	}
 	encodedData := urlData.Encode()
 
 	err := w.doMutation("PATCH", reqUrl, encodedData)
@@ -9,6 +9,12 @@ type (
 		ExpiresAt    time.Time
 	}
 
+	// Paging is returned by paginated endpoints.
+	Paging struct {
+		Previous string `json:"previous,omitempty"`
+		Next     string `json:"next,omitempty"`
+	}
+
 	MediaType       string
 	MediaStatus     string
 	MediaListStatus string
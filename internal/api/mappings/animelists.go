@@ -9,15 +9,19 @@ import (
 type (
 
 	// ReducedAnimeListResponse is the response from the reduced anime list API.
-	// It does not contain AniList ids for example.
+	// Items are indexed by AniDB, MyAnimeList and TheTVDB ID, the AniList ID is only set for some items.
 	ReducedAnimeListResponse struct {
 		items          []*ReducedAnimeListItem
 		itemsByAnidbID map[int]*ReducedAnimeListItem
+		itemsByMalID   map[int]*ReducedAnimeListItem
+		itemsByTvdbID  map[int][]*ReducedAnimeListItem
 		Count          int
 	}
 	ReducedAnimeListItem struct {
 		TheTvdbID interface{} `json:"thetvdb_id,omitempty"`
 		AnidbID   int         `json:"anidb_id,omitempty"`
+		MalID     int         `json:"mal_id,omitempty"`
+		AnilistID int         `json:"anilist_id,omitempty"`
 	}
 )
 
@@ -40,19 +44,33 @@ func GetReducedAnimeLists() (resp *ReducedAnimeListResponse, err error) {
 		return nil, err
 	}
 
+	return NewReducedAnimeListResponse(items), nil
+}
+
+// NewReducedAnimeListResponse indexes the given items.
+func NewReducedAnimeListResponse(items []*ReducedAnimeListItem) *ReducedAnimeListResponse {
 	itemsByAnidbID := make(map[int]*ReducedAnimeListItem)
+	itemsByMalID := make(map[int]*ReducedAnimeListItem)
+	itemsByTvdbID := make(map[int][]*ReducedAnimeListItem)
 	for _, item := range items {
-		if item.AnidbID == 0 {
-			continue
+		if item.AnidbID != 0 {
+			itemsByAnidbID[item.AnidbID] = item
+		}
+		if item.MalID != 0 {
+			itemsByMalID[item.MalID] = item
+		}
+		if tvdbID, ok := item.GetTvdbID(); ok && tvdbID != 0 {
+			itemsByTvdbID[tvdbID] = append(itemsByTvdbID[tvdbID], item)
 		}
-		itemsByAnidbID[item.AnidbID] = item
 	}
 
 	return &ReducedAnimeListResponse{
 		items:          items,
 		itemsByAnidbID: itemsByAnidbID,
+		itemsByMalID:   itemsByMalID,
+		itemsByTvdbID:  itemsByTvdbID,
 		Count:          len(items),
-	}, nil
+	}
 }
 
 func (i *ReducedAnimeListResponse) GetItems() []*ReducedAnimeListItem {
@@ -78,6 +96,53 @@ func (i *ReducedAnimeListResponse) FindTvdbIDFromAnidbID(anidbID int) (tvdbID in
 	return item.GetTvdbID()
 }
 
+// FindAnilistIDFromMalID will return the AniList ID for the given MyAnimeList ID.
+// If the MyAnimeList ID is not found, the second return value will be false, and the first return value will be 0.
+func (i *ReducedAnimeListResponse) FindAnilistIDFromMalID(malID int) (anilistID int, ok bool) {
+	if i == nil {
+		return 0, false
+	}
+
+	item, ok := i.itemsByMalID[malID]
+	if !ok || item.AnilistID == 0 {
+		return 0, false
+	}
+
+	return item.AnilistID, true
+}
+
+// FindAnilistIDFromAnidbID will return the AniList ID for the given AniDB ID.
+// If the AniDB ID is not found, the second return value will be false, and the first return value will be 0.
+func (i *ReducedAnimeListResponse) FindAnilistIDFromAnidbID(anidbID int) (anilistID int, ok bool) {
+	if i == nil {
+		return 0, false
+	}
+
+	item, ok := i.itemsByAnidbID[anidbID]
+	if !ok || item.AnilistID == 0 {
+		return 0, false
+	}
+
+	return item.AnilistID, true
+}
+
+// FindAnilistIDsFromTvdbID will return the AniList IDs for the given TVDB ID.
+// A TVDB series usually groups several seasons, so several AniList IDs can be returned.
+func (i *ReducedAnimeListResponse) FindAnilistIDsFromTvdbID(tvdbID int) []int {
+	ret := make([]int, 0)
+	if i == nil {
+		return ret
+	}
+
+	for _, item := range i.itemsByTvdbID[tvdbID] {
+		if item.AnilistID != 0 {
+			ret = append(ret, item.AnilistID)
+		}
+	}
+
+	return ret
+}
+
 func (i *ReducedAnimeListItem) GetTvdbID() (int, bool) {
 	if i == nil {
 		return 0, false
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"seanime/internal/core"
+	"slices"
+	"strings"
+)
+
+// Exit codes of the commands, so that they can be used in cron jobs and scripts.
+const (
+	ExitSuccess = 0
+	ExitFailure = 1 // The command failed
+	ExitUsage   = 2 // Unknown command or invalid arguments
+)
+
+var ErrUsage = errors.New("invalid usage")
+
+type (
+	// Options are the options of Run.
+	Options struct {
+		Args   []string         // The command and its arguments, e.g. ["db", "backup"]
+		Stdout io.Writer        // Output of the command, defaults to os.Stdout
+		Stderr io.Writer        // Errors, defaults to os.Stderr
+		NewApp func() *core.App // Creates the app for the commands that need it, it should be headless
+	}
+
+	command struct {
+		name        []string
+		usage       string
+		minArgs     int
+		maxArgs     int // -1 for no limit
+		needsApp    bool
+		description string
+		run         func(ctx *commandCtx, args []string) error
+	}
+
+	commandCtx struct {
+		app    *core.App
+		stdout io.Writer
+	}
+)
+
+var commands = []*command{
+	{
+		name:        []string{"scan"},
+		needsApp:    true,
+		description: "scan the library",
+		run:         runScan,
+	},
+	{
+		name:        []string{"parse"},
+		usage:       "<filename>",
+		minArgs:     1,
+		maxArgs:     1,
+		description: "print the parsed data of a filename as JSON",
+		run:         runParse,
+	},
+	{
+		name:        []string{"autodownloader", "run"},
+		needsApp:    true,
+		description: "check the Auto Downloader rules for new episodes",
+		run:         runAutoDownloader,
+	},
+	{
+		name:        []string{"snapshot", "create"},
+		usage:       "[mediaId...]",
+		maxArgs:     -1,
+		needsApp:    true,
+		description: "create an offline snapshot, downloading the given media",
+		run:         runCreateSnapshot,
+	},
+	{
+		name:        []string{"extensions", "install"},
+		usage:       "<manifest uri>",
+		minArgs:     1,
+		maxArgs:     1,
+		needsApp:    true,
+		description: "install or update an extension from its manifest URI",
+		run:         runInstallExtension,
+	},
+	{
+		name:        []string{"db", "backup"},
+		usage:       "[destination]",
+		maxArgs:     1,
+		needsApp:    true,
+		description: "save a copy of the database, in the data directory by default",
+		run:         runBackupDatabase,
+	},
+}
+
+// Run runs the command given by the arguments without starting the server and returns its exit code.
+func Run(opts *Options) int {
+	stdout, stderr := opts.Stdout, opts.Stderr
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	cmd, args, found := findCommand(opts.Args)
+	if !found {
+		_, _ = fmt.Fprintf(stderr, "Unknown command \"%s\"\n\n", strings.Join(opts.Args, " "))
+		PrintUsage(stderr)
+		return ExitUsage
+	}
+
+	if len(args) < cmd.minArgs || (cmd.maxArgs >= 0 && len(args) > cmd.maxArgs) {
+		_, _ = fmt.Fprintf(stderr, "Usage: seanime %s\n", cmd.String())
+		return ExitUsage
+	}
+
+	ctx := &commandCtx{stdout: stdout}
+	if cmd.needsApp {
+		ctx.app = opts.NewApp()
+		defer ctx.app.Cleanup()
+	}
+
+	if err := cmd.run(ctx, args); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %s\n", err.Error())
+		if errors.Is(err, ErrUsage) {
+			_, _ = fmt.Fprintf(stderr, "Usage: seanime %s\n", cmd.String())
+			return ExitUsage
+		}
+		return ExitFailure
+	}
+
+	return ExitSuccess
+}
+
+// PrintUsage prints the available commands.
+func PrintUsage(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "Commands:\n")
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(w, "  %-30s %s\n", cmd.String(), cmd.description)
+	}
+}
+
+func (c *command) String() string {
+	if c.usage == "" {
+		return strings.Join(c.name, " ")
+	}
+	return strings.Join(c.name, " ") + " " + c.usage
+}
+
+// findCommand returns the command with the longest name that the arguments start with, and the remaining arguments.
+func findCommand(args []string) (*command, []string, bool) {
+	var ret *command
+	for _, cmd := range commands {
+		if len(args) < len(cmd.name) || !slices.Equal(args[:len(cmd.name)], cmd.name) {
+			continue
+		}
+		if ret == nil || len(cmd.name) > len(ret.name) {
+			ret = cmd
+		}
+	}
+	if ret == nil {
+		return nil, nil, false
+	}
+	return ret, args[len(ret.name):], true
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seanime/internal/core"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		args         []string
+		expectedCode int
+	}{
+		{
+			name:         "No command",
+			args:         []string{},
+			expectedCode: ExitUsage,
+		},
+		{
+			name:         "Unknown command",
+			args:         []string{"foo"},
+			expectedCode: ExitUsage,
+		},
+		{
+			name:         "Missing subcommand",
+			args:         []string{"snapshot"},
+			expectedCode: ExitUsage,
+		},
+		{
+			name:         "Missing argument",
+			args:         []string{"parse"},
+			expectedCode: ExitUsage,
+		},
+		{
+			name:         "Too many arguments",
+			args:         []string{"db", "backup", "a", "b"},
+			expectedCode: ExitUsage,
+		},
+		{
+			name:         "Parse",
+			args:         []string{"parse", "[SubsPlease] Jujutsu Kaisen - 24 (1080p) [1C5A7B8B].mkv"},
+			expectedCode: ExitSuccess,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := Run(&Options{
+				Args:   tt.args,
+				Stdout: &stdout,
+				Stderr: &stderr,
+				NewApp: func() *core.App {
+					t.Fatal("the app should not be created")
+					return nil
+				},
+			})
+			assert.Equal(t, tt.expectedCode, code, stderr.String())
+		})
+	}
+
+}
+
+func TestRun_Parse(t *testing.T) {
+	var stdout bytes.Buffer
+	code := Run(&Options{
+		Args:   []string{"parse", "[SubsPlease] Jujutsu Kaisen - 24 (1080p) [1C5A7B8B].mkv"},
+		Stdout: &stdout,
+	})
+	require.Equal(t, ExitSuccess, code)
+
+	var res map[string]interface{}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &res))
+
+	assert.Equal(t, "Jujutsu Kaisen", res["title"])
+	assert.Equal(t, []interface{}{"24"}, res["episode_number"])
+	assert.Equal(t, "SubsPlease", res["release_group"])
+}
+
+func TestFindCommand(t *testing.T) {
+	cmd, args, found := findCommand([]string{"db", "backup", "/tmp/seanime.db"})
+	require.True(t, found)
+	assert.Equal(t, []string{"db", "backup"}, cmd.name)
+	assert.Equal(t, []string{"/tmp/seanime.db"}, args)
+
+	_, _, found = findCommand([]string{"db"})
+	assert.False(t, found)
+}
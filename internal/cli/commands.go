@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	"os"
+	"path/filepath"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/offline"
+	"seanime/internal/util"
+	"seanime/seanime-parser"
+	"strconv"
+	"time"
+)
+
+func runScan(ctx *commandCtx, _ []string) error {
+	if ctx.app.AutoScanner == nil {
+		return errors.New("scanner is not initialized")
+	}
+
+	if err := ctx.app.AutoScanner.RunNow(); err != nil {
+		return err
+	}
+
+	lfs, _, err := db_bridge.GetLocalFiles(ctx.app.Database)
+	if err != nil {
+		return err
+	}
+
+	matched := 0
+	for _, lf := range lfs {
+		if lf.MediaId != 0 {
+			matched++
+		}
+	}
+
+	_, _ = fmt.Fprintf(ctx.stdout, "Library scanned: %d %s, %d matched\n", len(lfs), util.Pluralize(len(lfs), "file", "files"), matched)
+	return nil
+}
+
+func runParse(ctx *commandCtx, args []string) error {
+	data, err := json.MarshalIndent(seanime_parser.Parse(args[0]), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(ctx.stdout, string(data))
+	return nil
+}
+
+func runAutoDownloader(ctx *commandCtx, _ []string) error {
+	downloaded, err := ctx.app.AutoDownloader.CheckForNewEpisodes()
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(ctx.stdout, "Auto Downloader: %d new %s\n", downloaded, util.Pluralize(downloaded, "episode", "episodes"))
+	return nil
+}
+
+func runCreateSnapshot(ctx *commandCtx, args []string) error {
+	if ctx.app.OfflineHub == nil {
+		return errors.New("offline hub is not initialized")
+	}
+
+	mediaIds := make([]int, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("%w: \"%s\" is not a media id", ErrUsage, arg)
+		}
+		mediaIds = append(mediaIds, id)
+	}
+
+	err := ctx.app.OfflineHub.CreateSnapshot(&offline.NewSnapshotOptions{
+		AnimeToDownload:  mediaIds,
+		DownloadAssetsOf: mediaIds,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(ctx.stdout, "Offline snapshot created")
+	return nil
+}
+
+func runInstallExtension(ctx *commandCtx, args []string) error {
+	res, err := ctx.app.ExtensionRepository.InstallExternalExtension(args[0])
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(ctx.stdout, res.Message)
+	return nil
+}
+
+// runBackupDatabase saves a copy of the database to the destination, or to the "backups" folder of the data directory.
+// If the destination is a directory, the copy is saved inside it.
+func runBackupDatabase(ctx *commandCtx, args []string) error {
+	filename := fmt.Sprintf("%s-%s.db", ctx.app.Config.Database.Name, time.Now().Format("2006-01-02_15-04-05"))
+
+	dest := filepath.Join(ctx.app.Config.Data.AppDataDir, "backups", filename)
+	if len(args) > 0 {
+		dest = args[0]
+		if info, err := os.Stat(dest); err == nil && info.IsDir() {
+			dest = filepath.Join(dest, filename)
+		}
+	}
+
+	if err := ctx.app.Database.Backup(dest); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(ctx.stdout, "Database saved to %s\n", dest)
+	return nil
+}
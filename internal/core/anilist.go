@@ -30,6 +30,7 @@ func (a *App) GetAccount() (*models.Account, error) {
 func (a *App) UpdateAnilistClientToken(token string) {
 	a.AnilistClient = anilist.NewAnilistClient(token)
 	a.AnilistPlatform.SetAnilistClient(a.AnilistClient) // Update Anilist Client Wrapper in Platform
+	a.ListSync.SetAnilistClient(a.AnilistClient)        // Update Anilist Client Wrapper in List Sync
 }
 
 // GetAnimeCollection returns the user's Anilist collection if it in the cache, otherwise it queries Anilist for the user's collection.
@@ -20,19 +20,26 @@ import (
 	"seanime/internal/library/fillermanager"
 	"seanime/internal/library/playbackmanager"
 	"seanime/internal/library/scanner"
+	"seanime/internal/listimport"
+	"seanime/internal/listsync"
 	"seanime/internal/manga"
+	"seanime/internal/mediaplayers/kodi"
 	"seanime/internal/mediaplayers/mediaplayer"
 	"seanime/internal/mediaplayers/mpchc"
 	"seanime/internal/mediaplayers/mpv"
+	"seanime/internal/mediaplayers/upnp"
 	"seanime/internal/mediaplayers/vlc"
 	"seanime/internal/mediastream"
 	"seanime/internal/offline"
 	"seanime/internal/onlinestream"
 	"seanime/internal/platforms/anilist_platform"
+	"seanime/internal/platforms/local_platform"
+	"seanime/internal/platforms/mal_platform"
 	"seanime/internal/platforms/platform"
 	"seanime/internal/torrent_clients/torrent_client"
 	"seanime/internal/torrents/torrent"
 	"seanime/internal/torrentstream"
+	"seanime/internal/tracker"
 	"seanime/internal/updater"
 	"seanime/internal/util"
 	"seanime/internal/util/filecache"
@@ -50,6 +57,7 @@ type (
 		AnizipCache                   *anizip.Cache // AnizipCache holds fetched AniZip media for 30 minutes. (used by route handlers)
 		AnilistClient                 anilist.AnilistClient
 		AnilistPlatform               platform.Platform
+		AnilistMutationQueue          *anilist_platform.MutationQueue
 		FillerManager                 *fillermanager.FillerManager
 		WSEventManager                *events.WSEventManager
 		AutoDownloader                *autodownloader.AutoDownloader
@@ -59,12 +67,15 @@ type (
 			VLC   *vlc.VLC
 			MpcHc *mpchc.MpcHc
 			Mpv   *mpv.Mpv
+			Kodi  *kodi.Kodi
+			Upnp  *upnp.Renderer
 		}
 		MediaPlayerRepository   *mediaplayer.Repository
 		Version                 string
 		Updater                 *updater.Updater
 		Settings                *models.Settings
 		AutoScanner             *autoscanner.AutoScanner
+		ScanTracker             *scanner.ScanTracker // Keeps track of the scans in progress so that they can be cancelled
 		PlaybackManager         *playbackmanager.PlaybackManager
 		FileCacher              *filecache.Cacher
 		OnlinestreamRepository  *onlinestream.Repository
@@ -74,6 +85,9 @@ type (
 		MangaDownloader         *manga.Downloader
 		Cleanups                []func()
 		OfflineHub              *offline.Hub
+		ListSync                *listsync.ListSync
+		ListImporter            *listimport.Importer
+		TrackerRepository       *tracker.Repository
 		MediastreamRepository   *mediastream.Repository
 		TorrentstreamRepository *torrentstream.Repository
 		FeatureFlags            FeatureFlags
@@ -90,6 +104,7 @@ type (
 		rawMangaCollection *anilist.MangaCollection // (retains custom lists)
 		account            *models.Account
 		previousVersion    string
+		headless           bool
 		moduleMu           sync.Mutex
 	}
 )
@@ -139,6 +154,7 @@ func NewApp(configOpts *ConfigOptions, selfupdater *updater.SelfUpdater) *App {
 
 	database.TrimLocalFileEntries()     // ran in goroutine
 	database.TrimScanSummaryEntries()   // ran in goroutine
+	database.TrimOrganizerJournals()    // ran in goroutine
 	database.TrimTorrentstreamHistory() // ran in goroutine
 
 	// Get token from stored account or return empty string
@@ -150,8 +166,29 @@ func NewApp(configOpts *ConfigOptions, selfupdater *updater.SelfUpdater) *App {
 	// Websocket Event Manager
 	wsEventManager := events.NewWSEventManager(logger)
 
+	// AniList Mutation Queue
+	// List mutations are saved before being sent to AniList so that they are not lost
+	anilistMutationQueue := anilist_platform.NewMutationQueue(&anilist_platform.NewMutationQueueOptions{
+		Logger:         logger,
+		Database:       database,
+		WSEventManager: wsEventManager,
+	})
+
 	// Anilist Platform
-	anilistPlatform := anilist_platform.NewAnilistPlatform(anilistCW, logger)
+	// The local and MAL platforms keep the list data elsewhere and only use AniList for metadata
+	var anilistPlatform platform.Platform
+	switch cfg.Platform.Type {
+	case PlatformTypeLocal:
+		anilistPlatform, err = local_platform.NewLocalPlatform(cfg.Data.AppDataDir, anilistCW, logger)
+		if err != nil {
+			logger.Fatal().Err(err).Msgf("app: Failed to initialize local platform")
+		}
+	case PlatformTypeMal:
+		anilistPlatform = mal_platform.NewMalPlatform(database, anilistCW, logger)
+	default:
+		anilistPlatform = anilist_platform.NewAnilistPlatformWithMutationQueue(anilistCW, anilistMutationQueue, logger)
+	}
+	logger.Info().Str("type", cfg.Platform.Type).Msg("app: Platform initialized")
 
 	// AniZip Cache
 	anizipCache := anizip.NewCache()
@@ -200,6 +237,7 @@ func NewApp(configOpts *ConfigOptions, selfupdater *updater.SelfUpdater) *App {
 		Database:                      database,
 		AnilistClient:                 anilistCW,
 		AnilistPlatform:               anilistPlatform,
+		AnilistMutationQueue:          anilistMutationQueue,
 		AnizipCache:                   anizipCache,
 		WSEventManager:                wsEventManager,
 		Logger:                        logger,
@@ -217,13 +255,18 @@ func NewApp(configOpts *ConfigOptions, selfupdater *updater.SelfUpdater) *App {
 		PlaybackManager:               nil, // Initialized in App.initModulesOnce
 		AutoDownloader:                nil, // Initialized in App.initModulesOnce
 		AutoScanner:                   nil, // Initialized in App.initModulesOnce
+		ScanTracker:                   scanner.NewScanTracker(),
 		MediastreamRepository:         nil, // Initialized in App.initModulesOnce
 		TorrentstreamRepository:       nil, // Initialized in App.initModulesOnce
 		OfflineHub:                    nil, // Initialized in App.initModulesOnce
+		ListSync:                      nil, // Initialized in App.initModulesOnce
+		ListImporter:                  nil, // Initialized in App.initModulesOnce
+		TrackerRepository:             nil, // Initialized in App.initModulesOnce
 		TorrentClientRepository:       nil, // Initialized in App.InitOrRefreshModules
 		MediaPlayerRepository:         nil, // Initialized in App.InitOrRefreshModules
 		DiscordPresence:               nil, // Initialized in App.InitOrRefreshModules
 		previousVersion:               previousVersion,
+		headless:                      configOpts.Headless,
 		FeatureFlags:                  NewFeatureFlags(cfg, logger),
 		SecondarySettings: struct {
 			Mediastream   *models.MediastreamSettings
@@ -259,7 +302,10 @@ func NewApp(configOpts *ConfigOptions, selfupdater *updater.SelfUpdater) *App {
 	app.InitOrRefreshTorrentstreamSettings()
 
 	// Perform actions that need to be done after the app has been initialized
-	app.performActionsOnce()
+	// Commands do their own work, e.g. the library is not refreshed on start
+	if !app.headless {
+		app.performActionsOnce()
+	}
 
 	return app
 }
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"seanime/internal/constants"
 	"seanime/internal/util"
+	"slices"
 	"strconv"
 )
 
@@ -51,12 +52,25 @@ type Config struct {
 	Anilist struct {
 		ClientID string
 	}
+	Platform struct {
+		Type string // "anilist", "local" or "mal", see PlatformType
+		// Trackers are the other lists progress updates are pushed to, "anilist" and/or "mal".
+		// They are opt-in so that a local list is never sent anywhere unless the user asks for it.
+		Trackers []string
+	}
 }
 
+const (
+	PlatformTypeAnilist = "anilist" // List data is stored on AniList
+	PlatformTypeLocal   = "local"   // List data is stored in a local database, AniList is only used for metadata
+	PlatformTypeMal     = "mal"     // List data is stored on MyAnimeList, AniList is only used for metadata
+)
+
 type ConfigOptions struct {
 	DataDir         string // The path to the Seanime data directory, if any
 	OnVersionChange []func(oldVersion string, newVersion string)
 	EmbeddedLogo    []byte // The embedded logo
+	Headless        bool   // The app is used by a command, the server is not started
 }
 
 // NewConfig initializes the config
@@ -116,6 +130,8 @@ func NewConfig(options *ConfigOptions, logger *zerolog.Logger) (*Config, error)
 	viper.SetDefault("offline.dir", "$SEANIME_DATA_DIR/offline")
 	viper.SetDefault("offline.assetDir", "$SEANIME_DATA_DIR/offline/assets")
 	viper.SetDefault("extensions.dir", "$SEANIME_DATA_DIR/extensions")
+	viper.SetDefault("platform.type", PlatformTypeAnilist)
+	viper.SetDefault("platform.trackers", []string{})
 
 	// Create and populate the config file if it doesn't exist
 	if err = createConfigFile(configPath); err != nil {
@@ -175,6 +191,23 @@ func (cfg *Config) GetServerURI(df ...string) string {
 	return pAddr
 }
 
+// GetPlatformName returns the display name of the platform that holds the user's lists.
+func (cfg *Config) GetPlatformName() string {
+	switch cfg.Platform.Type {
+	case PlatformTypeLocal:
+		return "Local"
+	case PlatformTypeMal:
+		return "MyAnimeList"
+	default:
+		return "AniList"
+	}
+}
+
+// IsTrackerEnabled returns true if progress updates should be pushed to the list of the platform, in addition to the app's platform.
+func (cfg *Config) IsTrackerEnabled(platformType string) bool {
+	return cfg.Platform.Type != platformType && slices.Contains(cfg.Platform.Trackers, platformType)
+}
+
 func getWorkingDir(useBinaryPath bool) (string, error) {
 	// Get the working directory
 	wd, err := os.Getwd()
@@ -280,6 +313,19 @@ func validateConfig(cfg *Config, logger *zerolog.Logger) error {
 		return wrapInvalidConfigValue("manga.downloadDir", err)
 	}
 
+	switch cfg.Platform.Type {
+	case PlatformTypeAnilist, PlatformTypeLocal, PlatformTypeMal:
+	default:
+		return errInvalidConfigValue("platform.type", "must be one of \"anilist\", \"local\" or \"mal\"")
+	}
+	for _, t := range cfg.Platform.Trackers {
+		switch t {
+		case PlatformTypeAnilist, PlatformTypeMal:
+		default:
+			return errInvalidConfigValue("platform.trackers", "can only contain \"anilist\" and \"mal\"")
+		}
+	}
+
 	if cfg.Extensions.Dir == "" {
 		return errInvalidConfigValue("extensions.dir", "cannot be empty")
 	}
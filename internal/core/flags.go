@@ -3,6 +3,8 @@ package core
 import (
 	"flag"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 )
 
@@ -10,20 +12,25 @@ type (
 	SeanimeFlags struct {
 		DataDir string
 		Update  bool
+		Command []string // Subcommand and its arguments, e.g. ["snapshot", "create"]. The server is not started if set.
 	}
 )
 
-func GetSeanimeFlags() SeanimeFlags {
+// GetSeanimeFlags parses the command line, printCommands prints the available commands in the help message.
+func GetSeanimeFlags(printCommands func(w io.Writer)) SeanimeFlags {
 	// Help flag
 	flag.Usage = func() {
 		fmt.Printf("Self-hosted, user-friendly, media server for anime and manga enthusiasts.\n\n")
-		fmt.Printf("Usage:\n  seanime [flags]\n\n")
+		fmt.Printf("Usage:\n  seanime [flags]\n  seanime [flags] <command> [arguments]\n\n")
 		fmt.Printf("Flags:\n")
 		fmt.Printf("  -datadir, --datadir string")
 		fmt.Printf("   directory that contains all Seanime data\n")
 		fmt.Printf("  -update")
 		fmt.Printf("   update the application\n")
 		fmt.Printf("  -h                           show this help message\n")
+		fmt.Printf("\n")
+		printCommands(os.Stdout)
+		fmt.Printf("\nCommands do not start the server. They exit with 0 on success, 1 on failure and 2 on invalid usage.\n")
 	}
 	// Parse flags
 	var dataDir string
@@ -37,5 +44,6 @@ func GetSeanimeFlags() SeanimeFlags {
 	return SeanimeFlags{
 		DataDir: strings.TrimSpace(dataDir),
 		Update:  update,
+		Command: flag.Args(),
 	}
 }
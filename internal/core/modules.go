@@ -10,19 +10,26 @@ import (
 	"seanime/internal/library/autoscanner"
 	"seanime/internal/library/fillermanager"
 	"seanime/internal/library/playbackmanager"
+	"seanime/internal/listimport"
+	"seanime/internal/listsync"
 	"seanime/internal/manga"
+	"seanime/internal/mediaplayers/kodi"
 	"seanime/internal/mediaplayers/mediaplayer"
 	"seanime/internal/mediaplayers/mpchc"
 	"seanime/internal/mediaplayers/mpv"
+	"seanime/internal/mediaplayers/upnp"
 	"seanime/internal/mediaplayers/vlc"
 	"seanime/internal/mediastream"
 	"seanime/internal/notifier"
 	"seanime/internal/offline"
+	"seanime/internal/platforms/anilist_platform"
+	"seanime/internal/platforms/mal_platform"
 	"seanime/internal/torrent_clients/qbittorrent"
 	"seanime/internal/torrent_clients/torrent_client"
 	"seanime/internal/torrent_clients/transmission"
 	"seanime/internal/torrents/torrent"
 	"seanime/internal/torrentstream"
+	"seanime/internal/tracker"
 )
 
 // initModulesOnce will initialize modules that need to persist.
@@ -52,6 +59,97 @@ func (a *App) initModulesOnce() {
 		},
 	})
 
+	// +---------------------+
+	// |   Mutation Queue    |
+	// +---------------------+
+
+	a.AnilistMutationQueue.SetRefreshCollectionsFunc(func() {
+		_, _ = a.RefreshAnimeCollection()
+		_, _ = a.RefreshMangaCollection()
+	})
+
+	if !a.IsOffline() {
+		// This is run in a goroutine
+		a.AnilistMutationQueue.Start()
+		a.AddCleanupFunction(func() {
+			a.AnilistMutationQueue.Stop()
+		})
+	}
+
+	// +---------------------+
+	// |      Trackers       |
+	// +---------------------+
+
+	// The list sync and the trackers need both the AniList and MyAnimeList lists,
+	// the app's platform is reused for the list it already manages
+	anilistListPlatform := a.AnilistPlatform
+	if a.Config.Platform.Type != PlatformTypeAnilist {
+		anilistListPlatform = anilist_platform.NewAnilistPlatform(a.AnilistClient, a.Logger)
+	}
+	malListPlatform := a.AnilistPlatform
+	if a.Config.Platform.Type != PlatformTypeMal {
+		malListPlatform = mal_platform.NewMalPlatform(a.Database, a.AnilistClient, a.Logger)
+	}
+
+	// The app's platform is the primary tracker, the other lists are only updated if the user enabled them and is logged in
+	a.TrackerRepository = tracker.NewRepository(&tracker.NewRepositoryOptions{
+		Logger:         a.Logger,
+		WSEventManager: a.WSEventManager,
+		Primary: tracker.NewPlatformTracker(&tracker.NewPlatformTrackerOptions{
+			Name:     a.Config.GetPlatformName(),
+			Platform: a.AnilistPlatform,
+		}),
+	})
+	if a.Config.IsTrackerEnabled(PlatformTypeAnilist) {
+		a.TrackerRepository.AddTracker(tracker.NewPlatformTracker(&tracker.NewPlatformTrackerOptions{
+			Name:     "AniList",
+			Logo:     "https://anilist.co/favicon.ico",
+			Platform: anilistListPlatform,
+			IsEnabled: func() bool {
+				return a.Database.GetAnilistToken() != ""
+			},
+		}))
+	}
+	if a.Config.IsTrackerEnabled(PlatformTypeMal) {
+		a.TrackerRepository.AddTracker(tracker.NewPlatformTracker(&tracker.NewPlatformTrackerOptions{
+			Name:     "MyAnimeList",
+			Logo:     "https://myanimelist.net/favicon.ico",
+			Platform: malListPlatform,
+			IsEnabled: func() bool {
+				_, err := a.Database.GetMalInfo()
+				return err == nil
+			},
+		}))
+	}
+
+	// +---------------------+
+	// |      List Sync      |
+	// +---------------------+
+
+	a.ListSync = listsync.New(&listsync.NewListSyncOptions{
+		Logger:          a.Logger,
+		Database:        a.Database,
+		AnilistPlatform: anilistListPlatform,
+		MalPlatform:     malListPlatform,
+		RefreshCollectionsFunc: func() {
+			_, _ = a.RefreshAnimeCollection()
+			_, _ = a.RefreshMangaCollection()
+		},
+	})
+
+	// +---------------------+
+	// |     List Import     |
+	// +---------------------+
+
+	a.ListImporter = listimport.New(&listimport.NewImporterOptions{
+		Logger:   a.Logger,
+		Platform: a.AnilistPlatform,
+		RefreshCollectionsFunc: func() {
+			_, _ = a.RefreshAnimeCollection()
+			_, _ = a.RefreshMangaCollection()
+		},
+	})
+
 	// +---------------------+
 	// |     Discord RPC     |
 	// +---------------------+
@@ -76,16 +174,20 @@ func (a *App) initModulesOnce() {
 
 	// Playback Manager
 	a.PlaybackManager = playbackmanager.New(&playbackmanager.NewPlaybackManagerOptions{
-		Logger:          a.Logger,
-		WSEventManager:  a.WSEventManager,
-		Platform:        a.AnilistPlatform,
-		Database:        a.Database,
-		DiscordPresence: a.DiscordPresence,
-		IsOffline:       a.IsOffline(),
-		OfflineHub:      a.OfflineHub,
+		Logger:            a.Logger,
+		WSEventManager:    a.WSEventManager,
+		Platform:          a.AnilistPlatform,
+		TrackerRepository: a.TrackerRepository,
+		Database:          a.Database,
+		DiscordPresence:   a.DiscordPresence,
+		IsOffline:         a.IsOffline(),
+		OfflineHub:        a.OfflineHub,
 		RefreshAnimeCollectionFunc: func() {
 			_, _ = a.RefreshAnimeCollection()
 		},
+		ProgressUpdatedFunc: func() {
+			a.ListSync.TriggerAutomaticSync()
+		},
 	})
 
 	// +---------------------+
@@ -126,6 +228,8 @@ func (a *App) initModulesOnce() {
 		Platform:       a.AnilistPlatform,
 		Logger:         a.Logger,
 		WSEventManager: a.WSEventManager,
+		ExtensionBank:  a.ExtensionRepository.GetExtensionBank(),
+		ScanTracker:    a.ScanTracker,
 	})
 
 	// This is run in a goroutine
@@ -251,6 +355,19 @@ func (a *App) InitOrRefreshModules() {
 			Logger: a.Logger,
 		}
 		a.MediaPlayer.Mpv = mpv.New(a.Logger, settings.MediaPlayer.MpvSocket, settings.MediaPlayer.MpvPath)
+		a.MediaPlayer.Kodi = &kodi.Kodi{
+			Host:     settings.MediaPlayer.KodiHost,
+			Port:     settings.MediaPlayer.KodiPort,
+			Username: settings.MediaPlayer.KodiUsername,
+			Password: settings.MediaPlayer.KodiPassword,
+			Logger:   a.Logger,
+		}
+		a.MediaPlayer.Upnp = &upnp.Renderer{
+			Device:     settings.MediaPlayer.UpnpRenderer,
+			ServerHost: a.Config.Server.Host,
+			ServerPort: a.Config.Server.Port,
+			Logger:     a.Logger,
+		}
 
 		// Set media player repository
 		a.MediaPlayerRepository = mediaplayer.NewRepository(&mediaplayer.NewRepositoryOptions{
@@ -259,7 +376,10 @@ func (a *App) InitOrRefreshModules() {
 			VLC:            a.MediaPlayer.VLC,
 			MpcHc:          a.MediaPlayer.MpcHc,
 			Mpv:            a.MediaPlayer.Mpv, // Socket
+			Kodi:           a.MediaPlayer.Kodi,
+			Upnp:           a.MediaPlayer.Upnp,
 			WSEventManager: a.WSEventManager,
+			ExtensionBank:  a.ExtensionRepository.GetExtensionBank(),
 		})
 
 		a.PlaybackManager.SetMediaPlayerRepository(a.MediaPlayerRepository)
@@ -329,6 +449,12 @@ func (a *App) InitOrRefreshModules() {
 		a.Logger.Warn().Msg("app: Did not initialize torrent client module, no settings found")
 	}
 
+	// +---------------------+
+	// |      List Sync      |
+	// +---------------------+
+
+	a.ListSync.SetSettings(settings.ListSync)
+
 	// +---------------------+
 	// |   AutoDownloader    |
 	// +---------------------+
@@ -343,9 +469,10 @@ func (a *App) InitOrRefreshModules() {
 	// +---------------------+
 
 	// Initialize library watcher
-	if settings.Library != nil && len(settings.Library.LibraryPath) > 0 {
+	// Commands do not watch the library, they exit once done
+	if settings.Library != nil && len(settings.Library.GetEnabledLibraryRoots()) > 0 && !a.headless {
 		go func() {
-			a.initLibraryWatcher(settings.Library.LibraryPath)
+			a.initLibraryWatcher(settings.Library.GetEnabledLibraryRoots())
 		}()
 	}
 
@@ -452,16 +579,19 @@ func (a *App) InitOrRefreshAnilistData() {
 	a.Logger.Debug().Msg("app: Fetching Anilist data")
 
 	acc, err := a.Database.GetAccount()
-	if err != nil {
-		return
-	}
-
-	if acc.Token == "" || acc.Username == "" {
+	if err != nil || acc.Token == "" || acc.Username == "" {
+		// The local and MAL platforms do not need an AniList account to load the collections
+		if a.Config.Platform.Type != PlatformTypeAnilist {
+			if _, err = a.RefreshAnimeCollection(); err != nil {
+				a.Logger.Error().Err(err).Msg("app: Failed to fetch collection")
+			}
+		}
 		return
 	}
 
 	// Set username to Anilist platform
 	a.AnilistPlatform.SetUsername(acc.Username)
+	a.ListSync.SetUsername(acc.Username)
 
 	// Set account
 	a.account = acc
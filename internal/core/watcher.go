@@ -2,13 +2,27 @@ package core
 
 import (
 	"github.com/dustin/go-humanize"
+	"github.com/samber/lo"
+	"seanime/internal/database/models"
 	"seanime/internal/library/scanner"
 	"seanime/internal/util"
 )
 
 // initLibraryWatcher will initialize the library watcher.
+// Every enabled root that is watched or auto-scanned is watched.
 //   - Used by AutoScanner
-func (a *App) initLibraryWatcher(path string) {
+func (a *App) initLibraryWatcher(roots []*models.LibraryRoot) {
+	roots = lo.Filter(roots, func(root *models.LibraryRoot, _ int) bool {
+		return root.Enabled && root.Path != "" && (root.Watch || root.AutoScan)
+	})
+	if len(roots) == 0 {
+		return
+	}
+
+	paths := lo.Map(roots, func(root *models.LibraryRoot, _ int) string {
+		return root.Path
+	})
+
 	// Create a new watcher
 	watcher, err := scanner.NewWatcher(&scanner.NewWatcherOptions{
 		Logger:         a.Logger,
@@ -21,14 +35,18 @@ func (a *App) initLibraryWatcher(path string) {
 
 	// Initialize library file watcher
 	err = watcher.InitLibraryFileWatcher(&scanner.WatchLibraryFilesOptions{
-		LibraryPath: path,
+		LibraryPaths: paths,
 	})
 	if err != nil {
 		a.Logger.Error().Err(err).Msg("app: Failed to watch library files")
 		return
 	}
 
-	dirSize, _ := util.DirSize(path)
+	var dirSize uint64
+	for _, path := range paths {
+		size, _ := util.DirSize(path)
+		dirSize += size
+	}
 	a.TotalLibrarySize = dirSize
 
 	a.Logger.Info().Msgf("watcher: Library size: %s", humanize.Bytes(dirSize))
@@ -38,9 +56,15 @@ func (a *App) initLibraryWatcher(path string) {
 
 	// Start watching
 	a.Watcher.StartWatching(
-		func() {
-			// Notify the auto scanner when a file action occurs
-			a.AutoScanner.Notify()
+		func(action scanner.FileAction, path string) {
+			// Notify the auto scanner when a file action occurs in an auto-scanned root
+			root, found := lo.Find(roots, func(root *models.LibraryRoot) bool {
+				return util.IsSubdirectory(root.Path, path)
+			})
+			if found && !root.AutoScan {
+				return
+			}
+			a.AutoScanner.NotifyFileAction(action, path)
 		})
 
 }
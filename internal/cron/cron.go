@@ -26,6 +26,7 @@ func RunJobs(app *core.App) {
 				select {
 				case <-refreshAnilistTicker.C:
 					RefreshAnimeCollectionJob(ctx)
+					SyncListsJob(ctx)
 				case <-refetchReleaseTicker.C:
 					app.Updater.ShouldRefetchReleases()
 				}
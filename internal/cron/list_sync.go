@@ -0,0 +1,12 @@
+package cron
+
+func SyncListsJob(c *JobCtx) {
+	defer func() {
+		if r := recover(); r != nil {
+		}
+	}()
+
+	if err := c.App.ListSync.RunAutomaticSync(); err != nil {
+		c.App.Logger.Error().Err(err).Msg("cron: Failed to sync lists")
+	}
+}
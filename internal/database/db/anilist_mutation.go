@@ -0,0 +1,40 @@
+package db
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"seanime/internal/database/models"
+)
+
+// GetAnilistMutations returns all pending AniList mutations, oldest first.
+func (db *Database) GetAnilistMutations() ([]*models.AnilistMutation, error) {
+	var res []*models.AnilistMutation
+	err := db.gormdb.Order("id asc").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (db *Database) GetAnilistMutation(mediaId int) (*models.AnilistMutation, bool) {
+	var res models.AnilistMutation
+	err := db.gormdb.Where("media_id = ?", mediaId).First(&res).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			db.Logger.Error().Err(err).Msg("db: Failed to get AniList mutation")
+		}
+		return nil, false
+	}
+
+	return &res, true
+}
+
+// SaveAnilistMutation inserts or updates a pending AniList mutation.
+func (db *Database) SaveAnilistMutation(mutation *models.AnilistMutation) error {
+	return db.gormdb.Save(mutation).Error
+}
+
+func (db *Database) DeleteAnilistMutation(mediaId int) error {
+	return db.gormdb.Where("media_id = ?", mediaId).Delete(&models.AnilistMutation{}).Error
+}
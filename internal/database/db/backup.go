@@ -0,0 +1,28 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Backup writes a consistent copy of the database to the given path.
+// The copy is made by SQLite, so it is safe to run while the database is in use.
+// Existing files are not overwritten.
+func (db *Database) Backup(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if err := db.gormdb.Exec("VACUUM INTO ?", path).Error; err != nil {
+		return err
+	}
+
+	db.Logger.Info().Str("path", path).Msg("db: Database backed up")
+
+	return nil
+}
@@ -71,10 +71,15 @@ func NewDatabase(appDataDir, dbName string, logger *zerolog.Logger) (*Database,
 func migrateTables(db *gorm.DB) error {
 	err := db.AutoMigrate(
 		&models.LocalFiles{},
+		&models.LocalFileIndex{},
+		&models.AniDBFileIndex{},
+		&models.ScanCheckpoint{},
 		&models.Settings{},
 		&models.Account{},
 		&models.Mal{},
 		&models.ScanSummary{},
+		&models.ScanSummaryFileState{},
+		&models.OrganizerJournal{},
 		&models.AutoDownloaderRule{},
 		&models.AutoDownloaderItem{},
 		&models.SilencedMediaEntry{},
@@ -86,6 +91,8 @@ func migrateTables(db *gorm.DB) error {
 		&models.MediastreamSettings{},
 		&models.MediaFiller{},
 		&models.MangaMapping{},
+		&models.ListSyncIgnoredDiff{},
+		&models.AnilistMutation{},
 		//&models.MangaChapterContainer{},
 	)
 	if err != nil {
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"gorm.io/gorm/clause"
+	"seanime/internal/database/models"
+)
+
+func (db *Database) GetListSyncIgnoredDiffs() ([]*models.ListSyncIgnoredDiff, error) {
+	var res []*models.ListSyncIgnoredDiff
+	err := db.gormdb.Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// UpsertListSyncIgnoredDiff ignores a diff, replacing the hash of a previously ignored diff with the same ID.
+func (db *Database) UpsertListSyncIgnoredDiff(diffId string, hash string) error {
+	err := db.gormdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "diff_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"hash", "updated_at"}),
+	}).Create(&models.ListSyncIgnoredDiff{
+		DiffID: diffId,
+		Hash:   hash,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *Database) DeleteListSyncIgnoredDiff(diffId string) error {
+	err := db.gormdb.Where("diff_id = ?", diffId).Delete(&models.ListSyncIgnoredDiff{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
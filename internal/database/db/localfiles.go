@@ -48,3 +48,68 @@ func (db *Database) InsertLocalFiles(lfs *models.LocalFiles) (*models.LocalFiles
 	}
 	return lfs, nil
 }
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (db *Database) GetLocalFileIndex() (*models.LocalFileIndex, error) {
+	var res models.LocalFileIndex
+	err := db.gormdb.Where("id = ?", 1).First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (db *Database) UpsertLocalFileIndex(index *models.LocalFileIndex) error {
+	index.ID = 1
+	return db.gormdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(index).Error
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (db *Database) GetAniDBFileIndex() (*models.AniDBFileIndex, error) {
+	var res models.AniDBFileIndex
+	err := db.gormdb.Where("id = ?", 1).First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (db *Database) UpsertAniDBFileIndex(index *models.AniDBFileIndex) error {
+	index.ID = 1
+	return db.gormdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(index).Error
+}
+
+func (db *Database) DeleteAniDBFileIndex() error {
+	return db.gormdb.Where("id = ?", 1).Delete(&models.AniDBFileIndex{}).Error
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (db *Database) GetScanCheckpoint() (*models.ScanCheckpoint, error) {
+	var res models.ScanCheckpoint
+	err := db.gormdb.Where("id = ?", 1).First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (db *Database) UpsertScanCheckpoint(checkpoint *models.ScanCheckpoint) error {
+	checkpoint.ID = 1
+	return db.gormdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(checkpoint).Error
+}
+
+func (db *Database) DeleteScanCheckpoint() error {
+	return db.gormdb.Where("id = ?", 1).Delete(&models.ScanCheckpoint{}).Error
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"seanime/internal/database/models"
+)
+
+func (db *Database) GetOrganizerJournals() ([]*models.OrganizerJournal, error) {
+	var res []*models.OrganizerJournal
+	err := db.gormdb.Order("id DESC").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (db *Database) GetOrganizerJournal(id uint) (*models.OrganizerJournal, error) {
+	var res models.OrganizerJournal
+	err := db.gormdb.Where("id = ?", id).First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (db *Database) UpdateOrganizerJournal(id uint, value []byte) error {
+	return db.gormdb.Model(&models.OrganizerJournal{}).Where("id = ?", id).Update("value", value).Error
+}
+
+func (db *Database) DeleteOrganizerJournal(id uint) error {
+	return db.gormdb.Where("id = ?", id).Delete(&models.OrganizerJournal{}).Error
+}
+
+func (db *Database) TrimOrganizerJournals() {
+	go func() {
+		var count int64
+		err := db.gormdb.Model(&models.OrganizerJournal{}).Count(&count).Error
+		if err != nil {
+			db.Logger.Error().Err(err).Msg("Failed to count organizer journals")
+			return
+		}
+		if count > 20 {
+			// Leave 10 entries
+			err = db.gormdb.Delete(&models.OrganizerJournal{}, "id IN (SELECT id FROM organizer_journals ORDER BY id ASC LIMIT ?)", count-10).Error
+			if err != nil {
+				db.Logger.Error().Err(err).Msg("Failed to delete old organizer journals")
+				return
+			}
+		}
+	}()
+}
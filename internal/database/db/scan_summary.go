@@ -7,10 +7,14 @@ import (
 )
 
 type ScanSummaryItem struct {
+	ID          uint                 `json:"id"`
 	CreatedAt   time.Time            `json:"createdAt"`
 	ScanSummary *summary.ScanSummary `json:"scanSummary"`
 }
 
+// maxScanSummaryEntries is the number of scan summaries kept to compare scans.
+const maxScanSummaryEntries = 30
+
 func (db *Database) TrimScanSummaryEntries() {
 	go func() {
 		var count int64
@@ -19,13 +23,62 @@ func (db *Database) TrimScanSummaryEntries() {
 			db.Logger.Error().Err(err).Msg("Failed to count scan summary entries")
 			return
 		}
-		if count > 10 {
-			// Leave 5 entries
-			err = db.gormdb.Delete(&models.ScanSummary{}, "id IN (SELECT id FROM scan_summaries ORDER BY id ASC LIMIT ?)", count-5).Error
+		if count > maxScanSummaryEntries {
+			err = db.gormdb.Delete(&models.ScanSummary{}, "id IN (SELECT id FROM scan_summaries ORDER BY id ASC LIMIT ?)", count-maxScanSummaryEntries).Error
 			if err != nil {
 				db.Logger.Error().Err(err).Msg("Failed to delete old scan summary entries")
 				return
 			}
 		}
+		// Delete the file states of deleted scan summaries
+		err = db.gormdb.Delete(&models.ScanSummaryFileState{}, "scan_summary_id NOT IN (SELECT id FROM scan_summaries)").Error
+		if err != nil {
+			db.Logger.Error().Err(err).Msg("Failed to delete old scan summary file states")
+			return
+		}
 	}()
 }
+
+func (db *Database) GetScanSummary(id uint) (*models.ScanSummary, error) {
+	var res models.ScanSummary
+	err := db.gormdb.Where("id = ?", id).First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (db *Database) InsertScanSummaryFileStates(states []*models.ScanSummaryFileState) error {
+	if len(states) == 0 {
+		return nil
+	}
+	return db.gormdb.CreateInBatches(states, 500).Error
+}
+
+// GetScanSummaryFileStates returns the states of a file after the last scans that included it, from newest to oldest.
+func (db *Database) GetScanSummaryFileStates(normalizedPath string, limit int) ([]*models.ScanSummaryFileState, error) {
+	var res []*models.ScanSummaryFileState
+	err := db.gormdb.Where("normalized_path = ?", normalizedPath).Order("scan_summary_id DESC").Limit(limit).Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetLatestScanSummaryFileStates returns the states of the files after the last scans.
+// If mediaId is not 0, only the files that were matched to the media after one of these scans are returned.
+func (db *Database) GetLatestScanSummaryFileStates(limit int, mediaId int) ([]*models.ScanSummaryFileState, error) {
+	var res []*models.ScanSummaryFileState
+	latest := db.gormdb.Model(&models.ScanSummary{}).Select("id").Order("id DESC").Limit(limit)
+	query := db.gormdb.Where("scan_summary_id IN (?)", latest)
+	if mediaId != 0 {
+		query = query.Where("normalized_path IN (?)", db.gormdb.Model(&models.ScanSummaryFileState{}).
+			Select("normalized_path").
+			Where("media_id = ? AND scan_summary_id IN (?)", mediaId, latest))
+	}
+	err := query.Order("scan_summary_id DESC").Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -42,6 +42,15 @@ func (db *Database) GetLibraryPathFromSettings() (string, error) {
 	return settings.Library.LibraryPath, nil
 }
 
+// GetLibraryRootsFromSettings returns the enabled library roots.
+func (db *Database) GetLibraryRootsFromSettings() ([]*models.LibraryRoot, error) {
+	settings, err := db.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	return settings.Library.GetEnabledLibraryRoots(), nil
+}
+
 func (db *Database) AutoUpdateProgressIsEnabled() (bool, error) {
 	settings, err := db.GetSettings()
 	if err != nil {
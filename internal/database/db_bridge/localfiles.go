@@ -6,6 +6,7 @@ import (
 	"seanime/internal/database/db"
 	"seanime/internal/database/models"
 	"seanime/internal/library/anime"
+	"seanime/internal/library/scanner"
 )
 
 var CurrLocalFilesDbId uint
@@ -95,3 +96,108 @@ func InsertLocalFiles(db *db.Database, lfs []*anime.LocalFile) ([]*anime.LocalFi
 	return lfs, nil
 
 }
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetFingerprintIndex returns the fingerprint index saved after the last scan.
+// It returns nil if there is no index.
+func GetFingerprintIndex(db *db.Database) *scanner.FingerprintIndex {
+	res, err := db.GetLocalFileIndex()
+	if err != nil {
+		return nil
+	}
+
+	var index scanner.FingerprintIndex
+	if err := json.Unmarshal(res.Value, &index); err != nil {
+		db.Logger.Warn().Err(err).Msg("db: Failed to unmarshal fingerprint index")
+		return nil
+	}
+
+	return &index
+}
+
+// SaveFingerprintIndex replaces the fingerprint index.
+func SaveFingerprintIndex(db *db.Database, index *scanner.FingerprintIndex) error {
+	if index == nil {
+		return nil
+	}
+
+	bytes, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	return db.UpsertLocalFileIndex(&models.LocalFileIndex{
+		Value: bytes,
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetAniDBFileIndex returns the imported AniDB file index.
+// It returns nil if no index was imported.
+func GetAniDBFileIndex(db *db.Database) *scanner.AniDBFileIndex {
+	res, err := db.GetAniDBFileIndex()
+	if err != nil {
+		return nil
+	}
+
+	var index scanner.AniDBFileIndex
+	if err := json.Unmarshal(res.Value, &index); err != nil {
+		db.Logger.Warn().Err(err).Msg("db: Failed to unmarshal AniDB file index")
+		return nil
+	}
+
+	return &index
+}
+
+// SaveAniDBFileIndex replaces the AniDB file index.
+func SaveAniDBFileIndex(db *db.Database, index *scanner.AniDBFileIndex) error {
+	if index == nil {
+		return nil
+	}
+
+	bytes, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	return db.UpsertAniDBFileIndex(&models.AniDBFileIndex{
+		Value: bytes,
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetScanCheckpoint returns the checkpoint of the last interrupted scan.
+// It returns nil if there is no checkpoint.
+func GetScanCheckpoint(db *db.Database) *scanner.ScanCheckpoint {
+	res, err := db.GetScanCheckpoint()
+	if err != nil {
+		return nil
+	}
+
+	var checkpoint scanner.ScanCheckpoint
+	if err := json.Unmarshal(res.Value, &checkpoint); err != nil {
+		db.Logger.Warn().Err(err).Msg("db: Failed to unmarshal scan checkpoint")
+		return nil
+	}
+
+	return &checkpoint
+}
+
+// SaveScanCheckpoint replaces the scan checkpoint.
+func SaveScanCheckpoint(db *db.Database, checkpoint *scanner.ScanCheckpoint) error {
+	if checkpoint == nil {
+		return nil
+	}
+
+	bytes, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	return db.UpsertScanCheckpoint(&models.ScanCheckpoint{
+		Value: bytes,
+	})
+}
@@ -0,0 +1,76 @@
+package db_bridge
+
+import (
+	"github.com/goccy/go-json"
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/library/organizer"
+)
+
+// GetOrganizerJournals returns the journals of the organizer, from newest to oldest.
+func GetOrganizerJournals(database *db.Database) ([]*organizer.Journal, error) {
+	res, err := database.GetOrganizerJournals()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*organizer.Journal, 0, len(res))
+	for _, r := range res {
+		journal, err := unmarshalOrganizerJournal(r)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, journal)
+	}
+
+	return ret, nil
+}
+
+func GetOrganizerJournal(database *db.Database, id uint) (*organizer.Journal, error) {
+	res, err := database.GetOrganizerJournal(id)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalOrganizerJournal(res)
+}
+
+// InsertOrganizerJournal saves the journal and sets its ID.
+// Dry runs and runs without operations are not saved.
+func InsertOrganizerJournal(database *db.Database, journal *organizer.Journal) error {
+	if journal == nil || journal.DryRun || len(journal.GetDoneOperations()) == 0 {
+		return nil
+	}
+
+	bytes, err := json.Marshal(journal)
+	if err != nil {
+		return err
+	}
+
+	entry := &models.OrganizerJournal{
+		Value: bytes,
+	}
+	if err = database.Gorm().Create(entry).Error; err != nil {
+		return err
+	}
+
+	journal.ID = entry.ID
+	return nil
+}
+
+// UpdateOrganizerJournal saves the status of the operations of the journal, e.g. after some of them were undone.
+func UpdateOrganizerJournal(database *db.Database, journal *organizer.Journal) error {
+	bytes, err := json.Marshal(journal)
+	if err != nil {
+		return err
+	}
+	return database.UpdateOrganizerJournal(journal.ID, bytes)
+}
+
+func unmarshalOrganizerJournal(r *models.OrganizerJournal) (*organizer.Journal, error) {
+	var journal organizer.Journal
+	if err := json.Unmarshal(r.Value, &journal); err != nil {
+		return nil, err
+	}
+	journal.ID = r.ID
+	return &journal, nil
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/goccy/go-json"
 	"seanime/internal/database/db"
 	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
 	"seanime/internal/library/summary"
 )
 
@@ -23,6 +24,7 @@ func GetScanSummaries(database *db.Database) ([]*db.ScanSummaryItem, error) {
 			return nil, err
 		}
 		items = append(items, &db.ScanSummaryItem{
+			ID:          r.ID,
 			CreatedAt:   r.CreatedAt,
 			ScanSummary: &sm,
 		})
@@ -31,7 +33,9 @@ func GetScanSummaries(database *db.Database) ([]*db.ScanSummaryItem, error) {
 	return items, nil
 }
 
-func InsertScanSummary(db *db.Database, sm *summary.ScanSummary) error {
+// InsertScanSummary saves the scan summary, and the state of the local files after the scan to compare scans.
+// The local files should be all the files the scan returned.
+func InsertScanSummary(db *db.Database, sm *summary.ScanSummary, lfs []*anime.LocalFile) error {
 	if sm == nil {
 		return nil
 	}
@@ -43,7 +47,92 @@ func InsertScanSummary(db *db.Database, sm *summary.ScanSummary) error {
 	}
 
 	// Save the data
-	return db.Gorm().Create(&models.ScanSummary{
+	entry := &models.ScanSummary{
 		Value: bytes,
-	}).Error
+	}
+	if err = db.Gorm().Create(entry).Error; err != nil {
+		return err
+	}
+
+	// Save the state of the files
+	states := make([]*models.ScanSummaryFileState, 0, len(lfs))
+	for _, state := range summary.NewScanFileStates(lfs, sm) {
+		states = append(states, &models.ScanSummaryFileState{
+			ScanSummaryID:  entry.ID,
+			Path:           state.Path,
+			NormalizedPath: summary.NormalizeScanFilePath(state.Path),
+			MediaId:        state.MediaId,
+			Episode:        state.Episode,
+			AniDBEpisode:   state.AniDBEpisode,
+			Type:           string(state.Type),
+			Locked:         state.Locked,
+			Ignored:        state.Ignored,
+			IsAlternate:    state.IsAlternate,
+			Scanned:        state.Scanned,
+		})
+	}
+
+	return db.InsertScanSummaryFileStates(states)
+}
+
+// GetScanFileHistory returns the state of a file after the last scans that included it, from newest to oldest,
+// with the changes since the previous scan and the logs of the scans that matched the file.
+func GetScanFileHistory(database *db.Database, path string, limit int) ([]*summary.ScanFileHistoryEntry, error) {
+	res, err := database.GetScanSummaryFileStates(summary.NormalizeScanFilePath(path), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	history := summary.NewScanFileHistory(getScanFileStates(res))
+
+	// Add the logs of the file
+	for _, entry := range history {
+		if !entry.Scanned {
+			continue
+		}
+		sm, err := database.GetScanSummary(entry.ScanSummaryId)
+		if err != nil {
+			continue
+		}
+		var scanSummary summary.ScanSummary
+		if err := json.Unmarshal(sm.Value, &scanSummary); err != nil {
+			database.Logger.Warn().Err(err).Msg("db: Failed to unmarshal scan summary")
+			continue
+		}
+		entry.Logs = scanSummary.GetFileLogs(path)
+	}
+
+	return history, nil
+}
+
+// GetMediaFlips returns the files that were matched to another media during the last scans, from newest to oldest.
+// If mediaId is not 0, only the files that were matched to the media are returned.
+func GetMediaFlips(database *db.Database, limit int, mediaId int) ([]*summary.MediaFlip, error) {
+	res, err := database.GetLatestScanSummaryFileStates(limit, mediaId)
+	if err != nil {
+		return nil, err
+	}
+
+	return summary.FindMediaFlips(getScanFileStates(res)), nil
+}
+
+// getScanFileStates converts the rows, the states are created right after their scan summary.
+func getScanFileStates(res []*models.ScanSummaryFileState) []*summary.ScanFileState {
+	ret := make([]*summary.ScanFileState, 0, len(res))
+	for _, r := range res {
+		ret = append(ret, &summary.ScanFileState{
+			ScanSummaryId: r.ScanSummaryID,
+			CreatedAt:     r.CreatedAt,
+			Path:          r.Path,
+			MediaId:       r.MediaId,
+			Episode:       r.Episode,
+			AniDBEpisode:  r.AniDBEpisode,
+			Type:          anime.LocalFileType(r.Type),
+			Locked:        r.Locked,
+			Ignored:       r.Ignored,
+			IsAlternate:   r.IsAlternate,
+			Scanned:       r.Scanned,
+		})
+	}
+	return ret
 }
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"seanime/internal/util"
 	"time"
 )
 
@@ -31,6 +32,27 @@ type LocalFiles struct {
 	Value []byte `gorm:"column:value" json:"value"`
 }
 
+// LocalFileIndex holds the fingerprints of the library files, used by incremental scans.
+// There is only one entry.
+type LocalFileIndex struct {
+	BaseModel
+	Value []byte `gorm:"column:value" json:"value"`
+}
+
+// AniDBFileIndex holds the imported AniDB file index, used to identify files by their hash.
+// There is only one entry.
+type AniDBFileIndex struct {
+	BaseModel
+	Value []byte `gorm:"column:value" json:"value"`
+}
+
+// ScanCheckpoint holds the checkpoint of an interrupted scan, used to resume it.
+// There is only one entry.
+type ScanCheckpoint struct {
+	BaseModel
+	Value []byte `gorm:"column:value" json:"value"`
+}
+
 // +---------------------+
 // |       Settings      |
 // +---------------------+
@@ -70,6 +92,159 @@ type LibrarySettings struct {
 	RefreshLibraryOnStart    bool   `gorm:"column:refresh_library_on_start" json:"refreshLibraryOnStart"`
 	// v2.1+
 	AutoPlayNextEpisode bool `gorm:"column:auto_play_next_episode" json:"autoPlayNextEpisode"`
+	// LibraryRoots are the library directories.
+	// If empty, LibraryPath is the only library directory.
+	LibraryRoots []*LibraryRoot `gorm:"column:library_roots;serializer:json" json:"libraryRoots"`
+	// ScannerUsePartialHash makes the scanner hash the start and end of files to detect moved files
+	ScannerUsePartialHash bool `gorm:"column:scanner_use_partial_hash" json:"scannerUsePartialHash"`
+	// ScannerHashIdentification makes the scanner identify files by their ED2K hash using the imported AniDB file index
+	ScannerHashIdentification bool `gorm:"column:scanner_hash_identification" json:"scannerHashIdentification"`
+	// ScannerConcurrency is the maximum number of files or media the scanner processes in parallel, 0 uses the number of CPUs
+	ScannerConcurrency int `gorm:"column:scanner_concurrency" json:"scannerConcurrency"`
+	// DuplicatePolicy decides which release of an episode is preferred when several files are matched to it
+	DuplicatePolicy *DuplicatePolicy `gorm:"column:duplicate_policy;serializer:json" json:"duplicatePolicy"`
+	// Organizer moves or renames matched files after a scan
+	Organizer *OrganizerSettings `gorm:"column:organizer;serializer:json" json:"organizer"`
+}
+
+// LibraryRoot is a library directory and its options.
+type LibraryRoot struct {
+	Path          string `json:"path"`
+	Enabled       bool   `json:"enabled"`       // Disabled roots are not scanned or watched
+	AutoScan      bool   `json:"autoScan"`      // Changes in the directory trigger the auto scanner (if it is enabled)
+	Watch         bool   `json:"watch"`         // Changes in the directory are reported to the client
+	TreatAsMovies bool   `json:"treatAsMovies"` // Files are matched with movies first
+}
+
+const (
+	DuplicateCriterionReleaseGroup DuplicateCriterion = "releaseGroup" // Files from the preferred release groups first
+	DuplicateCriterionVersion      DuplicateCriterion = "version"      // Highest release version first (e.g. v2 repacks)
+	DuplicateCriterionResolution   DuplicateCriterion = "resolution"   // Highest resolution first
+	DuplicateCriterionNewest       DuplicateCriterion = "newest"       // Most recently modified file first
+)
+
+// DefaultDuplicateCriteria are the criteria used when the policy has none.
+var DefaultDuplicateCriteria = []DuplicateCriterion{
+	DuplicateCriterionReleaseGroup,
+	DuplicateCriterionVersion,
+	DuplicateCriterionResolution,
+	DuplicateCriterionNewest,
+}
+
+type DuplicateCriterion string
+
+// DuplicatePolicy ranks the releases of an episode, the first one is preferred and the others are alternates.
+type DuplicatePolicy struct {
+	ReleaseGroups []string             `json:"releaseGroups"` // From most to least preferred, other groups come after them
+	Criteria      []DuplicateCriterion `json:"criteria"`      // Compared in order until one differs, DefaultDuplicateCriteria if empty
+}
+
+// GetCriteria returns the criteria of the policy, or the default criteria.
+func (p *DuplicatePolicy) GetCriteria() []DuplicateCriterion {
+	if p == nil || len(p.Criteria) == 0 {
+		return DefaultDuplicateCriteria
+	}
+	return p.Criteria
+}
+
+// OrganizerSettings are the options of the file organizer.
+type OrganizerSettings struct {
+	Enabled     bool   `json:"enabled"`     // Files are organized after each scan
+	Template    string `json:"template"`    // Path of the files relative to the destination, the default template is used if empty
+	Mode        string `json:"mode"`        // "move", "copy" or "hardlink"
+	Destination string `json:"destination"` // Directory the template is relative to, the library root of the file if empty, it must be inside a library root
+}
+
+// GetLibraryRoots returns the library directories.
+// Settings from before library roots were added have a single root, created from LibraryPath.
+func (s *LibrarySettings) GetLibraryRoots() []*LibraryRoot {
+	if s == nil {
+		return []*LibraryRoot{}
+	}
+	if len(s.LibraryRoots) > 0 {
+		return s.LibraryRoots
+	}
+	if s.LibraryPath == "" {
+		return []*LibraryRoot{}
+	}
+	return []*LibraryRoot{{
+		Path:     s.LibraryPath,
+		Enabled:  true,
+		AutoScan: true,
+		Watch:    true,
+	}}
+}
+
+// NormalizeLibraryRoots removes roots without a path and sets LibraryPath to the first enabled root.
+// LibraryPath is still used as the main library directory (e.g. when opening the library in the file explorer).
+func (s *LibrarySettings) NormalizeLibraryRoots() {
+	if s == nil || s.LibraryRoots == nil {
+		return
+	}
+	roots := make([]*LibraryRoot, 0, len(s.LibraryRoots))
+	for _, root := range s.LibraryRoots {
+		if root != nil && root.Path != "" {
+			roots = append(roots, root)
+		}
+	}
+	s.LibraryRoots = roots
+	for _, root := range roots {
+		if root.Enabled {
+			s.LibraryPath = root.Path
+			return
+		}
+	}
+	if len(roots) > 0 {
+		s.LibraryPath = roots[0].Path
+	}
+}
+
+// KeepOmittedSettings sets the settings the client did not send to their previous value.
+// Clients from before library roots were added omit them, a changed LibraryPath then replaces the root it was the path of.
+func (s *LibrarySettings) KeepOmittedSettings(prev *LibrarySettings) {
+	if s == nil || prev == nil {
+		return
+	}
+	if s.LibraryRoots == nil && len(prev.LibraryRoots) > 0 {
+		s.LibraryRoots = make([]*LibraryRoot, 0, len(prev.LibraryRoots))
+		for _, root := range prev.LibraryRoots {
+			if root == nil {
+				continue
+			}
+			r := *root
+			if s.LibraryPath != "" && s.LibraryPath != prev.LibraryPath && r.Path == prev.LibraryPath {
+				r.Path = s.LibraryPath
+			}
+			s.LibraryRoots = append(s.LibraryRoots, &r)
+		}
+	}
+	if s.DuplicatePolicy == nil {
+		s.DuplicatePolicy = prev.DuplicatePolicy
+	}
+	if s.Organizer == nil {
+		s.Organizer = prev.Organizer
+	}
+}
+
+// GetEnabledLibraryRoots returns the library directories that are enabled.
+func (s *LibrarySettings) GetEnabledLibraryRoots() []*LibraryRoot {
+	ret := make([]*LibraryRoot, 0)
+	for _, root := range s.GetLibraryRoots() {
+		if root.Enabled && root.Path != "" {
+			ret = append(ret, root)
+		}
+	}
+	return ret
+}
+
+// GetLibraryRoot returns the library directory containing the path.
+func (s *LibrarySettings) GetLibraryRoot(path string) (*LibraryRoot, bool) {
+	for _, root := range s.GetLibraryRoots() {
+		if root.Path != "" && util.IsSubdirectory(root.Path, path) {
+			return root, true
+		}
+	}
+	return nil, false
 }
 
 type MangaSettings struct {
@@ -77,7 +252,7 @@ type MangaSettings struct {
 }
 
 type MediaPlayerSettings struct {
-	Default     string `gorm:"column:default_player" json:"defaultPlayer"` // "vlc" or "mpc-hc"
+	Default     string `gorm:"column:default_player" json:"defaultPlayer"` // "vlc", "mpc-hc", "mpv", "kodi", "upnp" or the ID of a media player extension
 	Host        string `gorm:"column:player_host" json:"host"`
 	VlcUsername string `gorm:"column:vlc_username" json:"vlcUsername"`
 	VlcPassword string `gorm:"column:vlc_password" json:"vlcPassword"`
@@ -87,6 +262,13 @@ type MediaPlayerSettings struct {
 	MpcPath     string `gorm:"column:mpc_path" json:"mpcPath"`
 	MpvSocket   string `gorm:"column:mpv_socket" json:"mpvSocket"`
 	MpvPath     string `gorm:"column:mpv_path" json:"mpvPath"`
+	// Kodi usually runs on another device, so it has its own host
+	KodiHost     string `gorm:"column:kodi_host" json:"kodiHost"`
+	KodiPort     int    `gorm:"column:kodi_port" json:"kodiPort"`
+	KodiUsername string `gorm:"column:kodi_username" json:"kodiUsername"`
+	KodiPassword string `gorm:"column:kodi_password" json:"kodiPassword"`
+	// UDN of the UPnP/DLNA renderer, or the URL of its device description
+	UpnpRenderer string `gorm:"column:upnp_renderer" json:"upnpRenderer"`
 }
 
 type TorrentSettings struct {
@@ -146,6 +328,29 @@ type ScanSummary struct {
 	Value []byte `gorm:"column:value" json:"value"`
 }
 
+// ScanSummaryFileState is the state of a file after a scan.
+// The states are indexed by file and media to compare scans.
+type ScanSummaryFileState struct {
+	BaseModel
+	ScanSummaryID  uint   `gorm:"column:scan_summary_id;index" json:"scanSummaryId"`
+	Path           string `gorm:"column:path" json:"path"`
+	NormalizedPath string `gorm:"column:normalized_path;index" json:"normalizedPath"`
+	MediaId        int    `gorm:"column:media_id;index" json:"mediaId"`
+	Episode        int    `gorm:"column:episode" json:"episode"`
+	AniDBEpisode   string `gorm:"column:anidb_episode" json:"aniDBEpisode"`
+	Type           string `gorm:"column:type" json:"type"`
+	Locked         bool   `gorm:"column:locked" json:"locked"`
+	Ignored        bool   `gorm:"column:ignored" json:"ignored"`
+	IsAlternate    bool   `gorm:"column:is_alternate" json:"isAlternate"`
+	Scanned        bool   `gorm:"column:scanned" json:"scanned"`
+}
+
+// OrganizerJournal holds the operations of an organizer run, used to undo it.
+type OrganizerJournal struct {
+	BaseModel
+	Value []byte `gorm:"column:value" json:"value"`
+}
+
 // +---------------------+
 // |   Auto downloader   |
 // +---------------------+
@@ -316,3 +521,30 @@ type MangaChapterContainer struct {
 	ChapterID string `gorm:"column:chapter_id" json:"chapterId"`
 	Data      []byte `gorm:"column:data" json:"data"`
 }
+
+// +---------------------+
+// |      List Sync      |
+// +---------------------+
+
+// ListSyncIgnoredDiff is a list sync diff that the user chose to ignore.
+// The diff will show up again if its hash changes, i.e. if either list entry is modified.
+type ListSyncIgnoredDiff struct {
+	BaseModel
+	DiffID string `gorm:"column:diff_id;uniqueIndex" json:"diffId"`
+	Hash   string `gorm:"column:hash" json:"hash"`
+}
+
+// +---------------------+
+// |  AniList Mutations  |
+// +---------------------+
+
+// AnilistMutation is a pending AniList list mutation.
+// Mutations are coalesced by media, so there is at most one row per media.
+type AnilistMutation struct {
+	BaseModel
+	MediaID       int       `gorm:"column:media_id;uniqueIndex" json:"mediaId"`
+	Data          []byte    `gorm:"column:data" json:"data"`
+	Attempts      int       `gorm:"column:attempts" json:"attempts"`
+	NextAttemptAt time.Time `gorm:"column:next_attempt_at" json:"nextAttemptAt"`
+	LastError     string    `gorm:"column:last_error" json:"lastError"`
+}
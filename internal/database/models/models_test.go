@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLibrarySettings_KeepOmittedSettings(t *testing.T) {
+	prev := &LibrarySettings{
+		LibraryPath: "/anime",
+		LibraryRoots: []*LibraryRoot{
+			{Path: "/anime", Enabled: true},
+			{Path: "/movies", Enabled: true, TreatAsMovies: true},
+		},
+		Organizer: &OrganizerSettings{Enabled: true},
+	}
+
+	// A client that does not know about library roots changes the library path
+	s := &LibrarySettings{LibraryPath: "/media/anime"}
+	s.KeepOmittedSettings(prev)
+	s.NormalizeLibraryRoots()
+
+	require.Len(t, s.LibraryRoots, 2)
+	assert.Equal(t, "/media/anime", s.LibraryRoots[0].Path)
+	assert.Equal(t, "/movies", s.LibraryRoots[1].Path)
+	assert.Equal(t, "/media/anime", s.LibraryPath)
+	assert.Equal(t, prev.Organizer, s.Organizer)
+	// The previous settings are not modified
+	assert.Equal(t, "/anime", prev.LibraryRoots[0].Path)
+
+	// Roots sent by the client replace the previous ones, even if empty
+	s = &LibrarySettings{LibraryPath: "/anime", LibraryRoots: []*LibraryRoot{}}
+	s.KeepOmittedSettings(prev)
+	assert.Empty(t, s.LibraryRoots)
+}
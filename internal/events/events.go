@@ -7,6 +7,7 @@ const (
 	RefreshedAnilistMangaCollection = "refreshed-anilist-manga-collection" // The manga collection has been refreshed
 	LibraryWatcherFileAdded         = "library-watcher-file-added"         // A new file has been added to the library
 	LibraryWatcherFileRemoved       = "library-watcher-file-removed"       // A file has been removed from the library
+	LibraryLocalFilesUpdated        = "library-local-files-updated"        // The local files have been updated without a scan, e.g. a file was removed
 	AutoDownloaderItemAdded         = "auto-downloader-item-added"         // An item has been added to the auto downloader queue
 
 	AutoScanStarted   = "auto-scan-started"   // The auto scan has started
@@ -37,4 +38,6 @@ const (
 	ExtensionsReloaded = "extensions-reloaded"
 
 	ActiveTorrentCountUpdated = "active-torrent-count-updated"
+
+	TrackerProgressUpdated = "tracker-progress-updated" // Dispatches the results of a progress update on the secondary trackers
 )
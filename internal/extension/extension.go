@@ -15,6 +15,7 @@ const (
 	TypeMangaProvider        Type = "manga-provider"
 	TypeOnlinestreamProvider Type = "onlinestream-provider"
 	TypeMediaPlayer          Type = "mediaplayer"
+	TypeLibraryMatcher       Type = "library-matcher"
 )
 
 const (
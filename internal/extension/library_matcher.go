@@ -0,0 +1,134 @@
+package extension
+
+import (
+	"seanime/internal/library/anime"
+	"slices"
+	"strings"
+)
+
+type (
+	// LibraryMatcher is used by the scanner to match local files that could not be confidently matched by title comparison.
+	LibraryMatcher interface {
+		// Match returns the media the local file belongs to.
+		// The media ID should be one of the candidates, and the confidence should be between 0 and 1.
+		// It can return nil if the file cannot be matched.
+		Match(opts *LibraryMatchOptions) (*LibraryMatchResult, error)
+	}
+
+	LibraryMatchOptions struct {
+		// Path is the path of the local file.
+		Path string `json:"path"`
+		// ParsedData is the data parsed from the file name.
+		ParsedData *anime.LocalFileParsedData `json:"parsedData"`
+		// ParsedFolderData is the data parsed from the names of the parent folders.
+		ParsedFolderData []*anime.LocalFileParsedData `json:"parsedFolderData"`
+		// Candidates is the media the file can be matched to.
+		Candidates []*anime.NormalizedMedia `json:"candidates"`
+	}
+
+	LibraryMatchResult struct {
+		MediaId int `json:"mediaId"`
+		// Episode is the relative episode number of the file.
+		// Leave it at 0 to let the scanner use the parsed episode number.
+		Episode int `json:"episode,omitempty"`
+		// Confidence is between 0 and 1.
+		Confidence float64 `json:"confidence"`
+	}
+)
+
+type LibraryMatcherExtension interface {
+	BaseExtension
+	GetMatcher() LibraryMatcher
+}
+
+type LibraryMatcherExtensionImpl struct {
+	ext     *Extension
+	matcher LibraryMatcher
+}
+
+func NewLibraryMatcherExtension(ext *Extension, matcher LibraryMatcher) LibraryMatcherExtension {
+	return &LibraryMatcherExtensionImpl{
+		ext:     ext,
+		matcher: matcher,
+	}
+}
+
+func (m *LibraryMatcherExtensionImpl) GetMatcher() LibraryMatcher {
+	return m.matcher
+}
+
+// GetLibraryMatcherExtensions returns the library matchers in the bank, sorted by ID so that they are always called in the same order.
+func GetLibraryMatcherExtensions(bank *UnifiedBank) []LibraryMatcherExtension {
+	ret := make([]LibraryMatcherExtension, 0)
+	if bank == nil {
+		return ret
+	}
+	RangeExtensions(bank, func(id string, ext LibraryMatcherExtension) bool {
+		ret = append(ret, ext)
+		return true
+	})
+	slices.SortFunc(ret, func(a, b LibraryMatcherExtension) int {
+		return strings.Compare(a.GetID(), b.GetID())
+	})
+	return ret
+}
+
+func (m *LibraryMatcherExtensionImpl) GetExtension() *Extension {
+	return m.ext
+}
+
+func (m *LibraryMatcherExtensionImpl) GetType() Type {
+	return m.ext.Type
+}
+
+func (m *LibraryMatcherExtensionImpl) GetID() string {
+	return m.ext.ID
+}
+
+func (m *LibraryMatcherExtensionImpl) GetName() string {
+	return m.ext.Name
+}
+
+func (m *LibraryMatcherExtensionImpl) GetVersion() string {
+	return m.ext.Version
+}
+
+func (m *LibraryMatcherExtensionImpl) GetManifestURI() string {
+	return m.ext.ManifestURI
+}
+
+func (m *LibraryMatcherExtensionImpl) GetLanguage() Language {
+	return m.ext.Language
+}
+
+func (m *LibraryMatcherExtensionImpl) GetLang() string {
+	return GetExtensionLang(m.ext.Lang)
+}
+
+func (m *LibraryMatcherExtensionImpl) GetDescription() string {
+	return m.ext.Description
+}
+
+func (m *LibraryMatcherExtensionImpl) GetAuthor() string {
+	return m.ext.Author
+}
+
+func (m *LibraryMatcherExtensionImpl) GetPayload() string {
+	return m.ext.Payload
+}
+
+func (m *LibraryMatcherExtensionImpl) GetWebsite() string {
+	return m.ext.Website
+}
+
+func (m *LibraryMatcherExtensionImpl) GetIcon() string {
+	return m.ext.Icon
+}
+
+func (m *LibraryMatcherExtensionImpl) GetScopes() []string {
+	return m.ext.Scopes
+}
+
+func (m *LibraryMatcherExtensionImpl) GetConfig() Config {
+	return m.ext.Config
+}
@@ -1,82 +1,115 @@
 package extension
 
-//import (
-//	hibikemediaplayer "github.com/5rahim/hibike/pkg/extension/mediaplayer"
-//)
-//
-//type MediaPlayerExtension interface {
-//	BaseExtension
-//	GetMediaPlayer() hibikemediaplayer.MediaPlayer
-//}
-//
-//type MediaPlayerExtensionImpl struct {
-//	ext      *Extension
-//	provider hibikemediaplayer.MediaPlayer
-//}
-//
-//func NewMediaPlayerExtension(ext *Extension, provider hibikemediaplayer.MediaPlayer) MediaPlayerExtension {
-//	return &MediaPlayerExtensionImpl{
-//		ext:      ext,
-//		provider: provider,
-//	}
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetMediaPlayer() hibikemediaplayer.MediaPlayer {
-//	return m.provider
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetExtension() *Extension {
-//	return m.ext
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetType() Type {
-//	return m.ext.Type
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetID() string {
-//	return m.ext.ID
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetName() string {
-//	return m.ext.Name
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetVersion() string {
-//	return m.ext.Version
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetManifestURI() string {
-//	return m.ext.ManifestURI
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetLanguage() Language {
-//	return m.ext.Language
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetDescription() string {
-//	return m.ext.Description
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetAuthor() string {
-//	return m.ext.Author
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetPayload() string {
-//	return m.ext.Payload
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetWebsite() string {
-//	return m.ext.Website
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetIcon() string {
-//	return m.ext.Icon
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetScopes() []string {
-//	return m.ext.Scopes
-//}
-//
-//func (m *MediaPlayerExtensionImpl) GetConfig() Config {
-//	return m.ext.Config
-//}
+type (
+	// MediaPlayer is used by the media player repository to play videos with a player that is not built in.
+	// It is selected by setting the extension ID as the default media player.
+	MediaPlayer interface {
+		// Play opens the local file and starts playing it.
+		// Subsequent calls should load the new file instead of opening another instance of the player.
+		Play(req *MediaPlayerPlayRequest) error
+		// Stream opens the stream URL and starts playing it.
+		Stream(req *MediaPlayerPlayRequest) error
+		// GetPlaybackStatus returns the status of the current video.
+		// It is called every few seconds while the playback is tracked, an error is returned if nothing is playing.
+		GetPlaybackStatus() (*MediaPlayerPlaybackStatus, error)
+		// Stop stops the playback.
+		Stop() error
+	}
+
+	MediaPlayerPlayRequest struct {
+		// Path is the path of the local file, or the URL of the stream.
+		Path string `json:"path"`
+	}
+
+	MediaPlayerPlaybackStatus struct {
+		Filename string `json:"filename"`
+		// Filepath is the path of the file, if the player knows it.
+		Filepath string `json:"filepath,omitempty"`
+		// Position is the current position in seconds.
+		Position float64 `json:"position"`
+		// Duration is the duration of the video in seconds.
+		Duration float64 `json:"duration"`
+		Paused   bool    `json:"paused"`
+	}
+)
+
+type MediaPlayerExtension interface {
+	BaseExtension
+	GetMediaPlayer() MediaPlayer
+}
+
+type MediaPlayerExtensionImpl struct {
+	ext    *Extension
+	player MediaPlayer
+}
+
+func NewMediaPlayerExtension(ext *Extension, player MediaPlayer) MediaPlayerExtension {
+	return &MediaPlayerExtensionImpl{
+		ext:    ext,
+		player: player,
+	}
+}
+
+func (m *MediaPlayerExtensionImpl) GetMediaPlayer() MediaPlayer {
+	return m.player
+}
+
+func (m *MediaPlayerExtensionImpl) GetExtension() *Extension {
+	return m.ext
+}
+
+func (m *MediaPlayerExtensionImpl) GetType() Type {
+	return m.ext.Type
+}
+
+func (m *MediaPlayerExtensionImpl) GetID() string {
+	return m.ext.ID
+}
+
+func (m *MediaPlayerExtensionImpl) GetName() string {
+	return m.ext.Name
+}
+
+func (m *MediaPlayerExtensionImpl) GetVersion() string {
+	return m.ext.Version
+}
+
+func (m *MediaPlayerExtensionImpl) GetManifestURI() string {
+	return m.ext.ManifestURI
+}
+
+func (m *MediaPlayerExtensionImpl) GetLanguage() Language {
+	return m.ext.Language
+}
+
+func (m *MediaPlayerExtensionImpl) GetLang() string {
+	return GetExtensionLang(m.ext.Lang)
+}
+
+func (m *MediaPlayerExtensionImpl) GetDescription() string {
+	return m.ext.Description
+}
+
+func (m *MediaPlayerExtensionImpl) GetAuthor() string {
+	return m.ext.Author
+}
+
+func (m *MediaPlayerExtensionImpl) GetPayload() string {
+	return m.ext.Payload
+}
+
+func (m *MediaPlayerExtensionImpl) GetWebsite() string {
+	return m.ext.Website
+}
+
+func (m *MediaPlayerExtensionImpl) GetIcon() string {
+	return m.ext.Icon
+}
+
+func (m *MediaPlayerExtensionImpl) GetScopes() []string {
+	return m.ext.Scopes
+}
+
+func (m *MediaPlayerExtensionImpl) GetConfig() Config {
+	return m.ext.Config
+}
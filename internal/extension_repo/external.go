@@ -405,11 +405,12 @@ func (r *Repository) loadExternalExtension(filePath string) {
 	case extension.TypeAnimeTorrentProvider:
 		// Load torrent provider
 		loadingErr = r.loadExternalAnimeTorrentProviderExtension(ext)
+	case extension.TypeLibraryMatcher:
+		// Load library matcher
+		loadingErr = r.loadExternalLibraryMatcherExtension(ext)
 	case extension.TypeMediaPlayer:
 		// Load media player
-		// TODO
-		//loadingErr = r.loadExternalMediaPlayerExtension(ext)
-		loadingErr = fmt.Errorf("media player extension type not yet supported")
+		loadingErr = r.loadExternalMediaPlayerExtension(ext)
 	default:
 		r.logger.Error().Str("type", string(ext.Type)).Msg("extensions: Extension type not supported")
 		loadingErr = fmt.Errorf("extension type not supported")
@@ -0,0 +1,47 @@
+package extension_repo
+
+import (
+	"fmt"
+	"seanime/internal/extension"
+	"seanime/internal/util"
+)
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Library matcher
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (r *Repository) loadExternalLibraryMatcherExtension(ext *extension.Extension) (err error) {
+	defer util.HandlePanicInModuleWithError("extension_repo/loadExternalLibraryMatcherExtension", &err)
+
+	switch ext.Language {
+	case extension.LanguageJavascript:
+		err = r.loadExternalLibraryMatcherExtensionJS(ext, extension.LanguageJavascript)
+	case extension.LanguageTypescript:
+		err = r.loadExternalLibraryMatcherExtensionJS(ext, extension.LanguageTypescript)
+	default:
+		// Go library matchers are not supported, the interpreter does not expose the extension package
+		err = fmt.Errorf("unsupported language: %v", ext.Language)
+	}
+
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (r *Repository) loadExternalLibraryMatcherExtensionJS(ext *extension.Extension, language extension.Language) error {
+
+	matcher, gojaExt, err := NewGojaLibraryMatcher(ext, language, r.logger)
+	if err != nil {
+		return err
+	}
+
+	// Add the goja extension pointer to the map
+	r.gojaExtensions.Set(ext.ID, gojaExt)
+
+	// Add the extension to the map
+	retExt := extension.NewLibraryMatcherExtension(ext, matcher)
+	r.extensionBank.Set(ext.ID, retExt)
+	return nil
+}
@@ -0,0 +1,92 @@
+package extension_repo
+
+import (
+	"fmt"
+	"github.com/dop251/goja"
+	"github.com/rs/zerolog"
+	"seanime/internal/extension"
+	"seanime/internal/util"
+	"sync"
+)
+
+type (
+	GojaLibraryMatcher struct {
+		gojaExtensionImpl
+		// The matcher is called concurrently by the scanner, but the VM is not goroutine-safe
+		mu sync.Mutex
+	}
+)
+
+func NewGojaLibraryMatcher(ext *extension.Extension, language extension.Language, logger *zerolog.Logger) (extension.LibraryMatcher, *GojaLibraryMatcher, error) {
+	logger.Trace().Str("id", ext.ID).Any("language", language).Msg("extensions: Loading external library matcher")
+
+	vm, err := SetupGojaExtensionVM(ext, language, logger)
+	if err != nil {
+		logger.Error().Err(err).Str("id", ext.ID).Msg("extensions: Failed to create javascript VM")
+		return nil, nil, err
+	}
+
+	// Create the matcher
+	_, err = vm.RunString(`function NewProvider() {
+   return new Provider()
+}`)
+	if err != nil {
+		vm.ClearInterrupt()
+		logger.Error().Err(err).Str("id", ext.ID).Msg("extensions: Failed to create library matcher")
+		return nil, nil, err
+	}
+
+	newProviderFunc, ok := goja.AssertFunction(vm.Get("NewProvider"))
+	if !ok {
+		vm.ClearInterrupt()
+		logger.Error().Str("id", ext.ID).Msg("extensions: Failed to invoke library matcher constructor")
+		return nil, nil, fmt.Errorf("failed to invoke library matcher constructor")
+	}
+
+	classObjVal, err := newProviderFunc(goja.Undefined())
+	if err != nil {
+		vm.ClearInterrupt()
+		logger.Error().Err(err).Str("id", ext.ID).Msg("extensions: Failed to create library matcher")
+		return nil, nil, err
+	}
+
+	classObj := classObjVal.ToObject(vm)
+
+	ret := &GojaLibraryMatcher{
+		gojaExtensionImpl: gojaExtensionImpl{
+			vm:       vm,
+			logger:   logger,
+			ext:      ext,
+			classObj: classObj,
+		},
+	}
+	return ret, ret, nil
+}
+
+func (g *GojaLibraryMatcher) GetVM() *goja.Runtime {
+	return g.vm
+}
+
+func (g *GojaLibraryMatcher) Match(opts *extension.LibraryMatchOptions) (ret *extension.LibraryMatchResult, err error) {
+	defer util.HandlePanicInModuleWithError(g.ext.ID, &err)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	method, err := g.callClassMethod("match", g.vm.ToValue(structToMap(opts)))
+	if err != nil {
+		return nil, err
+	}
+
+	promiseRes, err := g.waitForPromise(method)
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.unmarshalValue(promiseRes, &ret)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -1,149 +1,147 @@
 package extension_repo
 
-//import (
-//	"fmt"
-//	"github.com/dop251/goja"
-//	"github.com/rs/zerolog"
-//	"seanime/internal/extension"
-//
-//	hibikemediaplayer "github.com/5rahim/hibike/pkg/extension/mediaplayer"
-//)
-//
-//type (
-//	GojaMediaPlayer struct {
-//		gojaExtensionImpl
-//	}
-//)
-//
-//func NewGojaMediaPlayer(ext *extension.Extension, language extension.Language, logger *zerolog.Logger) (hibikemediaplayer.MediaPlayer, *GojaMediaPlayer, error) {
-//	logger.Trace().Str("id", ext.ID).Any("language", language).Msg("extensions: Loading external online streaming provider")
-//
-//	vm, err := SetupGojaExtensionVM(ext, language, logger)
-//	if err != nil {
-//		logger.Error().Err(err).Str("id", ext.ID).Msg("extensions: Failed to create javascript VM")
-//		return nil, nil, err
-//	}
-//
-//	// Create the provider
-//	_, err = vm.RunString(`function NewMediaPlayer() {
-//   return new MediaPlayer()
-//}`)
-//	if err != nil {
-//		vm.ClearInterrupt()
-//		logger.Error().Err(err).Str("id", ext.ID).Msg("extensions: Failed to create online streaming provider")
-//		return nil, nil, err
-//	}
-//
-//	newMediaPlayerFunc, ok := goja.AssertFunction(vm.Get("NewMediaPlayer"))
-//	if !ok {
-//		vm.ClearInterrupt()
-//		logger.Error().Str("id", ext.ID).Msg("extensions: Failed to invoke online streaming provider constructor")
-//		return nil, nil, fmt.Errorf("failed to invoke online streaming provider constructor")
-//	}
-//
-//	classObjVal, err := newMediaPlayerFunc(goja.Undefined())
-//	if err != nil {
-//		vm.ClearInterrupt()
-//		logger.Error().Err(err).Str("id", ext.ID).Msg("extensions: Failed to create online streaming provider")
-//		return nil, nil, err
-//	}
-//
-//	classObj := classObjVal.ToObject(vm)
-//
-//	ret := &GojaMediaPlayer{
-//		gojaExtensionImpl: gojaExtensionImpl{
-//			vm:       vm,
-//			logger:   logger,
-//			ext:      ext,
-//			classObj: classObj,
-//		},
-//	}
-//	return ret, ret, nil
-//}
-//
-//func (g *GojaMediaPlayer) GetVM() *goja.Runtime {
-//	return g.vm
-//}
-//
-//func (g *GojaMediaPlayer) GetSettings() (ret hibikemediaplayer.Settings) {
-//
-//	res, err := g.callClassMethod("getSettings")
-//	if err != nil {
-//		return hibikemediaplayer.Settings{}
-//	}
-//
-//	err = g.unmarshalValue(res, &ret)
-//	if err != nil {
-//		return hibikemediaplayer.Settings{}
-//	}
-//
-//	return
-//}
-//
-//func (g *GojaMediaPlayer) InitConfig(config map[string]interface{}) {
-//	_, err := g.callClassMethod("initConfig", g.vm.ToValue(config))
-//	if err != nil {
-//		return
-//	}
-//}
-//
-//func (g *GojaMediaPlayer) Start() error {
-//	_, err := g.callClassMethod("start")
-//	if err != nil {
-//		return err
-//	}
-//	return nil
-//}
-//
-//func (g *GojaMediaPlayer) Stop() error {
-//	_, err := g.callClassMethod("stop")
-//	if err != nil {
-//		return err
-//	}
-//	return nil
-//}
-//
-//func (g *GojaMediaPlayer) Play(req hibikemediaplayer.PlayRequest) (*hibikemediaplayer.PlayResponse, error) {
-//	res, err := g.callClassMethod("play", g.vm.ToValue(structToMap(req)))
-//	if err != nil {
-//		return nil, err
-//	}
-//
-//	var ret hibikemediaplayer.PlayResponse
-//	err = g.unmarshalValue(res, &ret)
-//	if err != nil {
-//		return nil, err
-//	}
-//
-//	return &ret, nil
-//}
-//
-//func (g *GojaMediaPlayer) Stream(req hibikemediaplayer.PlayRequest) (*hibikemediaplayer.PlayResponse, error) {
-//	res, err := g.callClassMethod("stream", g.vm.ToValue(structToMap(req)))
-//	if err != nil {
-//		return nil, err
-//	}
-//
-//	var ret hibikemediaplayer.PlayResponse
-//	err = g.unmarshalValue(res, &ret)
-//	if err != nil {
-//		return nil, err
-//	}
-//
-//	return &ret, nil
-//}
-//
-//func (g *GojaMediaPlayer) GetPlaybackStatus() (*hibikemediaplayer.PlaybackStatus, error) {
-//	res, err := g.callClassMethod("getPlaybackStatus")
-//	if err != nil {
-//		return nil, err
-//	}
-//
-//	var ret hibikemediaplayer.PlaybackStatus
-//	err = g.unmarshalValue(res, &ret)
-//	if err != nil {
-//		return nil, err
-//	}
-//
-//	return &ret, nil
-//}
+import (
+	"errors"
+	"fmt"
+	"github.com/dop251/goja"
+	"github.com/rs/zerolog"
+	"seanime/internal/extension"
+	"seanime/internal/util"
+	"sync"
+	"time"
+)
+
+type (
+	GojaMediaPlayer struct {
+		gojaExtensionImpl
+		// The player is called by the tracking goroutine and the playback manager, but the VM is not goroutine-safe
+		mu sync.Mutex
+	}
+)
+
+func NewGojaMediaPlayer(ext *extension.Extension, language extension.Language, logger *zerolog.Logger) (extension.MediaPlayer, *GojaMediaPlayer, error) {
+	logger.Trace().Str("id", ext.ID).Any("language", language).Msg("extensions: Loading external media player")
+
+	vm, err := SetupGojaExtensionVM(ext, language, logger)
+	if err != nil {
+		logger.Error().Err(err).Str("id", ext.ID).Msg("extensions: Failed to create javascript VM")
+		return nil, nil, err
+	}
+
+	// Create the player
+	_, err = vm.RunString(`function NewProvider() {
+   return new Provider()
+}`)
+	if err != nil {
+		vm.ClearInterrupt()
+		logger.Error().Err(err).Str("id", ext.ID).Msg("extensions: Failed to create media player")
+		return nil, nil, err
+	}
+
+	newProviderFunc, ok := goja.AssertFunction(vm.Get("NewProvider"))
+	if !ok {
+		vm.ClearInterrupt()
+		logger.Error().Str("id", ext.ID).Msg("extensions: Failed to invoke media player constructor")
+		return nil, nil, fmt.Errorf("failed to invoke media player constructor")
+	}
+
+	classObjVal, err := newProviderFunc(goja.Undefined())
+	if err != nil {
+		vm.ClearInterrupt()
+		logger.Error().Err(err).Str("id", ext.ID).Msg("extensions: Failed to create media player")
+		return nil, nil, err
+	}
+
+	classObj := classObjVal.ToObject(vm)
+
+	ret := &GojaMediaPlayer{
+		gojaExtensionImpl: gojaExtensionImpl{
+			vm:       vm,
+			logger:   logger,
+			ext:      ext,
+			classObj: classObj,
+		},
+	}
+	return ret, ret, nil
+}
+
+func (g *GojaMediaPlayer) GetVM() *goja.Runtime {
+	return g.vm
+}
+
+func (g *GojaMediaPlayer) Play(req *extension.MediaPlayerPlayRequest) (err error) {
+	defer util.HandlePanicInModuleWithError(g.ext.ID, &err)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	_, err = g.call("play", g.vm.ToValue(structToMap(req)))
+	return err
+}
+
+func (g *GojaMediaPlayer) Stream(req *extension.MediaPlayerPlayRequest) (err error) {
+	defer util.HandlePanicInModuleWithError(g.ext.ID, &err)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	_, err = g.call("stream", g.vm.ToValue(structToMap(req)))
+	return err
+}
+
+func (g *GojaMediaPlayer) GetPlaybackStatus() (ret *extension.MediaPlayerPlaybackStatus, err error) {
+	defer util.HandlePanicInModuleWithError(g.ext.ID, &err)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	res, err := g.call("getPlaybackStatus")
+	if err != nil {
+		return nil, err
+	}
+
+	if res == nil || goja.IsUndefined(res) || goja.IsNull(res) {
+		return nil, errors.New("nothing is playing")
+	}
+
+	err = g.unmarshalValue(res, &ret)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (g *GojaMediaPlayer) Stop() (err error) {
+	defer util.HandlePanicInModuleWithError(g.ext.ID, &err)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	_, err = g.call("stop")
+	return err
+}
+
+// call calls the class method and waits for the promise it returns, if any.
+// Unlike waitForPromise, the result can be undefined, e.g. for async methods that do not return anything.
+func (g *GojaMediaPlayer) call(name string, args ...goja.Value) (goja.Value, error) {
+	res, err := g.callClassMethod(name, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	promise, ok := res.Export().(*goja.Promise)
+	if !ok {
+		return res, nil
+	}
+
+	for promise.State() == goja.PromiseStatePending {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if promise.State() == goja.PromiseStateRejected {
+		return nil, g.error(fmt.Errorf("%v", promise.Result()), "promise rejected")
+	}
+
+	return promise.Result(), nil
+}
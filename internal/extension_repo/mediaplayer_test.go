@@ -0,0 +1,48 @@
+package extension_repo_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"seanime/internal/extension"
+	"seanime/internal/extension_repo"
+	"seanime/internal/util"
+	"testing"
+)
+
+func TestGojaMediaPlayer(t *testing.T) {
+	fileB, err := os.ReadFile("./mediaplayer_testdir/my-media-player.ts")
+	require.NoError(t, err)
+
+	ext := &extension.Extension{
+		ID:       "my-media-player",
+		Name:     "MyMediaPlayer",
+		Version:  "0.1.0",
+		Language: extension.LanguageTypescript,
+		Type:     extension.TypeMediaPlayer,
+		Payload:  string(fileB),
+	}
+
+	player, _, err := extension_repo.NewGojaMediaPlayer(ext, ext.Language, util.NewLogger())
+	require.NoError(t, err)
+
+	// Nothing is playing
+	_, err = player.GetPlaybackStatus()
+	assert.Error(t, err)
+
+	err = player.Play(&extension.MediaPlayerPlayRequest{Path: "/anime/Sousou no Frieren/[SubsPlease] Sousou no Frieren - 01 (1080p) [F02B9CEE].mkv"})
+	require.NoError(t, err)
+
+	status, err := player.GetPlaybackStatus()
+	require.NoError(t, err)
+	assert.Equal(t, "[SubsPlease] Sousou no Frieren - 01 (1080p) [F02B9CEE].mkv", status.Filename)
+	assert.Equal(t, float64(300), status.Position)
+	assert.Equal(t, float64(1440), status.Duration)
+	assert.False(t, status.Paused)
+
+	err = player.Stop()
+	require.NoError(t, err)
+
+	_, err = player.GetPlaybackStatus()
+	assert.Error(t, err)
+}
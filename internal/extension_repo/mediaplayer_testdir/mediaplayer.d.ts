@@ -0,0 +1,13 @@
+declare interface MediaPlayerPlayRequest {
+    path: string
+}
+
+declare interface MediaPlayerPlaybackStatus {
+    filename: string
+    filepath?: string
+    // Current position in seconds
+    position: number
+    // Duration of the video in seconds
+    duration: number
+    paused: boolean
+}
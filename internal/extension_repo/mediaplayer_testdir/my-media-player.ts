@@ -0,0 +1,36 @@
+/// <reference path="./mediaplayer.d.ts" />
+
+// Keeps the playback state in memory, a real player would send the requests to the player's API.
+class Provider {
+
+    current: MediaPlayerPlaybackStatus | null = null
+
+    async play(req: MediaPlayerPlayRequest): Promise<void> {
+        this.open(req.path)
+    }
+
+    async stream(req: MediaPlayerPlayRequest): Promise<void> {
+        this.open(req.path)
+    }
+
+    async getPlaybackStatus(): Promise<MediaPlayerPlaybackStatus | null> {
+        if (!this.current) return null
+        this.current.position += 300
+        return this.current
+    }
+
+    async stop(): Promise<void> {
+        this.current = null
+    }
+
+    private open(path: string) {
+        const parts = path.split(/[\\/]/)
+        this.current = {
+            filename: parts[parts.length - 1],
+            filepath: path,
+            position: 0,
+            duration: 1440,
+            paused: false,
+        }
+    }
+}
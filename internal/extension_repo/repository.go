@@ -63,6 +63,16 @@ type (
 		SupportsDub    bool     `json:"supportsDub"`
 	}
 
+	LibraryMatcherExtensionItem struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	MediaPlayerExtensionItem struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
 	AnimeTorrentProviderExtensionItem struct {
 		ID       string                                      `json:"id"`
 		Name     string                                      `json:"name"`
@@ -191,6 +201,34 @@ func (r *Repository) ListAnimeTorrentProviderExtensions() []*AnimeTorrentProvide
 	return ret
 }
 
+func (r *Repository) ListMediaPlayerExtensions() []*MediaPlayerExtensionItem {
+	ret := make([]*MediaPlayerExtensionItem, 0)
+
+	extension.RangeExtensions(r.extensionBank, func(key string, ext extension.MediaPlayerExtension) bool {
+		ret = append(ret, &MediaPlayerExtensionItem{
+			ID:   ext.GetID(),
+			Name: ext.GetName(),
+		})
+		return true
+	})
+
+	return ret
+}
+
+func (r *Repository) ListLibraryMatcherExtensions() []*LibraryMatcherExtensionItem {
+	ret := make([]*LibraryMatcherExtensionItem, 0)
+
+	extension.RangeExtensions(r.extensionBank, func(key string, ext extension.LibraryMatcherExtension) bool {
+		ret = append(ret, &LibraryMatcherExtensionItem{
+			ID:   ext.GetID(),
+			Name: ext.GetName(),
+		})
+		return true
+	})
+
+	return ret
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // GetLoadedExtension returns the loaded extension by ID.
@@ -224,6 +262,20 @@ func (r *Repository) GetAnimeTorrentProviderExtensionByID(id string) (extension.
 	return ext, found
 }
 
+func (r *Repository) GetLibraryMatcherExtensionByID(id string) (extension.LibraryMatcherExtension, bool) {
+	ext, found := extension.GetExtension[extension.LibraryMatcherExtension](r.extensionBank, id)
+	return ext, found
+}
+
+func (r *Repository) GetLibraryMatcherExtensions() []extension.LibraryMatcherExtension {
+	return extension.GetLibraryMatcherExtensions(r.extensionBank)
+}
+
+func (r *Repository) GetMediaPlayerExtensionByID(id string) (extension.MediaPlayerExtension, bool) {
+	ext, found := extension.GetExtension[extension.MediaPlayerExtension](r.extensionBank, id)
+	return ext, found
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Built-in extensions
 // - Built-in extensions are loaded once, on application startup
@@ -44,7 +44,9 @@ func manifestSanityCheck(ext *extension.Extension) error {
 	// Check type
 	if ext.Type != extension.TypeMangaProvider &&
 		ext.Type != extension.TypeOnlinestreamProvider &&
-		ext.Type != extension.TypeAnimeTorrentProvider {
+		ext.Type != extension.TypeAnimeTorrentProvider &&
+		ext.Type != extension.TypeLibraryMatcher &&
+		ext.Type != extension.TypeMediaPlayer {
 		return fmt.Errorf("unsupported extension type: %v", ext.Type)
 	}
 
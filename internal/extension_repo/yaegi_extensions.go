@@ -107,36 +107,3 @@ func NewYaegiMangaProvider(interp *interp.Interpreter, ext *extension.Extension,
 
 	return provider, nil
 }
-
-//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-
-//func NewYaegiMediaPlayer(interp *interp.Interpreter, ext *extension.Extension, logger *zerolog.Logger) (hibikemediaplayer.MediaPlayer, error) {
-//
-//	extensionPackageName := "ext_" + util.GenerateCryptoID()
-//
-//	logger.Trace().Str("id", ext.ID).Str("language", "go").Str("packageName", extensionPackageName).Msg("extensions: Loading media player extension")
-//
-//	// Load the extension payload
-//	_, err := yaegiEval(interp, ReplacePackageName(ext.Payload, extensionPackageName))
-//	if err != nil {
-//		logger.Error().Err(err).Str("id", ext.ID).Msg(MsgYaegiFailedToEvaluateExtensionCode)
-//		return nil, fmt.Errorf(MsgYaegiFailedToEvaluateExtensionCode+": %v", err)
-//	}
-//
-//	// Get the provider
-//	newProviderFuncVal, err := yaegiEval(interp, extensionPackageName+`.NewMediaPlayer`)
-//	if err != nil {
-//		logger.Error().Err(err).Str("id", ext.ID).Msg(MsgYaegiFailedToEvaluateExtensionCode)
-//		return nil, fmt.Errorf(MsgYaegiFailedToEvaluateExtensionCode+": %v", err)
-//	}
-//
-//	newProviderFunc, ok := newProviderFuncVal.Interface().(func(logger *zerolog.Logger) hibikemediaplayer.MediaPlayer)
-//	if !ok {
-//		logger.Error().Str("id", ext.ID).Msg(MsgYaegiFailedToInstantiateExtension)
-//		return nil, fmt.Errorf(MsgYaegiFailedToInstantiateExtension)
-//	}
-//
-//	provider := newProviderFunc(logger)
-//
-//	return provider, nil
-//}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"os"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/library/scanner"
+	"time"
+)
+
+type AniDBFileIndexInfo struct {
+	Count     int        `json:"count"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	// Skipped is the number of entries of the dump that could not be parsed or resolved, only set after an import.
+	Skipped int `json:"skipped"`
+}
+
+// HandleGetAniDBFileIndexInfo
+//
+//	@summary returns information about the imported AniDB file index.
+//	@desc The index is used by the scanner to identify files by their ED2K hash when "scannerHashIdentification" is enabled.
+//	@route /api/v1/library/anidb-file-index [GET]
+//	@returns handlers.AniDBFileIndexInfo
+func HandleGetAniDBFileIndexInfo(c *RouteCtx) error {
+	index := db_bridge.GetAniDBFileIndex(c.App.Database)
+	if index == nil {
+		return c.RespondWithData(&AniDBFileIndexInfo{})
+	}
+
+	return c.RespondWithData(&AniDBFileIndexInfo{
+		Count:     len(index.Files),
+		UpdatedAt: &index.UpdatedAt,
+	})
+}
+
+// HandleImportAniDBFileIndex
+//
+//	@summary imports an AniDB file index from a dump file.
+//	@desc The dump file is read from the given path and replaces the current index.
+//	@desc Each line of the dump is "ed2k|size|aid|epno[|anilist_id]".
+//	@desc Entries without an AniList ID are resolved using AniZip, entries that cannot be resolved are skipped.
+//	@route /api/v1/library/anidb-file-index [POST]
+//	@returns handlers.AniDBFileIndexInfo
+func HandleImportAniDBFileIndex(c *RouteCtx) error {
+
+	type body struct {
+		Path string `json:"path"`
+	}
+
+	var b body
+	if err := c.Fiber.BodyParser(&b); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	if b.Path == "" {
+		return c.RespondWithError(errors.New("path is required"))
+	}
+
+	file, err := os.Open(b.Path)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+	defer file.Close()
+
+	index, skipped, err := scanner.ParseAniDBFileDump(file)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	skipped += index.ResolveMediaIds(c.App.AnizipCache, c.App.Logger)
+
+	if err = db_bridge.SaveAniDBFileIndex(c.App.Database, index); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	c.App.Logger.Info().Int("count", len(index.Files)).Int("skipped", skipped).Msg("scanner: Imported AniDB file index")
+
+	return c.RespondWithData(&AniDBFileIndexInfo{
+		Count:     len(index.Files),
+		UpdatedAt: &index.UpdatedAt,
+		Skipped:   skipped,
+	})
+}
+
+// HandleDeleteAniDBFileIndex
+//
+//	@summary deletes the imported AniDB file index.
+//	@route /api/v1/library/anidb-file-index [DELETE]
+//	@returns bool
+func HandleDeleteAniDBFileIndex(c *RouteCtx) error {
+	if err := c.App.Database.DeleteAniDBFileIndex(); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(true)
+}
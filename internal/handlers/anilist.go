@@ -105,6 +105,8 @@ func HandleEditAnilistListEntry(c *RouteCtx) error {
 		_, _ = c.App.RefreshMangaCollection()
 	}
 
+	c.App.ListSync.TriggerAutomaticSync()
+
 	return c.RespondWithData(true)
 }
 
@@ -410,3 +412,56 @@ func HandleGetAniListStats(c *RouteCtx) error {
 
 	return c.RespondWithData(ret)
 }
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// HandleGetAnilistMutationQueue
+//
+//	@summary returns the list updates that have not been sent to AniList yet.
+//	@desc Updates that failed because AniList could not be reached are retried in the background.
+//	@route /api/v1/anilist/mutation-queue [GET]
+//	@returns []anilist_platform.QueuedMutation
+func HandleGetAnilistMutationQueue(c *RouteCtx) error {
+	ret, err := c.App.AnilistMutationQueue.GetMutations()
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(ret)
+}
+
+// HandleRetryAnilistMutationQueue
+//
+//	@summary sends the queued list updates to AniList immediately.
+//	@desc The client should refetch the queue after this mutation.
+//	@route /api/v1/anilist/mutation-queue/retry [POST]
+//	@returns bool
+func HandleRetryAnilistMutationQueue(c *RouteCtx) error {
+	c.App.AnilistMutationQueue.RetryNow()
+
+	return c.RespondWithData(true)
+}
+
+// HandleDeleteAnilistQueuedMutation
+//
+//	@summary removes a queued list update.
+//	@desc The update will not be sent to AniList.
+//	@route /api/v1/anilist/mutation-queue [DELETE]
+//	@returns bool
+func HandleDeleteAnilistQueuedMutation(c *RouteCtx) error {
+
+	type body struct {
+		MediaId int `json:"mediaId"`
+	}
+
+	p := new(body)
+	if err := c.Fiber.BodyParser(p); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	if err := c.App.AnilistMutationQueue.Remove(p.MediaId); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(true)
+}
@@ -16,6 +16,7 @@ import (
 	"seanime/internal/library/anime"
 	"seanime/internal/library/scanner"
 	"seanime/internal/library/summary"
+	"seanime/internal/tracker"
 	"seanime/internal/util"
 	"seanime/internal/util/limiter"
 	"seanime/internal/util/result"
@@ -370,7 +371,7 @@ func HandleAnimeEntryManualMatch(c *RouteCtx) error {
 
 	// Save the scan summary
 	go func() {
-		err = db_bridge.InsertScanSummary(c.App.Database, scanSummaryLogger.GenerateSummary())
+		err = db_bridge.InsertScanSummary(c.App.Database, scanSummaryLogger.GenerateSummary(), selectedLfs)
 	}()
 
 	// Remove select local files from the database slice, we will add them (hydrated) later
@@ -524,9 +525,10 @@ func HandleUpdateAnimeEntryProgress(c *RouteCtx) error {
 		return c.RespondWithError(err)
 	}
 
-	// Update the progress on AniList
-	err := c.App.AnilistPlatform.UpdateEntryProgress(
+	// Update the progress on AniList and the other enabled trackers
+	_, err := c.App.TrackerRepository.UpdateProgress(
 		b.MediaId,
+		tracker.AnimeKind,
 		b.EpisodeNumber,
 		&b.TotalEpisodes,
 	)
@@ -536,5 +538,7 @@ func HandleUpdateAnimeEntryProgress(c *RouteCtx) error {
 
 	_, _ = c.App.RefreshAnimeCollection() // Refresh the AniList collection
 
+	c.App.ListSync.TriggerAutomaticSync()
+
 	return c.RespondWithData(true)
 }
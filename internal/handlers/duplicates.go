@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	"os"
+	"path/filepath"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/organizer"
+	"seanime/internal/library/scanner"
+	"seanime/internal/util"
+)
+
+// HandleGetDuplicateEpisodes
+//
+//	@summary returns the episodes that have several files.
+//	@desc The files of each episode are ranked with the duplicate policy of the library settings.
+//	@desc The first file is preferred, the others are alternates and are not listed in the episodes of the entry.
+//	@route /api/v1/library/duplicates [GET]
+//	@returns []scanner.DuplicateEpisodeGroup
+func HandleGetDuplicateEpisodes(c *RouteCtx) error {
+
+	settings, err := c.App.Database.GetSettings()
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	lfs, _, err := db_bridge.GetLocalFiles(c.App.Database)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(scanner.FindDuplicateEpisodes(lfs, settings.Library.DuplicatePolicy))
+}
+
+// HandleResolveDuplicateEpisodes
+//
+//	@summary deletes or moves alternate files.
+//	@desc "action" is either "delete" or "move". Moved files are put in "destination", an absolute path outside the library roots.
+//	@desc Files are never overwritten, a file whose name is taken in "destination" is not moved.
+//	@desc If "paths" is empty, all the alternate files are resolved. Otherwise, only the given alternate files are.
+//	@desc Preferred files are never deleted or moved.
+//	@desc The resolved files are removed from the local files.
+//	@route /api/v1/library/duplicates/resolve [POST]
+//	@returns []anime.LocalFile
+func HandleResolveDuplicateEpisodes(c *RouteCtx) error {
+
+	type body struct {
+		Action      string   `json:"action"`
+		Destination string   `json:"destination"`
+		Paths       []string `json:"paths"`
+	}
+
+	var b body
+	if err := c.Fiber.BodyParser(&b); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	settings, err := c.App.Database.GetSettings()
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	switch b.Action {
+	case "delete":
+	case "move":
+		if b.Destination == "" {
+			return c.RespondWithError(errors.New("destination is required"))
+		}
+		if !filepath.IsAbs(b.Destination) {
+			return c.RespondWithError(errors.New("destination must be an absolute path"))
+		}
+		// Moved files would be scanned again
+		for _, root := range settings.Library.GetLibraryRoots() {
+			if util.IsSubdirectory(root.Path, b.Destination) {
+				return c.RespondWithError(fmt.Errorf("destination must be outside the library root \"%s\"", root.Path))
+			}
+		}
+		if err = os.MkdirAll(b.Destination, 0755); err != nil {
+			return c.RespondWithError(err)
+		}
+	default:
+		return c.RespondWithError(fmt.Errorf("unknown action \"%s\"", b.Action))
+	}
+
+	lfs, lfsId, err := db_bridge.GetLocalFiles(c.App.Database)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	// Get the alternate files to resolve
+	alternates := make([]*anime.LocalFile, 0)
+	for _, group := range scanner.FindDuplicateEpisodes(lfs, settings.Library.DuplicatePolicy) {
+		for _, lf := range group.Alternates {
+			if len(b.Paths) == 0 || lo.ContainsBy(b.Paths, lf.HasSamePath) {
+				alternates = append(alternates, lf)
+			}
+		}
+	}
+
+	resolved := make(map[string]struct{})
+	for _, lf := range alternates {
+		if b.Action == "delete" {
+			err = os.Remove(lf.Path)
+		} else {
+			err = organizer.MoveFile(lf.Path, filepath.Join(b.Destination, filepath.Base(lf.Path)))
+		}
+		if err != nil {
+			c.App.Logger.Warn().Err(err).Str("path", lf.Path).Msgf("duplicates: Failed to %s alternate file", b.Action)
+			continue
+		}
+		resolved[lf.GetNormalizedPath()] = struct{}{}
+	}
+
+	// Remove the resolved files from the local files
+	newLfs := lo.Filter(lfs, func(lf *anime.LocalFile, _ int) bool {
+		_, ok := resolved[lf.GetNormalizedPath()]
+		return !ok
+	})
+	// Update the alternate files of the remaining duplicates
+	scanner.MarkAlternateLocalFiles(newLfs, settings.Library.DuplicatePolicy, nil)
+
+	retLfs, err := db_bridge.SaveLocalFiles(c.App.Database, lfsId, newLfs)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(retLfs)
+}
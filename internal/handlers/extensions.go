@@ -164,6 +164,27 @@ func HandleListAnimeTorrentProviderExtensions(c *RouteCtx) error {
 	return c.RespondWithData(extensions)
 }
 
+// HandleListLibraryMatcherExtensions
+//
+//	@summary returns the installed library matchers.
+//	@route /api/v1/extensions/list/library-matcher [GET]
+//	@returns []extension_repo.LibraryMatcherExtensionItem
+func HandleListLibraryMatcherExtensions(c *RouteCtx) error {
+	extensions := c.App.ExtensionRepository.ListLibraryMatcherExtensions()
+	return c.RespondWithData(extensions)
+}
+
+// HandleListMediaPlayerExtensions
+//
+//	@summary returns the installed media players.
+//	@desc The ID of a media player extension can be used as the default media player.
+//	@route /api/v1/extensions/list/mediaplayer [GET]
+//	@returns []extension_repo.MediaPlayerExtensionItem
+func HandleListMediaPlayerExtensions(c *RouteCtx) error {
+	extensions := c.App.ExtensionRepository.ListMediaPlayerExtensions()
+	return c.RespondWithData(extensions)
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // HandleRunExtensionPlaygroundCode
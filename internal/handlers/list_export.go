@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"fmt"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/listexport"
+)
+
+// HandleExportList
+//
+//	@summary exports the user's lists and local library.
+//	@desc The "format" query parameter is either "mal_xml", "csv" or "json" (default).
+//	@desc The "type" query parameter can be "anime" or "manga" to only export one media type.
+//	@desc The JSON archive contains the anime and manga collections and, for each media, the episodes on disk, the file paths and the release groups.
+//	@desc The MyAnimeList XML export only contains entries that are on MyAnimeList, it can be imported on MyAnimeList.
+//	@desc The response is the file, not a JSON object.
+//	@route /api/v1/list-export [GET]
+//	@returns nil
+func HandleExportList(c *RouteCtx) error {
+
+	format, err := listexport.ParseFormat(c.Fiber.Query("format"))
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	animeCollection, err := c.App.GetAnimeCollection(false)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	mangaCollection, err := c.App.GetMangaCollection(false)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	// The library can be empty
+	lfs, _, _ := db_bridge.GetLocalFiles(c.App.Database)
+
+	export, err := listexport.NewExport(&listexport.ExportOptions{
+		Format:          format,
+		MediaType:       listexport.MediaType(c.Fiber.Query("type")),
+		AnimeCollection: animeCollection,
+		MangaCollection: mangaCollection,
+		LocalFiles:      lfs,
+	})
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	c.Fiber.Set("Content-Type", export.ContentType)
+	c.Fiber.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename))
+	return c.Fiber.Send(export.Data)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"seanime/internal/listimport"
+)
+
+// HandleImportList
+//
+//	@summary imports list entries from a MyAnimeList XML export or an AniList JSON export.
+//	@desc The "content" field is the content of the file, binary files (e.g. gzipped MyAnimeList exports) must be base64 encoded with "isBase64" set to true.
+//	@desc The "format" field is either "mal_xml" or "anilist_json", it is detected from the content if empty.
+//	@desc Entries are written to the list of the platform used by the app.
+//	@desc Entries that are already in the list with different data are reported as conflicts and left untouched unless "overwrite" is true.
+//	@desc If "dryRun" is true, nothing is written and the returned report describes what the import would do.
+//	@desc The client should refetch collection-dependent queries after this mutation.
+//	@route /api/v1/list-import [POST]
+//	@returns listimport.Report
+func HandleImportList(c *RouteCtx) error {
+
+	type body struct {
+		Content   string `json:"content"`
+		IsBase64  bool   `json:"isBase64"`
+		Format    string `json:"format"`
+		DryRun    bool   `json:"dryRun"`
+		Overwrite bool   `json:"overwrite"`
+	}
+
+	var b body
+	if err := c.Fiber.BodyParser(&b); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	if b.Content == "" {
+		return c.RespondWithError(errors.New("empty file"))
+	}
+
+	data := []byte(b.Content)
+	if b.IsBase64 {
+		var err error
+		data, err = base64.StdEncoding.DecodeString(b.Content)
+		if err != nil {
+			return c.RespondWithError(err)
+		}
+	}
+
+	report, err := c.App.ListImporter.Import(data, &listimport.ImportOptions{
+		Format:    listimport.Format(b.Format),
+		DryRun:    b.DryRun,
+		Overwrite: b.Overwrite,
+	})
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(report)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"seanime/internal/listsync"
+)
+
+// HandleGetListSyncPreview
+//
+//	@summary returns the differences between the AniList and MyAnimeList lists.
+//	@desc This fetches both lists and compares the status, progress, score and dates of each entry.
+//	@desc The returned diffs can then be applied or ignored individually.
+//	@route /api/v1/list-sync/preview [GET]
+//	@returns listsync.Preview
+func HandleGetListSyncPreview(c *RouteCtx) error {
+
+	preview, err := c.App.ListSync.GetPreview()
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(preview)
+}
+
+// HandleApplyListSyncDiffs
+//
+//	@summary applies the given diffs from the last preview.
+//	@desc The "origin" field is the list used as the source of truth ("anilist" or "mal").
+//	@desc If it is empty, the origin from the settings is used.
+//	@desc The client should refetch the preview and collection-dependent queries after this mutation.
+//	@route /api/v1/list-sync/apply [POST]
+//	@returns listsync.ApplyResult
+func HandleApplyListSyncDiffs(c *RouteCtx) error {
+
+	type body struct {
+		DiffIds []string `json:"diffIds"`
+		Origin  string   `json:"origin"`
+	}
+
+	var b body
+	if err := c.Fiber.BodyParser(&b); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	if len(b.DiffIds) == 0 {
+		return c.RespondWithError(errors.New("no diffs to apply"))
+	}
+
+	origin := c.App.ListSync.GetOrigin()
+	if b.Origin != "" {
+		origin = listsync.ParseOrigin(b.Origin)
+	}
+
+	res, err := c.App.ListSync.Apply(b.DiffIds, origin)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(res)
+}
+
+// HandleIgnoreListSyncDiff
+//
+//	@summary ignores or un-ignores a diff from the last preview.
+//	@desc Ignored diffs are skipped by the automatic sync until either list entry changes.
+//	@route /api/v1/list-sync/ignore [POST]
+//	@returns bool
+func HandleIgnoreListSyncDiff(c *RouteCtx) error {
+
+	type body struct {
+		DiffId  string `json:"diffId"`
+		Ignored bool   `json:"ignored"`
+	}
+
+	var b body
+	if err := c.Fiber.BodyParser(&b); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	if err := c.App.ListSync.Ignore(b.DiffId, b.Ignored); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(true)
+}
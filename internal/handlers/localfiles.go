@@ -257,17 +257,19 @@ func HandleDeleteLocalFiles(c *RouteCtx) error {
 
 // HandleRemoveEmptyDirectories
 //
-//	@summary deletes the empty directories from the library path.
+//	@summary deletes the empty directories from the library directories.
 //	@route /api/v1/library/empty-directories [DELETE]
 //	@returns bool
 func HandleRemoveEmptyDirectories(c *RouteCtx) error {
 
-	libraryPath, err := c.App.Database.GetLibraryPathFromSettings()
+	libraryRoots, err := c.App.Database.GetLibraryRootsFromSettings()
 	if err != nil {
 		return c.RespondWithError(err)
 	}
 
-	filesystem.RemoveEmptyDirectories(libraryPath, c.App.Logger)
+	for _, root := range libraryRoots {
+		filesystem.RemoveEmptyDirectories(root.Path, c.App.Logger)
+	}
 
 	return c.RespondWithData(true)
 
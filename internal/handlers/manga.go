@@ -3,6 +3,7 @@ package handlers
 import (
 	"seanime/internal/api/anilist"
 	"seanime/internal/manga"
+	"seanime/internal/tracker"
 	"seanime/internal/util/result"
 	"time"
 )
@@ -357,9 +358,10 @@ func HandleUpdateMangaProgress(c *RouteCtx) error {
 		return c.RespondWithError(err)
 	}
 
-	// Update the progress on AniList
-	err := c.App.AnilistPlatform.UpdateEntryProgress(
+	// Update the progress on AniList and the other enabled trackers
+	_, err := c.App.TrackerRepository.UpdateProgress(
 		b.MediaId,
+		tracker.MangaKind,
 		b.ChapterNumber,
 		&b.TotalChapters,
 	)
@@ -369,6 +371,8 @@ func HandleUpdateMangaProgress(c *RouteCtx) error {
 
 	_, _ = c.App.RefreshMangaCollection() // Refresh the AniList collection
 
+	c.App.ListSync.TriggerAutomaticSync()
+
 	return c.RespondWithData(true)
 }
 
@@ -1,5 +1,10 @@
 package handlers
 
+import (
+	"seanime/internal/mediaplayers/upnp"
+	"time"
+)
+
 // HandleStartDefaultMediaPlayer
 //
 //	@summary launches the default media player (vlc or mpc-hc).
@@ -24,8 +29,36 @@ func HandleStartDefaultMediaPlayer(c *RouteCtx) error {
 		if err != nil {
 			return c.RespondWithError(err)
 		}
+	case "kodi":
+		// Kodi cannot be launched remotely, check that it can be reached
+		err = c.App.MediaPlayer.Kodi.Ping()
+		if err != nil {
+			return c.RespondWithError(err)
+		}
+	case "upnp":
+		// The renderer is turned on from the TV, check that it can be found
+		_, err = c.App.MediaPlayer.Upnp.GetDevice()
+		if err != nil {
+			return c.RespondWithError(err)
+		}
 	}
 
 	return c.RespondWithData(true)
 
 }
+
+// HandleDiscoverUpnpRenderers
+//
+//	@summary returns the UPnP/DLNA media renderers found on the network.
+//	@desc The UDN of a renderer can be used as the "upnpRenderer" media player setting.
+//	@route /api/v1/media-player/upnp/renderers [GET]
+//	@returns []upnp.Device
+func HandleDiscoverUpnpRenderers(c *RouteCtx) error {
+
+	devices, err := upnp.Discover(upnp.MediaRendererType, 3*time.Second)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(devices)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"errors"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/organizer"
+	"seanime/internal/library/scanner"
+)
+
+// HandleOrganizeLocalFiles
+//
+//	@summary moves, copies or hard links the matched files to the layout given by a template.
+//	@desc The options of the library settings are used, the ones in the body override them (e.g. to preview another template).
+//	@desc If "dryRun" is true, nothing is changed and the planned operations are returned.
+//	@desc The local files are updated in place, so that their match and lock state is kept.
+//	@desc The operations are saved in a journal that can be undone with HandleUndoOrganizeLocalFiles.
+//	@route /api/v1/library/organize [POST]
+//	@returns organizer.Journal
+func HandleOrganizeLocalFiles(c *RouteCtx) error {
+
+	type body struct {
+		DryRun      bool   `json:"dryRun"`
+		Template    string `json:"template"`
+		Mode        string `json:"mode"`
+		Destination string `json:"destination"`
+	}
+
+	var b body
+	if err := c.Fiber.BodyParser(&b); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	settings, err := c.App.Database.GetSettings()
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	opts := &models.OrganizerSettings{Enabled: true}
+	if settings.Library.Organizer != nil {
+		*opts = *settings.Library.Organizer
+		opts.Enabled = true
+	}
+	if b.Template != "" {
+		opts.Template = b.Template
+	}
+	if b.Mode != "" {
+		opts.Mode = b.Mode
+	}
+	if b.Destination != "" {
+		opts.Destination = b.Destination
+	}
+	library := *settings.Library
+	library.Organizer = opts
+
+	org := organizer.NewFromSettings(&library, c.App.Logger)
+	org.DryRun = b.DryRun
+
+	lfs, lfsId, err := db_bridge.GetLocalFiles(c.App.Database)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	collection, err := c.App.GetAnimeCollection(false)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	journal, err := org.Organize(lfs, organizer.NewMediaFromCollection(collection))
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	if b.DryRun {
+		return c.RespondWithData(journal)
+	}
+
+	if _, err = db_bridge.SaveLocalFiles(c.App.Database, lfsId, lfs); err != nil {
+		// The files should not stay at paths the local files do not know about
+		if undoErr := organizer.Undo(journal, lfs, c.App.Logger); undoErr != nil {
+			saveOrganizerJournal(c, journal)
+		}
+		return c.RespondWithError(err)
+	}
+
+	// Update the fingerprint index so that incremental scans do not match the files again
+	if index := db_bridge.GetFingerprintIndex(c.App.Database); index != nil {
+		renameOrganizedFiles(index, journal)
+		if err = db_bridge.SaveFingerprintIndex(c.App.Database, index); err != nil {
+			c.App.Logger.Warn().Err(err).Msg("organizer: Failed to save fingerprint index")
+		}
+	}
+
+	saveOrganizerJournal(c, journal)
+
+	return c.RespondWithData(journal)
+}
+
+// HandleGetOrganizerJournals
+//
+//	@summary returns the journals of the last organizer runs, from newest to oldest.
+//	@route /api/v1/library/organize/journals [GET]
+//	@returns []organizer.Journal
+func HandleGetOrganizerJournals(c *RouteCtx) error {
+	journals, err := db_bridge.GetOrganizerJournals(c.App.Database)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+	return c.RespondWithData(journals)
+}
+
+// HandleUndoOrganizeLocalFiles
+//
+//	@summary reverts the operations of an organizer run.
+//	@desc Moved files are moved back, copies and hard links are removed, and the local files are updated in place.
+//	@desc The journal is deleted once all of its operations are undone.
+//	@route /api/v1/library/organize/undo [POST]
+//	@returns organizer.Journal
+func HandleUndoOrganizeLocalFiles(c *RouteCtx) error {
+
+	type body struct {
+		ID uint `json:"id"`
+	}
+
+	var b body
+	if err := c.Fiber.BodyParser(&b); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	journal, err := db_bridge.GetOrganizerJournal(c.App.Database, b.ID)
+	if err != nil {
+		return c.RespondWithError(errors.New("journal not found"))
+	}
+
+	lfs, lfsId, err := db_bridge.GetLocalFiles(c.App.Database)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	undoErr := organizer.Undo(journal, lfs, c.App.Logger)
+
+	if _, err = db_bridge.SaveLocalFiles(c.App.Database, lfsId, lfs); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	// Update the fingerprint index so that incremental scans do not match the files again
+	if index := db_bridge.GetFingerprintIndex(c.App.Database); index != nil {
+		for _, op := range journal.Operations {
+			if op.Status == organizer.OperationStatusUndone {
+				index.Rename(op.To, op.From, false)
+			}
+		}
+		if err = db_bridge.SaveFingerprintIndex(c.App.Database, index); err != nil {
+			c.App.Logger.Warn().Err(err).Msg("organizer: Failed to save fingerprint index")
+		}
+	}
+
+	if undoErr != nil {
+		_ = db_bridge.UpdateOrganizerJournal(c.App.Database, journal)
+		return c.RespondWithError(undoErr)
+	}
+
+	_ = c.App.Database.DeleteOrganizerJournal(journal.ID)
+
+	return c.RespondWithData(journal)
+}
+
+// organizeScannedLocalFiles organizes the files after a scan if the organizer is enabled.
+// The local files must already be saved, they are saved again with their new paths and the operations are undone if that fails.
+// The local files and the fingerprint index of the scan are updated in place.
+func organizeScannedLocalFiles(c *RouteCtx, settings *models.Settings, lfs []*anime.LocalFile, index *scanner.FingerprintIndex) {
+	org := organizer.NewFromSettings(settings.Library, c.App.Logger)
+	if org == nil {
+		return
+	}
+
+	collection, err := c.App.GetAnimeCollection(false)
+	if err != nil {
+		c.App.Logger.Warn().Err(err).Msg("organizer: Failed to get anime collection")
+		return
+	}
+
+	journal, err := org.Organize(lfs, organizer.NewMediaFromCollection(collection))
+	if err != nil {
+		c.App.Logger.Warn().Err(err).Msg("organizer: Failed to organize local files")
+		return
+	}
+
+	if len(journal.GetDoneOperations()) > 0 {
+		_, lfsId, err := db_bridge.GetLocalFiles(c.App.Database)
+		if err == nil {
+			_, err = db_bridge.SaveLocalFiles(c.App.Database, lfsId, lfs)
+		}
+		if err != nil {
+			c.App.Logger.Error().Err(err).Msg("organizer: Failed to save organized local files, undoing operations")
+			if err = organizer.Undo(journal, lfs, c.App.Logger); err != nil {
+				// Keep the journal so that the remaining operations can be undone
+				c.App.Logger.Error().Err(err).Msg("organizer: Failed to undo operations")
+				saveOrganizerJournal(c, journal)
+			}
+			return
+		}
+	}
+
+	if index != nil {
+		renameOrganizedFiles(index, journal)
+	}
+
+	saveOrganizerJournal(c, journal)
+}
+
+// renameOrganizedFiles sets the fingerprints of the organized files at their new path.
+func renameOrganizedFiles(index *scanner.FingerprintIndex, journal *organizer.Journal) {
+	for _, op := range journal.GetDoneOperations() {
+		index.Rename(op.From, op.To, journal.Mode != organizer.ModeMove)
+	}
+}
+
+func saveOrganizerJournal(c *RouteCtx, journal *organizer.Journal) {
+	if err := db_bridge.InsertOrganizerJournal(c.App.Database, journal); err != nil {
+		c.App.Logger.Warn().Err(err).Msg("organizer: Failed to save journal")
+	}
+}
@@ -148,6 +148,7 @@ func InitRoutes(app *core.App, fiberApp *fiber.App) {
 	v1.Post("/open-in-explorer", makeHandler(app, HandleOpenInExplorer))
 
 	v1.Post("/media-player/start", makeHandler(app, HandleStartDefaultMediaPlayer))
+	v1.Get("/media-player/upnp/renderers", makeHandler(app, HandleDiscoverUpnpRenderers))
 
 	//
 	// AniList
@@ -175,6 +176,11 @@ func InitRoutes(app *core.App, fiberApp *fiber.App) {
 
 	v1Anilist.Get("/stats", makeHandler(app, HandleGetAniListStats))
 
+	v1Anilist.Get("/mutation-queue", makeHandler(app, HandleGetAnilistMutationQueue))
+	v1Anilist.Delete("/mutation-queue", makeHandler(app, HandleDeleteAnilistQueuedMutation))
+
+	v1Anilist.Post("/mutation-queue/retry", makeHandler(app, HandleRetryAnilistMutationQueue))
+
 	//
 	// MAL
 	//
@@ -183,6 +189,30 @@ func InitRoutes(app *core.App, fiberApp *fiber.App) {
 
 	v1.Post("/mal/logout", makeHandler(app, HandleMALLogout))
 
+	//
+	// List Sync
+	//
+
+	v1ListSync := v1.Group("/list-sync")
+
+	v1ListSync.Get("/preview", makeHandler(app, HandleGetListSyncPreview))
+
+	v1ListSync.Post("/apply", makeHandler(app, HandleApplyListSyncDiffs))
+
+	v1ListSync.Post("/ignore", makeHandler(app, HandleIgnoreListSyncDiff))
+
+	//
+	// List Import
+	//
+
+	v1.Post("/list-import", makeHandler(app, HandleImportList))
+
+	//
+	// List Export
+	//
+
+	v1.Get("/list-export", makeHandler(app, HandleExportList))
+
 	//
 	// Library
 	//
@@ -191,6 +221,16 @@ func InitRoutes(app *core.App, fiberApp *fiber.App) {
 
 	v1Library.Post("/scan", makeHandler(app, HandleScanLocalFiles))
 
+	v1Library.Post("/scan/plan", makeHandler(app, HandlePlanScanLocalFiles))
+
+	v1Library.Post("/scan/cancel", makeHandler(app, HandleCancelScan))
+
+	v1Library.Get("/anidb-file-index", makeHandler(app, HandleGetAniDBFileIndexInfo))
+
+	v1Library.Post("/anidb-file-index", makeHandler(app, HandleImportAniDBFileIndex))
+
+	v1Library.Delete("/anidb-file-index", makeHandler(app, HandleDeleteAniDBFileIndex))
+
 	v1Library.Delete("/empty-directories", makeHandler(app, HandleRemoveEmptyDirectories))
 
 	v1Library.Get("/local-files", makeHandler(app, HandleGetLocalFiles))
@@ -201,10 +241,24 @@ func InitRoutes(app *core.App, fiberApp *fiber.App) {
 
 	v1Library.Delete("/local-files", makeHandler(app, HandleDeleteLocalFiles))
 
+	v1Library.Get("/duplicates", makeHandler(app, HandleGetDuplicateEpisodes))
+
+	v1Library.Post("/duplicates/resolve", makeHandler(app, HandleResolveDuplicateEpisodes))
+
+	v1Library.Post("/organize", makeHandler(app, HandleOrganizeLocalFiles))
+
+	v1Library.Get("/organize/journals", makeHandler(app, HandleGetOrganizerJournals))
+
+	v1Library.Post("/organize/undo", makeHandler(app, HandleUndoOrganizeLocalFiles))
+
 	v1Library.Get("/collection", makeHandler(app, HandleGetLibraryCollection))
 
 	v1Library.Get("/scan-summaries", makeHandler(app, HandleGetScanSummaries))
 
+	v1Library.Post("/scan-summaries/file-history", makeHandler(app, HandleGetScanFileHistory))
+
+	v1Library.Post("/scan-summaries/media-flips", makeHandler(app, HandleGetScanMediaFlips))
+
 	v1Library.Get("/missing-episodes", makeHandler(app, HandleGetMissingEpisodes))
 
 	v1Library.Patch("/local-file", makeHandler(app, HandleUpdateLocalFileData))
@@ -414,6 +468,8 @@ func InitRoutes(app *core.App, fiberApp *fiber.App) {
 	v1Extensions.Get("/list/manga-provider", makeHandler(app, HandleListMangaProviderExtensions))
 	v1Extensions.Get("/list/onlinestream-provider", makeHandler(app, HandleListOnlinestreamProviderExtensions))
 	v1Extensions.Get("/list/anime-torrent-provider", makeHandler(app, HandleListAnimeTorrentProviderExtensions))
+	v1Extensions.Get("/list/library-matcher", makeHandler(app, HandleListLibraryMatcherExtensions))
+	v1Extensions.Get("/list/mediaplayer", makeHandler(app, HandleListMediaPlayerExtensions))
 
 	//
 	// Websocket
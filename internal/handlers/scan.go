@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"seanime/internal/database/db_bridge"
 	"seanime/internal/library/scanner"
@@ -12,6 +13,8 @@ import (
 //	@summary scans the user's library.
 //	@desc This will scan the user's library.
 //	@desc The response is ignored, the client should re-fetch the library after this.
+//	@desc If "incremental" is true, only files that are new or changed since the last scan are matched.
+//	@desc If the last scan with the same options was interrupted, the scan resumes from its last completed stage.
 //	@route /api/v1/library/scan [POST]
 //	@returns []anime.LocalFile
 func HandleScanLocalFiles(c *RouteCtx) error {
@@ -22,15 +25,20 @@ func HandleScanLocalFiles(c *RouteCtx) error {
 		Enhanced         bool `json:"enhanced"`
 		SkipLockedFiles  bool `json:"skipLockedFiles"`
 		SkipIgnoredFiles bool `json:"skipIgnoredFiles"`
+		Incremental      bool `json:"incremental"`
 	}
 
 	var b body
 
-	// Retrieve the user's library path
-	libraryPath, err := c.App.Database.GetLibraryPathFromSettings()
+	// Retrieve the user's library directories
+	settings, err := c.App.Database.GetSettings()
 	if err != nil {
 		return c.RespondWithError(err)
 	}
+	libraryRoots := settings.Library.GetEnabledLibraryRoots()
+	if len(libraryRoots) == 0 {
+		return c.RespondWithError(errors.New("library path is not set"))
+	}
 
 	if err = c.Fiber.BodyParser(&b); err != nil {
 		return c.RespondWithError(err)
@@ -55,9 +63,15 @@ func HandleScanLocalFiles(c *RouteCtx) error {
 		return c.RespondWithError(err)
 	}
 
+	// Files are identified by hash if enabled
+	var fileIndex *scanner.AniDBFileIndex
+	if settings.Library.ScannerHashIdentification {
+		fileIndex = db_bridge.GetAniDBFileIndex(c.App.Database)
+	}
+
 	// Create a new scanner
 	sc := scanner.Scanner{
-		DirPath:            libraryPath,
+		Roots:              libraryRoots,
 		Enhanced:           b.Enhanced,
 		Platform:           c.App.AnilistPlatform,
 		Logger:             c.App.Logger,
@@ -67,29 +81,149 @@ func HandleScanLocalFiles(c *RouteCtx) error {
 		SkipIgnoredFiles:   b.SkipIgnoredFiles,
 		ScanSummaryLogger:  scanSummaryLogger,
 		ScanLogger:         scanLogger,
+		Incremental:        b.Incremental,
+		FingerprintIndex:   db_bridge.GetFingerprintIndex(c.App.Database),
+		UsePartialHash:     settings.Library.ScannerUsePartialHash,
+		Concurrency:        settings.Library.ScannerConcurrency,
+		DuplicatePolicy:    settings.Library.DuplicatePolicy,
+		LibraryMatchers:    c.App.ExtensionRepository.GetLibraryMatcherExtensions(),
+		FileIndex:          fileIndex,
+		Checkpoint:         db_bridge.GetScanCheckpoint(c.App.Database),
+		OnCheckpoint: func(cp *scanner.ScanCheckpoint) {
+			if err := db_bridge.SaveScanCheckpoint(c.App.Database, cp); err != nil {
+				c.App.Logger.Warn().Err(err).Msg("scanner: Failed to save scan checkpoint")
+			}
+		},
 	}
 
+	// The scan can be cancelled with HandleCancelScan
+	ctx, done := c.App.ScanTracker.Track(context.Background())
+	defer done()
+
 	// Scan the library
-	allLfs, err := sc.Scan()
+	allLfs, err := sc.Scan(ctx)
 	if err != nil {
 		if errors.Is(err, scanner.ErrNoLocalFiles) {
+			_ = c.App.Database.DeleteScanCheckpoint()
 			return c.RespondWithData([]interface{}{})
 		} else {
+			// The checkpoint is kept so that the next scan resumes from it
 			return c.RespondWithError(err)
 		}
 	}
 
+	// The scan completed, it should not be resumed
+	_ = c.App.Database.DeleteScanCheckpoint()
+
 	// Insert the local files
 	lfs, err := db_bridge.InsertLocalFiles(c.App.Database, allLfs)
 	if err != nil {
 		return c.RespondWithError(err)
 	}
 
+	// Move the matched files to the template layout if the organizer is enabled
+	// This is done once the local files are saved so that files are never moved without their local files
+	organizeScannedLocalFiles(c, settings, lfs, sc.GetFingerprintIndex())
+
+	// Save the fingerprint index for the next incremental scan
+	if err = db_bridge.SaveFingerprintIndex(c.App.Database, sc.GetFingerprintIndex()); err != nil {
+		c.App.Logger.Warn().Err(err).Msg("scanner: Failed to save fingerprint index")
+	}
+
 	// Save the scan summary
-	err = db_bridge.InsertScanSummary(c.App.Database, scanSummaryLogger.GenerateSummary())
+	err = db_bridge.InsertScanSummary(c.App.Database, scanSummaryLogger.GenerateSummary(), lfs)
 
 	go c.App.AutoDownloader.CleanUpDownloadedItems()
 
 	return c.RespondWithData(lfs)
 
 }
+
+// HandlePlanScanLocalFiles
+//
+//	@summary returns what a scan of the user's library would change, without saving anything.
+//	@desc This runs the whole scan but does not save the local files, and does not add unknown media to the collection.
+//	@desc The changes are relative to the current local files, with the scan summary logs that explain each change.
+//	@route /api/v1/library/scan/plan [POST]
+//	@returns scanner.ScanPlan
+func HandlePlanScanLocalFiles(c *RouteCtx) error {
+
+	c.AcceptJSON()
+
+	type body struct {
+		Enhanced         bool `json:"enhanced"`
+		SkipLockedFiles  bool `json:"skipLockedFiles"`
+		SkipIgnoredFiles bool `json:"skipIgnoredFiles"`
+		Incremental      bool `json:"incremental"`
+	}
+
+	var b body
+
+	settings, err := c.App.Database.GetSettings()
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+	libraryRoots := settings.Library.GetEnabledLibraryRoots()
+	if len(libraryRoots) == 0 {
+		return c.RespondWithError(errors.New("library path is not set"))
+	}
+
+	if err = c.Fiber.BodyParser(&b); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	existingLfs, _, err := db_bridge.GetLocalFiles(c.App.Database)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	var fileIndex *scanner.AniDBFileIndex
+	if settings.Library.ScannerHashIdentification {
+		fileIndex = db_bridge.GetAniDBFileIndex(c.App.Database)
+	}
+
+	sc := scanner.Scanner{
+		Roots:              libraryRoots,
+		Enhanced:           b.Enhanced,
+		Platform:           c.App.AnilistPlatform,
+		Logger:             c.App.Logger,
+		WSEventManager:     c.App.WSEventManager,
+		ExistingLocalFiles: existingLfs,
+		SkipLockedFiles:    b.SkipLockedFiles,
+		SkipIgnoredFiles:   b.SkipIgnoredFiles,
+		ScanSummaryLogger:  summary.NewScanSummaryLogger(),
+		Incremental:        b.Incremental,
+		FingerprintIndex:   db_bridge.GetFingerprintIndex(c.App.Database),
+		UsePartialHash:     settings.Library.ScannerUsePartialHash,
+		Concurrency:        settings.Library.ScannerConcurrency,
+		DuplicatePolicy:    settings.Library.DuplicatePolicy,
+		LibraryMatchers:    c.App.ExtensionRepository.GetLibraryMatcherExtensions(),
+		FileIndex:          fileIndex,
+	}
+
+	ctx, done := c.App.ScanTracker.Track(context.Background())
+	defer done()
+
+	plan, err := sc.Plan(ctx)
+	if err != nil {
+		// The local files are not replaced if there is nothing to scan
+		if errors.Is(err, scanner.ErrNoLocalFiles) {
+			return c.RespondWithData(scanner.NewScanPlan(existingLfs, existingLfs, nil))
+		}
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(plan)
+}
+
+// HandleCancelScan
+//
+//	@summary cancels the scans in progress.
+//	@desc The scans stop at the end of their current stage.
+//	@desc A cancelled scan resumes from its last completed stage the next time the library is scanned with the same options.
+//	@desc Returns false if there was no scan in progress.
+//	@route /api/v1/library/scan/cancel [POST]
+//	@returns bool
+func HandleCancelScan(c *RouteCtx) error {
+	return c.RespondWithData(c.App.ScanTracker.Cancel())
+}
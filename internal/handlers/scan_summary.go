@@ -16,3 +16,64 @@ func HandleGetScanSummaries(c *RouteCtx) error {
 
 	return c.RespondWithData(sm)
 }
+
+// HandleGetScanFileHistory
+//
+//	@summary returns the state of a file after the last scans that included it.
+//	@desc The entries are ordered from newest to oldest, each one holds the changes since the previous scan.
+//	@desc The logs of the scans that matched the file are included.
+//	@route /api/v1/library/scan-summaries/file-history [POST]
+//	@returns []summary.ScanFileHistoryEntry
+func HandleGetScanFileHistory(c *RouteCtx) error {
+
+	type body struct {
+		Path  string `json:"path"`
+		Limit int    `json:"limit"`
+	}
+
+	var b body
+	if err := c.Fiber.BodyParser(&b); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	if b.Limit <= 0 {
+		b.Limit = 10
+	}
+
+	history, err := db_bridge.GetScanFileHistory(c.App.Database, b.Path, b.Limit)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(history)
+}
+
+// HandleGetScanMediaFlips
+//
+//	@summary returns the files that were matched to another media during the last scans.
+//	@desc If "mediaId" is set, only the files that were matched to this media are returned.
+//	@route /api/v1/library/scan-summaries/media-flips [POST]
+//	@returns []summary.MediaFlip
+func HandleGetScanMediaFlips(c *RouteCtx) error {
+
+	type body struct {
+		Limit   int `json:"limit"`
+		MediaId int `json:"mediaId"`
+	}
+
+	var b body
+	if err := c.Fiber.BodyParser(&b); err != nil {
+		return c.RespondWithError(err)
+	}
+
+	if b.Limit <= 0 {
+		b.Limit = 10
+	}
+
+	flips, err := db_bridge.GetMediaFlips(c.App.Database, b.Limit, b.MediaId)
+	if err != nil {
+		return c.RespondWithError(err)
+	}
+
+	return c.RespondWithData(flips)
+}
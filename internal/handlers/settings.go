@@ -53,6 +53,8 @@ func HandleGettingStarted(c *RouteCtx) error {
 		return c.RespondWithError(err)
 	}
 
+	b.Library.NormalizeLibraryRoots()
+
 	settings, err := c.App.Database.UpsertSettings(&models.Settings{
 		BaseModel: models.BaseModel{
 			ID:        1,
@@ -127,6 +129,7 @@ func HandleSaveSettings(c *RouteCtx) error {
 		Discord       models.DiscordSettings      `json:"discord"`
 		Manga         models.MangaSettings        `json:"manga"`
 		Notifications models.NotificationSettings `json:"notifications"`
+		ListSync      models.ListSyncSettings     `json:"listSync"`
 	}
 	var b body
 
@@ -145,6 +148,11 @@ func HandleSaveSettings(c *RouteCtx) error {
 		autoDownloaderSettings.Enabled = false
 	}
 
+	if prevSettings != nil {
+		b.Library.KeepOmittedSettings(prevSettings.Library)
+	}
+	b.Library.NormalizeLibraryRoots()
+
 	settings, err := c.App.Database.UpsertSettings(&models.Settings{
 		BaseModel: models.BaseModel{
 			ID:        1,
@@ -157,6 +165,7 @@ func HandleSaveSettings(c *RouteCtx) error {
 		Manga:          &b.Manga,
 		Discord:        &b.Discord,
 		Notifications:  &b.Notifications,
+		ListSync:       &b.ListSync,
 		AutoDownloader: &autoDownloaderSettings,
 	})
 
@@ -220,6 +220,10 @@ func (e *AnimeEntry) hydrateEntryEpisodeData(
 
 	p := pool.NewWithResults[*AnimeEntryEpisode]()
 	for _, lf := range e.LocalFiles {
+		// Only the preferred release of an episode is listed
+		if lf.IsAlternate() {
+			continue
+		}
 		p.Go(func() *AnimeEntryEpisode {
 			return NewAnimeEntryEpisode(&NewAnimeEntryEpisodeOptions{
 				LocalFile:            lf,
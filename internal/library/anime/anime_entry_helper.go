@@ -112,7 +112,7 @@ func (e *AnimeEntry) FindLocalFiles() ([]*LocalFile, bool) {
 	return e.LocalFiles, true
 }
 
-// FindMainLocalFiles returns *main* local files.
+// FindMainLocalFiles returns *main* local files, without the alternate releases.
 // Returns false if there are no local files.
 func (e *AnimeEntry) FindMainLocalFiles() ([]*LocalFile, bool) {
 	if !e.IsDownloaded() {
@@ -120,7 +120,7 @@ func (e *AnimeEntry) FindMainLocalFiles() ([]*LocalFile, bool) {
 	}
 	lfs := make([]*LocalFile, 0)
 	for _, lf := range e.LocalFiles {
-		if lf.IsMain() {
+		if lf.IsMain() && !lf.IsAlternate() {
 			lfs = append(lfs, lf)
 		}
 	}
@@ -228,7 +228,7 @@ func (e *SimpleAnimeEntry) FindMainLocalFiles() ([]*LocalFile, bool) {
 	}
 	lfs := make([]*LocalFile, 0)
 	for _, lf := range e.LocalFiles {
-		if lf.IsMain() {
+		if lf.IsMain() && !lf.IsAlternate() {
 			lfs = append(lfs, lf)
 		}
 	}
@@ -120,6 +120,10 @@ func (e *SimpleAnimeEntry) hydrateEntryEpisodeData() {
 
 	p := pool.NewWithResults[*AnimeEntryEpisode]()
 	for _, lf := range e.LocalFiles {
+		// Only the preferred release of an episode is listed
+		if lf.IsAlternate() {
+			continue
+		}
 		lf := lf
 		p.Go(func() *AnimeEntryEpisode {
 			return NewSimpleAnimeEntryEpisode(&NewSimpleAnimeEntryEpisodeOptions{
@@ -31,6 +31,9 @@ type (
 		Episode      int           `json:"episode"`
 		AniDBEpisode string        `json:"aniDBEpisode"`
 		Type         LocalFileType `json:"type"`
+		// IsAlternate is true if another release of the same episode is preferred.
+		// Alternate files are not used for the episode list of the entry.
+		IsAlternate bool `json:"isAlternate,omitempty"`
 	}
 
 	// LocalFileParsedData holds parsed data from a media file's name.
@@ -45,8 +48,11 @@ type (
 		PartRange    []string `json:"partRange,omitempty"`
 		Episode      string   `json:"episode,omitempty"`
 		EpisodeRange []string `json:"episodeRange,omitempty"`
+		EpisodeAlt   string   `json:"episodeAlt,omitempty"` // e.g. "01" in "105 (S05E01)", "105" in "S05E01 - 105"
 		EpisodeTitle string   `json:"episodeTitle,omitempty"`
 		Year         string   `json:"year,omitempty"`
+		Resolution   string   `json:"resolution,omitempty"`
+		Version      string   `json:"version,omitempty"`
 	}
 )
 
@@ -99,6 +105,10 @@ func NewLocalFileParsedData(original string, elements *seanime_parser.Metadata)
 	i.ReleaseGroup = elements.ReleaseGroup
 	i.EpisodeTitle = elements.EpisodeTitle
 	i.Year = elements.Year
+	i.Resolution = elements.VideoResolution
+	if len(elements.ReleaseVersion) > 0 {
+		i.Version = elements.ReleaseVersion[0]
+	}
 
 	if len(elements.SeasonNumber) > 0 {
 		if len(elements.SeasonNumber) == 1 {
@@ -116,6 +126,10 @@ func NewLocalFileParsedData(original string, elements *seanime_parser.Metadata)
 		}
 	}
 
+	if len(elements.EpisodeNumberAlt) == 1 {
+		i.EpisodeAlt = elements.EpisodeNumberAlt[0]
+	}
+
 	if len(elements.PartNumber) > 0 {
 		if len(elements.PartNumber) == 1 {
 			i.Part = elements.PartNumber[0]
@@ -54,6 +54,11 @@ func (f *LocalFile) IsMain() bool {
 	return f.Metadata.Type == LocalFileTypeMain
 }
 
+// IsAlternate returns true if the file is an alternate release of an episode.
+func (f *LocalFile) IsAlternate() bool {
+	return f.Metadata != nil && f.Metadata.IsAlternate
+}
+
 // GetMetadata returns the file metadata.
 // This requires the LocalFile to be hydrated.
 func (f *LocalFile) GetMetadata() *LocalFileMetadata {
@@ -53,10 +53,11 @@ func (lfw *LocalFileWrapper) GetLocalEntryById(mId int) (*LocalFileWrapperEntry,
 }
 
 // GetMainLocalFiles returns the *main* local files.
+// Alternate releases of episodes are not returned.
 func (e *LocalFileWrapperEntry) GetMainLocalFiles() ([]*LocalFile, bool) {
 	lfs := make([]*LocalFile, 0)
 	for _, lf := range e.localFiles {
-		if lf.IsMain() {
+		if lf.IsMain() && !lf.IsAlternate() {
 			lfs = append(lfs, lf)
 		}
 	}
@@ -117,9 +118,10 @@ func (e *LocalFileWrapperEntry) HasMainLocalFiles() bool {
 }
 
 // FindLocalFileWithEpisodeNumber returns the *main* local file with the given episode number.
+// The preferred release is returned if there are alternates.
 func (e *LocalFileWrapperEntry) FindLocalFileWithEpisodeNumber(ep int) (*LocalFile, bool) {
 	for _, lf := range e.localFiles {
-		if !lf.IsMain() {
+		if !lf.IsMain() || lf.IsAlternate() {
 			continue
 		}
 		if lf.GetEpisodeNumber() == ep {
@@ -1,6 +1,7 @@
 package autodownloader
 
 import (
+	"errors"
 	"fmt"
 	hibiketorrent "github.com/5rahim/hibike/pkg/extension/torrent"
 	"github.com/adrg/strutil/metrics"
@@ -199,11 +200,11 @@ func (ad *AutoDownloader) start() {
 		case <-ad.startCh:
 			if ad.settings.Enabled {
 				ad.logger.Debug().Msg("autodownloader: Auto Downloader started")
-				ad.checkForNewEpisodes()
+				_, _ = ad.checkForNewEpisodes()
 			}
 		case <-ticker.C:
 			if ad.settings.Enabled {
-				ad.checkForNewEpisodes()
+				_, _ = ad.checkForNewEpisodes()
 			}
 		}
 		ticker.Stop()
@@ -211,14 +212,25 @@ func (ad *AutoDownloader) start() {
 
 }
 
-func (ad *AutoDownloader) checkForNewEpisodes() {
-	defer util.HandlePanicInModuleThen("autodownloader/checkForNewEpisodes", func() {})
+// CheckForNewEpisodes checks the rules for new episodes right away and waits for the torrents to be added.
+// It returns the number of episodes that were downloaded or added to the queue.
+func (ad *AutoDownloader) CheckForNewEpisodes() (int, error) {
+	if ad == nil {
+		return 0, errors.New("auto downloader is not initialized")
+	}
+	return ad.checkForNewEpisodes()
+}
+
+func (ad *AutoDownloader) checkForNewEpisodes() (downloaded int, err error) {
+	defer util.HandlePanicInModuleThen("autodownloader/checkForNewEpisodes", func() {
+		err = errors.New("autodownloader: recovered from panic")
+	})
 
 	ad.mu.Lock()
-	if ad == nil || ad.torrentRepository == nil || !ad.settings.Enabled || ad.settings.Provider == "" || ad.settings.Provider == torrent.ProviderNone {
+	if ad.torrentRepository == nil || ad.settings == nil || !ad.settings.Enabled || ad.settings.Provider == "" || ad.settings.Provider == torrent.ProviderNone {
 		ad.logger.Warn().Msg("autodownloader: Could not check for new episodes. AutoDownloader is not enabled or provider is not set.")
 		ad.mu.Unlock()
-		return
+		return 0, errors.New("auto downloader is not enabled or provider is not set")
 	}
 
 	// DEVNOTE: [checkForNewEpisodes] is called on startup, when the default anime provider extension has not yet been loaded.
@@ -226,12 +238,12 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 	if !found {
 		//ad.logger.Warn().Msg("autodownloader: Could not check for new episodes. Default provider not found.")
 		ad.mu.Unlock()
-		return
+		return 0, errors.New("default anime provider not found")
 	}
 	if providerExt.GetProvider().GetSettings().Type != hibiketorrent.AnimeProviderTypeMain {
 		ad.logger.Warn().Msgf("autodownloader: Could not check for new episodes. Provider '%s' cannot be used for auto downloading.", providerExt.GetName())
 		ad.mu.Unlock()
-		return
+		return 0, fmt.Errorf("provider '%s' cannot be used for auto downloading", providerExt.GetName())
 	}
 	ad.mu.Unlock()
 
@@ -241,14 +253,14 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 	rules, err := db_bridge.GetAutoDownloaderRules(ad.database)
 	if err != nil {
 		ad.logger.Error().Err(err).Msg("autodownloader: Failed to fetch rules from the database")
-		return
+		return 0, err
 	}
 
 	// Get local files from the database
 	lfs, _, err := db_bridge.GetLocalFiles(ad.database)
 	if err != nil {
 		ad.logger.Error().Err(err).Msg("autodownloader: Failed to fetch local files from the database")
-		return
+		return 0, err
 	}
 	// Create a LocalFileWrapper
 	lfWrapper := anime.NewLocalFileWrapper(lfs)
@@ -257,7 +269,7 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 	torrents, err = ad.getLatestTorrents(rules)
 	if err != nil {
 		ad.logger.Error().Err(err).Msg("autodownloader: Failed to get latest torrents")
-		return
+		return 0, err
 	}
 
 	// Get existing torrents
@@ -269,7 +281,6 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 		}
 	}
 
-	downloaded := 0
 	mu := sync.Mutex{}
 
 	// Going through each rule
@@ -319,7 +330,9 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 				t := torrentsToDownload[0]
 				ok := ad.downloadTorrent(t.torrent, rule, t.episode)
 				if ok {
+					mu.Lock()
 					downloaded++
+					mu.Unlock()
 				}
 				return
 			}
@@ -387,6 +400,7 @@ func (ad *AutoDownloader) checkForNewEpisodes() {
 		}
 	}
 
+	return downloaded, nil
 }
 
 func (ad *AutoDownloader) torrentFollowsRule(
@@ -1,12 +1,17 @@
 package autoscanner
 
 import (
+	"context"
 	"errors"
 	"github.com/rs/zerolog"
 	"seanime/internal/database/db"
 	"seanime/internal/database/db_bridge"
+	"seanime/internal/database/models"
 	"seanime/internal/events"
+	"seanime/internal/extension"
+	"seanime/internal/library/anime"
 	"seanime/internal/library/autodownloader"
+	"seanime/internal/library/organizer"
 	"seanime/internal/library/scanner"
 	"seanime/internal/library/summary"
 	"seanime/internal/notifier"
@@ -30,6 +35,12 @@ type (
 		wsEventManager events.WSEventManagerInterface
 		db             *db.Database                   // Database instance is required to update the local files.
 		autoDownloader *autodownloader.AutoDownloader // AutoDownloader instance is required to refresh queue.
+		extensionBank  *extension.UnifiedBank         // Used to get the library matchers.
+		scanTracker    *scanner.ScanTracker           // Used to cancel the scan.
+		fullScan       bool                           // Used to indicate that a full scan was requested.
+		pendingPaths   []string                       // New files that are scanned on their own.
+		removedLfs     []*anime.LocalFile             // Files removed since the last scan, used to detect moved files.
+		lfsMu          sync.Mutex                     // Used to prevent concurrent updates of the local files.
 	}
 	NewAutoScannerOptions struct {
 		Database       *db.Database
@@ -39,6 +50,8 @@ type (
 		Enabled        bool
 		AutoDownloader *autodownloader.AutoDownloader
 		WaitTime       time.Duration
+		ExtensionBank  *extension.UnifiedBank
+		ScanTracker    *scanner.ScanTracker
 	}
 )
 
@@ -48,6 +61,11 @@ func New(opts *NewAutoScannerOptions) *AutoScanner {
 		wt = opts.WaitTime
 	}
 
+	scanTracker := opts.ScanTracker
+	if scanTracker == nil {
+		scanTracker = scanner.NewScanTracker()
+	}
+
 	return &AutoScanner{
 		fileActionCh:   make(chan struct{}, 1),
 		scannedCh:      make(chan struct{}, 1),
@@ -60,10 +78,13 @@ func New(opts *NewAutoScannerOptions) *AutoScanner {
 		logger:         opts.Logger,
 		wsEventManager: opts.WSEventManager,
 		db:             opts.Database,
+		extensionBank:  opts.ExtensionBank,
+		scanTracker:    scanTracker,
 	}
 }
 
 // Notify is used to notify the AutoScanner that a file action has occurred.
+// This triggers a full scan of the library.
 func (as *AutoScanner) Notify() {
 	if as == nil {
 		return
@@ -76,6 +97,13 @@ func (as *AutoScanner) Notify() {
 	as.mu.Lock()
 	defer as.mu.Unlock()
 
+	as.fullScan = true
+	as.trigger()
+}
+
+// trigger signals the watch loop, or sets the missedAction flag if it is waiting.
+// The mutex should be held.
+func (as *AutoScanner) trigger() {
 	// If we are currently scanning, we will set the missedAction flag to true.
 	if as.waiting {
 		as.missedAction = true
@@ -152,20 +180,42 @@ func (as *AutoScanner) waitAndScan() {
 	as.mu.Unlock()
 
 	// Trigger a scan.
-	as.scan()
+	as.process()
+}
+
+// process scans the new files on their own, or the whole library if a full scan was requested
+// or if some of the new files could not be matched.
+func (as *AutoScanner) process() {
+	as.mu.Lock()
+	fullScan, paths, removedLfs := as.fullScan, as.pendingPaths, as.removedLfs
+	as.fullScan, as.pendingPaths, as.removedLfs = false, nil, nil
+	as.mu.Unlock()
+
+	if !fullScan && len(paths) > 0 {
+		fullScan = !as.scanFiles(paths, removedLfs)
+	}
+
+	if fullScan {
+		_ = as.scan()
+	}
 }
 
 // RunNow bypasses checks and triggers a scan immediately, even if the autoscanner is disabled.
-func (as *AutoScanner) RunNow() {
-	as.scan()
+// It returns an error if the scan failed or was cancelled.
+func (as *AutoScanner) RunNow() error {
+	return as.scan()
 }
 
 // scan is used to trigger a scan.
-func (as *AutoScanner) scan() {
+func (as *AutoScanner) scan() (err error) {
 	defer util.HandlePanicInModuleThen("scanner/autoscanner/scan", func() {
 		as.logger.Error().Msg("autoscanner: Recovered from panic")
+		err = errors.New("autoscanner: recovered from panic")
 	})
 
+	as.lfsMu.Lock()
+	defer as.lfsMu.Unlock()
+
 	// Create scan summary logger
 	scanSummaryLogger := summary.NewScanSummaryLogger()
 
@@ -176,24 +226,31 @@ func (as *AutoScanner) scan() {
 	settings, err := as.db.GetSettings()
 	if err != nil || settings == nil {
 		as.logger.Error().Err(err).Msg("autoscanner: Failed to get settings")
-		return
+		return errors.New("could not get settings")
 	}
 
-	if settings.Library.LibraryPath == "" {
+	libraryRoots := settings.Library.GetEnabledLibraryRoots()
+	if len(libraryRoots) == 0 {
 		as.logger.Error().Msg("autoscanner: Library path is not set")
-		return
+		return errors.New("library path is not set")
 	}
 
 	// Get existing local files
 	existingLfs, _, err := db_bridge.GetLocalFiles(as.db)
 	if err != nil {
 		as.logger.Error().Err(err).Msg("autoscanner: Failed to get existing local files")
-		return
+		return err
+	}
+
+	// Files are identified by hash if enabled
+	var fileIndex *scanner.AniDBFileIndex
+	if settings.Library.ScannerHashIdentification {
+		fileIndex = db_bridge.GetAniDBFileIndex(as.db)
 	}
 
 	// Create a new scanner
 	sc := scanner.Scanner{
-		DirPath:            settings.Library.LibraryPath,
+		Roots:              libraryRoots,
 		Enhanced:           false, // Do not use enhanced mode for auto scanner.
 		Platform:           as.platform,
 		Logger:             as.logger,
@@ -202,18 +259,42 @@ func (as *AutoScanner) scan() {
 		SkipLockedFiles:    true, // Skip locked files by default.
 		SkipIgnoredFiles:   true,
 		ScanSummaryLogger:  scanSummaryLogger,
+		Incremental:        true, // Only new or changed files are matched
+		FingerprintIndex:   db_bridge.GetFingerprintIndex(as.db),
+		UsePartialHash:     settings.Library.ScannerUsePartialHash,
+		Concurrency:        settings.Library.ScannerConcurrency,
+		DuplicatePolicy:    settings.Library.DuplicatePolicy,
+		LibraryMatchers:    extension.GetLibraryMatcherExtensions(as.extensionBank),
+		FileIndex:          fileIndex,
+		Checkpoint:         db_bridge.GetScanCheckpoint(as.db),
+		OnCheckpoint: func(cp *scanner.ScanCheckpoint) {
+			if err := db_bridge.SaveScanCheckpoint(as.db, cp); err != nil {
+				as.logger.Warn().Err(err).Msg("autoscanner: Failed to save scan checkpoint")
+			}
+		},
 	}
 
-	allLfs, err := sc.Scan()
+	ctx, done := as.scanTracker.Track(context.Background())
+	defer done()
+
+	allLfs, err := sc.Scan(ctx)
 	if err != nil {
 		if errors.Is(err, scanner.ErrNoLocalFiles) {
-			return
+			_ = as.db.DeleteScanCheckpoint()
+			return nil
+		} else if errors.Is(err, scanner.ErrScanCancelled) {
+			// The checkpoint is kept so that the next scan resumes from it
+			as.logger.Info().Msg("autoscanner: Scan cancelled")
+			return err
 		} else {
 			as.logger.Error().Err(err).Msg("autoscanner: Failed to scan library")
-			return
+			return err
 		}
 	}
 
+	// The scan completed, it should not be resumed
+	_ = as.db.DeleteScanCheckpoint()
+
 	if as.db != nil && len(allLfs) > 0 {
 		as.logger.Trace().Msg("autoscanner: Updating local files")
 
@@ -221,11 +302,20 @@ func (as *AutoScanner) scan() {
 		_, err = db_bridge.InsertLocalFiles(as.db, allLfs)
 		if err != nil {
 			as.logger.Error().Err(err).Msg("failed to insert local files")
-			return
+			return err
+		}
+
+		// Move the matched files to the template layout if the organizer is enabled
+		// This is done once the local files are saved so that files are never moved without their local files
+		as.organizeLocalFiles(settings, allLfs, sc.GetFingerprintIndex())
+
+		// Save the fingerprint index for the next scan
+		if err = db_bridge.SaveFingerprintIndex(as.db, sc.GetFingerprintIndex()); err != nil {
+			as.logger.Error().Err(err).Msg("autoscanner: Failed to save fingerprint index")
 		}
 
 		// Save the scan summary
-		err = db_bridge.InsertScanSummary(as.db, scanSummaryLogger.GenerateSummary())
+		err = db_bridge.InsertScanSummary(as.db, scanSummaryLogger.GenerateSummary(), allLfs)
 		if err != nil {
 			as.logger.Error().Err(err).Msg("failed to insert scan summary")
 		}
@@ -236,5 +326,51 @@ func (as *AutoScanner) scan() {
 
 	notifier.GlobalNotifier.Notify(notifier.AutoScanner, "Your library has been scanned.")
 
-	return
+	return nil
+}
+
+// organizeLocalFiles organizes the files after a scan if the organizer is enabled.
+// The local files must already be saved, they are saved again with their new paths and the operations are undone if that fails.
+// The local files and the fingerprint index of the scan are updated in place.
+func (as *AutoScanner) organizeLocalFiles(settings *models.Settings, lfs []*anime.LocalFile, index *scanner.FingerprintIndex) {
+	org := organizer.NewFromSettings(settings.Library, as.logger)
+	if org == nil {
+		return
+	}
+
+	collection, err := as.platform.GetAnimeCollection(false)
+	if err != nil {
+		as.logger.Warn().Err(err).Msg("autoscanner: Failed to get anime collection")
+		return
+	}
+
+	journal, err := org.Organize(lfs, organizer.NewMediaFromCollection(collection))
+	if err != nil {
+		as.logger.Warn().Err(err).Msg("autoscanner: Failed to organize local files")
+		return
+	}
+
+	if len(journal.GetDoneOperations()) > 0 {
+		_, lfsId, err := db_bridge.GetLocalFiles(as.db)
+		if err == nil {
+			_, err = db_bridge.SaveLocalFiles(as.db, lfsId, lfs)
+		}
+		if err != nil {
+			as.logger.Error().Err(err).Msg("autoscanner: Failed to save organized local files, undoing operations")
+			if err = organizer.Undo(journal, lfs, as.logger); err != nil {
+				// Keep the journal so that the remaining operations can be undone
+				as.logger.Error().Err(err).Msg("autoscanner: Failed to undo organizer operations")
+				_ = db_bridge.InsertOrganizerJournal(as.db, journal)
+			}
+			return
+		}
+	}
+
+	for _, op := range journal.GetDoneOperations() {
+		index.Rename(op.From, op.To, journal.Mode != organizer.ModeMove)
+	}
+
+	if err = db_bridge.InsertOrganizerJournal(as.db, journal); err != nil {
+		as.logger.Warn().Err(err).Msg("autoscanner: Failed to save organizer journal")
+	}
 }
@@ -0,0 +1,162 @@
+package autoscanner
+
+import (
+	"context"
+	"seanime/internal/database/db_bridge"
+	"seanime/internal/events"
+	"seanime/internal/extension"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/filesystem"
+	"seanime/internal/library/scanner"
+	"seanime/internal/util"
+)
+
+// NotifyFileAction is used to notify the AutoScanner that a file was added or removed.
+//   - Removed files are removed from the local files right away.
+//   - Added files are matched on their own after the wait time, the whole library is scanned only if they cannot be matched.
+func (as *AutoScanner) NotifyFileAction(action scanner.FileAction, path string) {
+	if as == nil {
+		return
+	}
+
+	defer util.HandlePanicInModuleThen("scanner/autoscanner/NotifyFileAction", func() {
+		as.logger.Error().Msg("autoscanner: recovered from panic")
+	})
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	if !as.enabled {
+		return
+	}
+
+	switch action {
+	case scanner.FileActionRemoved:
+		// Wait for the scan in progress, if any, so that it does not add the file back
+		go as.removeLocalFiles(path)
+	case scanner.FileActionAdded:
+		as.pendingPaths = append(as.pendingPaths, path)
+		as.trigger()
+	}
+}
+
+// removeLocalFiles removes the local files that no longer exist at the path or inside it if it is a directory.
+// The removed files are kept until the next scan to detect files that were moved.
+func (as *AutoScanner) removeLocalFiles(path string) {
+	defer util.HandlePanicInModuleThen("scanner/autoscanner/removeLocalFiles", func() {
+		as.logger.Error().Msg("autoscanner: recovered from panic")
+	})
+
+	as.lfsMu.Lock()
+	defer as.lfsMu.Unlock()
+
+	lfs, lfsId, err := db_bridge.GetLocalFiles(as.db)
+	if err != nil {
+		as.logger.Error().Err(err).Msg("autoscanner: Failed to get local files")
+		return
+	}
+
+	remaining := make([]*anime.LocalFile, 0, len(lfs))
+	removed := make([]*anime.LocalFile, 0)
+	for _, lf := range lfs {
+		if util.IsSubdirectory(path, lf.Path) && !filesystem.FileExists(lf.Path) {
+			removed = append(removed, lf)
+		} else {
+			remaining = append(remaining, lf)
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+
+	if _, err = db_bridge.SaveLocalFiles(as.db, lfsId, remaining); err != nil {
+		as.logger.Error().Err(err).Msg("autoscanner: Failed to save local files")
+		return
+	}
+
+	as.mu.Lock()
+	as.removedLfs = append(as.removedLfs, removed...)
+	as.mu.Unlock()
+
+	as.logger.Debug().Int("count", len(removed)).Str("path", path).Msg("autoscanner: Removed local files")
+	as.wsEventManager.SendEvent(events.LibraryLocalFilesUpdated, nil)
+}
+
+// scanFiles matches and hydrates the new files on their own, and saves them.
+// It returns false if some files could not be matched or if the scan failed, the whole library should then be scanned.
+func (as *AutoScanner) scanFiles(paths []string, removedLfs []*anime.LocalFile) (ok bool) {
+	defer util.HandlePanicInModuleThen("scanner/autoscanner/scanFiles", func() {
+		as.logger.Error().Msg("autoscanner: Recovered from panic")
+		ok = false
+	})
+
+	as.lfsMu.Lock()
+	defer as.lfsMu.Unlock()
+
+	as.logger.Trace().Int("count", len(paths)).Msg("autoscanner: Scanning new files")
+	as.wsEventManager.SendEvent(events.AutoScanStarted, nil)
+	defer as.wsEventManager.SendEvent(events.AutoScanCompleted, nil)
+
+	settings, err := as.db.GetSettings()
+	if err != nil || settings == nil {
+		as.logger.Error().Err(err).Msg("autoscanner: Failed to get settings")
+		return false
+	}
+
+	existingLfs, lfsId, err := db_bridge.GetLocalFiles(as.db)
+	if err != nil {
+		as.logger.Error().Err(err).Msg("autoscanner: Failed to get existing local files")
+		return false
+	}
+
+	var fileIndex *scanner.AniDBFileIndex
+	if settings.Library.ScannerHashIdentification {
+		fileIndex = db_bridge.GetAniDBFileIndex(as.db)
+	}
+
+	sc := scanner.Scanner{
+		Roots:              settings.Library.GetEnabledLibraryRoots(),
+		Platform:           as.platform,
+		Logger:             as.logger,
+		WSEventManager:     as.wsEventManager,
+		ExistingLocalFiles: existingLfs,
+		FingerprintIndex:   db_bridge.GetFingerprintIndex(as.db),
+		UsePartialHash:     settings.Library.ScannerUsePartialHash,
+		Concurrency:        settings.Library.ScannerConcurrency,
+		DuplicatePolicy:    settings.Library.DuplicatePolicy,
+		LibraryMatchers:    extension.GetLibraryMatcherExtensions(as.extensionBank),
+		FileIndex:          fileIndex,
+	}
+
+	ctx, done := as.scanTracker.Track(context.Background())
+	defer done()
+
+	lfs, unmatched, err := sc.ScanFiles(ctx, paths, removedLfs)
+	if err != nil {
+		as.logger.Error().Err(err).Msg("autoscanner: Failed to scan new files")
+		return false
+	}
+
+	// The matched files are saved even if the library is scanned after, so that they appear right away
+	if _, err = db_bridge.SaveLocalFiles(as.db, lfsId, lfs); err != nil {
+		as.logger.Error().Err(err).Msg("autoscanner: Failed to save local files")
+		return false
+	}
+
+	// Move the matched files to the template layout if the organizer is enabled
+	// This is done once the local files are saved so that files are never moved without their local files
+	as.organizeLocalFiles(settings, lfs, sc.GetFingerprintIndex())
+
+	if err = db_bridge.SaveFingerprintIndex(as.db, sc.GetFingerprintIndex()); err != nil {
+		as.logger.Error().Err(err).Msg("autoscanner: Failed to save fingerprint index")
+	}
+
+	if len(unmatched) > 0 {
+		as.logger.Debug().Strs("paths", unmatched).Msg("autoscanner: Could not match new files, scanning the library")
+		return false
+	}
+
+	as.logger.Info().Int("count", len(paths)).Msg("autoscanner: Scanned new files")
+
+	return true
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package organizer
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isCrossDeviceError returns true if the rename failed because the destination is on another filesystem.
+func isCrossDeviceError(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package organizer
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorNotSameDevice is ERROR_NOT_SAME_DEVICE, returned when a file is renamed to another drive.
+const errorNotSameDevice syscall.Errno = 17
+
+// isCrossDeviceError returns true if the rename failed because the destination is on another drive.
+func isCrossDeviceError(err error) bool {
+	return errors.Is(err, errorNotSameDevice) || errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,375 @@
+package organizer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"os"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"slices"
+	"time"
+)
+
+const (
+	ModeMove     Mode = "move"     // The file is moved, the original path no longer exists
+	ModeCopy     Mode = "copy"     // The file is copied, the original file is kept
+	ModeHardlink Mode = "hardlink" // A hard link is created, the original file is kept
+)
+
+const (
+	OperationStatusPlanned OperationStatus = "planned" // Dry run, nothing was changed
+	OperationStatusDone    OperationStatus = "done"
+	OperationStatusSkipped OperationStatus = "skipped" // The destination is taken or the media is unknown
+	OperationStatusFailed  OperationStatus = "failed"
+	OperationStatusUndone  OperationStatus = "undone"
+)
+
+type (
+	Mode            string
+	OperationStatus string
+
+	// Organizer moves, copies or hard links matched files to the path given by a template.
+	// The path is relative to Destination, or to the library root of the file if Destination is empty.
+	// Destination must be inside a library root so that the organized files are still scanned.
+	Organizer struct {
+		Logger      *zerolog.Logger
+		Roots       []string // Library roots, files outside them are not organized
+		Template    string
+		Mode        Mode
+		Destination string
+		DryRun      bool
+	}
+
+	// Journal records the operations of a run so that they can be undone.
+	Journal struct {
+		ID         uint         `json:"id"` // Set by the database
+		CreatedAt  time.Time    `json:"createdAt"`
+		Mode       Mode         `json:"mode"`
+		DryRun     bool         `json:"dryRun"`
+		Operations []*Operation `json:"operations"`
+	}
+
+	Operation struct {
+		From    string          `json:"from"`
+		To      string          `json:"to"`
+		MediaId int             `json:"mediaId"`
+		Status  OperationStatus `json:"status"`
+		Error   string          `json:"error,omitempty"`
+	}
+)
+
+// NewFromSettings returns an organizer configured with the library settings, or nil if the organizer is disabled.
+func NewFromSettings(settings *models.LibrarySettings, logger *zerolog.Logger) *Organizer {
+	if settings == nil || settings.Organizer == nil || !settings.Organizer.Enabled {
+		return nil
+	}
+	roots := make([]string, 0)
+	for _, root := range settings.GetEnabledLibraryRoots() {
+		roots = append(roots, root.Path)
+	}
+	return &Organizer{
+		Logger:      logger,
+		Roots:       roots,
+		Template:    settings.Organizer.Template,
+		Mode:        Mode(settings.Organizer.Mode),
+		Destination: settings.Organizer.Destination,
+	}
+}
+
+// NewMediaFromCollection returns the media of the collection, used to fill the templates.
+func NewMediaFromCollection(collection *anilist.AnimeCollection) []*anime.NormalizedMedia {
+	ret := make([]*anime.NormalizedMedia, 0)
+	if collection == nil {
+		return ret
+	}
+	for _, m := range collection.GetAllAnime() {
+		ret = append(ret, anime.NewNormalizedMedia(m))
+	}
+	return ret
+}
+
+// Organize moves the matched files to their template path and updates the local files of moved files in place,
+// so that their match and lock state is kept. Copied and hard linked files keep their local file at the original path.
+//
+// Ignored, unmatched and alternate files, as well as openings and endings, are not organized.
+// Files are never overwritten, a file whose destination is taken is skipped.
+func (o *Organizer) Organize(lfs []*anime.LocalFile, media []*anime.NormalizedMedia) (*Journal, error) {
+	if o.Template == "" {
+		o.Template = DefaultTemplate
+	}
+	if o.Mode == "" {
+		o.Mode = ModeMove
+	}
+	if err := ValidateTemplate(o.Template); err != nil {
+		return nil, err
+	}
+	if o.Mode != ModeMove && o.Mode != ModeCopy && o.Mode != ModeHardlink {
+		return nil, fmt.Errorf("unknown organizer mode \"%s\"", o.Mode)
+	}
+	if o.Destination != "" && !slices.ContainsFunc(o.Roots, func(r string) bool { return util.IsSubdirectory(r, o.Destination) }) {
+		return nil, fmt.Errorf("organizer destination \"%s\" is not inside a library root", o.Destination)
+	}
+
+	mediaMap := make(map[int]*anime.NormalizedMedia, len(media))
+	for _, m := range media {
+		mediaMap[m.ID] = m
+	}
+
+	journal := &Journal{
+		CreatedAt:  time.Now(),
+		Mode:       o.Mode,
+		DryRun:     o.DryRun,
+		Operations: make([]*Operation, 0),
+	}
+
+	// Paths taken by the files of this run
+	taken := make(map[string]struct{})
+
+	for _, lf := range lfs {
+		if lf.MediaId == 0 || lf.IsIgnored() || lf.IsAlternate() || lf.Metadata == nil {
+			continue
+		}
+		if lf.GetType() != anime.LocalFileTypeMain && lf.GetType() != anime.LocalFileTypeSpecial {
+			continue
+		}
+
+		op := &Operation{From: lf.Path, MediaId: lf.MediaId}
+
+		m, ok := mediaMap[lf.MediaId]
+		if !ok {
+			op.Status = OperationStatusSkipped
+			op.Error = "media not found"
+			journal.Operations = append(journal.Operations, op)
+			continue
+		}
+
+		dest := o.getDestination(lf.Path)
+		if dest == "" {
+			continue
+		}
+
+		op.To = filepath.Join(dest, renderTemplate(o.Template, lf, m))
+		if normalizePath(op.To) == normalizePath(op.From) {
+			continue
+		}
+
+		if _, found := taken[normalizePath(op.To)]; found {
+			op.Status = OperationStatusSkipped
+			op.Error = "another file has the same destination"
+			journal.Operations = append(journal.Operations, op)
+			continue
+		}
+		taken[normalizePath(op.To)] = struct{}{}
+
+		if _, err := os.Stat(op.To); err == nil {
+			op.Status = OperationStatusSkipped
+			op.Error = "destination already exists"
+			journal.Operations = append(journal.Operations, op)
+			continue
+		}
+
+		if o.DryRun {
+			op.Status = OperationStatusPlanned
+			journal.Operations = append(journal.Operations, op)
+			continue
+		}
+
+		if err := o.apply(op.From, op.To); err != nil {
+			o.Logger.Warn().Err(err).Str("from", op.From).Str("to", op.To).Msg("organizer: Failed to organize file")
+			op.Status = OperationStatusFailed
+			op.Error = err.Error()
+			journal.Operations = append(journal.Operations, op)
+			continue
+		}
+
+		op.Status = OperationStatusDone
+		journal.Operations = append(journal.Operations, op)
+
+		if o.Mode == ModeMove {
+			lf.Path = op.To
+			lf.Name = filepath.Base(op.To)
+		}
+	}
+
+	o.Logger.Debug().
+		Int("done", len(journal.GetDoneOperations())).
+		Int("operations", len(journal.Operations)).
+		Bool("dryRun", o.DryRun).
+		Msg("organizer: Organized local files")
+
+	return journal, nil
+}
+
+// Undo reverts the operations of the journal in reverse order and updates the local files in place.
+// Moved files are moved back, copies and hard links are removed.
+func Undo(journal *Journal, lfs []*anime.LocalFile, logger *zerolog.Logger) error {
+	if journal == nil || journal.DryRun {
+		return nil
+	}
+
+	var errs []error
+	for _, op := range slices.Backward(journal.Operations) {
+		if op.Status != OperationStatusDone {
+			continue
+		}
+
+		var err error
+		if journal.Mode == ModeMove {
+			if _, statErr := os.Stat(op.From); statErr == nil {
+				err = fmt.Errorf("%s already exists", op.From)
+			} else if err = os.MkdirAll(filepath.Dir(op.From), 0755); err == nil {
+				err = MoveFile(op.To, op.From)
+			}
+		} else {
+			err = os.Remove(op.To)
+		}
+		if err != nil {
+			logger.Warn().Err(err).Str("from", op.To).Str("to", op.From).Msg("organizer: Failed to undo operation")
+			errs = append(errs, err)
+			continue
+		}
+		op.Status = OperationStatusUndone
+
+		if journal.Mode != ModeMove {
+			continue
+		}
+		for _, lf := range lfs {
+			if lf.HasSamePath(op.To) {
+				lf.Path = op.From
+				lf.Name = filepath.Base(op.From)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// GetDoneOperations returns the operations that changed the filesystem.
+func (j *Journal) GetDoneOperations() []*Operation {
+	ret := make([]*Operation, 0)
+	for _, op := range j.Operations {
+		if op.Status == OperationStatusDone {
+			ret = append(ret, op)
+		}
+	}
+	return ret
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// getDestination returns the directory the template is relative to, or an empty string if the file is outside the library roots.
+func (o *Organizer) getDestination(path string) string {
+	root := ""
+	for _, r := range o.Roots {
+		if util.IsSubdirectory(r, path) && len(r) > len(root) {
+			root = r
+		}
+	}
+	if root == "" {
+		return ""
+	}
+	if o.Destination != "" {
+		return o.Destination
+	}
+	return root
+}
+
+// isProtectedDir returns true if the directory is a library root or the destination.
+func (o *Organizer) isProtectedDir(dir string) bool {
+	if o.Destination != "" && normalizePath(o.Destination) == normalizePath(dir) {
+		return true
+	}
+	return slices.ContainsFunc(o.Roots, func(r string) bool { return normalizePath(r) == normalizePath(dir) })
+}
+
+func (o *Organizer) apply(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	switch o.Mode {
+	case ModeCopy:
+		return copyFile(from, to)
+	case ModeHardlink:
+		return os.Link(from, to)
+	default:
+		if err := MoveFile(from, to); err != nil {
+			return err
+		}
+		// Remove the directory of the file if it is now empty, e.g. a release folder
+		if dir := filepath.Dir(from); !o.isProtectedDir(dir) {
+			removeEmptyDir(dir)
+		}
+		return nil
+	}
+}
+
+// MoveFile renames the file, or copies and removes it if it is moved to another drive.
+// The destination is never overwritten, an error is returned if it exists.
+func MoveFile(from, to string) error {
+	if _, err := os.Lstat(to); err == nil {
+		return &os.PathError{Op: "move", Path: to, Err: os.ErrExist}
+	}
+	err := os.Rename(from, to)
+	if err == nil || !isCrossDeviceError(err) {
+		return err
+	}
+	if err = copyFile(from, to); err != nil {
+		return err
+	}
+	// Keep a single copy of the file if the original cannot be removed, e.g. it is locked
+	if err = os.Remove(from); err != nil {
+		_ = os.Remove(to)
+		return err
+	}
+	return nil
+}
+
+// copyFile copies the file and its modification time, so that incremental scans see the copy as unchanged.
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(to, info.ModTime(), info.ModTime())
+	}
+	if err != nil {
+		_ = os.Remove(to)
+	}
+	return err
+}
+
+// removeEmptyDir removes the directory if it is empty.
+func removeEmptyDir(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) > 0 {
+		return
+	}
+	_ = os.Remove(dir)
+}
+
+// normalizePath returns the path used to compare paths, case is only folded on case-insensitive systems.
+func normalizePath(path string) string {
+	return util.FoldPathCase(filepath.Clean(path))
+}
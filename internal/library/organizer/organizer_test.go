@@ -0,0 +1,193 @@
+package organizer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"runtime"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"testing"
+)
+
+func TestOrganizer_Organize(t *testing.T) {
+	frieren := newTestMedia(154587, "Sousou no Frieren", "")
+	media := []*anime.NormalizedMedia{frieren}
+
+	newLibrary := func(t *testing.T) (string, []*anime.LocalFile) {
+		root := t.TempDir()
+		files := []string{
+			"Frieren/[SubsPlease] Sousou no Frieren - 01 (1080p) [4E1B9F0A].mkv",
+			"Frieren/[SubsPlease] Sousou no Frieren - 02 (1080p) [8B22F6C1].mkv",
+			"Unknown/[SubsPlease] Unknown - 01 (1080p).mkv",
+		}
+		lfs := make([]*anime.LocalFile, 0)
+		for i, file := range files {
+			path := filepath.Join(root, filepath.FromSlash(file))
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte(file), 0644))
+			lf := anime.NewLocalFile(path, root)
+			if i < 2 {
+				lf.MediaId = frieren.ID
+				lf.Metadata = &anime.LocalFileMetadata{Episode: i + 1, AniDBEpisode: lf.ParsedData.Episode, Type: anime.LocalFileTypeMain}
+				lf.Locked = true
+			}
+			lfs = append(lfs, lf)
+		}
+		return root, lfs
+	}
+
+	t.Run("Dry run", func(t *testing.T) {
+		root, lfs := newLibrary(t)
+		org := &Organizer{Logger: util.NewLogger(), Roots: []string{root}, DryRun: true}
+
+		journal, err := org.Organize(lfs, media)
+		require.NoError(t, err)
+		require.Len(t, journal.Operations, 2)
+		assert.Equal(t, OperationStatusPlanned, journal.Operations[0].Status)
+		assert.Equal(t, filepath.Join(root, "Sousou no Frieren", "Sousou no Frieren - S01E01 [SubsPlease][1080p].mkv"), journal.Operations[0].To)
+		assert.FileExists(t, journal.Operations[0].From)
+		assert.NoFileExists(t, journal.Operations[0].To)
+		assert.Equal(t, journal.Operations[0].From, lfs[0].Path)
+	})
+
+	t.Run("Move and undo", func(t *testing.T) {
+		root, lfs := newLibrary(t)
+		org := &Organizer{Logger: util.NewLogger(), Roots: []string{root}}
+
+		journal, err := org.Organize(lfs, media)
+		require.NoError(t, err)
+		require.Len(t, journal.GetDoneOperations(), 2)
+		for i, op := range journal.Operations {
+			assert.NoFileExists(t, op.From)
+			assert.FileExists(t, op.To)
+			// The local files keep their match and lock state
+			assert.Equal(t, op.To, lfs[i].Path)
+			assert.Equal(t, filepath.Base(op.To), lfs[i].Name)
+			assert.Equal(t, frieren.ID, lfs[i].MediaId)
+			assert.True(t, lfs[i].Locked)
+		}
+		// The emptied release folder is removed
+		assert.NoDirExists(t, filepath.Join(root, "Frieren"))
+
+		// Organizing again does nothing
+		again, err := org.Organize(lfs, media)
+		require.NoError(t, err)
+		assert.Empty(t, again.Operations)
+
+		require.NoError(t, Undo(journal, lfs, util.NewLogger()))
+		for i, op := range journal.Operations {
+			assert.Equal(t, OperationStatusUndone, op.Status)
+			assert.FileExists(t, op.From)
+			assert.NoFileExists(t, op.To)
+			assert.Equal(t, op.From, lfs[i].Path)
+		}
+	})
+
+	t.Run("Hardlink", func(t *testing.T) {
+		root, lfs := newLibrary(t)
+		org := &Organizer{Logger: util.NewLogger(), Roots: []string{root}, Mode: ModeHardlink}
+
+		journal, err := org.Organize(lfs, media)
+		require.NoError(t, err)
+		require.Len(t, journal.GetDoneOperations(), 2)
+		assert.FileExists(t, journal.Operations[0].From)
+		assert.FileExists(t, journal.Operations[0].To)
+		// The local files keep the original path
+		assert.Equal(t, journal.Operations[0].From, lfs[0].Path)
+
+		require.NoError(t, Undo(journal, lfs, util.NewLogger()))
+		assert.FileExists(t, journal.Operations[0].From)
+		assert.NoFileExists(t, journal.Operations[0].To)
+	})
+
+	t.Run("Conflicts", func(t *testing.T) {
+		root, lfs := newLibrary(t)
+		org := &Organizer{Logger: util.NewLogger(), Roots: []string{root}, Mode: ModeCopy, Template: "{romaji}/{romaji}.{ext}"}
+
+		// The two episodes have the same destination
+		journal, err := org.Organize(lfs, media)
+		require.NoError(t, err)
+		require.Len(t, journal.Operations, 2)
+		assert.Equal(t, OperationStatusDone, journal.Operations[0].Status)
+		assert.Equal(t, OperationStatusSkipped, journal.Operations[1].Status)
+
+		// The destination exists
+		_, lfs = newLibrary(t)
+		org.Roots = []string{filepath.Dir(filepath.Dir(lfs[0].Path)), root}
+		org.Destination = root
+		journal, err = org.Organize(lfs[:1], media)
+		require.NoError(t, err)
+		require.Len(t, journal.Operations, 1)
+		assert.Equal(t, OperationStatusSkipped, journal.Operations[0].Status)
+		assert.Equal(t, "destination already exists", journal.Operations[0].Error)
+	})
+
+	t.Run("Destination outside the library roots", func(t *testing.T) {
+		root, lfs := newLibrary(t)
+		org := &Organizer{Logger: util.NewLogger(), Roots: []string{root}, Destination: t.TempDir()}
+
+		_, err := org.Organize(lfs, media)
+		require.Error(t, err)
+		assert.FileExists(t, lfs[0].Path)
+	})
+
+	t.Run("Case-only rename", func(t *testing.T) {
+		root := t.TempDir()
+		path := filepath.Join(root, "sousou no frieren.mkv")
+		require.NoError(t, os.WriteFile(path, []byte("episode 1"), 0644))
+		lf := anime.NewLocalFile(path, root)
+		lf.MediaId = frieren.ID
+		lf.Metadata = &anime.LocalFileMetadata{Episode: 1, AniDBEpisode: "1", Type: anime.LocalFileTypeMain}
+
+		org := &Organizer{Logger: util.NewLogger(), Roots: []string{root}, Template: "{romaji}.{ext}"}
+		journal, err := org.Organize([]*anime.LocalFile{lf}, media)
+		require.NoError(t, err)
+
+		switch runtime.GOOS {
+		case "windows", "darwin":
+			// The paths are the same on case-insensitive systems
+			assert.Empty(t, journal.Operations)
+		default:
+			require.Len(t, journal.GetDoneOperations(), 1)
+			assert.Equal(t, filepath.Join(root, "Sousou no Frieren.mkv"), lf.Path)
+			assert.FileExists(t, lf.Path)
+			assert.NoFileExists(t, path)
+		}
+	})
+
+	t.Run("Unknown media", func(t *testing.T) {
+		root, lfs := newLibrary(t)
+		org := &Organizer{Logger: util.NewLogger(), Roots: []string{root}, DryRun: true}
+
+		journal, err := org.Organize(lfs, []*anime.NormalizedMedia{})
+		require.NoError(t, err)
+		require.Len(t, journal.Operations, 2)
+		assert.Equal(t, OperationStatusSkipped, journal.Operations[0].Status)
+	})
+}
+
+func TestMoveFile(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "a.mkv")
+	to := filepath.Join(dir, "b.mkv")
+	require.NoError(t, os.WriteFile(from, []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(to, []byte("b"), 0644))
+
+	// The destination is not overwritten
+	require.ErrorIs(t, MoveFile(from, to), os.ErrExist)
+	data, err := os.ReadFile(to)
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+
+	require.NoError(t, os.Remove(to))
+	require.NoError(t, MoveFile(from, to))
+	assert.NoFileExists(t, from)
+	assert.FileExists(t, to)
+
+	// Errors other than moving to another drive are returned without copying the file
+	err = MoveFile(from, filepath.Join(dir, "c.mkv"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	assert.NoFileExists(t, filepath.Join(dir, "c.mkv"))
+}
@@ -0,0 +1,149 @@
+package organizer
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"strconv"
+	"strings"
+)
+
+// DefaultTemplate is used when the organizer settings have no template.
+const DefaultTemplate = "{romaji}/{romaji} - S{season}E{episode} [{group}][{resolution}].{ext}"
+
+// TemplateVariables are the variables that can be used in a template.
+var TemplateVariables = []string{
+	"romaji",       // Romaji title of the media
+	"english",      // English title of the media, or the romaji title
+	"title",        // Preferred title of the media
+	"year",         // Start year of the media
+	"mediaId",      // AniList ID of the media
+	"season",       // Season number, 00 for specials
+	"episode",      // Episode number
+	"episodeTitle", // Episode title parsed from the file name
+	"group",        // Release group
+	"resolution",   // Resolution (e.g. 1080p)
+	"version",      // Release version (e.g. v2)
+	"ext",          // File extension, without the dot
+}
+
+var (
+	templateVariableRegex = regexp.MustCompile(`\{(\w+)}`)
+	invalidCharsRegex     = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+	emptyBracketsRegex    = regexp.MustCompile(`\[\s*]|\(\s*\)|\{\s*}`)
+	multipleSpacesRegex   = regexp.MustCompile(`\s{2,}`)
+	spaceBeforeExtRegex   = regexp.MustCompile(`\s+(\.\w+)$`)
+)
+
+// ValidateTemplate returns an error if the template uses unknown variables, or if it could produce a path outside the destination.
+func ValidateTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("template is empty")
+	}
+	if filepath.IsAbs(template) || strings.HasPrefix(template, "/") || strings.HasPrefix(template, "\\") {
+		return fmt.Errorf("template should be a relative path")
+	}
+	for _, segment := range strings.FieldsFunc(template, isPathSeparator) {
+		if strings.TrimSpace(segment) == ".." {
+			return fmt.Errorf("template should not contain \"..\"")
+		}
+	}
+	for _, match := range templateVariableRegex.FindAllStringSubmatch(template, -1) {
+		if !isTemplateVariable(match[1]) {
+			return fmt.Errorf("unknown template variable \"%s\"", match[0])
+		}
+	}
+	return nil
+}
+
+// renderTemplate returns the relative path of the file.
+// Empty brackets left by missing values are removed, e.g. "[{group}]" for a file without a release group.
+func renderTemplate(template string, lf *anime.LocalFile, media *anime.NormalizedMedia) string {
+	vars := getTemplateValues(lf, media)
+
+	segments := make([]string, 0)
+	for _, segment := range strings.FieldsFunc(template, isPathSeparator) {
+		segment = templateVariableRegex.ReplaceAllStringFunc(segment, func(s string) string {
+			return vars[s[1:len(s)-1]]
+		})
+		segment = emptyBracketsRegex.ReplaceAllString(segment, "")
+		segment = multipleSpacesRegex.ReplaceAllString(segment, " ")
+		segment = strings.TrimRight(strings.TrimSpace(segment), ". ")
+		// Remove the separators left before the extension, e.g. "Title - 01 .mkv"
+		segment = spaceBeforeExtRegex.ReplaceAllString(segment, "$1")
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return filepath.Join(segments...)
+}
+
+func getTemplateValues(lf *anime.LocalFile, media *anime.NormalizedMedia) map[string]string {
+	ret := make(map[string]string, len(TemplateVariables))
+
+	ret["romaji"] = sanitizeValue(media.GetRomajiTitleSafe())
+	ret["english"] = sanitizeValue(media.GetTitleSafe())
+	ret["title"] = sanitizeValue(media.GetPreferredTitle())
+	if year := media.GetStartYearSafe(); year > 0 {
+		ret["year"] = strconv.Itoa(year)
+	}
+	ret["mediaId"] = strconv.Itoa(media.ID)
+	ret["season"] = fmt.Sprintf("%02d", getSeasonNumber(lf, media))
+	ret["episode"] = fmt.Sprintf("%02d", lf.GetEpisodeNumber())
+	ret["ext"] = strings.TrimPrefix(filepath.Ext(lf.Path), ".")
+
+	if lf.ParsedData != nil {
+		ret["episodeTitle"] = sanitizeValue(lf.ParsedData.EpisodeTitle)
+		ret["group"] = sanitizeValue(lf.ParsedData.ReleaseGroup)
+		ret["resolution"] = sanitizeValue(lf.ParsedData.Resolution)
+		if lf.ParsedData.Version != "" {
+			ret["version"] = "v" + sanitizeValue(strings.TrimPrefix(strings.ToLower(lf.ParsedData.Version), "v"))
+		}
+	}
+
+	return ret
+}
+
+// getSeasonNumber returns 0 for specials, otherwise the season parsed from the file or its folders,
+// then the season found in the titles of the media, and 1 if there is none.
+func getSeasonNumber(lf *anime.LocalFile, media *anime.NormalizedMedia) int {
+	if lf.GetType() == anime.LocalFileTypeSpecial {
+		return 0
+	}
+	if lf.ParsedData != nil {
+		if season, ok := util.StringToInt(lf.ParsedData.Season); ok && season > 0 {
+			return season
+		}
+	}
+	for i := len(lf.ParsedFolderData) - 1; i >= 0; i-- {
+		if season, ok := util.StringToInt(lf.ParsedFolderData[i].Season); ok && season > 0 {
+			return season
+		}
+	}
+	if season := media.GetPossibleSeasonNumber(); season > 0 {
+		return season
+	}
+	return 1
+}
+
+// sanitizeValue removes the characters that are not allowed in file names.
+func sanitizeValue(s string) string {
+	s = invalidCharsRegex.ReplaceAllString(s, " ")
+	return strings.TrimSpace(multipleSpacesRegex.ReplaceAllString(s, " "))
+}
+
+func isTemplateVariable(name string) bool {
+	for _, v := range TemplateVariables {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isPathSeparator(r rune) bool {
+	return r == '/' || r == '\\'
+}
@@ -0,0 +1,94 @@
+package organizer
+
+import (
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"testing"
+)
+
+func newTestMedia(id int, romaji string, english string) *anime.NormalizedMedia {
+	title := &anilist.BaseAnime_Title{Romaji: lo.ToPtr(romaji)}
+	if english != "" {
+		title.English = lo.ToPtr(english)
+	}
+	return anime.NewNormalizedMedia(&anilist.BaseAnime{
+		ID:        id,
+		Title:     title,
+		StartDate: &anilist.BaseAnime_StartDate{Year: lo.ToPtr(2023)},
+	})
+}
+
+func TestRenderTemplate(t *testing.T) {
+	frieren := newTestMedia(154587, "Sousou no Frieren", "Frieren: Beyond Journey's End")
+
+	tests := []struct {
+		name     string
+		template string
+		path     string
+		episode  int
+		fileType anime.LocalFileType
+		expected string
+	}{
+		{
+			name:     "Default template",
+			template: DefaultTemplate,
+			path:     "/anime/[SubsPlease] Sousou no Frieren - 05 (1080p) [4E1B9F0A].mkv",
+			episode:  5,
+			fileType: anime.LocalFileTypeMain,
+			expected: "Sousou no Frieren/Sousou no Frieren - S01E05 [SubsPlease][1080p].mkv",
+		},
+		{
+			name:     "Missing release group and resolution",
+			template: DefaultTemplate,
+			path:     "/anime/Sousou no Frieren - 05.mkv",
+			episode:  5,
+			fileType: anime.LocalFileTypeMain,
+			expected: "Sousou no Frieren/Sousou no Frieren - S01E05.mkv",
+		},
+		{
+			name:     "Special",
+			template: DefaultTemplate,
+			path:     "/anime/[SubsPlease] Sousou no Frieren - OVA (1080p).mkv",
+			episode:  1,
+			fileType: anime.LocalFileTypeSpecial,
+			expected: "Sousou no Frieren/Sousou no Frieren - S00E01 [SubsPlease][1080p].mkv",
+		},
+		{
+			name:     "Invalid characters are removed",
+			template: "{english} ({year})/{english} - {episode}{version}.{ext}",
+			path:     "/anime/[SubsPlease] Sousou no Frieren - 05v2 (1080p).mkv",
+			episode:  5,
+			fileType: anime.LocalFileTypeMain,
+			expected: "Frieren Beyond Journey's End (2023)/Frieren Beyond Journey's End - 05v2.mkv",
+		},
+		{
+			name:     "Season from the file name",
+			template: "{romaji}/Season {season}/{episode}.{ext}",
+			path:     "/anime/[Group] Sousou no Frieren S02E03.mkv",
+			episode:  3,
+			fileType: anime.LocalFileTypeMain,
+			expected: "Sousou no Frieren/Season 02/03.mkv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lf := anime.NewLocalFile(tt.path, "/anime")
+			lf.MediaId = frieren.ID
+			lf.Metadata = &anime.LocalFileMetadata{Episode: tt.episode, Type: tt.fileType}
+
+			assert.Equal(t, filepath.FromSlash(tt.expected), renderTemplate(tt.template, lf, frieren))
+		})
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	assert.NoError(t, ValidateTemplate(DefaultTemplate))
+	assert.Error(t, ValidateTemplate(""))
+	assert.Error(t, ValidateTemplate("/{romaji}/{episode}.{ext}"))
+	assert.Error(t, ValidateTemplate("../{romaji}/{episode}.{ext}"))
+	assert.Error(t, ValidateTemplate("{romaji}/{unknown}.{ext}"))
+}
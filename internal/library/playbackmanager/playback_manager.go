@@ -15,6 +15,7 @@ import (
 	"seanime/internal/mediaplayers/mediaplayer"
 	"seanime/internal/offline"
 	"seanime/internal/platforms/platform"
+	"seanime/internal/tracker"
 	"seanime/internal/util"
 	"sync"
 )
@@ -50,7 +51,9 @@ type (
 		mediaPlayerRepoSubscriber  *mediaplayer.RepositorySubscriber // Used to listen for media player events
 		wsEventManager             events.WSEventManagerInterface
 		platform                   platform.Platform
-		refreshAnimeCollectionFunc func() // This function is called to refresh the AniList collection
+		trackerRepository          *tracker.Repository // Used to push progress updates to every enabled tracker
+		refreshAnimeCollectionFunc func()              // This function is called to refresh the AniList collection
+		progressUpdatedFunc        func()              // This function is called after the progress is updated
 		mu                         sync.Mutex
 		eventMu                    sync.Mutex
 		cancel                     context.CancelFunc
@@ -115,8 +118,10 @@ type (
 		WSEventManager             events.WSEventManagerInterface
 		Logger                     *zerolog.Logger
 		Platform                   platform.Platform
+		TrackerRepository          *tracker.Repository // Optional, defaults to a repository that only updates the platform
 		Database                   *db.Database
 		RefreshAnimeCollectionFunc func() // This function is called to refresh the AniList collection
+		ProgressUpdatedFunc        func() // This function is called after the progress is updated (optional)
 		DiscordPresence            *discordrpc_presence.Presence
 		IsOffline                  bool
 		OfflineHub                 offline.HubInterface
@@ -135,7 +140,9 @@ func New(opts *NewPlaybackManagerOptions) *PlaybackManager {
 		discordPresence:                opts.DiscordPresence,
 		wsEventManager:                 opts.WSEventManager,
 		platform:                       opts.Platform,
+		trackerRepository:              opts.TrackerRepository,
 		refreshAnimeCollectionFunc:     opts.RefreshAnimeCollectionFunc,
+		progressUpdatedFunc:            opts.ProgressUpdatedFunc,
 		mu:                             sync.Mutex{},
 		autoPlayMu:                     sync.Mutex{},
 		eventMu:                        sync.Mutex{},
@@ -153,6 +160,14 @@ func New(opts *NewPlaybackManagerOptions) *PlaybackManager {
 		currentMediaListEntry:          mo.None[*anilist.MediaListEntry](),
 	}
 
+	if pm.trackerRepository == nil {
+		pm.trackerRepository = tracker.NewRepository(&tracker.NewRepositoryOptions{
+			Logger:         opts.Logger,
+			WSEventManager: opts.WSEventManager,
+			Primary:        tracker.NewPlatformTracker(&tracker.NewPlatformTrackerOptions{Name: "AniList", Platform: opts.Platform}),
+		})
+	}
+
 	pm.playlistHub = newPlaylistHub(pm)
 
 	return pm
@@ -10,6 +10,7 @@ import (
 	"seanime/internal/events"
 	"seanime/internal/library/anime"
 	"seanime/internal/mediaplayers/mediaplayer"
+	"seanime/internal/tracker"
 	"seanime/internal/util"
 )
 
@@ -490,9 +491,10 @@ func (pm *PlaybackManager) updateProgress() (err error) {
 		return errors.New("media ID not found")
 	}
 
-	// Update the progress on AniList
-	err = pm.platform.UpdateEntryProgress(
+	// Update the progress on AniList and the other enabled trackers
+	_, err = pm.trackerRepository.UpdateProgress(
 		mediaId,
+		tracker.AnimeKind,
 		epNum,
 		&totalEpisodes,
 	)
@@ -503,6 +505,10 @@ func (pm *PlaybackManager) updateProgress() (err error) {
 
 	pm.refreshAnimeCollectionFunc() // Refresh the AniList collection
 
+	if pm.progressUpdatedFunc != nil {
+		pm.progressUpdatedFunc()
+	}
+
 	pm.Logger.Info().Msg("playback manager: Updated progress on AniList")
 
 	return nil
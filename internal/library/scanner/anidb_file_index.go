@@ -0,0 +1,202 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"seanime/internal/api/anizip"
+	"seanime/internal/library/anime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// AniDBFileIndex maps the ED2K hashes of known releases to their media and episode.
+	// It is imported from a dump file and used to identify files whose names cannot be parsed.
+	AniDBFileIndex struct {
+		UpdatedAt time.Time                  `json:"updatedAt"`
+		Files     map[string]*AniDBFileEntry `json:"files"` // Indexed by file key
+	}
+
+	AniDBFileEntry struct {
+		ED2K         string `json:"ed2k"`
+		Size         int64  `json:"size"`
+		AnimeId      int    `json:"animeId,omitempty"` // AniDB anime ID
+		MediaId      int    `json:"mediaId"`           // AniList media ID
+		AniDBEpisode string `json:"aniDBEpisode"`      // AniDB episode number, e.g. "1", "S1", "C1"
+	}
+)
+
+func NewAniDBFileIndex() *AniDBFileIndex {
+	return &AniDBFileIndex{
+		Files: make(map[string]*AniDBFileEntry),
+	}
+}
+
+// IsEmpty returns true if the index cannot identify any file.
+func (idx *AniDBFileIndex) IsEmpty() bool {
+	return idx == nil || len(idx.Files) == 0
+}
+
+// Get returns the entry of the file with the given hash and size.
+func (idx *AniDBFileIndex) Get(ed2k string, size int64) (*AniDBFileEntry, bool) {
+	if idx.IsEmpty() {
+		return nil, false
+	}
+	entry, ok := idx.Files[getAniDBFileKey(ed2k, size)]
+	return entry, ok
+}
+
+func (idx *AniDBFileIndex) Set(entry *AniDBFileEntry) {
+	idx.Files[getAniDBFileKey(entry.ED2K, entry.Size)] = entry
+}
+
+func getAniDBFileKey(ed2k string, size int64) string {
+	return strings.ToLower(ed2k) + ":" + strconv.FormatInt(size, 10)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ParseAniDBFileDump reads a file dump.
+// Each line describes a file with pipe-separated fields, lines starting with "#" are ignored:
+//
+//	ed2k|size|aid|epno[|anilist_id]
+//
+// The AniList ID is optional, entries without it need to be resolved with ResolveMediaIds.
+// Invalid lines are skipped and counted.
+func ParseAniDBFileDump(r io.Reader) (ret *AniDBFileIndex, skipped int, err error) {
+	ret = NewAniDBFileIndex()
+	ret.UpdatedAt = time.Now()
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry, ok := parseAniDBFileDumpLine(line)
+		if !ok {
+			skipped++
+			continue
+		}
+		ret.Set(entry)
+	}
+	if err = sc.Err(); err != nil {
+		return nil, skipped, fmt.Errorf("could not read file dump: %w", err)
+	}
+
+	return ret, skipped, nil
+}
+
+func parseAniDBFileDumpLine(line string) (*AniDBFileEntry, bool) {
+	fields := strings.Split(line, "|")
+	if len(fields) < 4 {
+		return nil, false
+	}
+
+	ret := &AniDBFileEntry{
+		ED2K:         strings.ToLower(strings.TrimSpace(fields[0])),
+		AniDBEpisode: strings.ToUpper(strings.TrimSpace(fields[3])),
+	}
+	if len(ret.ED2K) != 32 || ret.AniDBEpisode == "" {
+		return nil, false
+	}
+
+	var err error
+	if ret.Size, err = strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64); err != nil || ret.Size <= 0 {
+		return nil, false
+	}
+	if ret.AnimeId, err = strconv.Atoi(strings.TrimSpace(fields[2])); err != nil {
+		return nil, false
+	}
+	if len(fields) > 4 && strings.TrimSpace(fields[4]) != "" {
+		if ret.MediaId, err = strconv.Atoi(strings.TrimSpace(fields[4])); err != nil {
+			return nil, false
+		}
+	}
+	if ret.MediaId == 0 && ret.AnimeId == 0 {
+		return nil, false
+	}
+
+	return ret, true
+}
+
+// ResolveMediaIds sets the AniList ID of the entries that only have an AniDB anime ID, using the AniZip mappings.
+// Entries that cannot be resolved are removed. It returns the number of removed entries.
+func (idx *AniDBFileIndex) ResolveMediaIds(cache *anizip.Cache, logger *zerolog.Logger) (removed int) {
+	mediaIds := make(map[int]int) // AniDB ID -> AniList ID
+
+	for key, entry := range idx.Files {
+		if entry.MediaId != 0 {
+			continue
+		}
+
+		mediaId, ok := mediaIds[entry.AnimeId]
+		if !ok {
+			media, err := anizip.FetchAniZipMediaC("anidb", entry.AnimeId, cache)
+			if err == nil && media.Mappings != nil {
+				mediaId = media.Mappings.AnilistID
+			} else {
+				logger.Debug().Err(err).Int("animeId", entry.AnimeId).Msg("scanner: Could not resolve AniDB anime")
+			}
+			mediaIds[entry.AnimeId] = mediaId
+		}
+
+		if mediaId == 0 {
+			delete(idx.Files, key)
+			removed++
+			continue
+		}
+		entry.MediaId = mediaId
+	}
+
+	return removed
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetMetadata returns the local file metadata of the entry.
+// It returns false for episode types that have no equivalent, e.g. "O1" (other) or "P1" (parody).
+func (e *AniDBFileEntry) GetMetadata() (*anime.LocalFileMetadata, bool) {
+	if e.AniDBEpisode == "" {
+		return nil, false
+	}
+
+	prefix := e.AniDBEpisode[:1]
+	number := e.AniDBEpisode
+	if prefix < "0" || prefix > "9" {
+		number = e.AniDBEpisode[1:]
+	} else {
+		prefix = ""
+	}
+	episode, err := strconv.Atoi(number)
+	if err != nil {
+		return nil, false
+	}
+
+	switch prefix {
+	case "":
+		return &anime.LocalFileMetadata{
+			Episode:      episode,
+			AniDBEpisode: strconv.Itoa(episode),
+			Type:         anime.LocalFileTypeMain,
+		}, true
+	case "S":
+		return &anime.LocalFileMetadata{
+			Episode:      episode,
+			AniDBEpisode: "S" + strconv.Itoa(episode),
+			Type:         anime.LocalFileTypeSpecial,
+		}, true
+	case "C", "T": // Credits (openings and endings) and trailers
+		return &anime.LocalFileMetadata{
+			Episode:      0,
+			AniDBEpisode: "",
+			Type:         anime.LocalFileTypeNC,
+		}, true
+	}
+
+	return nil, false
+}
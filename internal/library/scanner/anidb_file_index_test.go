@@ -0,0 +1,133 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/md4"
+	"os"
+	"path/filepath"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"strings"
+	"testing"
+)
+
+func TestGetED2KHash(t *testing.T) {
+	md4Sum := func(data []byte) []byte {
+		h := md4.New()
+		h.Write(data)
+		return h.Sum(nil)
+	}
+
+	twoChunks := bytes.Repeat([]byte{'a'}, ed2kChunkSize+10)
+	twoChunksHash := md4Sum(append(md4Sum(twoChunks[:ed2kChunkSize]), md4Sum(twoChunks[ed2kChunkSize:])...))
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{name: "empty", data: []byte{}, expected: "31d6cfe0d16ae931b73c59d7e0c089c0"},
+		{name: "single chunk", data: []byte("abc"), expected: "a448017aaf21d8525fc10ae87aa6729d"},
+		{name: "exactly one chunk", data: twoChunks[:ed2kChunkSize], expected: hex.EncodeToString(md4Sum(twoChunks[:ed2kChunkSize]))},
+		{name: "two chunks", data: twoChunks, expected: hex.EncodeToString(twoChunksHash)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := getED2KHash(bytes.NewReader(tt.data))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, hash)
+		})
+	}
+}
+
+func TestParseAniDBFileDump(t *testing.T) {
+	dump := `# ed2k|size|aid|epno|anilist_id
+a448017aaf21d8525fc10ae87aa6729d|3|1|1|457
+31D6CFE0D16AE931B73C59D7E0C089C0|100|1|s2|457
+0123456789abcdef0123456789abcdef|200|42|C1
+invalid line
+0123456789abcdef0123456789abcdef|abc|1|1|457
+`
+	index, skipped, err := ParseAniDBFileDump(strings.NewReader(dump))
+	require.NoError(t, err)
+	assert.Equal(t, 2, skipped)
+	assert.Len(t, index.Files, 3)
+
+	entry, ok := index.Get("A448017AAF21D8525FC10AE87AA6729D", 3)
+	require.True(t, ok)
+	assert.Equal(t, 457, entry.MediaId)
+
+	_, ok = index.Get("a448017aaf21d8525fc10ae87aa6729d", 4)
+	assert.False(t, ok, "size should be part of the key")
+
+	entry, ok = index.Get("31d6cfe0d16ae931b73c59d7e0c089c0", 100)
+	require.True(t, ok)
+	metadata, ok := entry.GetMetadata()
+	require.True(t, ok)
+	assert.Equal(t, &anime.LocalFileMetadata{Episode: 2, AniDBEpisode: "S2", Type: anime.LocalFileTypeSpecial}, metadata)
+
+	entry, ok = index.Get("0123456789abcdef0123456789abcdef", 200)
+	require.True(t, ok)
+	assert.Equal(t, 0, entry.MediaId) // Needs to be resolved
+	metadata, ok = entry.GetMetadata()
+	require.True(t, ok)
+	assert.Equal(t, anime.LocalFileTypeNC, metadata.Type)
+
+	_, ok = (&AniDBFileEntry{AniDBEpisode: "O1"}).GetMetadata()
+	assert.False(t, ok)
+}
+
+func TestScanner_identifyLocalFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	identified := writeFile("[Group] 3f8a2c.mkv", "abc")
+	unknown := writeFile("Mushishi - 02.mkv", "episode 2")
+
+	index, _, err := ParseAniDBFileDump(strings.NewReader("a448017aaf21d8525fc10ae87aa6729d|3|1|5|457"))
+	require.NoError(t, err)
+
+	files, _, err := getMediaFilesFromRoots([]*models.LibraryRoot{{Path: dir, Enabled: true}}, util.NewLogger())
+	require.NoError(t, err)
+
+	scn := &Scanner{Logger: util.NewLogger(), FileIndex: index}
+	lfs, _ := scn.getLocalFiles(files)
+	remaining, identifiedLfs, err := scn.identifyLocalFiles(context.Background(), lfs)
+	require.NoError(t, err)
+
+	require.Len(t, identifiedLfs, 1)
+	assert.Equal(t, identified, identifiedLfs[0].Path)
+	assert.Equal(t, 457, identifiedLfs[0].MediaId)
+	assert.Equal(t, &anime.LocalFileMetadata{Episode: 5, AniDBEpisode: "5", Type: anime.LocalFileTypeMain}, identifiedLfs[0].Metadata)
+
+	require.Len(t, remaining, 1)
+	assert.Equal(t, unknown, remaining[0].Path)
+
+	// The hashes are cached in the fingerprint index and reused by the next scan
+	fp, ok := scn.GetFingerprintIndex().Get(unknown)
+	require.True(t, ok)
+	assert.NotEmpty(t, fp.ED2K)
+
+	next := &Scanner{Logger: util.NewLogger(), FileIndex: index, FingerprintIndex: scn.GetFingerprintIndex()}
+	next.getLocalFiles(files)
+	fp, ok = next.GetFingerprintIndex().Get(unknown)
+	require.True(t, ok)
+	assert.NotEmpty(t, fp.ED2K)
+}
+
+func TestScanner_getHashConcurrency(t *testing.T) {
+	assert.Equal(t, 2, (&Scanner{Concurrency: 2}).getHashConcurrency())
+	assert.Equal(t, 16, (&Scanner{Concurrency: 16}).getHashConcurrency())
+	assert.LessOrEqual(t, (&Scanner{}).getHashConcurrency(), defaultMaxConcurrentHashes)
+}
@@ -0,0 +1,200 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"github.com/goccy/go-json"
+	"os"
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/summary"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	ScanStageFiles     ScanStage = "files"     // Local files retrieved, parsed and filtered
+	ScanStageMedia     ScanStage = "media"     // Media fetched
+	ScanStageMatching  ScanStage = "matching"  // Local files matched
+	ScanStageHydration ScanStage = "hydration" // Local files hydrated
+)
+
+const (
+	// scanCheckpointVersion is incremented when checkpoints change in a way that invalidates older ones.
+	scanCheckpointVersion = 2
+	// scanCheckpointMaxAge is the age after which a checkpoint is not resumed, the library has likely changed.
+	scanCheckpointMaxAge = 24 * time.Hour
+)
+
+var (
+	ErrScanCancelled = errors.New("scan cancelled")
+
+	scanStages = []ScanStage{ScanStageFiles, ScanStageMedia, ScanStageMatching, ScanStageHydration}
+)
+
+type (
+	ScanStage string
+
+	// ScanCheckpoint is the state of a scan after its last completed stage.
+	// It is saved after each stage so that an interrupted scan can resume from it.
+	ScanCheckpoint struct {
+		Version          int       `json:"version"`
+		Key              string    `json:"key"`              // Identifies the scan options, only a scan with the same options can resume
+		ContentSignature string    `json:"contentSignature"` // Identifies the files in the library roots, only a scan of the same files can resume
+		Stage            ScanStage `json:"stage"`            // Last completed stage
+		CreatedAt        time.Time `json:"createdAt"`
+		UpdatedAt        time.Time `json:"updatedAt"`
+		// Files stage
+		LocalFiles            []*anime.LocalFile `json:"localFiles"` // Files that go through the matcher and hydrator
+		ReusedLocalFiles      []*anime.LocalFile `json:"reusedLocalFiles"`
+		SkippedLocalFiles     []*anime.LocalFile `json:"skippedLocalFiles"`
+		IdentifiedLocalFiles  []*anime.LocalFile `json:"identifiedLocalFiles"`
+		UnreachableLocalFiles []*anime.LocalFile `json:"unreachableLocalFiles"`
+		FingerprintIndex      *FingerprintIndex  `json:"fingerprintIndex"`
+		// Media stage
+		AllMedia        []*anilist.CompleteAnime              `json:"allMedia"`
+		UnknownMediaIds []int                                 `json:"unknownMediaIds"`
+		AnimeCollection *anilist.AnimeCollectionWithRelations `json:"animeCollection"`
+		// Logs of the completed stages
+		SummaryLogs []*summary.ScanSummaryLog `json:"summaryLogs"`
+	}
+)
+
+// HasCompleted returns true if the stage was completed before the checkpoint was saved.
+func (cp *ScanCheckpoint) HasCompleted(stage ScanStage) bool {
+	if cp == nil {
+		return false
+	}
+	return slices.Index(scanStages, cp.Stage) >= slices.Index(scanStages, stage)
+}
+
+// getCheckpointKey returns the key identifying the options of the scan.
+func (scn *Scanner) getCheckpointKey() string {
+	data, _ := json.Marshal(struct {
+		Roots            any
+		Enhanced         bool
+		SkipLockedFiles  bool
+		SkipIgnoredFiles bool
+		Incremental      bool
+		DryRun           bool
+	}{scn.getRoots(), scn.Enhanced, scn.SkipLockedFiles, scn.SkipIgnoredFiles, scn.Incremental, scn.DryRun})
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// getResumableCheckpoint returns the checkpoint the scan should resume from, or a new one.
+func (scn *Scanner) getResumableCheckpoint() *ScanCheckpoint {
+	key := scn.getCheckpointKey()
+
+	cp := scn.Checkpoint
+	if cp != nil && cp.Version == scanCheckpointVersion && cp.Key == key && cp.Stage != "" && time.Since(cp.UpdatedAt) < scanCheckpointMaxAge {
+		// Files might have been added, removed or replaced since the checkpoint was saved
+		mediaFiles, _, err := getMediaFilesFromRoots(scn.getRoots(), scn.Logger)
+		if err == nil && getContentSignature(mediaFiles) == cp.ContentSignature {
+			scn.Logger.Info().Str("stage", string(cp.Stage)).Msg("scanner: Resuming scan from checkpoint")
+			return cp
+		}
+		scn.Logger.Debug().Msg("scanner: Library has changed since the checkpoint, starting a new scan")
+	}
+
+	return &ScanCheckpoint{
+		Version:   scanCheckpointVersion,
+		Key:       key,
+		CreatedAt: time.Now(),
+	}
+}
+
+// getContentSignature returns a signature of the media files, their paths and the newest modification time.
+// It changes when a file is added, removed, renamed or modified, without reading the files.
+func getContentSignature(mediaFiles []*mediaFile) string {
+	paths := make([]string, 0, len(mediaFiles))
+	var newestModTime int64
+	for _, file := range mediaFiles {
+		paths = append(paths, file.Path)
+		if info, err := os.Stat(file.Path); err == nil && info.ModTime().UnixNano() > newestModTime {
+			newestModTime = info.ModTime().UnixNano()
+		}
+	}
+	slices.Sort(paths)
+
+	data, _ := json.Marshal(struct {
+		Paths         []string
+		NewestModTime int64
+	}{paths, newestModTime})
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// saveCheckpoint marks the stage as completed and passes the checkpoint to OnCheckpoint.
+func (scn *Scanner) saveCheckpoint(cp *ScanCheckpoint, stage ScanStage) {
+	cp.Stage = stage
+	cp.UpdatedAt = time.Now()
+	cp.SummaryLogs = scn.ScanSummaryLogger.Logs
+
+	if scn.OnCheckpoint != nil {
+		scn.OnCheckpoint(cp)
+	}
+}
+
+// checkCancelled returns ErrScanCancelled if the context was cancelled.
+func checkCancelled(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ErrScanCancelled
+	}
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ScanTracker keeps track of the scans in progress so that they can be cancelled.
+type ScanTracker struct {
+	mu      sync.Mutex
+	cancels map[int]context.CancelFunc
+	nextId  int
+}
+
+func NewScanTracker() *ScanTracker {
+	return &ScanTracker{
+		cancels: make(map[int]context.CancelFunc),
+	}
+}
+
+// Track returns a context that is cancelled by Cancel.
+// The returned function must be called when the scan ends.
+func (t *ScanTracker) Track(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	id := t.nextId
+	t.nextId++
+	t.cancels[id] = cancel
+
+	return ctx, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.cancels, id)
+		cancel()
+	}
+}
+
+// IsScanning returns true if a scan is in progress.
+func (t *ScanTracker) IsScanning() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.cancels) > 0
+}
+
+// Cancel cancels the scans in progress.
+// It returns false if there was no scan to cancel.
+func (t *ScanTracker) Cancel() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, cancel := range t.cancels {
+		cancel()
+	}
+	return len(t.cancels) > 0
+}
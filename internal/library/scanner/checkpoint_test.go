@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"seanime/internal/database/models"
+	"seanime/internal/events"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"testing"
+	"time"
+)
+
+func TestScanCheckpoint_HasCompleted(t *testing.T) {
+	var cp *ScanCheckpoint
+	assert.False(t, cp.HasCompleted(ScanStageFiles))
+
+	cp = &ScanCheckpoint{}
+	assert.False(t, cp.HasCompleted(ScanStageFiles))
+
+	cp.Stage = ScanStageMedia
+	assert.True(t, cp.HasCompleted(ScanStageFiles))
+	assert.True(t, cp.HasCompleted(ScanStageMedia))
+	assert.False(t, cp.HasCompleted(ScanStageMatching))
+	assert.False(t, cp.HasCompleted(ScanStageHydration))
+}
+
+func TestScanner_Scan_Checkpoint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Mushishi", "Mushishi - 01.mkv")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("episode 1"), 0644))
+
+	newScanner := func() *Scanner {
+		return &Scanner{
+			Roots:          []*models.LibraryRoot{{Path: dir, Enabled: true}},
+			Logger:         util.NewLogger(),
+			WSEventManager: events.NewMockWSEventManager(util.NewLogger()),
+			DryRun:         true,
+		}
+	}
+
+	// The scan is cancelled after the first stage
+	var checkpoint *ScanCheckpoint
+	sc := newScanner()
+	sc.OnCheckpoint = func(cp *ScanCheckpoint) {
+		checkpoint = cp
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := sc.Scan(ctx)
+	require.ErrorIs(t, err, ErrScanCancelled)
+	require.NotNil(t, checkpoint)
+	assert.Equal(t, ScanStageFiles, checkpoint.Stage)
+	require.Len(t, checkpoint.LocalFiles, 1)
+	assert.Equal(t, path, checkpoint.LocalFiles[0].Path)
+
+	// Simulate an interrupted scan that completed the hydration stage
+	checkpoint.Stage = ScanStageHydration
+	checkpoint.LocalFiles[0].MediaId = 457
+	checkpoint.LocalFiles[0].Metadata = &anime.LocalFileMetadata{Episode: 1, AniDBEpisode: "1", Type: anime.LocalFileTypeMain}
+
+	// A scan with different options does not resume from the checkpoint
+	sc = newScanner()
+	sc.Enhanced = true
+	sc.Checkpoint = checkpoint
+	assert.Equal(t, ScanStage(""), sc.getResumableCheckpoint().Stage)
+
+	// An old checkpoint is not resumed
+	sc = newScanner()
+	sc.Checkpoint = &ScanCheckpoint{Version: checkpoint.Version, Key: checkpoint.Key, Stage: checkpoint.Stage, UpdatedAt: time.Now().Add(-scanCheckpointMaxAge)}
+	assert.Equal(t, ScanStage(""), sc.getResumableCheckpoint().Stage)
+
+	// A checkpoint is not resumed if the files have changed
+	newPath := filepath.Join(dir, "Mushishi", "Mushishi - 02.mkv")
+	require.NoError(t, os.WriteFile(newPath, []byte("episode 2"), 0644))
+	sc = newScanner()
+	sc.Checkpoint = checkpoint
+	assert.Equal(t, ScanStage(""), sc.getResumableCheckpoint().Stage)
+	require.NoError(t, os.Remove(newPath))
+
+	// The scan resumes from the checkpoint, the files are not matched again
+	sc = newScanner()
+	sc.Checkpoint = checkpoint
+	lfs, err := sc.Scan(context.Background())
+	require.NoError(t, err)
+	require.Len(t, lfs, 1)
+	assert.Equal(t, 457, lfs[0].MediaId)
+	assert.Equal(t, 1, lfs[0].Metadata.Episode)
+	assert.NotNil(t, sc.GetFingerprintIndex())
+}
+
+func TestScanTracker(t *testing.T) {
+	tracker := NewScanTracker()
+	assert.False(t, tracker.Cancel())
+
+	ctx, done := tracker.Track(context.Background())
+	assert.True(t, tracker.IsScanning())
+	assert.NoError(t, ctx.Err())
+
+	assert.True(t, tracker.Cancel())
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	done()
+	assert.False(t, tracker.IsScanning())
+	assert.False(t, tracker.Cancel())
+}
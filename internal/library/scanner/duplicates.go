@@ -0,0 +1,210 @@
+package scanner
+
+import (
+	"os"
+	"regexp"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/summary"
+	"seanime/internal/util"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DuplicateEpisodeGroup holds the files matched to the same episode.
+type DuplicateEpisodeGroup struct {
+	MediaId      int                 `json:"mediaId"`
+	AniDBEpisode string              `json:"aniDBEpisode"`
+	Episode      int                 `json:"episode"`
+	Type         anime.LocalFileType `json:"type"`
+	Preferred    *anime.LocalFile    `json:"preferred"`
+	Alternates   []*anime.LocalFile  `json:"alternates"` // From most to least preferred
+}
+
+type duplicateKey struct {
+	mediaId      int
+	fileType     anime.LocalFileType
+	aniDBEpisode string
+}
+
+var (
+	resolutionHeightRegex = regexp.MustCompile(`(?i)^(\d{3,4})p$`)
+	resolutionSizeRegex   = regexp.MustCompile(`(?i)^\d{3,4}x(\d{3,4})$`)
+)
+
+// FindDuplicateEpisodes groups the main and special files matched to the same episode, and ranks them with the policy.
+// Ties are broken by path so that the same file is always preferred.
+func FindDuplicateEpisodes(lfs []*anime.LocalFile, policy *models.DuplicatePolicy) []*DuplicateEpisodeGroup {
+	groups := make(map[duplicateKey][]*anime.LocalFile)
+	keys := make([]duplicateKey, 0)
+	for _, lf := range lfs {
+		if lf.MediaId == 0 || lf.IsIgnored() || lf.Metadata == nil || lf.Metadata.AniDBEpisode == "" {
+			continue
+		}
+		if lf.Metadata.Type != anime.LocalFileTypeMain && lf.Metadata.Type != anime.LocalFileTypeSpecial {
+			continue
+		}
+		key := duplicateKey{mediaId: lf.MediaId, fileType: lf.Metadata.Type, aniDBEpisode: lf.Metadata.AniDBEpisode}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], lf)
+	}
+
+	ranker := newDuplicateRanker(policy)
+
+	ret := make([]*DuplicateEpisodeGroup, 0)
+	for _, key := range keys {
+		files := groups[key]
+		if len(files) < 2 {
+			continue
+		}
+		slices.SortStableFunc(files, ranker.compare)
+		ret = append(ret, &DuplicateEpisodeGroup{
+			MediaId:      key.mediaId,
+			AniDBEpisode: key.aniDBEpisode,
+			Episode:      files[0].GetEpisodeNumber(),
+			Type:         key.fileType,
+			Preferred:    files[0],
+			Alternates:   files[1:],
+		})
+	}
+
+	slices.SortStableFunc(ret, func(a, b *DuplicateEpisodeGroup) int {
+		if a.MediaId != b.MediaId {
+			return a.MediaId - b.MediaId
+		}
+		if a.Type != b.Type {
+			return strings.Compare(string(a.Type), string(b.Type))
+		}
+		return a.Episode - b.Episode
+	})
+
+	return ret
+}
+
+// MarkAlternateLocalFiles marks the files that are not the preferred release of their episode as alternates.
+// Files that are no longer duplicates are unmarked.
+func MarkAlternateLocalFiles(lfs []*anime.LocalFile, policy *models.DuplicatePolicy, summaryLogger *summary.ScanSummaryLogger) []*DuplicateEpisodeGroup {
+	for _, lf := range lfs {
+		if lf.Metadata != nil {
+			lf.Metadata.IsAlternate = false
+		}
+	}
+
+	groups := FindDuplicateEpisodes(lfs, policy)
+	for _, group := range groups {
+		for _, lf := range group.Alternates {
+			lf.Metadata.IsAlternate = true
+			summaryLogger.LogAlternate(lf, group.Preferred)
+		}
+	}
+
+	return groups
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type duplicateRanker struct {
+	criteria      []models.DuplicateCriterion
+	releaseGroups []string
+	modTimes      map[string]time.Time
+}
+
+func newDuplicateRanker(policy *models.DuplicatePolicy) *duplicateRanker {
+	ret := &duplicateRanker{
+		criteria:      policy.GetCriteria(),
+		releaseGroups: make([]string, 0),
+		modTimes:      make(map[string]time.Time),
+	}
+	if policy != nil {
+		for _, group := range policy.ReleaseGroups {
+			ret.releaseGroups = append(ret.releaseGroups, strings.ToLower(strings.TrimSpace(group)))
+		}
+	}
+	return ret
+}
+
+// compare returns a negative number if a is preferred over b.
+func (r *duplicateRanker) compare(a, b *anime.LocalFile) int {
+	for _, criterion := range r.criteria {
+		var diff int
+		switch criterion {
+		case models.DuplicateCriterionReleaseGroup:
+			diff = r.getReleaseGroupRank(a) - r.getReleaseGroupRank(b)
+		case models.DuplicateCriterionVersion:
+			diff = getReleaseVersion(b) - getReleaseVersion(a)
+		case models.DuplicateCriterionResolution:
+			diff = getResolutionHeight(b) - getResolutionHeight(a)
+		case models.DuplicateCriterionNewest:
+			diff = r.getModTime(b).Compare(r.getModTime(a))
+		}
+		if diff != 0 {
+			return diff
+		}
+	}
+	return strings.Compare(a.GetNormalizedPath(), b.GetNormalizedPath())
+}
+
+// getReleaseGroupRank returns the index of the file's release group in the preferred groups.
+func (r *duplicateRanker) getReleaseGroupRank(lf *anime.LocalFile) int {
+	if lf.ParsedData == nil || lf.ParsedData.ReleaseGroup == "" {
+		return len(r.releaseGroups)
+	}
+	idx := slices.Index(r.releaseGroups, strings.ToLower(lf.ParsedData.ReleaseGroup))
+	if idx == -1 {
+		return len(r.releaseGroups)
+	}
+	return idx
+}
+
+func (r *duplicateRanker) getModTime(lf *anime.LocalFile) time.Time {
+	if t, ok := r.modTimes[lf.Path]; ok {
+		return t
+	}
+	var t time.Time
+	if info, err := os.Stat(lf.Path); err == nil {
+		t = info.ModTime()
+	}
+	r.modTimes[lf.Path] = t
+	return t
+}
+
+// getReleaseVersion returns the version parsed from the file name (e.g. 2 for "v2"), files without a version are version 1.
+func getReleaseVersion(lf *anime.LocalFile) int {
+	if lf.ParsedData == nil || lf.ParsedData.Version == "" {
+		return 1
+	}
+	if v, ok := util.StringToInt(strings.TrimPrefix(strings.ToLower(lf.ParsedData.Version), "v")); ok {
+		return v
+	}
+	return 1
+}
+
+// getResolutionHeight returns the vertical resolution parsed from the file name, or 0.
+func getResolutionHeight(lf *anime.LocalFile) int {
+	if lf.ParsedData == nil || lf.ParsedData.Resolution == "" {
+		return 0
+	}
+	res := strings.TrimSpace(lf.ParsedData.Resolution)
+	switch strings.ToLower(res) {
+	case "4k", "uhd":
+		return 2160
+	case "fhd":
+		return 1080
+	case "hd":
+		return 720
+	case "sd":
+		return 480
+	}
+	if matches := resolutionHeightRegex.FindStringSubmatch(res); len(matches) == 2 {
+		h, _ := util.StringToInt(matches[1])
+		return h
+	}
+	if matches := resolutionSizeRegex.FindStringSubmatch(res); len(matches) == 2 {
+		h, _ := util.StringToInt(matches[1])
+		return h
+	}
+	return 0
+}
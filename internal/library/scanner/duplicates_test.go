@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFindDuplicateEpisodes(t *testing.T) {
+	dir := t.TempDir()
+	newFile := func(name string, episode int, modTime time.Time) *anime.LocalFile {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(name), 0644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+		lf := anime.NewLocalFile(path, dir)
+		lf.MediaId = 154587
+		lf.Metadata = &anime.LocalFileMetadata{Episode: episode, AniDBEpisode: strconv.Itoa(episode), Type: anime.LocalFileTypeMain}
+		return lf
+	}
+
+	now := time.Now()
+	subsPlease := newFile("[SubsPlease] Sousou no Frieren - 01 (720p) [4E1B9F0A].mkv", 1, now.Add(-time.Hour))
+	subsPlease1080 := newFile("[SubsPlease] Sousou no Frieren - 01 (1080p) [8B22F6C1].mkv", 1, now.Add(-2*time.Hour))
+	erai := newFile("[Erai-raws] Sousou no Frieren - 01v2 [720p].mkv", 1, now.Add(-3*time.Hour))
+	single := newFile("[SubsPlease] Sousou no Frieren - 02 (1080p) [00000000].mkv", 2, now)
+
+	require.Equal(t, "1080p", subsPlease1080.ParsedData.Resolution)
+	require.Equal(t, "2", erai.ParsedData.Version)
+
+	lfs := []*anime.LocalFile{subsPlease, subsPlease1080, erai, single}
+
+	tests := []struct {
+		name     string
+		policy   *models.DuplicatePolicy
+		expected []*anime.LocalFile
+	}{
+		{
+			name:     "Default",
+			policy:   nil,
+			expected: []*anime.LocalFile{erai, subsPlease1080, subsPlease},
+		},
+		{
+			name:     "Release group",
+			policy:   &models.DuplicatePolicy{ReleaseGroups: []string{"subsplease"}},
+			expected: []*anime.LocalFile{subsPlease1080, subsPlease, erai},
+		},
+		{
+			name:     "Resolution",
+			policy:   &models.DuplicatePolicy{Criteria: []models.DuplicateCriterion{models.DuplicateCriterionResolution, models.DuplicateCriterionNewest}},
+			expected: []*anime.LocalFile{subsPlease1080, subsPlease, erai},
+		},
+		{
+			name:     "Newest",
+			policy:   &models.DuplicatePolicy{Criteria: []models.DuplicateCriterion{models.DuplicateCriterionNewest}},
+			expected: []*anime.LocalFile{subsPlease, subsPlease1080, erai},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := MarkAlternateLocalFiles(lfs, tt.policy, nil)
+			require.Len(t, groups, 1)
+			assert.Equal(t, "1", groups[0].AniDBEpisode)
+			assert.Equal(t, tt.expected[0].Path, groups[0].Preferred.Path)
+			require.Len(t, groups[0].Alternates, 2)
+			for i, lf := range groups[0].Alternates {
+				assert.Equal(t, tt.expected[i+1].Path, lf.Path)
+			}
+
+			assert.False(t, tt.expected[0].IsAlternate())
+			assert.True(t, tt.expected[1].IsAlternate())
+			assert.True(t, tt.expected[2].IsAlternate())
+			assert.False(t, single.IsAlternate())
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package scanner
+
+import (
+	"encoding/hex"
+	"golang.org/x/crypto/md4"
+	"io"
+	"os"
+)
+
+// ed2kChunkSize is the size of the chunks hashed separately by ED2K.
+const ed2kChunkSize = 9728000
+
+// GetED2KHash returns the ED2K hash of the file, as used by AniDB.
+//
+// Files smaller than a chunk are hashed with MD4.
+// Larger files are split in chunks and the hash is the MD4 of the concatenated MD4 hashes of the chunks.
+// Files whose size is a multiple of the chunk size do not get an additional empty chunk.
+func GetED2KHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return getED2KHash(file)
+}
+
+func getED2KHash(r io.Reader) (string, error) {
+	chunkHashes := make([]byte, 0)
+	chunkHasher := md4.New()
+	chunks := 0
+
+	for {
+		chunkHasher.Reset()
+		n, err := io.CopyN(chunkHasher, r, ed2kChunkSize)
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		if n == 0 && chunks > 0 {
+			break
+		}
+		chunkHashes = chunkHasher.Sum(chunkHashes)
+		chunks++
+		if n < ed2kChunkSize {
+			break
+		}
+	}
+
+	if chunks == 1 {
+		return hex.EncodeToString(chunkHashes), nil
+	}
+
+	hasher := md4.New()
+	hasher.Write(chunkHashes)
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
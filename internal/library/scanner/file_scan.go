@@ -0,0 +1,237 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"github.com/samber/lo"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/api/anizip"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/filesystem"
+	"seanime/internal/library/summary"
+	"seanime/internal/util"
+	"seanime/internal/util/limiter"
+	"time"
+)
+
+// ScanFiles matches and hydrates the given files only, e.g. files reported by the library watcher.
+//
+// The other files of the library are not read again. The returned local files are ExistingLocalFiles
+// without the files in removedLfs, and with the new files added. Paths that are already in the local files are skipped.
+// A new file with the same content as a removed file (i.e. the file was moved or renamed) keeps its match and lock state.
+//
+// The paths of the files that could not be matched are returned, a full scan should be run for them.
+// GetFingerprintIndex returns FingerprintIndex updated with the new files.
+func (scn *Scanner) ScanFiles(ctx context.Context, paths []string, removedLfs []*anime.LocalFile) (lfs []*anime.LocalFile, unmatched []string, err error) {
+
+	defer util.HandlePanicWithError(&err)
+
+	if scn.ScanSummaryLogger == nil {
+		scn.ScanSummaryLogger = summary.NewScanSummaryLogger()
+	}
+
+	// Start from the previous index, removed files are dropped once the moved files are detected
+	scn.fingerprintIndex = NewFingerprintIndex()
+	scn.fingerprintIndex.UpdatedAt = time.Now()
+	if scn.FingerprintIndex.IsValid() {
+		for key, fp := range scn.FingerprintIndex.Files {
+			scn.fingerprintIndex.Files[key] = fp
+		}
+	}
+
+	existingLfs := lo.Filter(scn.ExistingLocalFiles, func(lf *anime.LocalFile, _ int) bool {
+		return !lf.IsIncluded(removedLfs)
+	})
+
+	mediaFiles := scn.getNewMediaFiles(paths, existingLfs)
+
+	scn.Logger.Debug().Int("count", len(mediaFiles)).Msg("scanner: Scanning files")
+
+	// Read the folder overrides and NFO files of the new files only
+	mediaFiles = scn.loadSidecarFiles(mediaFiles)
+
+	// Reuse the match of moved files, parse the others
+	movedLfs, toParse := scn.getMovedLocalFiles(mediaFiles, removedLfs)
+	for _, lf := range removedLfs {
+		if !filesystem.FileExists(lf.Path) {
+			delete(scn.fingerprintIndex.Files, normalizeFingerprintPath(lf.Path))
+		}
+	}
+	localFiles := scn.parseLocalFiles(toParse)
+
+	localFiles, identifiedLfs, err := scn.identifyLocalFiles(ctx, localFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(localFiles) > 0 {
+		if err = scn.matchAndHydrateFiles(localFiles, identifiedLfs); err != nil && !errors.Is(err, ErrNoLocalFiles) {
+			return nil, nil, err
+		}
+	}
+
+	unmatched = make([]string, 0)
+	for _, lf := range localFiles {
+		if lf.MediaId == 0 {
+			unmatched = append(unmatched, lf.Path)
+		}
+	}
+
+	lfs = make([]*anime.LocalFile, 0, len(existingLfs)+len(localFiles)+len(movedLfs)+len(identifiedLfs))
+	lfs = append(lfs, existingLfs...)
+	lfs = append(lfs, localFiles...)
+	lfs = append(lfs, movedLfs...)
+	lfs = append(lfs, identifiedLfs...)
+
+	MarkAlternateLocalFiles(lfs, scn.DuplicatePolicy, scn.ScanSummaryLogger)
+
+	scn.Logger.Debug().
+		Int("scanned", len(localFiles)).
+		Int("moved", len(movedLfs)).
+		Int("identified", len(identifiedLfs)).
+		Int("unmatched", len(unmatched)).
+		Msg("scanner: Scanned files")
+
+	return lfs, unmatched, nil
+}
+
+// getNewMediaFiles returns the video files among the paths that are in a library root and are not already local files.
+func (scn *Scanner) getNewMediaFiles(paths []string, existingLfs []*anime.LocalFile) []*mediaFile {
+	existing := make(map[string]struct{}, len(existingLfs))
+	for _, lf := range existingLfs {
+		existing[normalizeFingerprintPath(lf.Path)] = struct{}{}
+	}
+
+	roots := scn.getRoots()
+	ret := make([]*mediaFile, 0, len(paths))
+	for _, path := range lo.Uniq(paths) {
+		if !util.IsValidVideoExtension(filepath.Ext(path)) || !filesystem.FileExists(path) {
+			continue
+		}
+		if _, found := existing[normalizeFingerprintPath(path)]; found {
+			continue
+		}
+		for _, root := range roots {
+			if util.IsSubdirectory(root.Path, path) {
+				ret = append(ret, &mediaFile{Path: path, RootPath: root.Path})
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// getMovedLocalFiles returns the files that have the same content as a removed file, with the match of the removed file.
+// The other files are returned to be parsed and matched.
+func (scn *Scanner) getMovedLocalFiles(files []*mediaFile, removedLfs []*anime.LocalFile) (movedLfs []*anime.LocalFile, toParse []*mediaFile) {
+	movedLfs = make([]*anime.LocalFile, 0)
+	toParse = make([]*mediaFile, 0, len(files))
+
+	// Removed files, grouped by size
+	removed := make(map[int64][]*FileFingerprint)
+	removedByPath := make(map[string]*anime.LocalFile, len(removedLfs))
+	for _, lf := range removedLfs {
+		if fp, found := scn.FingerprintIndex.Get(lf.Path); found {
+			removed[fp.Size] = append(removed[fp.Size], fp)
+			removedByPath[normalizeFingerprintPath(lf.Path)] = lf
+		}
+	}
+
+	for _, file := range files {
+		fp, err := NewFileFingerprint(file.Path, scn.UsePartialHash)
+		if err != nil {
+			scn.Logger.Warn().Err(err).Str("path", file.Path).Msg("scanner: Could not get file fingerprint")
+			toParse = append(toParse, file)
+			continue
+		}
+
+		if candidates, ok := removed[fp.Size]; ok {
+			if i, ok := findSameContent(candidates, fp); ok {
+				prevLf := removedByPath[normalizeFingerprintPath(candidates[i].Path)]
+				fp.ED2K = candidates[i].ED2K
+				removed[fp.Size] = append(candidates[:i], candidates[i+1:]...)
+
+				lf := anime.NewLocalFile(file.Path, file.RootPath)
+				lf.MediaId = prevLf.MediaId
+				lf.Metadata = prevLf.Metadata
+				lf.Locked = prevLf.Locked
+				lf.Ignored = prevLf.Ignored
+				scn.fingerprintIndex.Set(fp)
+				scn.Logger.Debug().Str("from", prevLf.Path).Str("to", file.Path).Msg("scanner: Detected moved file")
+				movedLfs = append(movedLfs, lf)
+				continue
+			}
+		}
+
+		scn.fingerprintIndex.Set(fp)
+		toParse = append(toParse, file)
+	}
+
+	return movedLfs, toParse
+}
+
+// matchAndHydrateFiles fetches the media, then matches and hydrates the local files in place.
+func (scn *Scanner) matchAndHydrateFiles(localFiles []*anime.LocalFile, identifiedLfs []*anime.LocalFile) error {
+	completeAnimeCache := anilist.NewCompleteAnimeCache()
+	anizipCache := anizip.NewCache()
+	anilistRateLimiter := limiter.NewAnilistLimiter()
+
+	mf, err := NewMediaFetcher(&MediaFetcherOptions{
+		Enhanced:           false,
+		Concurrency:        scn.Concurrency,
+		Platform:           scn.Platform,
+		LocalFiles:         localFiles,
+		CompleteAnimeCache: completeAnimeCache,
+		AnizipCache:        anizipCache,
+		Logger:             scn.Logger,
+		AnilistRateLimiter: anilistRateLimiter,
+		ScanLogger:         scn.ScanLogger,
+		ExtraMediaIds:      append(scn.getPinnedMediaIds(localFiles), getMediaIds(identifiedLfs)...),
+	})
+	if err != nil {
+		return err
+	}
+
+	mc := NewMediaContainer(&MediaContainerOptions{
+		AllMedia:   mf.AllMedia,
+		ScanLogger: scn.ScanLogger,
+	})
+
+	unmatchedLfs := scn.matchMovieLocalFiles(localFiles, mf.AllMedia, completeAnimeCache)
+
+	matcher := &Matcher{
+		LocalFiles:         unmatchedLfs,
+		MediaContainer:     mc,
+		CompleteAnimeCache: completeAnimeCache,
+		Logger:             scn.Logger,
+		ScanLogger:         scn.ScanLogger,
+		ScanSummaryLogger:  scn.ScanSummaryLogger,
+		FolderOverrides:    scn.folderOverrides,
+		NfoFiles:           scn.nfoFiles,
+		LibraryMatchers:    scn.LibraryMatchers,
+		Concurrency:        scn.Concurrency,
+	}
+	if err = matcher.MatchLocalFilesWithMedia(); err != nil && len(unmatchedLfs) > 0 {
+		return err
+	}
+
+	hydrator := &FileHydrator{
+		AllMedia:           mc.NormalizedMedia,
+		LocalFiles:         localFiles,
+		AnizipCache:        anizipCache,
+		Platform:           scn.Platform,
+		CompleteAnimeCache: completeAnimeCache,
+		AnilistRateLimiter: anilistRateLimiter,
+		Logger:             scn.Logger,
+		ScanLogger:         scn.ScanLogger,
+		ScanSummaryLogger:  scn.ScanSummaryLogger,
+		FolderOverrides:    scn.folderOverrides,
+		Concurrency:        scn.Concurrency,
+	}
+	hydrator.HydrateMetadata()
+
+	scn.ScanSummaryLogger.HydrateData(localFiles, mc.NormalizedMedia, mf.AnimeCollectionWithRelations)
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"seanime/internal/database/models"
+	"seanime/internal/events"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"testing"
+)
+
+func TestScanner_ScanFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	ep1 := writeFile("Mushishi/Mushishi - 01.mkv", "episode 1")
+	ep2 := writeFile("Mushishi/Mushishi - 02.mkv", "episode 2")
+	ep3 := writeFile("Mushishi/Mushishi - 03.mkv", "episode 3")
+
+	// Index and local files of the last scan
+	index := NewFingerprintIndex()
+	existingLfs := make([]*anime.LocalFile, 0)
+	for i, path := range []string{ep1, ep2, ep3} {
+		fp, err := NewFileFingerprint(path, false)
+		require.NoError(t, err)
+		index.Set(fp)
+		lf := anime.NewLocalFile(path, dir)
+		lf.MediaId = 457
+		lf.Metadata = &anime.LocalFileMetadata{Episode: i + 1, AniDBEpisode: lf.ParsedData.Episode, Type: anime.LocalFileTypeMain}
+		lf.Locked = i == 1
+		existingLfs = append(existingLfs, lf)
+	}
+
+	// Episode 2 is renamed and episode 3 is deleted, they were removed from the local files by the watcher
+	renamed := writeFile("Mushishi/Season 1/[Group] Mushishi - 02.mkv", "")
+	require.NoError(t, os.Remove(renamed))
+	require.NoError(t, os.Rename(ep2, renamed))
+	require.NoError(t, os.Remove(ep3))
+	removedLfs := existingLfs[1:]
+
+	scn := &Scanner{
+		Roots:              []*models.LibraryRoot{{Path: dir, Enabled: true}},
+		Logger:             util.NewLogger(),
+		WSEventManager:     events.NewMockWSEventManager(util.NewLogger()),
+		ExistingLocalFiles: existingLfs[:1],
+		FingerprintIndex:   index,
+	}
+
+	// Known files and files that are not videos are skipped
+	notes := writeFile("Mushishi/notes.txt", "notes")
+	lfs, unmatched, err := scn.ScanFiles(context.Background(), []string{renamed, ep1, notes}, removedLfs)
+	require.NoError(t, err)
+	assert.Empty(t, unmatched)
+	require.Len(t, lfs, 2)
+
+	// The renamed file keeps its match and lock state
+	assert.Equal(t, ep1, lfs[0].Path)
+	assert.Equal(t, renamed, lfs[1].Path)
+	assert.Equal(t, 457, lfs[1].MediaId)
+	assert.Equal(t, 2, lfs[1].Metadata.Episode)
+	assert.True(t, lfs[1].Locked)
+
+	// The index has the new path, the removed files are dropped
+	newIndex := scn.GetFingerprintIndex()
+	_, found := newIndex.Get(renamed)
+	assert.True(t, found)
+	_, found = newIndex.Get(ep2)
+	assert.False(t, found)
+	_, found = newIndex.Get(ep3)
+	assert.False(t, found)
+	_, found = newIndex.Get(ep1)
+	assert.True(t, found)
+}
@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"seanime/internal/util"
+	"time"
+)
+
+const (
+	// fingerprintIndexVersion is incremented when the fingerprints change in a way that invalidates older indexes.
+	fingerprintIndexVersion = 2 // 2: paths are only case-folded on Windows and macOS
+	// partialHashChunkSize is the number of bytes read at the start and at the end of a file for the partial hash.
+	partialHashChunkSize = 64 * 1024
+)
+
+type (
+	// FingerprintIndex holds the fingerprint of every file of the library at the time of the last scan.
+	// It is used by incremental scans to skip files that have not changed and to detect files that were moved or renamed.
+	FingerprintIndex struct {
+		Version   int                         `json:"version"`
+		UpdatedAt time.Time                   `json:"updatedAt"`
+		Files     map[string]*FileFingerprint `json:"files"` // Indexed by normalized path
+	}
+
+	// FileFingerprint identifies a file without reading its content.
+	FileFingerprint struct {
+		Path        string `json:"path"`
+		Size        int64  `json:"size"`
+		ModTime     int64  `json:"modTime"`               // Unix nanoseconds
+		PartialHash string `json:"partialHash,omitempty"` // Hash of the size, and the first and last chunks of the file
+		ED2K        string `json:"ed2k,omitempty"`        // ED2K hash of the whole file, only computed for hash identification
+	}
+)
+
+func NewFingerprintIndex() *FingerprintIndex {
+	return &FingerprintIndex{
+		Version: fingerprintIndexVersion,
+		Files:   make(map[string]*FileFingerprint),
+	}
+}
+
+// IsValid returns false if the index cannot be used by an incremental scan.
+func (idx *FingerprintIndex) IsValid() bool {
+	return idx != nil && idx.Version == fingerprintIndexVersion && idx.Files != nil
+}
+
+func (idx *FingerprintIndex) Get(path string) (*FileFingerprint, bool) {
+	if idx == nil || idx.Files == nil {
+		return nil, false
+	}
+	fp, ok := idx.Files[normalizeFingerprintPath(path)]
+	return fp, ok
+}
+
+func (idx *FingerprintIndex) Set(fp *FileFingerprint) {
+	idx.Files[normalizeFingerprintPath(fp.Path)] = fp
+}
+
+// Rename sets the fingerprint of a file that was moved, copied or hard linked by the organizer.
+// The fingerprint of the original path is kept if keepOld is true.
+func (idx *FingerprintIndex) Rename(from, to string, keepOld bool) {
+	fp, ok := idx.Get(from)
+	if !ok {
+		return
+	}
+	if !keepOld {
+		delete(idx.Files, normalizeFingerprintPath(from))
+	}
+	newFp := *fp
+	newFp.Path = to
+	idx.Set(&newFp)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NewFileFingerprint returns the fingerprint of the file.
+// The partial hash is only computed if withHash is true.
+func NewFileFingerprint(path string, withHash bool) (*FileFingerprint, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &FileFingerprint{
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime().UnixNano(),
+	}
+
+	if withHash {
+		ret.PartialHash, err = getPartialHash(path, info.Size())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// IsUnchanged returns true if the file at the same path has not been modified.
+func (fp *FileFingerprint) IsUnchanged(other *FileFingerprint) bool {
+	return fp.Size == other.Size && fp.ModTime == other.ModTime
+}
+
+// IsSameContent returns true if the fingerprints likely belong to the same file at different paths.
+// The partial hashes are compared if both are present, otherwise the size and modification time are compared.
+func (fp *FileFingerprint) IsSameContent(other *FileFingerprint) bool {
+	if fp.Size != other.Size {
+		return false
+	}
+	if fp.PartialHash != "" && other.PartialHash != "" {
+		return fp.PartialHash == other.PartialHash
+	}
+	return fp.ModTime == other.ModTime
+}
+
+func getPartialHash(path string, size int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha1.New()
+	_, _ = fmt.Fprintf(h, "%d", size)
+
+	// Hash the first chunk
+	if _, err = io.CopyN(h, file, partialHashChunkSize); err != nil && err != io.EOF {
+		return "", err
+	}
+
+	// Hash the last chunk if the file is large enough
+	if size > 2*partialHashChunkSize {
+		if _, err = file.Seek(-partialHashChunkSize, io.SeekEnd); err != nil {
+			return "", err
+		}
+		if _, err = io.CopyN(h, file, partialHashChunkSize); err != nil && err != io.EOF {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// normalizeFingerprintPath returns the key of the path in the index, see util.FoldPathCase.
+func normalizeFingerprintPath(path string) string {
+	return util.FoldPathCase(path)
+}
@@ -0,0 +1,207 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+	"os"
+	"path/filepath"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"strconv"
+	"strings"
+)
+
+// FolderOverrideFilename is the name of the sidecar file read in library folders.
+const FolderOverrideFilename = ".seanime.json"
+
+type (
+	// FolderOverride is the content of a FolderOverrideFilename file.
+	// It applies to every file in the folder and its subfolders.
+	// Overrides in subfolders take precedence over the ones in parent folders, exclude patterns are combined.
+	//
+	//	{
+	//		"mediaId": 21,
+	//		"episodeOffset": -12,
+	//		"episodes": { "13": 1, "14": 2 },
+	//		"type": "main",
+	//		"exclude": ["*sample*", "Extras/*"]
+	//	}
+	FolderOverride struct {
+		MediaId       int                 `json:"mediaId,omitempty"`       // Matches the files to this media, skipping the matcher
+		EpisodeOffset int                 `json:"episodeOffset,omitempty"` // Added to the parsed episode numbers
+		Episodes      map[string]int      `json:"episodes,omitempty"`      // Maps parsed episode numbers (e.g. absolute numbers) to relative episode numbers
+		Type          anime.LocalFileType `json:"type,omitempty"`          // Forces the type of the files
+		Exclude       []string            `json:"exclude,omitempty"`       // Glob patterns of files to exclude from the library, relative to the folder
+	}
+
+	// FolderOverrides holds the folder overrides found in the library.
+	FolderOverrides struct {
+		folders map[string]*FolderOverride // Indexed by normalized folder path
+		logger  *zerolog.Logger
+	}
+)
+
+// LoadFolderOverrides reads the folder overrides of the directories containing the files, up to their library root.
+func LoadFolderOverrides(files []*mediaFile, logger *zerolog.Logger) *FolderOverrides {
+	ret := &FolderOverrides{
+		folders: make(map[string]*FolderOverride),
+		logger:  logger,
+	}
+
+	checked := make(map[string]struct{})
+	for _, file := range files {
+		for _, dir := range getParentDirs(file.Path, file.RootPath) {
+			key := normalizeFingerprintPath(dir)
+			if _, ok := checked[key]; ok {
+				break // Parent directories have been checked as well
+			}
+			checked[key] = struct{}{}
+
+			override, err := readFolderOverride(dir)
+			if err != nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					logger.Warn().Err(err).Str("dir", dir).Msg("scanner: Could not read folder override")
+				}
+				continue
+			}
+			ret.folders[key] = override
+			logger.Debug().Str("dir", dir).Msg("scanner: Found folder override")
+		}
+	}
+
+	return ret
+}
+
+func readFolderOverride(dir string) (*FolderOverride, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FolderOverrideFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	var ret FolderOverride
+	if err = json.Unmarshal(data, &ret); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FolderOverrideFilename, err)
+	}
+
+	switch ret.Type {
+	case "", anime.LocalFileTypeMain, anime.LocalFileTypeSpecial, anime.LocalFileTypeNC:
+	default:
+		return nil, fmt.Errorf("invalid %s: unknown type \"%s\"", FolderOverrideFilename, ret.Type)
+	}
+
+	return &ret, nil
+}
+
+// IsEmpty returns true if no override applies.
+func (fo *FolderOverrides) IsEmpty() bool {
+	return fo == nil || len(fo.folders) == 0
+}
+
+// Get returns the override that applies to the file.
+func (fo *FolderOverrides) Get(path string) (*FolderOverride, bool) {
+	if fo.IsEmpty() {
+		return nil, false
+	}
+
+	var ret *FolderOverride
+	// Go from the top-most folder to the file's folder so that the nearest folder takes precedence
+	dirs := getParentDirs(path, "")
+	for i := len(dirs) - 1; i >= 0; i-- {
+		override, ok := fo.folders[normalizeFingerprintPath(dirs[i])]
+		if !ok {
+			continue
+		}
+		if ret == nil {
+			ret = &FolderOverride{}
+		}
+		ret.merge(override)
+	}
+
+	return ret, ret != nil
+}
+
+// IsExcluded returns true if the file matches an exclude pattern of its folder or of a parent folder.
+func (fo *FolderOverrides) IsExcluded(path string) bool {
+	if fo.IsEmpty() {
+		return false
+	}
+
+	for _, dir := range getParentDirs(path, "") {
+		override, ok := fo.folders[normalizeFingerprintPath(dir)]
+		if !ok || len(override.Exclude) == 0 {
+			continue
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range override.Exclude {
+			pattern = filepath.ToSlash(pattern)
+			// Patterns without a slash match the file name, like in .gitignore
+			target := rel
+			if !strings.Contains(pattern, "/") {
+				target = filepath.Base(path)
+			}
+			if ok, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(target)); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// GetEpisode returns the episode number after applying the episode mapping or offset.
+// It returns false if the override does not change episode numbers.
+func (o *FolderOverride) GetEpisode(episode int) (int, bool) {
+	if o == nil {
+		return episode, false
+	}
+	if len(o.Episodes) > 0 && episode > -1 {
+		if ep, ok := o.Episodes[strconv.Itoa(episode)]; ok {
+			return ep, true
+		}
+	}
+	if o.EpisodeOffset != 0 && episode > -1 {
+		return episode + o.EpisodeOffset, true
+	}
+	return episode, false
+}
+
+func (o *FolderOverride) merge(other *FolderOverride) {
+	if other.MediaId != 0 {
+		o.MediaId = other.MediaId
+	}
+	if other.EpisodeOffset != 0 {
+		o.EpisodeOffset = other.EpisodeOffset
+	}
+	if len(other.Episodes) > 0 {
+		o.Episodes = other.Episodes
+	}
+	if other.Type != "" {
+		o.Type = other.Type
+	}
+	o.Exclude = append(o.Exclude, other.Exclude...)
+}
+
+// getParentDirs returns the directories containing the file, from the nearest to the farthest.
+// If rootPath is set, it stops at the root.
+func getParentDirs(path string, rootPath string) []string {
+	ret := make([]string, 0)
+	dir := filepath.Dir(path)
+	for {
+		if rootPath != "" && !util.IsSubdirectory(rootPath, dir) {
+			break
+		}
+		ret = append(ret, dir)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ret
+}
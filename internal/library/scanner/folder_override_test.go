@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"testing"
+)
+
+func TestFolderOverrides(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(name string, content string) string {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	writeFile(".seanime.json", `{"exclude": ["*sample*"], "episodeOffset": -1}`)
+	writeFile("One Piece/.seanime.json", `{"mediaId": 21, "episodes": {"1071": 1}, "exclude": ["Extras/*"]}`)
+	writeFile("Broken/.seanime.json", `{"type": "trailer"}`)
+
+	files := lo.Map([]string{
+		writeFile("One Piece/One Piece - 1071.mkv", ""),
+		writeFile("One Piece/One Piece - 1072 sample.mkv", ""),
+		writeFile("One Piece/Extras/Making of.mkv", ""),
+		writeFile("Mushishi/Mushishi - 02.mkv", ""),
+		writeFile("Broken/Broken - 01.mkv", ""),
+	}, func(path string, _ int) *mediaFile {
+		return &mediaFile{Path: path, RootPath: root}
+	})
+
+	overrides := LoadFolderOverrides(files, util.NewLogger())
+	require.False(t, overrides.IsEmpty())
+
+	// Exclude patterns are combined
+	assert.False(t, overrides.IsExcluded(files[0].Path))
+	assert.True(t, overrides.IsExcluded(files[1].Path))
+	assert.True(t, overrides.IsExcluded(files[2].Path))
+	assert.False(t, overrides.IsExcluded(files[3].Path))
+
+	// The nearest folder takes precedence
+	override, ok := overrides.Get(files[0].Path)
+	require.True(t, ok)
+	assert.Equal(t, 21, override.MediaId)
+	ep, ok := override.GetEpisode(1071)
+	assert.True(t, ok)
+	assert.Equal(t, 1, ep)
+	ep, _ = override.GetEpisode(1072)
+	assert.Equal(t, 1071, ep) // Offset from the parent folder
+
+	override, ok = overrides.Get(files[3].Path)
+	require.True(t, ok)
+	assert.Equal(t, 0, override.MediaId)
+	ep, _ = override.GetEpisode(2)
+	assert.Equal(t, 1, ep)
+
+	// Invalid overrides are ignored
+	override, _ = overrides.Get(files[4].Path)
+	assert.Equal(t, anime.LocalFileType(""), override.Type)
+}
+
+func TestFolderOverrides_MatchAndHydrate(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "[Group] Bocchi the Rock")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".seanime.json"), []byte(`{"mediaId": 130003, "episodeOffset": -12}`), 0644))
+
+	files := []*mediaFile{{Path: filepath.Join(dir, "[Group] BTR - 13.mkv"), RootPath: root}}
+	overrides := LoadFolderOverrides(files, util.NewLogger())
+	lfs := []*anime.LocalFile{anime.NewLocalFile(files[0].Path, root)}
+
+	allMedia := []*anilist.CompleteAnime{{
+		ID:       130003,
+		Format:   lo.ToPtr(anilist.MediaFormatTv),
+		Episodes: lo.ToPtr(12),
+		Status:   lo.ToPtr(anilist.MediaStatusFinished),
+		Title:    &anilist.CompleteAnime_Title{Romaji: lo.ToPtr("Bocchi the Rock!"), English: lo.ToPtr("Bocchi the Rock!")},
+	}}
+	mc := NewMediaContainer(&MediaContainerOptions{AllMedia: allMedia})
+
+	matcher := &Matcher{
+		LocalFiles:         lfs,
+		MediaContainer:     mc,
+		CompleteAnimeCache: anilist.NewCompleteAnimeCache(),
+		Logger:             util.NewLogger(),
+		FolderOverrides:    overrides,
+	}
+	require.NoError(t, matcher.MatchLocalFilesWithMedia())
+	assert.Equal(t, 130003, lfs[0].MediaId)
+
+	hydrator := &FileHydrator{
+		LocalFiles:      lfs,
+		AllMedia:        mc.NormalizedMedia,
+		Logger:          util.NewLogger(),
+		FolderOverrides: overrides,
+	}
+	hydrator.HydrateMetadata()
+	assert.Equal(t, 130003, lfs[0].MediaId)
+	assert.Equal(t, 1, lfs[0].Metadata.Episode)
+	assert.Equal(t, "1", lfs[0].Metadata.AniDBEpisode)
+	assert.Equal(t, anime.LocalFileTypeMain, lfs[0].Metadata.Type)
+}
@@ -0,0 +1,131 @@
+package scanner
+
+import (
+	"context"
+	"github.com/sourcegraph/conc/pool"
+	"seanime/internal/library/anime"
+	"sync"
+	"time"
+)
+
+// defaultMaxConcurrentHashes limits the number of files hashed at the same time when the scanner's concurrency is not set.
+// Hashing reads whole files, so it is bound by the disk rather than the number of CPUs.
+const defaultMaxConcurrentHashes = 4
+
+// identifyLocalFiles identifies local files by looking up their ED2K hash in the AniDB file index.
+// It returns the files that still need to go through the matcher and hydrator,
+// and the identified files, whose media and metadata are set.
+//
+// Hashes are stored in the fingerprint index so that unchanged files are only hashed once.
+// Files matched by a folder override are not identified.
+// It returns ErrScanCancelled if the context is cancelled while hashing.
+func (scn *Scanner) identifyLocalFiles(ctx context.Context, lfs []*anime.LocalFile) (remaining []*anime.LocalFile, identified []*anime.LocalFile, err error) {
+	remaining = make([]*anime.LocalFile, 0, len(lfs))
+	identified = make([]*anime.LocalFile, 0)
+
+	if scn.FileIndex.IsEmpty() {
+		return lfs, identified, nil
+	}
+
+	start := time.Now()
+	hashed := 0
+
+	mu := sync.Mutex{}
+	p := pool.New().WithMaxGoroutines(scn.getHashConcurrency())
+	for _, lf := range lfs {
+		p.Go(func() {
+			// Skip the remaining files, the scan will stop
+			if ctx.Err() != nil {
+				return
+			}
+
+			entry, computed, ok := scn.lookupFileIndex(lf)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if computed {
+				hashed++
+			}
+			if !ok {
+				remaining = append(remaining, lf)
+				return
+			}
+
+			metadata, ok := entry.GetMetadata()
+			if !ok {
+				remaining = append(remaining, lf)
+				return
+			}
+
+			lf.MediaId = entry.MediaId
+			lf.Metadata = metadata
+			identified = append(identified, lf)
+
+			if scn.ScanLogger != nil {
+				scn.ScanLogger.logger.Debug().
+					Str("filename", lf.Name).
+					Int("mediaId", lf.MediaId).
+					Str("aniDBEpisode", entry.AniDBEpisode).
+					Msg("File identified by hash")
+			}
+			scn.ScanSummaryLogger.LogHashIdentified(lf, entry.MediaId, entry.AniDBEpisode)
+		})
+	}
+	p.Wait()
+
+	if err = checkCancelled(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	scn.Logger.Debug().
+		Int("identified", len(identified)).
+		Int("hashed", hashed).
+		Int64("ms", time.Since(start).Milliseconds()).
+		Msg("scanner: Identified files by hash")
+
+	if scn.ScanLogger != nil {
+		scn.ScanLogger.logger.Info().
+			Int("identified", len(identified)).
+			Int("hashed", hashed).
+			Int64("ms", time.Since(start).Milliseconds()).
+			Msg("Identified files by hash")
+	}
+
+	return remaining, identified, nil
+}
+
+// lookupFileIndex returns the file index entry of the local file.
+// computed is true if the hash of the file had to be computed.
+func (scn *Scanner) lookupFileIndex(lf *anime.LocalFile) (entry *AniDBFileEntry, computed bool, ok bool) {
+	if override, found := scn.folderOverrides.Get(lf.Path); found && override.MediaId != 0 {
+		return nil, false, false
+	}
+
+	fp, found := scn.fingerprintIndex.Get(lf.Path)
+	if !found {
+		return nil, false, false
+	}
+
+	if fp.ED2K == "" {
+		hash, err := GetED2KHash(lf.Path)
+		if err != nil {
+			scn.Logger.Warn().Err(err).Str("path", lf.Path).Msg("scanner: Could not compute ED2K hash")
+			return nil, false, false
+		}
+		fp.ED2K = hash
+		computed = true
+	}
+
+	entry, ok = scn.FileIndex.Get(fp.ED2K, fp.Size)
+	return entry, computed, ok
+}
+
+// getHashConcurrency returns the maximum number of files hashed at the same time.
+// The scanner's concurrency is used if it is set, otherwise the default is capped by the number of CPUs.
+func (scn *Scanner) getHashConcurrency() int {
+	if scn.Concurrency > 0 {
+		return scn.Concurrency
+	}
+	return min(getConcurrency(0), defaultMaxConcurrentHashes)
+}
@@ -1,7 +1,9 @@
 package scanner
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/rs/zerolog"
 	"github.com/samber/lo"
 	lop "github.com/samber/lo/parallel"
@@ -14,6 +16,7 @@ import (
 	"seanime/internal/util"
 	"seanime/internal/util/comparison"
 	"seanime/internal/util/limiter"
+	"slices"
 	"strconv"
 	"time"
 )
@@ -31,10 +34,14 @@ type FileHydrator struct {
 	ScanLogger         *ScanLogger                // optional
 	ScanSummaryLogger  *summary.ScanSummaryLogger // optional
 	ForceMediaId       int                        // optional - force all local files to have this media ID
+	FolderOverrides    *FolderOverrides           // optional
+	Concurrency        int                        // optional - maximum number of media groups hydrated in parallel, defaults to the number of CPUs
+	Context            context.Context            // optional - the remaining groups are not hydrated when it is cancelled
 }
 
 // HydrateMetadata will hydrate the metadata of each LocalFile with the metadata of the matched anilist.BaseAnime.
 // It will divide the LocalFiles into groups based on their media ID and process each group in parallel.
+// The AniList and AniZip requests of all groups share the same rate limiters.
 func (fh *FileHydrator) HydrateMetadata() {
 	start := time.Now()
 	rateLimiter := limiter.NewLimiter(5*time.Second, 20)
@@ -55,10 +62,22 @@ func (fh *FileHydrator) HydrateMetadata() {
 			Msg("Starting metadata hydration process")
 	}
 
+	ctx := fh.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	// Process each group in parallel
-	p := pool.New()
-	for mId, files := range groups {
+	// Groups are started in order of media ID so that the requests are made in the same order across scans
+	mIds := lo.Keys(groups)
+	slices.Sort(mIds)
+	p := pool.New().WithMaxGoroutines(getConcurrency(fh.Concurrency))
+	for _, mId := range mIds {
+		files := groups[mId]
 		p.Go(func() {
+			if ctx.Err() != nil {
+				return
+			}
 			if len(files) > 0 {
 				fh.hydrateGroupMetadata(mId, files, rateLimiter)
 			}
@@ -122,6 +141,35 @@ func (fh *FileHydrator) hydrateGroupMetadata(
 			}
 		}
 
+		// Alternate episode number, used when the episode number does not fit the media tree
+		// e.g. "01" in "105 (S05E01)"
+		altEpisode := -1
+		if len(lf.ParsedData.EpisodeAlt) > 0 {
+			if ep, ok := util.StringToInt(lf.ParsedData.EpisodeAlt); ok {
+				altEpisode = ep
+			}
+		}
+
+		// Folder override
+		// Applied before the detection of the episode number and type
+		forceMediaId := fh.ForceMediaId
+		episodeOverridden := false
+		if override, ok := fh.FolderOverrides.Get(lf.Path); ok {
+			if override.MediaId != 0 {
+				forceMediaId = override.MediaId
+			}
+			episode, episodeOverridden = override.GetEpisode(episode)
+			if override.Type != "" {
+				fh.hydrateWithFolderOverrideType(lf, override.Type, episode)
+				if fh.ScanLogger != nil {
+					fh.logFileHydration(zerolog.DebugLevel, lf, mId, episode).
+						Msg("File type set by folder override")
+				}
+				fh.ScanSummaryLogger.LogFolderOverride(lf, fmt.Sprintf("type %s, episode %d", lf.Metadata.Type, lf.Metadata.Episode))
+				return
+			}
+		}
+
 		// NC metadata
 		if comparison.ValueContainsNC(lf.Name) {
 			lf.Metadata.Episode = 0
@@ -162,6 +210,20 @@ func (fh *FileHydrator) hydrateGroupMetadata(
 			fh.ScanSummaryLogger.LogMetadataSpecial(lf, lf.Metadata.Episode, lf.Metadata.AniDBEpisode)
 			return
 		}
+		// Episode number set by folder override
+		if episodeOverridden {
+			lf.Metadata.Episode = episode
+			lf.Metadata.AniDBEpisode = strconv.Itoa(episode)
+
+			/*Log */
+			if fh.ScanLogger != nil {
+				fh.logFileHydration(zerolog.DebugLevel, lf, mId, episode).
+					Msg("Episode number set by folder override")
+			}
+			fh.ScanSummaryLogger.LogFolderOverride(lf, fmt.Sprintf("episode %d", episode))
+			return
+		}
+
 		// Movie metadata
 		if *media.Format == anilist.MediaFormatMovie {
 			lf.Metadata.Episode = 1
@@ -258,7 +320,7 @@ func (fh *FileHydrator) hydrateGroupMetadata(
 		}
 
 		// Absolute episode count
-		if episode > media.GetCurrentEpisodeCount() && fh.ForceMediaId == 0 {
+		if episode > media.GetCurrentEpisodeCount() && forceMediaId == 0 {
 			if !treeFetched {
 
 				mediaTreeFetchStart := time.Now()
@@ -270,6 +332,7 @@ func (fh *FileHydrator) hydrateGroupMetadata(
 						tree:        tree,
 						anizipCache: fh.AnizipCache,
 						rateLimiter: rateLimiter,
+						concurrency: fh.Concurrency,
 					})
 					// Hoist the media tree analysis, so it will be used by other files
 					// We don't care if it's nil because [normalizeEpisodeNumberAndHydrate] will handle it
@@ -303,6 +366,11 @@ func (fh *FileHydrator) hydrateGroupMetadata(
 			// Normalize episode number
 			if err := fh.normalizeEpisodeNumberAndHydrate(mediaTreeAnalysis, lf, episode, media.GetCurrentEpisodeCount()); err != nil {
 
+				// Use the alternate episode number if it fits the media
+				if fh.hydrateWithAltEpisode(lf, media, mId, episode, altEpisode) {
+					return
+				}
+
 				/*Log */
 				if fh.ScanLogger != nil {
 					fh.logFileHydration(zerolog.WarnLevel, lf, mId, episode).
@@ -331,11 +399,11 @@ func (fh *FileHydrator) hydrateGroupMetadata(
 		}
 
 		// Absolute episode count with forced media ID
-		if fh.ForceMediaId != 0 && episode > media.GetCurrentEpisodeCount() {
+		if forceMediaId != 0 && episode > media.GetCurrentEpisodeCount() {
 
 			// When we encounter a file with an episode number higher than the media's episode count
 			// we have a forced media ID, we will fetch the media from AniList and get the offset
-			azm, err := anizip.FetchAniZipMediaC("anilist", fh.ForceMediaId, fh.AnizipCache)
+			azm, err := anizip.FetchAniZipMediaC("anilist", forceMediaId, fh.AnizipCache)
 			if err != nil {
 				/*Log */
 				if fh.ScanLogger != nil {
@@ -345,7 +413,7 @@ func (fh *FileHydrator) hydrateGroupMetadata(
 				}
 				lf.Metadata.Episode = episode
 				lf.Metadata.AniDBEpisode = strconv.Itoa(episode)
-				lf.MediaId = fh.ForceMediaId
+				lf.MediaId = forceMediaId
 				fh.ScanSummaryLogger.LogMetadataEpisodeNormalizationFailed(lf, errors.New("could not fetch AniDB metadata"), lf.Metadata.Episode, lf.Metadata.AniDBEpisode)
 				return
 			}
@@ -360,7 +428,7 @@ func (fh *FileHydrator) hydrateGroupMetadata(
 				}
 				lf.Metadata.Episode = episode
 				lf.Metadata.AniDBEpisode = strconv.Itoa(episode)
-				lf.MediaId = fh.ForceMediaId
+				lf.MediaId = forceMediaId
 				fh.ScanSummaryLogger.LogMetadataEpisodeNormalizationFailed(lf, errors.New("could not find absolute episode offset"), lf.Metadata.Episode, lf.Metadata.AniDBEpisode)
 				return
 			}
@@ -392,6 +460,11 @@ func (fh *FileHydrator) hydrateGroupMetadata(
 			}
 
 			if relativeEp < 1 {
+				// Use the alternate episode number if it fits the media
+				if fh.hydrateWithAltEpisode(lf, media, forceMediaId, episode, altEpisode) {
+					return
+				}
+
 				if fh.ScanLogger != nil {
 					fh.logFileHydration(zerolog.WarnLevel, lf, mId, episode).
 						Dict("normalization", zerolog.Dict().
@@ -402,7 +475,7 @@ func (fh *FileHydrator) hydrateGroupMetadata(
 				}
 				lf.Metadata.Episode = episode
 				lf.Metadata.AniDBEpisode = strconv.Itoa(episode)
-				lf.MediaId = fh.ForceMediaId
+				lf.MediaId = forceMediaId
 				fh.ScanSummaryLogger.LogMetadataEpisodeNormalizationFailed(lf, errors.New("could not find relative episode number"), lf.Metadata.Episode, lf.Metadata.AniDBEpisode)
 				return
 			}
@@ -411,13 +484,13 @@ func (fh *FileHydrator) hydrateGroupMetadata(
 				fh.logFileHydration(zerolog.DebugLevel, lf, mId, relativeEp).
 					Dict("mediaTreeAnalysis", zerolog.Dict().
 						Bool("normalized", true).
-						Int("forcedMediaId", fh.ForceMediaId),
+						Int("forcedMediaId", forceMediaId),
 					).
 					Msg("File has been marked as main")
 			}
 			lf.Metadata.Episode = relativeEp
 			lf.Metadata.AniDBEpisode = strconv.Itoa(relativeEp)
-			lf.MediaId = fh.ForceMediaId
+			lf.MediaId = forceMediaId
 			fh.ScanSummaryLogger.LogMetadataMain(lf, lf.Metadata.Episode, lf.Metadata.AniDBEpisode)
 			return
 
@@ -427,6 +500,44 @@ func (fh *FileHydrator) hydrateGroupMetadata(
 
 }
 
+// hydrateWithFolderOverrideType sets the metadata of a file whose type is forced by a folder override.
+func (fh *FileHydrator) hydrateWithFolderOverrideType(lf *anime.LocalFile, fileType anime.LocalFileType, episode int) {
+	lf.Metadata.Type = fileType
+	switch fileType {
+	case anime.LocalFileTypeNC:
+		lf.Metadata.Episode = 0
+		lf.Metadata.AniDBEpisode = ""
+	case anime.LocalFileTypeSpecial:
+		lf.Metadata.Episode = max(episode, 1)
+		lf.Metadata.AniDBEpisode = "S" + strconv.Itoa(lf.Metadata.Episode)
+	default:
+		lf.Metadata.Episode = max(episode, 1)
+		lf.Metadata.AniDBEpisode = strconv.Itoa(lf.Metadata.Episode)
+	}
+}
+
+// hydrateWithAltEpisode sets the alternate episode number of the file if it fits the media.
+// e.g. "105 (S05E01)" is episode 1 of the media if the absolute episode number 105 could not be normalized.
+func (fh *FileHydrator) hydrateWithAltEpisode(lf *anime.LocalFile, media *anime.NormalizedMedia, mId int, episode int, altEpisode int) bool {
+	if altEpisode < 1 || altEpisode > media.GetCurrentEpisodeCount() {
+		return false
+	}
+
+	lf.MediaId = mId
+	lf.Metadata.Type = anime.LocalFileTypeMain
+	lf.Metadata.Episode = altEpisode
+	lf.Metadata.AniDBEpisode = strconv.Itoa(altEpisode)
+
+	/*Log */
+	if fh.ScanLogger != nil {
+		fh.logFileHydration(zerolog.DebugLevel, lf, mId, episode).
+			Int("altEpisode", altEpisode).
+			Msg("File has been marked as main with its alternate episode number")
+	}
+	fh.ScanSummaryLogger.LogMetadataAltEpisode(lf, episode, lf.Metadata.Episode, lf.Metadata.AniDBEpisode)
+	return true
+}
+
 func (fh *FileHydrator) logFileHydration(level zerolog.Level, lf *anime.LocalFile, mId int, episode int) *zerolog.Event {
 	return fh.ScanLogger.LogFileHydrator(level).
 		Str("filename", lf.Name).
@@ -1,6 +1,8 @@
 package scanner
 
 import (
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
 	"seanime/internal/api/anilist"
 	"seanime/internal/api/anizip"
 	"seanime/internal/library/anime"
@@ -121,3 +123,56 @@ func TestFileHydrator_HydrateMetadata(t *testing.T) {
 	}
 
 }
+
+func TestFileHydrator_hydrateWithAltEpisode(t *testing.T) {
+
+	media := anime.NewNormalizedMedia(&anilist.BaseAnime{
+		ID:       1,
+		Episodes: lo.ToPtr(12),
+	})
+
+	tests := []struct {
+		name            string
+		path            string
+		expectedOk      bool
+		expectedEpisode int
+	}{
+		{
+			name:            "absolute number with season episode number",
+			path:            "E:/Anime/Show/Show - 105 (S05E01) - Episode Title [HDTV-720p].mkv",
+			expectedOk:      true,
+			expectedEpisode: 1,
+		},
+		{
+			name:       "alternate number does not fit the media",
+			path:       "E:/Anime/Show/Show - S05E01 - 105 - Episode Title.mkv",
+			expectedOk: false,
+		},
+		{
+			name:       "no alternate number",
+			path:       "E:/Anime/Show/Show - 105 [1080p].mkv",
+			expectedOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := &FileHydrator{}
+
+			lf := anime.NewLocalFile(tt.path, "E:/Anime")
+			episode, _ := util.StringToInt(lf.ParsedData.Episode)
+			altEpisode := -1
+			if ep, ok := util.StringToInt(lf.ParsedData.EpisodeAlt); ok {
+				altEpisode = ep
+			}
+
+			ok := fh.hydrateWithAltEpisode(lf, media, media.ID, episode, altEpisode)
+			assert.Equal(t, tt.expectedOk, ok)
+			if tt.expectedOk {
+				assert.Equal(t, media.ID, lf.MediaId)
+				assert.Equal(t, anime.LocalFileTypeMain, lf.Metadata.Type)
+				assert.Equal(t, tt.expectedEpisode, lf.Metadata.Episode)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package scanner
+
+import (
+	"github.com/sourcegraph/conc/iter"
+	"seanime/internal/library/anime"
+	"time"
+)
+
+// fingerprintedFile is a media file and its current fingerprint.
+type fingerprintedFile struct {
+	*mediaFile
+	fingerprint *FileFingerprint // nil if the file could not be read
+}
+
+// getLocalFiles creates the local files that need to go through the matcher and hydrator.
+//
+// During an incremental scan, files that have not changed since the last scan, and files that were moved or renamed,
+// are returned separately as "reused" files. They keep their match and lock state.
+// Unmatched files are always matched again, as new media might have been added to the collection,
+// and so are files with a folder override or an NFO file, in case it changed.
+//
+// The fingerprint index is updated with every file found.
+func (scn *Scanner) getLocalFiles(files []*mediaFile) (localFiles []*anime.LocalFile, reusedLfs []*anime.LocalFile) {
+	prevIndex := scn.FingerprintIndex
+	incremental := scn.Incremental && prevIndex.IsValid()
+
+	// Get the fingerprints of all files
+	fFiles := iter.Mapper[*mediaFile, *fingerprintedFile]{MaxGoroutines: getConcurrency(scn.Concurrency)}.Map(files, func(f **mediaFile) *fingerprintedFile {
+		file := *f
+		withHash := scn.UsePartialHash
+		prev, found := prevIndex.Get(file.Path)
+
+		fp, err := NewFileFingerprint(file.Path, false)
+		if err != nil {
+			scn.Logger.Warn().Err(err).Str("path", file.Path).Msg("scanner: Could not get file fingerprint")
+			return &fingerprintedFile{mediaFile: file}
+		}
+
+		// Reuse the hashes of unchanged files
+		if found && fp.IsUnchanged(prev) {
+			fp.ED2K = prev.ED2K
+		}
+		if withHash && found && prev.PartialHash != "" && fp.IsUnchanged(prev) {
+			fp.PartialHash = prev.PartialHash
+			withHash = false
+		}
+		if withHash {
+			if fp.PartialHash, err = getPartialHash(file.Path, fp.Size); err != nil {
+				scn.Logger.Warn().Err(err).Str("path", file.Path).Msg("scanner: Could not hash file")
+			}
+		}
+
+		return &fingerprintedFile{mediaFile: file, fingerprint: fp}
+	})
+
+	// Build the new index
+	scn.fingerprintIndex = NewFingerprintIndex()
+	scn.fingerprintIndex.UpdatedAt = time.Now()
+	for _, file := range fFiles {
+		if file.fingerprint != nil {
+			scn.fingerprintIndex.Set(file.fingerprint)
+		}
+	}
+
+	localFiles = make([]*anime.LocalFile, 0, len(files))
+	reusedLfs = make([]*anime.LocalFile, 0)
+
+	if !incremental {
+		return scn.parseLocalFiles(files), reusedLfs
+	}
+
+	existingLfs := make(map[string]*anime.LocalFile, len(scn.ExistingLocalFiles))
+	for _, lf := range scn.ExistingLocalFiles {
+		existingLfs[normalizeFingerprintPath(lf.Path)] = lf
+	}
+
+	// Files that were in the library but are no longer at the same path, grouped by size
+	removed := make(map[int64][]*FileFingerprint)
+	for key, fp := range prevIndex.Files {
+		if _, ok := existingLfs[key]; !ok {
+			continue
+		}
+		if _, ok := scn.fingerprintIndex.Files[key]; ok {
+			continue
+		}
+		removed[fp.Size] = append(removed[fp.Size], fp)
+	}
+
+	toParse := make([]*mediaFile, 0)
+	movedCount := 0
+	for _, file := range fFiles {
+		if file.fingerprint == nil {
+			toParse = append(toParse, file.mediaFile)
+			continue
+		}
+
+		// Files with a folder override or an NFO file are matched again in case it changed
+		_, hasOverride := scn.folderOverrides.Get(file.Path)
+		if nfo, ok := scn.nfoFiles.Get(file.Path); ok && nfo.MediaId != 0 {
+			hasOverride = true
+		}
+
+		// Unchanged file
+		prev, found := prevIndex.Get(file.Path)
+		if lf, ok := existingLfs[normalizeFingerprintPath(file.Path)]; ok && found && file.fingerprint.IsUnchanged(prev) {
+			if lf.IsLocked() || lf.IsIgnored() || (lf.MediaId != 0 && !hasOverride) {
+				reusedLfs = append(reusedLfs, lf)
+				continue
+			}
+		}
+
+		// Moved or renamed file
+		if candidates, ok := removed[file.fingerprint.Size]; ok && !found {
+			if i, ok := findSameContent(candidates, file.fingerprint); ok {
+				prevLf := existingLfs[normalizeFingerprintPath(candidates[i].Path)]
+				file.fingerprint.ED2K = candidates[i].ED2K
+				removed[file.fingerprint.Size] = append(candidates[:i], candidates[i+1:]...)
+
+				lf := anime.NewLocalFile(file.Path, file.RootPath)
+				lf.MediaId = prevLf.MediaId
+				lf.Metadata = prevLf.Metadata
+				lf.Locked = prevLf.Locked
+				lf.Ignored = prevLf.Ignored
+				if lf.IsLocked() || lf.IsIgnored() || (lf.MediaId != 0 && !hasOverride) {
+					scn.Logger.Debug().Str("from", prevLf.Path).Str("to", file.Path).Msg("scanner: Detected moved file")
+					reusedLfs = append(reusedLfs, lf)
+					movedCount++
+					continue
+				}
+			}
+		}
+
+		toParse = append(toParse, file.mediaFile)
+	}
+
+	localFiles = scn.parseLocalFiles(toParse)
+
+	scn.Logger.Debug().
+		Int("new", len(localFiles)).
+		Int("reused", len(reusedLfs)).
+		Int("moved", movedCount).
+		Msg("scanner: Compared files with the fingerprint index")
+
+	if scn.ScanLogger != nil {
+		scn.ScanLogger.logger.Info().
+			Int("new", len(localFiles)).
+			Int("reused", len(reusedLfs)).
+			Int("moved", movedCount).
+			Msg("Compared files with the fingerprint index")
+	}
+
+	return localFiles, reusedLfs
+}
+
+// parseLocalFiles creates the local files in parallel, their order is the order of the media files.
+func (scn *Scanner) parseLocalFiles(files []*mediaFile) []*anime.LocalFile {
+	return iter.Mapper[*mediaFile, *anime.LocalFile]{MaxGoroutines: getConcurrency(scn.Concurrency)}.Map(files, func(file **mediaFile) *anime.LocalFile {
+		return anime.NewLocalFile((*file).Path, (*file).RootPath)
+	})
+}
+
+func findSameContent(candidates []*FileFingerprint, fp *FileFingerprint) (int, bool) {
+	for i, candidate := range candidates {
+		if candidate.IsSameContent(fp) {
+			return i, true
+		}
+	}
+	return -1, false
+}
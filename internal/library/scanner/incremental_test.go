@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"runtime"
+	"seanime/internal/database/models"
+	"seanime/internal/util"
+	"testing"
+	"time"
+)
+
+func TestScanner_getLocalFiles_Incremental(t *testing.T) {
+	for _, usePartialHash := range []bool{false, true} {
+		t.Run(map[bool]string{false: "ModTime", true: "PartialHash"}[usePartialHash], func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile := func(name string, content string) string {
+				path := filepath.Join(dir, name)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+				return path
+			}
+			getMediaFiles := func() []*mediaFile {
+				files, _, err := getMediaFilesFromRoots([]*models.LibraryRoot{{Path: dir, Enabled: true}}, util.NewLogger())
+				require.NoError(t, err)
+				return files
+			}
+
+			ep1 := writeFile("Mushishi/Mushishi - 01.mkv", "episode 1")
+			ep2 := writeFile("Mushishi/Mushishi - 02.mkv", "episode 2 (locked)")
+			ep3 := writeFile("Mushishi/Mushishi - 03.mkv", "episode 3 (unmatched)")
+
+			// First scan, every file is parsed
+			scn := &Scanner{Logger: util.NewLogger(), Incremental: true, UsePartialHash: usePartialHash}
+			lfs, reused := scn.getLocalFiles(getMediaFiles())
+			assert.Len(t, lfs, 3)
+			assert.Empty(t, reused)
+			require.Len(t, scn.GetFingerprintIndex().Files, 3)
+			if fp, ok := scn.GetFingerprintIndex().Get(ep1); assert.True(t, ok) {
+				assert.Equal(t, usePartialHash, fp.PartialHash != "")
+			}
+
+			// Simulate the matching
+			for _, lf := range lfs {
+				switch lf.Path {
+				case ep1:
+					lf.MediaId = 457
+				case ep2:
+					lf.MediaId = 457
+					lf.Locked = true
+				}
+			}
+
+			// Second scan, one file is moved and one is modified
+			renamed := filepath.Join(dir, "Mushishi", "Season 1", "[Group] Mushishi - 02.mkv")
+			require.NoError(t, os.MkdirAll(filepath.Dir(renamed), 0755))
+			require.NoError(t, os.Rename(ep2, renamed))
+			writeFile("Mushishi/Mushishi - 01.mkv", "episode 1 (v2)")
+			require.NoError(t, os.Chtimes(ep1, time.Now(), time.Now().Add(time.Hour)))
+			ep4 := writeFile("Mushishi/Mushishi - 04.mkv", "episode 4")
+
+			scn = &Scanner{
+				Logger:             util.NewLogger(),
+				Incremental:        true,
+				UsePartialHash:     usePartialHash,
+				FingerprintIndex:   scn.GetFingerprintIndex(),
+				ExistingLocalFiles: lfs,
+			}
+			lfs, reused = scn.getLocalFiles(getMediaFiles())
+
+			parsed := make([]string, 0)
+			for _, lf := range lfs {
+				parsed = append(parsed, lf.Path)
+			}
+			assert.ElementsMatch(t, []string{ep1, ep3, ep4}, parsed)
+
+			if assert.Len(t, reused, 1) {
+				assert.Equal(t, renamed, reused[0].Path)
+				assert.Equal(t, "[Group] Mushishi - 02.mkv", reused[0].Name)
+				assert.Equal(t, 457, reused[0].MediaId)
+				assert.True(t, reused[0].Locked)
+			}
+			assert.Len(t, scn.GetFingerprintIndex().Files, 4)
+
+			// Full scan, every file is parsed
+			scn = &Scanner{
+				Logger:             util.NewLogger(),
+				FingerprintIndex:   scn.GetFingerprintIndex(),
+				ExistingLocalFiles: append(lfs, reused...),
+			}
+			lfs, reused = scn.getLocalFiles(getMediaFiles())
+			assert.Len(t, lfs, 4)
+			assert.Empty(t, reused)
+		})
+	}
+}
+
+func TestFingerprintIndex_IsValid(t *testing.T) {
+	var idx *FingerprintIndex
+	assert.False(t, idx.IsValid())
+	assert.True(t, NewFingerprintIndex().IsValid())
+	assert.False(t, (&FingerprintIndex{Version: fingerprintIndexVersion + 1, Files: map[string]*FileFingerprint{}}).IsValid())
+
+	_, found := idx.Get("/anime/file.mkv")
+	assert.False(t, found)
+}
+
+func TestFingerprintIndex_PathCase(t *testing.T) {
+	idx := NewFingerprintIndex()
+	idx.Set(&FileFingerprint{Path: "/anime/Frieren/01.mkv", Size: 1})
+	idx.Set(&FileFingerprint{Path: "/anime/frieren/01.mkv", Size: 2})
+
+	fp, found := idx.Get("/anime/Frieren/01.mkv")
+	require.True(t, found)
+
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		// Both paths are the same file
+		assert.Len(t, idx.Files, 1)
+		assert.Equal(t, int64(2), fp.Size)
+	default:
+		assert.Len(t, idx.Files, 2)
+		assert.Equal(t, int64(1), fp.Size)
+	}
+}
@@ -2,9 +2,13 @@ package scanner
 
 import (
 	"github.com/rs/zerolog"
+	"github.com/samber/lo"
 	lop "github.com/samber/lo/parallel"
+	"path/filepath"
+	"seanime/internal/database/models"
 	"seanime/internal/library/anime"
 	"seanime/internal/library/filesystem"
+	"strings"
 )
 
 // GetLocalFilesFromDir creates a new LocalFile for each video file
@@ -26,3 +30,58 @@ func GetLocalFilesFromDir(dirPath string, logger *zerolog.Logger) ([]*anime.Loca
 
 	return localFiles, err
 }
+
+// GetLocalFilesFromRoots creates a new LocalFile for each video file in the library roots.
+// Roots that could not be read are returned alongside the files, an error is returned only if no root could be read.
+func GetLocalFilesFromRoots(roots []*models.LibraryRoot, logger *zerolog.Logger) (lfs []*anime.LocalFile, unreachable []*models.LibraryRoot, err error) {
+	files, unreachable, err := getMediaFilesFromRoots(roots, logger)
+	if err != nil {
+		return nil, unreachable, err
+	}
+
+	lfs = lop.Map(files, func(file *mediaFile, _ int) *anime.LocalFile {
+		return anime.NewLocalFile(file.Path, file.RootPath)
+	})
+
+	return lfs, unreachable, nil
+}
+
+// mediaFile is a video file found in a library root.
+type mediaFile struct {
+	Path     string
+	RootPath string
+}
+
+// getMediaFilesFromRoots returns the video files in the library roots without parsing them.
+func getMediaFilesFromRoots(roots []*models.LibraryRoot, logger *zerolog.Logger) (files []*mediaFile, unreachable []*models.LibraryRoot, err error) {
+	files = make([]*mediaFile, 0)
+	unreachable = make([]*models.LibraryRoot, 0)
+
+	for _, root := range roots {
+		paths, rootErr := filesystem.GetMediaFilePathsFromDirS(root.Path)
+		if rootErr != nil {
+			logger.Warn().Err(rootErr).Str("path", root.Path).Msg("localfile: Could not read library root")
+			unreachable = append(unreachable, root)
+			err = rootErr
+			continue
+		}
+		for _, path := range paths {
+			files = append(files, &mediaFile{Path: path, RootPath: root.Path})
+		}
+	}
+
+	if len(roots) > 0 && len(unreachable) == len(roots) {
+		return nil, unreachable, err
+	}
+
+	// Remove duplicates, in case a root is inside another root
+	files = lo.UniqBy(files, func(file *mediaFile) string {
+		return strings.ToLower(filepath.ToSlash(file.Path))
+	})
+
+	logger.Trace().
+		Any("count", len(files)).
+		Msg("localfile: Retrieved media files from library roots")
+
+	return files, unreachable, nil
+}
@@ -2,6 +2,11 @@ package scanner
 
 import (
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"seanime/internal/database/models"
+	"seanime/internal/library/anime"
 	"seanime/internal/util"
 	"testing"
 )
@@ -19,3 +24,51 @@ func TestGetLocalFilesFromDir(t *testing.T) {
 		t.Logf("Found %d local files", len(localFiles))
 	}
 }
+
+func TestGetLocalFilesFromRoots(t *testing.T) {
+	logger := util.NewLogger()
+
+	diskA := t.TempDir()
+	diskB := t.TempDir()
+	missing := filepath.Join(t.TempDir(), "disconnected")
+
+	for _, path := range []string{
+		filepath.Join(diskA, "Sousou no Frieren", "[SubsPlease] Sousou no Frieren - 01 (1080p).mkv"),
+		filepath.Join(diskA, "Sousou no Frieren", "[SubsPlease] Sousou no Frieren - 02 (1080p).mkv"),
+		filepath.Join(diskB, "Movies", "Kimi no Na wa (2016).mkv"),
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte{}, 0644))
+	}
+
+	roots := []*models.LibraryRoot{
+		{Path: diskA, Enabled: true},
+		{Path: diskB, Enabled: true, TreatAsMovies: true},
+		{Path: missing, Enabled: true},
+		{Path: filepath.Join(diskA, "Sousou no Frieren"), Enabled: true}, // Inside another root
+	}
+
+	lfs, unreachable, err := GetLocalFilesFromRoots(roots, logger)
+	require.NoError(t, err)
+	assert.Len(t, lfs, 3)
+	if assert.Len(t, unreachable, 1) {
+		assert.Equal(t, missing, unreachable[0].Path)
+	}
+
+	// Files of unreachable roots are kept
+	scn := &Scanner{
+		Roots: roots,
+		ExistingLocalFiles: []*anime.LocalFile{
+			anime.NewLocalFile(filepath.Join(missing, "Mushishi", "Mushishi - 01.mkv"), missing),
+			anime.NewLocalFile(filepath.Join(diskA, "Deleted - 01.mkv"), diskA),
+		},
+	}
+	kept := scn.getExistingLocalFilesInRoots(unreachable)
+	if assert.Len(t, kept, 1) {
+		assert.Equal(t, "Mushishi - 01.mkv", kept[0].Name)
+	}
+
+	// No root can be read
+	_, _, err = GetLocalFilesFromRoots([]*models.LibraryRoot{{Path: missing, Enabled: true}}, logger)
+	assert.Error(t, err)
+}
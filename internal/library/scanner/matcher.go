@@ -1,18 +1,24 @@
 package scanner
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/rs/zerolog"
 	"github.com/samber/lo"
 	lop "github.com/samber/lo/parallel"
+	"github.com/sourcegraph/conc/iter"
 	"github.com/sourcegraph/conc/pool"
 	"math"
 	"seanime/internal/api/anilist"
+	"seanime/internal/extension"
 	"seanime/internal/library/anime"
 	"seanime/internal/library/summary"
 	"seanime/internal/util"
 	"seanime/internal/util/comparison"
+	"seanime/internal/util/result"
+	"strconv"
 	"time"
 )
 
@@ -23,12 +29,23 @@ type Matcher struct {
 	Logger             *zerolog.Logger
 	ScanLogger         *ScanLogger
 	ScanSummaryLogger  *summary.ScanSummaryLogger // optional
+	FolderOverrides    *FolderOverrides           // optional
+	NfoFiles           *NfoFiles                  // optional
+	// LibraryMatchers are called, in order, for files that could not be confidently matched by title comparison
+	LibraryMatchers []extension.LibraryMatcherExtension // optional
+	Concurrency     int                                 // optional - maximum number of files matched in parallel, defaults to the number of CPUs
+	Context         context.Context                     // optional - the remaining files are not matched when it is cancelled
+	// Paths of the files matched by a library matcher or an NFO file, they are not validated
+	trustedMatches *result.Map[string, struct{}]
 }
 
 var (
 	ErrNoLocalFiles = errors.New("[matcher] no local files")
 )
 
+// LibraryMatcherMinConfidence is the minimum confidence a library matcher result needs to be accepted.
+const LibraryMatcherMinConfidence = 0.5
+
 // MatchLocalFilesWithMedia will match each anime.LocalFile with a specific anilist.BaseAnime and modify the LocalFile's `mediaId`
 func (m *Matcher) MatchLocalFilesWithMedia() error {
 
@@ -49,10 +66,23 @@ func (m *Matcher) MatchLocalFilesWithMedia() error {
 
 	m.Logger.Debug().Msg("matcher: Starting matching process")
 
+	m.trustedMatches = result.NewResultMap[string, struct{}]()
+
+	ctx := m.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	// Parallelize the matching process
-	lop.ForEach(m.LocalFiles, func(localFile *anime.LocalFile, _ int) {
-		m.matchLocalFileWithMedia(localFile)
+	iter.Iterator[*anime.LocalFile]{MaxGoroutines: getConcurrency(m.Concurrency)}.ForEach(m.LocalFiles, func(localFile **anime.LocalFile) {
+		if ctx.Err() != nil {
+			return
+		}
+		m.matchLocalFileWithMedia(*localFile)
 	})
+	if err := checkCancelled(ctx); err != nil {
+		return err
+	}
 
 	m.validateMatches()
 
@@ -95,6 +125,38 @@ func (m *Matcher) matchLocalFileWithMedia(lf *anime.LocalFile) {
 		m.ScanSummaryLogger.LogFileNotMatched(lf, "Already matched")
 		return
 	}
+	// Check if the folder pins the media
+	if override, ok := m.FolderOverrides.Get(lf.Path); ok && override.MediaId != 0 {
+		if m.ScanLogger != nil {
+			m.ScanLogger.LogMatcher(zerolog.DebugLevel).
+				Str("filename", lf.Name).
+				Int("mediaId", override.MediaId).
+				Msg("File matched by folder override")
+		}
+		m.ScanSummaryLogger.LogFolderOverride(lf, fmt.Sprintf("matched to media %d", override.MediaId))
+		lf.MediaId = override.MediaId
+		return
+	}
+	// Check if an NFO file identifies the media
+	if nfo, ok := m.NfoFiles.Get(lf.Path); ok && nfo.MediaId != 0 {
+		if m.ScanLogger != nil {
+			m.ScanLogger.LogMatcher(zerolog.DebugLevel).
+				Str("filename", lf.Name).
+				Int("mediaId", nfo.MediaId).
+				Msg("File matched by NFO file")
+		}
+		lf.MediaId = nfo.MediaId
+		// Replace the parsed episode so that the hydrator uses it
+		if episode, ok := nfo.GetEpisode(); ok && lf.ParsedData != nil {
+			lf.ParsedData.Episode = strconv.Itoa(episode)
+			lf.ParsedData.EpisodeRange = nil
+			m.ScanSummaryLogger.LogNfo(lf, fmt.Sprintf("matched to media %d, episode %d", nfo.MediaId, episode))
+		} else {
+			m.ScanSummaryLogger.LogNfo(lf, fmt.Sprintf("matched to media %d", nfo.MediaId))
+		}
+		m.trustedMatches.Set(lf.Path, struct{}{})
+		return
+	}
 	// Check if the local file has a title
 	if lf.GetParsedTitle() == "" {
 		if m.ScanLogger != nil {
@@ -297,6 +359,7 @@ func (m *Matcher) matchLocalFileWithMedia(lf *anime.LocalFile) {
 				Msg("No media found from comparison result")
 		}
 		m.ScanSummaryLogger.LogFileNotMatched(lf, "No media found from comparison result")
+		m.matchWithExtensions(lf)
 		return
 	}
 
@@ -315,6 +378,7 @@ func (m *Matcher) matchLocalFileWithMedia(lf *anime.LocalFile) {
 				Msg("Best title rating too low, un-matching file")
 		}
 		m.ScanSummaryLogger.LogFailedMatch(lf, "Rating too low")
+		m.matchWithExtensions(lf)
 		return
 	}
 
@@ -331,6 +395,74 @@ func (m *Matcher) matchLocalFileWithMedia(lf *anime.LocalFile) {
 
 }
 
+// matchWithExtensions asks the library matchers to match a file that could not be confidently matched.
+// The first result that is confident enough and points to a known media is used.
+func (m *Matcher) matchWithExtensions(lf *anime.LocalFile) {
+	if len(m.LibraryMatchers) == 0 {
+		return
+	}
+
+	opts := &extension.LibraryMatchOptions{
+		Path:             lf.Path,
+		ParsedData:       lf.ParsedData,
+		ParsedFolderData: lf.ParsedFolderData,
+		Candidates:       m.MediaContainer.NormalizedMedia,
+	}
+
+	for _, ext := range m.LibraryMatchers {
+		res, err := ext.GetMatcher().Match(opts)
+		if err != nil {
+			m.Logger.Warn().Err(err).Str("id", ext.GetID()).Str("filename", lf.Name).Msg("matcher: Library matcher failed")
+			continue
+		}
+		if res == nil || res.MediaId == 0 {
+			continue
+		}
+
+		if res.Confidence < LibraryMatcherMinConfidence {
+			if m.ScanLogger != nil {
+				m.ScanLogger.LogMatcher(zerolog.DebugLevel).
+					Str("filename", lf.Name).
+					Str("extension", ext.GetID()).
+					Any("confidence", res.Confidence).
+					Msg("Library matcher confidence too low")
+			}
+			continue
+		}
+
+		if _, found := m.MediaContainer.GetMediaFromId(res.MediaId); !found {
+			if m.ScanLogger != nil {
+				m.ScanLogger.LogMatcher(zerolog.WarnLevel).
+					Str("filename", lf.Name).
+					Str("extension", ext.GetID()).
+					Int("mediaId", res.MediaId).
+					Msg("Library matcher returned an unknown media")
+			}
+			continue
+		}
+
+		if m.ScanLogger != nil {
+			m.ScanLogger.LogMatcher(zerolog.DebugLevel).
+				Str("filename", lf.Name).
+				Str("extension", ext.GetID()).
+				Int("mediaId", res.MediaId).
+				Int("episode", res.Episode).
+				Any("confidence", res.Confidence).
+				Msg("File matched by library matcher")
+		}
+		m.ScanSummaryLogger.LogExtensionMatch(lf, ext.GetID(), res.MediaId, res.Confidence)
+
+		lf.MediaId = res.MediaId
+		// Replace the parsed episode so that the hydrator uses it
+		if res.Episode > 0 && lf.ParsedData != nil {
+			lf.ParsedData.Episode = strconv.Itoa(res.Episode)
+			lf.ParsedData.EpisodeRange = nil
+		}
+		m.trustedMatches.Set(lf.Path, struct{}{})
+		return
+	}
+}
+
 //----------------------------------------------------------------------------------------------------------------------
 
 // validateMatches compares groups of local files' titles with the media titles and un-matches the local files that have a lower rating than the highest rating.
@@ -341,7 +473,14 @@ func (m *Matcher) validateMatches() {
 	}
 
 	// Group local files by media ID
-	groups := lop.GroupBy(m.LocalFiles, func(localFile *anime.LocalFile) int {
+	// Files matched by a folder override, an NFO file or a library matcher are not validated
+	groups := lop.GroupBy(lo.Filter(m.LocalFiles, func(lf *anime.LocalFile, _ int) bool {
+		if m.trustedMatches != nil && m.trustedMatches.Has(lf.Path) {
+			return false
+		}
+		override, ok := m.FolderOverrides.Get(lf.Path)
+		return !ok || override.MediaId == 0
+	}), func(localFile *anime.LocalFile) int {
 		return localFile.MediaId
 	})
 
@@ -349,7 +488,7 @@ func (m *Matcher) validateMatches() {
 	delete(groups, 0)
 
 	// Un-match files with lower ratings
-	p := pool.New()
+	p := pool.New().WithMaxGoroutines(getConcurrency(m.Concurrency))
 	for mId, files := range groups {
 		p.Go(func() {
 			if len(files) > 0 {
@@ -2,10 +2,16 @@ package scanner
 
 import (
 	"context"
+	"fmt"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"seanime/internal/api/anilist"
+	"seanime/internal/extension"
 	"seanime/internal/library/anime"
+	"seanime/internal/library/summary"
 	"seanime/internal/util"
+	"strings"
 	"testing"
 )
 
@@ -96,3 +102,124 @@ func TestMatcher_MatchLocalFileWithMedia(t *testing.T) {
 	}
 
 }
+
+type fakeLibraryMatcher struct {
+	results map[string]*extension.LibraryMatchResult // Indexed by parsed title
+}
+
+func (f *fakeLibraryMatcher) Match(opts *extension.LibraryMatchOptions) (*extension.LibraryMatchResult, error) {
+	return f.results[strings.ToLower(opts.ParsedData.Title)], nil
+}
+
+func TestMatcher_LibraryMatchers(t *testing.T) {
+	allMedia := []*anilist.CompleteAnime{{
+		ID:       130003,
+		Format:   lo.ToPtr(anilist.MediaFormatTv),
+		Episodes: lo.ToPtr(12),
+		Status:   lo.ToPtr(anilist.MediaStatusFinished),
+		Title:    &anilist.CompleteAnime_Title{Romaji: lo.ToPtr("Bocchi the Rock!"), English: lo.ToPtr("Bocchi the Rock!")},
+	}}
+	mc := NewMediaContainer(&MediaContainerOptions{AllMedia: allMedia})
+
+	lfs := []*anime.LocalFile{
+		anime.NewLocalFile("/mnt/anime/BTR - 05.mkv", "/mnt/anime"),
+		anime.NewLocalFile("/mnt/anime/Kessoku Band - 02.mkv", "/mnt/anime"),
+		anime.NewLocalFile("/mnt/anime/Frieren - 01.mkv", "/mnt/anime"),
+	}
+
+	matcher := &Matcher{
+		LocalFiles:         lfs,
+		MediaContainer:     mc,
+		CompleteAnimeCache: anilist.NewCompleteAnimeCache(),
+		Logger:             util.NewLogger(),
+		LibraryMatchers: []extension.LibraryMatcherExtension{
+			extension.NewLibraryMatcherExtension(&extension.Extension{ID: "fake"}, &fakeLibraryMatcher{
+				results: map[string]*extension.LibraryMatchResult{
+					"btr":          {MediaId: 130003, Episode: 6, Confidence: 0.9},
+					"kessoku band": {MediaId: 130003, Confidence: 0.2}, // Not confident enough
+					"frieren":      {MediaId: 154587, Confidence: 1},   // Not a candidate
+				},
+			}),
+		},
+	}
+	require.NoError(t, matcher.MatchLocalFilesWithMedia())
+
+	assert.Equal(t, 130003, lfs[0].MediaId)
+	assert.Equal(t, "6", lfs[0].ParsedData.Episode)
+	assert.Equal(t, 0, lfs[1].MediaId)
+	assert.Equal(t, 0, lfs[2].MediaId)
+}
+
+func TestMatcher_Concurrency(t *testing.T) {
+	newMedia := func(id int, title string) *anilist.CompleteAnime {
+		return &anilist.CompleteAnime{
+			ID:       id,
+			Format:   lo.ToPtr(anilist.MediaFormatTv),
+			Episodes: lo.ToPtr(12),
+			Status:   lo.ToPtr(anilist.MediaStatusFinished),
+			Title:    &anilist.CompleteAnime_Title{Romaji: lo.ToPtr(title), English: lo.ToPtr(title)},
+		}
+	}
+	allMedia := []*anilist.CompleteAnime{
+		newMedia(130003, "Bocchi the Rock!"),
+		newMedia(154587, "Frieren: Beyond Journey's End"),
+		newMedia(457, "Mushishi"),
+	}
+
+	titles := []string{"Bocchi the Rock", "Frieren", "Mushishi", "Made in Abyss"}
+	match := func(concurrency int) []*anime.LocalFile {
+		lfs := make([]*anime.LocalFile, 0)
+		for _, title := range titles {
+			for ep := 1; ep <= 12; ep++ {
+				lfs = append(lfs, anime.NewLocalFile(fmt.Sprintf("/mnt/anime/%s/%s - %02d.mkv", title, title, ep), "/mnt/anime"))
+			}
+		}
+		matcher := &Matcher{
+			LocalFiles:         lfs,
+			MediaContainer:     NewMediaContainer(&MediaContainerOptions{AllMedia: allMedia}),
+			CompleteAnimeCache: anilist.NewCompleteAnimeCache(),
+			Logger:             util.NewLogger(),
+			ScanSummaryLogger:  summary.NewScanSummaryLogger(),
+			Concurrency:        concurrency,
+		}
+		require.NoError(t, matcher.MatchLocalFilesWithMedia())
+		return lfs
+	}
+
+	// The result does not depend on the number of workers
+	expected := match(1)
+	for _, concurrency := range []int{2, 16} {
+		lfs := match(concurrency)
+		require.Len(t, lfs, len(expected))
+		for i, lf := range lfs {
+			assert.Equal(t, expected[i].Path, lf.Path)
+			assert.Equal(t, expected[i].MediaId, lf.MediaId, lf.Path)
+		}
+	}
+	assert.Equal(t, 130003, expected[0].MediaId)
+	assert.Equal(t, 0, expected[len(expected)-1].MediaId)
+}
+
+func TestMatcher_Cancelled(t *testing.T) {
+	allMedia := []*anilist.CompleteAnime{{
+		ID:       130003,
+		Format:   lo.ToPtr(anilist.MediaFormatTv),
+		Episodes: lo.ToPtr(12),
+		Status:   lo.ToPtr(anilist.MediaStatusFinished),
+		Title:    &anilist.CompleteAnime_Title{Romaji: lo.ToPtr("Bocchi the Rock!"), English: lo.ToPtr("Bocchi the Rock!")},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	lfs := []*anime.LocalFile{anime.NewLocalFile("/mnt/anime/Bocchi the Rock - 01.mkv", "/mnt/anime")}
+	matcher := &Matcher{
+		LocalFiles:         lfs,
+		MediaContainer:     NewMediaContainer(&MediaContainerOptions{AllMedia: allMedia}),
+		CompleteAnimeCache: anilist.NewCompleteAnimeCache(),
+		Logger:             util.NewLogger(),
+		Context:            ctx,
+	}
+	require.ErrorIs(t, matcher.MatchLocalFilesWithMedia(), ErrScanCancelled)
+	assert.Equal(t, 0, lfs[0].MediaId)
+}
@@ -1,11 +1,13 @@
 package scanner
 
 import (
+	"context"
 	"errors"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/rs/zerolog"
 	"github.com/samber/lo"
 	lop "github.com/samber/lo/parallel"
+	"github.com/sourcegraph/conc/iter"
 	"seanime/internal/api/anilist"
 	"seanime/internal/api/anizip"
 	"seanime/internal/api/mal"
@@ -14,6 +16,7 @@ import (
 	"seanime/internal/util"
 	"seanime/internal/util/limiter"
 	"seanime/internal/util/parallel"
+	"slices"
 	"time"
 )
 
@@ -36,6 +39,8 @@ type MediaFetcherOptions struct {
 	AnilistRateLimiter     *limiter.Limiter
 	DisableAnimeCollection bool
 	ScanLogger             *ScanLogger
+	ExtraMediaIds          []int // Media that should be fetched even if they are not in the collection, e.g. media pinned by folder overrides
+	Concurrency            int   // Maximum number of media fetched in parallel, defaults to the number of CPUs
 }
 
 // NewMediaFetcher
@@ -125,6 +130,7 @@ func NewMediaFetcher(opts *MediaFetcherOptions) (ret *MediaFetcher, retErr error
 			opts.AnizipCache,
 			opts.AnilistRateLimiter,
 			mf.ScanLogger,
+			opts.Concurrency,
 		)
 		if ok {
 			// We assume the CompleteAnimeCache is populated. We overwrite AllMedia with the cache content.
@@ -134,6 +140,34 @@ func NewMediaFetcher(opts *MediaFetcherOptions) (ret *MediaFetcher, retErr error
 				mf.AllMedia = append(mf.AllMedia, value)
 				return true
 			})
+			// Sort the media so that the matcher always gets them in the same order
+			sortMediaById(mf.AllMedia)
+		}
+	}
+
+	// +---------------------+
+	// |    Extra media      |
+	// +---------------------+
+
+	// Media that are not cached are fetched in parallel, they are added in the order of the IDs
+	extraMedia := iter.Mapper[int, *anilist.CompleteAnime]{MaxGoroutines: getConcurrency(opts.Concurrency)}.Map(lo.Uniq(opts.ExtraMediaIds), func(id *int) *anilist.CompleteAnime {
+		mId := *id
+		if media, found := opts.CompleteAnimeCache.Get(mId); found {
+			return media
+		}
+
+		opts.AnilistRateLimiter.Wait()
+		res, err := opts.Platform.GetAnilistClient().CompleteAnimeByID(context.Background(), &mId)
+		if err != nil || res.GetMedia() == nil {
+			opts.Logger.Warn().Err(err).Int("mediaId", mId).Msg("media fetcher: Could not fetch media")
+			return nil
+		}
+		opts.CompleteAnimeCache.Set(mId, res.GetMedia())
+		return res.GetMedia()
+	})
+	for _, media := range extraMedia {
+		if media != nil && !lo.ContainsBy(mf.AllMedia, func(m *anilist.CompleteAnime) bool { return m.ID == media.ID }) {
+			mf.AllMedia = append(mf.AllMedia, media)
 		}
 	}
 
@@ -177,6 +211,7 @@ func FetchMediaFromLocalFiles(
 	anizipCache *anizip.Cache,
 	anilistRateLimiter *limiter.Limiter,
 	scanLogger *ScanLogger,
+	concurrency int, // Maximum number of requests made in parallel, defaults to the number of CPUs
 ) ([]*anilist.CompleteAnime, bool) {
 
 	if scanLogger != nil {
@@ -233,10 +268,10 @@ func FetchMediaFromLocalFiles(
 
 	// Get AniZip mappings for each MAL ID and store them in `anizipCache`
 	// This step is necessary because MAL doesn't provide AniList IDs and some MAL media don't exist on AniList
-	lop.ForEach(malIds, func(id int, index int) {
+	iter.Iterator[int]{MaxGoroutines: getConcurrency(concurrency)}.ForEach(malIds, func(id *int) {
 		rateLimiter2.Wait()
-		_, _ = anizipCache.GetOrSet(anizip.GetCacheKey("mal", id), func() (*anizip.Media, error) {
-			res, err := anizip.FetchAniZipMedia("mal", id)
+		_, _ = anizipCache.GetOrSet(anizip.GetCacheKey("mal", *id), func() (*anizip.Media, error) {
+			res, err := anizip.FetchAniZipMedia("mal", *id)
 			return res, err
 		})
 	})
@@ -253,14 +288,14 @@ func FetchMediaFromLocalFiles(
 		}
 		return true
 	})
+	slices.Sort(anilistIds)
 
 	// Fetch all media from the AniList IDs
-	anilistMedia := make([]*anilist.CompleteAnime, 0)
-	lop.ForEach(anilistIds, func(id int, index int) {
+	// Media that could not be fetched are nil
+	anilistResults := iter.Mapper[int, *anilist.CompleteAnime]{MaxGoroutines: getConcurrency(concurrency)}.Map(anilistIds, func(id *int) *anilist.CompleteAnime {
 		anilistRateLimiter.Wait()
-		media, err := platform.GetAnimeWithRelations(id)
+		media, err := platform.GetAnimeWithRelations(*id)
 		if err == nil {
-			anilistMedia = append(anilistMedia, media)
 			if scanLogger != nil {
 				scanLogger.LogMediaFetcher(zerolog.DebugLevel).
 					Str("module", "Enhanced").
@@ -271,10 +306,14 @@ func FetchMediaFromLocalFiles(
 			if scanLogger != nil {
 				scanLogger.LogMediaFetcher(zerolog.WarnLevel).
 					Str("module", "Enhanced").
-					Int("id", id).
+					Int("id", *id).
 					Msg("Failed to fetch Anilist media from MAL id")
 			}
 		}
+		return media
+	})
+	anilistMedia := lo.Filter(anilistResults, func(media *anilist.CompleteAnime, _ int) bool {
+		return media != nil
 	})
 
 	if scanLogger != nil {
@@ -297,9 +336,9 @@ func FetchMediaFromLocalFiles(
 	start := time.Now()
 	// For each media, fetch its relations
 	// The relations are fetched in parallel and added to `completeAnime`
-	lop.ForEach(anilistMedia, func(m *anilist.CompleteAnime, index int) {
+	iter.Iterator[*anilist.CompleteAnime]{MaxGoroutines: getConcurrency(concurrency)}.ForEach(anilistMedia, func(m **anilist.CompleteAnime) {
 		// We ignore errors because we want to continue even if one of the media fails
-		_ = m.FetchMediaTree(anilist.FetchMediaTreeAll, platform.GetAnilistClient(), anilistRateLimiter, tree, completeAnime)
+		_ = (*m).FetchMediaTree(anilist.FetchMediaTreeAll, platform.GetAnilistClient(), anilistRateLimiter, tree, completeAnime)
 	})
 
 	// +---------------------+
@@ -312,6 +351,7 @@ func FetchMediaFromLocalFiles(
 		scanned = append(scanned, value)
 		return true
 	})
+	sortMediaById(scanned)
 
 	if scanLogger != nil {
 		scanLogger.LogMediaFetcher(zerolog.InfoLevel).
@@ -326,3 +366,9 @@ func FetchMediaFromLocalFiles(
 
 	return scanned, true
 }
+
+func sortMediaById(media []*anilist.CompleteAnime) {
+	slices.SortFunc(media, func(a, b *anilist.CompleteAnime) int {
+		return a.ID - b.ID
+	})
+}
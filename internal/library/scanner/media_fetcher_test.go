@@ -243,6 +243,7 @@ func TestFetchMediaFromLocalFiles(t *testing.T) {
 				anizipCache,
 				anilistRateLimiter,
 				scanLogger,
+				0,
 			)
 			if !ok {
 				t.Fatal("could not fetch media from local files")
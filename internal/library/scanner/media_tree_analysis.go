@@ -4,10 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"github.com/samber/lo"
-	"github.com/sourcegraph/conc/pool"
+	"github.com/sourcegraph/conc/iter"
 	"seanime/internal/api/anilist"
 	"seanime/internal/api/anizip"
 	"seanime/internal/util/limiter"
+	"slices"
 )
 
 type (
@@ -15,6 +16,7 @@ type (
 		tree        *anilist.CompleteAnimeRelationTree
 		anizipCache *anizip.Cache
 		rateLimiter *limiter.Limiter
+		concurrency int // Maximum number of branches fetched in parallel, defaults to the number of CPUs
 	}
 
 	MediaTreeAnalysis struct {
@@ -45,64 +47,73 @@ func NewMediaTreeAnalysis(opts *MediaTreeAnalysisOptions) (*MediaTreeAnalysis, e
 		relations = append(relations, value)
 		return true
 	})
+	// Sort the relations so that the branches are always in the same order
+	slices.SortFunc(relations, func(a, b *anilist.CompleteAnime) int {
+		return a.ID - b.ID
+	})
 
 	// Get Anizip data for all related media in the tree
 	// With each Anizip media, get the min and max absolute episode number
 	// Create new MediaTreeAnalysisBranch for each Anizip media
-	p := pool.NewWithResults[*MediaTreeAnalysisBranch]().WithErrors()
-	for _, rel := range relations {
-		p.Go(func() (*MediaTreeAnalysisBranch, error) {
-			opts.rateLimiter.Wait()
-			azm, err := anizip.FetchAniZipMediaC("anilist", rel.ID, opts.anizipCache)
-			if err != nil {
-				return nil, err
-			}
-			// Get the first episode
-			firstEp, ok := azm.Episodes["1"]
-			if !ok {
-				return nil, errors.New("no first episode")
-			}
+	// Branches that could not be analyzed are nil
+	results := iter.Mapper[*anilist.CompleteAnime, *MediaTreeAnalysisBranch]{MaxGoroutines: getConcurrency(opts.concurrency)}.Map(relations, func(r **anilist.CompleteAnime) *MediaTreeAnalysisBranch {
+		branch, _ := analyzeMediaTreeBranch(*r, opts)
+		return branch
+	})
+	branches := lo.Filter(results, func(branch *MediaTreeAnalysisBranch, _ int) bool {
+		return branch != nil
+	})
 
-			// discrepancy: "seasonNumber":1,"episodeNumber":12,"absoluteEpisodeNumber":13,
-			// this happens when the media has a separate entry but is technically the same season
-			// when we detect this, we should use the "episodeNumber" as the absoluteEpisodeNumber
-			// this is a hacky fix, but it works for the cases I've seen so far
-			usePartEpisodeNumber := firstEp.EpisodeNumber > 1 && firstEp.AbsoluteEpisodeNumber-firstEp.EpisodeNumber > 1
-			partAbsoluteEpisodeNumber := 0
-			maxPartAbsoluteEpisodeNumber := 0
-			if usePartEpisodeNumber {
-				partAbsoluteEpisodeNumber = firstEp.EpisodeNumber
-				maxPartAbsoluteEpisodeNumber = partAbsoluteEpisodeNumber + azm.GetMainEpisodeCount() - 1
-			}
+	if len(branches) == 0 {
+		return nil, errors.New("no branches found")
+	}
 
-			// If the first episode exists and has a valid absolute episode number, create a new MediaTreeAnalysisBranch
-			if azm.Episodes != nil && firstEp.AbsoluteEpisodeNumber > 0 {
-				return &MediaTreeAnalysisBranch{
-					media:                        rel,
-					anizipMedia:                  azm,
-					minPartAbsoluteEpisodeNumber: partAbsoluteEpisodeNumber,
-					maxPartAbsoluteEpisodeNumber: maxPartAbsoluteEpisodeNumber,
-					minAbsoluteEpisode:           firstEp.AbsoluteEpisodeNumber,
-					// The max absolute episode number is the first episode's absolute episode number plus the total episode count minus 1
-					// We subtract 1 because the first episode's absolute episode number is already included in the total episode count
-					// e.g, if the first episode's absolute episode number is 13 and the total episode count is 12, the max absolute episode number is 24
-					maxAbsoluteEpisode: firstEp.AbsoluteEpisodeNumber + (azm.GetMainEpisodeCount() - 1),
-					totalEpisodeCount:  azm.GetMainEpisodeCount(),
-				}, nil
-			}
+	return &MediaTreeAnalysis{branches: branches}, nil
 
-			return nil, errors.New("could not analyze media tree branch")
+}
 
-		})
+// analyzeMediaTreeBranch fetches the AniZip media of the related media and creates its MediaTreeAnalysisBranch.
+func analyzeMediaTreeBranch(rel *anilist.CompleteAnime, opts *MediaTreeAnalysisOptions) (*MediaTreeAnalysisBranch, error) {
+	opts.rateLimiter.Wait()
+	azm, err := anizip.FetchAniZipMediaC("anilist", rel.ID, opts.anizipCache)
+	if err != nil {
+		return nil, err
+	}
+	// Get the first episode
+	firstEp, ok := azm.Episodes["1"]
+	if !ok {
+		return nil, errors.New("no first episode")
 	}
-	branches, _ := p.Wait()
 
-	if branches == nil || len(branches) == 0 {
-		return nil, errors.New("no branches found")
+	// discrepancy: "seasonNumber":1,"episodeNumber":12,"absoluteEpisodeNumber":13,
+	// this happens when the media has a separate entry but is technically the same season
+	// when we detect this, we should use the "episodeNumber" as the absoluteEpisodeNumber
+	// this is a hacky fix, but it works for the cases I've seen so far
+	usePartEpisodeNumber := firstEp.EpisodeNumber > 1 && firstEp.AbsoluteEpisodeNumber-firstEp.EpisodeNumber > 1
+	partAbsoluteEpisodeNumber := 0
+	maxPartAbsoluteEpisodeNumber := 0
+	if usePartEpisodeNumber {
+		partAbsoluteEpisodeNumber = firstEp.EpisodeNumber
+		maxPartAbsoluteEpisodeNumber = partAbsoluteEpisodeNumber + azm.GetMainEpisodeCount() - 1
 	}
 
-	return &MediaTreeAnalysis{branches: branches}, nil
+	// If the first episode exists and has a valid absolute episode number, create a new MediaTreeAnalysisBranch
+	if azm.Episodes != nil && firstEp.AbsoluteEpisodeNumber > 0 {
+		return &MediaTreeAnalysisBranch{
+			media:                        rel,
+			anizipMedia:                  azm,
+			minPartAbsoluteEpisodeNumber: partAbsoluteEpisodeNumber,
+			maxPartAbsoluteEpisodeNumber: maxPartAbsoluteEpisodeNumber,
+			minAbsoluteEpisode:           firstEp.AbsoluteEpisodeNumber,
+			// The max absolute episode number is the first episode's absolute episode number plus the total episode count minus 1
+			// We subtract 1 because the first episode's absolute episode number is already included in the total episode count
+			// e.g, if the first episode's absolute episode number is 13 and the total episode count is 12, the max absolute episode number is 24
+			maxAbsoluteEpisode: firstEp.AbsoluteEpisodeNumber + (azm.GetMainEpisodeCount() - 1),
+			totalEpisodeCount:  azm.GetMainEpisodeCount(),
+		}, nil
+	}
 
+	return nil, errors.New("could not analyze media tree branch")
 }
 
 // getRelativeEpisodeNumber uses the MediaTreeAnalysis to get the relative episode number for an absolute episode number
@@ -0,0 +1,269 @@
+package scanner
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"os"
+	"path/filepath"
+	"seanime/internal/api/mappings"
+	"strconv"
+	"strings"
+)
+
+// NfoShowFilename is the name of the NFO file describing a series, written by Kodi, Jellyfin, Emby or Plex agents.
+// Episode NFO files have the same name as the video file, e.g. "Frieren - 01.nfo" for "Frieren - 01.mkv".
+const NfoShowFilename = "tvshow.nfo"
+
+type (
+	// NfoMetadata holds the IDs and episode numbers found in NFO files.
+	NfoMetadata struct {
+		AnilistId int `json:"anilistId,omitempty"`
+		AnidbId   int `json:"anidbId,omitempty"`
+		TvdbId    int `json:"tvdbId,omitempty"`
+		MalId     int `json:"malId,omitempty"`
+		Season    int `json:"season"`  // -1 if not set
+		Episode   int `json:"episode"` // -1 if not set
+		// MediaId is the AniList ID, resolved from the other IDs if needed
+		MediaId int `json:"mediaId,omitempty"`
+
+		isEpisodeDetails bool
+		hasEpisodeIds    bool // The IDs come from the episode NFO file rather than the series NFO file
+	}
+
+	// NfoFiles holds the NFO files found next to the library files.
+	NfoFiles struct {
+		shows    map[string]*NfoMetadata // Indexed by normalized folder path
+		episodes map[string]*NfoMetadata // Indexed by normalized video file path
+		logger   *zerolog.Logger
+	}
+
+	nfoDocument struct {
+		XMLName   xml.Name
+		UniqueIds []nfoUniqueId `xml:"uniqueid"`
+		AnilistId string        `xml:"anilistid"`
+		AnidbId   string        `xml:"anidbid"`
+		TvdbId    string        `xml:"tvdbid"`
+		MalId     string        `xml:"malid"`
+		Season    string        `xml:"season"`
+		Episode   string        `xml:"episode"`
+	}
+
+	nfoUniqueId struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	}
+)
+
+// LoadNfoFiles reads the episode NFO files of the files, and the series NFO files of their folders, up to their library root.
+func LoadNfoFiles(files []*mediaFile, logger *zerolog.Logger) *NfoFiles {
+	ret := &NfoFiles{
+		shows:    make(map[string]*NfoMetadata),
+		episodes: make(map[string]*NfoMetadata),
+		logger:   logger,
+	}
+
+	checked := make(map[string]struct{})
+	for _, file := range files {
+		// Episode NFO
+		nfoPath := strings.TrimSuffix(file.Path, filepath.Ext(file.Path)) + ".nfo"
+		if metadata, err := readNfoFile(nfoPath); err == nil {
+			if metadata.isEpisodeDetails {
+				// The AniDB and TVDB IDs of episode NFO files are episode IDs
+				metadata.AnidbId, metadata.TvdbId, metadata.MalId = 0, 0, 0
+			}
+			ret.episodes[normalizeFingerprintPath(file.Path)] = metadata
+		} else if !errors.Is(err, os.ErrNotExist) {
+			logger.Debug().Err(err).Str("path", nfoPath).Msg("scanner: Could not read NFO file")
+		}
+
+		// Series NFO
+		for _, dir := range getParentDirs(file.Path, file.RootPath) {
+			key := normalizeFingerprintPath(dir)
+			if _, ok := checked[key]; ok {
+				break // Parent directories have been checked as well
+			}
+			checked[key] = struct{}{}
+
+			metadata, err := readNfoFile(filepath.Join(dir, NfoShowFilename))
+			if err != nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					logger.Debug().Err(err).Str("dir", dir).Msg("scanner: Could not read NFO file")
+				}
+				continue
+			}
+			ret.shows[key] = metadata
+		}
+	}
+
+	if !ret.IsEmpty() {
+		logger.Debug().Int("shows", len(ret.shows)).Int("episodes", len(ret.episodes)).Msg("scanner: Found NFO files")
+	}
+
+	return ret
+}
+
+func readNfoFile(path string) (*NfoMetadata, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Only the root element is decoded, some NFO files have a URL after it
+	var doc nfoDocument
+	if err = xml.NewDecoder(file).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid NFO file: %w", err)
+	}
+
+	ret := &NfoMetadata{
+		AnilistId: parseNfoInt(doc.AnilistId),
+		AnidbId:   parseNfoInt(doc.AnidbId),
+		TvdbId:    parseNfoInt(doc.TvdbId),
+		MalId:     parseNfoInt(doc.MalId),
+		Season:    -1,
+		Episode:   -1,
+
+		isEpisodeDetails: doc.XMLName.Local == "episodedetails",
+	}
+	for _, uid := range doc.UniqueIds {
+		id := parseNfoInt(uid.Value)
+		if id == 0 {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(uid.Type)) {
+		case "anilist":
+			ret.AnilistId = id
+		case "anidb":
+			ret.AnidbId = id
+		case "tvdb":
+			ret.TvdbId = id
+		case "mal", "myanimelist":
+			ret.MalId = id
+		}
+	}
+	if ep, err := strconv.Atoi(strings.TrimSpace(doc.Episode)); err == nil {
+		ret.Episode = ep
+	}
+	if season, err := strconv.Atoi(strings.TrimSpace(doc.Season)); err == nil {
+		ret.Season = season
+	}
+
+	return ret, nil
+}
+
+func parseNfoInt(value string) int {
+	ret, _ := strconv.Atoi(strings.TrimSpace(value))
+	return ret
+}
+
+// IsEmpty returns true if no NFO file was found.
+func (nf *NfoFiles) IsEmpty() bool {
+	return nf == nil || (len(nf.shows) == 0 && len(nf.episodes) == 0)
+}
+
+// Get returns the metadata of the file, from its episode NFO file and the nearest series NFO file.
+// IDs of the episode NFO file take precedence.
+func (nf *NfoFiles) Get(path string) (*NfoMetadata, bool) {
+	if nf.IsEmpty() {
+		return nil, false
+	}
+
+	var ret *NfoMetadata
+	for _, dir := range getParentDirs(path, "") {
+		if show, ok := nf.shows[normalizeFingerprintPath(dir)]; ok {
+			cp := *show
+			ret = &cp
+			break
+		}
+	}
+
+	if episode, ok := nf.episodes[normalizeFingerprintPath(path)]; ok {
+		if ret == nil {
+			ret = &NfoMetadata{}
+		}
+		ret.merge(episode)
+	}
+
+	// The IDs of a series NFO file are those of the first season, which is a different AniList media than the later seasons.
+	// The file is left to the matcher rather than being hydrated as an episode of the first season.
+	if ret != nil && ret.Season > 1 && !ret.hasEpisodeIds {
+		ret.MediaId = 0
+		ret.Episode = -1
+	}
+
+	return ret, ret != nil
+}
+
+// ResolveMediaIds sets the AniList ID of the NFO files that only have AniDB, MyAnimeList or TVDB IDs.
+// The anime lists are only fetched if needed.
+func (nf *NfoFiles) ResolveMediaIds(getAnimeLists func() (*mappings.ReducedAnimeListResponse, error)) {
+	if nf.IsEmpty() {
+		return
+	}
+
+	all := make([]*NfoMetadata, 0, len(nf.shows)+len(nf.episodes))
+	for _, metadata := range nf.shows {
+		all = append(all, metadata)
+	}
+	for _, metadata := range nf.episodes {
+		all = append(all, metadata)
+	}
+
+	var animeLists *mappings.ReducedAnimeListResponse
+	fetched := false
+	for _, metadata := range all {
+		if metadata.AnilistId != 0 {
+			metadata.MediaId = metadata.AnilistId
+			continue
+		}
+		if metadata.AnidbId == 0 && metadata.MalId == 0 && metadata.TvdbId == 0 {
+			continue
+		}
+
+		if !fetched {
+			fetched = true
+			var err error
+			if animeLists, err = getAnimeLists(); err != nil {
+				nf.logger.Warn().Err(err).Msg("scanner: Could not fetch anime mappings, NFO files without AniList IDs are ignored")
+			}
+		}
+		if animeLists == nil {
+			continue
+		}
+
+		if id, ok := animeLists.FindAnilistIDFromAnidbID(metadata.AnidbId); ok {
+			metadata.MediaId = id
+		} else if id, ok := animeLists.FindAnilistIDFromMalID(metadata.MalId); ok {
+			metadata.MediaId = id
+		} else if ids := animeLists.FindAnilistIDsFromTvdbID(metadata.TvdbId); len(ids) == 1 {
+			// TVDB series often group several seasons, the ID is only used if there is no ambiguity
+			metadata.MediaId = ids[0]
+		}
+	}
+}
+
+// GetEpisode returns the episode number from the NFO file.
+// Specials (season 0) are ignored since their numbering differs between databases.
+// Episodes of later seasons are only returned if the episode NFO file has its own IDs, see NfoFiles.Get.
+func (m *NfoMetadata) GetEpisode() (int, bool) {
+	if m == nil || m.Episode < 1 || m.Season == 0 {
+		return 0, false
+	}
+	return m.Episode, true
+}
+
+func (m *NfoMetadata) merge(other *NfoMetadata) {
+	// IDs of the episode take precedence, they could point to another series than the folder
+	if other.MediaId != 0 {
+		m.AnilistId = other.AnilistId
+		m.AnidbId = other.AnidbId
+		m.TvdbId = other.TvdbId
+		m.MalId = other.MalId
+		m.MediaId = other.MediaId
+		m.hasEpisodeIds = true
+	}
+	m.Season = other.Season
+	m.Episode = other.Episode
+}
@@ -0,0 +1,141 @@
+package scanner
+
+import (
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"seanime/internal/api/anilist"
+	"seanime/internal/api/mappings"
+	"seanime/internal/library/anime"
+	"seanime/internal/util"
+	"testing"
+)
+
+func TestNfoFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(name string, content string) string {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	writeFile("Sousou no Frieren/tvshow.nfo", `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<tvshow>
+  <title>Frieren: Beyond Journey's End</title>
+  <uniqueid type="tvdb" default="true">424536</uniqueid>
+  <uniqueid type="anidb">17617</uniqueid>
+</tvshow>
+https://anidb.net/anime/17617`)
+	writeFile("Sousou no Frieren/Season 1/Frieren S01E03.nfo", `<episodedetails>
+  <title>Killing Magic</title>
+  <season>1</season>
+  <episode>3</episode>
+  <uniqueid type="tvdb">9851234</uniqueid>
+</episodedetails>`)
+	writeFile("Sousou no Frieren/Season 0/Frieren S00E01.nfo", `<episodedetails><season>0</season><episode>1</episode></episodedetails>`)
+	writeFile("Sousou no Frieren/Season 2/Frieren S02E03.nfo", `<episodedetails><season>2</season><episode>3</episode></episodedetails>`)
+	writeFile("Sousou no Frieren/Season 2/Frieren S02E04.nfo", `<episodedetails><season>2</season><episode>4</episode><uniqueid type="anilist">182255</uniqueid></episodedetails>`)
+	writeFile("Bocchi/tvshow.nfo", `<tvshow><tvdbid>403172</tvdbid></tvshow>`)
+	writeFile("Broken/tvshow.nfo", `not xml`)
+
+	files := lo.Map([]string{
+		writeFile("Sousou no Frieren/Season 1/Frieren S01E03.mkv", ""),
+		writeFile("Sousou no Frieren/Season 0/Frieren S00E01.mkv", ""),
+		writeFile("Bocchi/Bocchi - 01.mkv", ""),
+		writeFile("Broken/Broken - 01.mkv", ""),
+		writeFile("Mushishi/Mushishi - 01.mkv", ""),
+		writeFile("Sousou no Frieren/Season 2/Frieren S02E03.mkv", ""),
+		writeFile("Sousou no Frieren/Season 2/Frieren S02E04.mkv", ""),
+	}, func(path string, _ int) *mediaFile {
+		return &mediaFile{Path: path, RootPath: root}
+	})
+
+	nfoFiles := LoadNfoFiles(files, util.NewLogger())
+	require.False(t, nfoFiles.IsEmpty())
+
+	animeLists := mappings.NewReducedAnimeListResponse([]*mappings.ReducedAnimeListItem{
+		{AnidbID: 17617, AnilistID: 154587, TheTvdbID: 424536},
+		{AnidbID: 17277, AnilistID: 130003, TheTvdbID: 403172},
+		{AnidbID: 18000, AnilistID: 160000, TheTvdbID: 424536},
+	})
+	nfoFiles.ResolveMediaIds(func() (*mappings.ReducedAnimeListResponse, error) {
+		return animeLists, nil
+	})
+
+	// AniDB ID of the series, episode number from the episode NFO
+	nfo, ok := nfoFiles.Get(files[0].Path)
+	require.True(t, ok)
+	assert.Equal(t, 154587, nfo.MediaId)
+	ep, ok := nfo.GetEpisode()
+	assert.True(t, ok)
+	assert.Equal(t, 3, ep)
+
+	// Specials keep the series but not the episode number
+	nfo, ok = nfoFiles.Get(files[1].Path)
+	require.True(t, ok)
+	assert.Equal(t, 154587, nfo.MediaId)
+	_, ok = nfo.GetEpisode()
+	assert.False(t, ok)
+
+	// Unambiguous TVDB ID
+	nfo, ok = nfoFiles.Get(files[2].Path)
+	require.True(t, ok)
+	assert.Equal(t, 130003, nfo.MediaId)
+
+	// Invalid and missing NFO files
+	_, ok = nfoFiles.Get(files[3].Path)
+	assert.False(t, ok)
+	_, ok = nfoFiles.Get(files[4].Path)
+	assert.False(t, ok)
+
+	// The series IDs are those of the first season, later seasons are left to the matcher
+	nfo, ok = nfoFiles.Get(files[5].Path)
+	require.True(t, ok)
+	assert.Equal(t, 0, nfo.MediaId)
+	_, ok = nfo.GetEpisode()
+	assert.False(t, ok)
+
+	// Unless the episode NFO has its own IDs
+	nfo, ok = nfoFiles.Get(files[6].Path)
+	require.True(t, ok)
+	assert.Equal(t, 182255, nfo.MediaId)
+	ep, ok = nfo.GetEpisode()
+	assert.True(t, ok)
+	assert.Equal(t, 4, ep)
+}
+
+func TestNfoFiles_Match(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "BTR")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tvshow.nfo"), []byte(`<tvshow><uniqueid type="anilist">130003</uniqueid></tvshow>`), 0644))
+
+	files := []*mediaFile{{Path: filepath.Join(dir, "BTR - 13.mkv"), RootPath: root}}
+	nfoFiles := LoadNfoFiles(files, util.NewLogger())
+	nfoFiles.ResolveMediaIds(func() (*mappings.ReducedAnimeListResponse, error) {
+		t.Fatal("anime lists should not be fetched")
+		return nil, nil
+	})
+
+	lfs := []*anime.LocalFile{anime.NewLocalFile(files[0].Path, root)}
+	mc := NewMediaContainer(&MediaContainerOptions{AllMedia: []*anilist.CompleteAnime{{
+		ID:       130003,
+		Format:   lo.ToPtr(anilist.MediaFormatTv),
+		Episodes: lo.ToPtr(12),
+		Status:   lo.ToPtr(anilist.MediaStatusFinished),
+		Title:    &anilist.CompleteAnime_Title{Romaji: lo.ToPtr("Bocchi the Rock!"), English: lo.ToPtr("Bocchi the Rock!")},
+	}}})
+
+	matcher := &Matcher{
+		LocalFiles:         lfs,
+		MediaContainer:     mc,
+		CompleteAnimeCache: anilist.NewCompleteAnimeCache(),
+		Logger:             util.NewLogger(),
+		NfoFiles:           nfoFiles,
+	}
+	require.NoError(t, matcher.MatchLocalFilesWithMedia())
+	assert.Equal(t, 130003, lfs[0].MediaId)
+}
@@ -0,0 +1,167 @@
+package scanner
+
+import (
+	"context"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/summary"
+	"slices"
+	"strings"
+)
+
+const (
+	PlanChangeMatched        PlanChangeType = "matched"         // The file was unmatched or is new, and is now matched
+	PlanChangeRematched      PlanChangeType = "rematched"       // The file is now matched to a different media
+	PlanChangeEpisodeChanged PlanChangeType = "episode_changed" // The file is matched to the same media but its episode changed
+	PlanChangeUnmatched      PlanChangeType = "unmatched"       // The file was matched, or is new, and is now unmatched
+	PlanChangeRemoved        PlanChangeType = "removed"         // The file is no longer in the library
+)
+
+type (
+	PlanChangeType string
+
+	// ScanPlan is what a scan would change in the library if its result was saved.
+	ScanPlan struct {
+		LocalFiles []*anime.LocalFile     `json:"localFiles"` // Local files that would be saved
+		Changes    []*ScanPlanChange      `json:"changes"`
+		Counts     map[PlanChangeType]int `json:"counts"`
+		Unchanged  int                    `json:"unchanged"`
+		Summary    *summary.ScanSummary   `json:"summary,omitempty"`
+	}
+
+	ScanPlanChange struct {
+		Type     PlanChangeType `json:"type"`
+		Path     string         `json:"path"`
+		Previous *PlanFileState `json:"previous,omitempty"` // nil if the file is new
+		Current  *PlanFileState `json:"current,omitempty"`  // nil if the file was removed
+		// Reasons are the scan summary logs of the file, explaining the match
+		Reasons []*summary.ScanSummaryLog `json:"reasons"`
+	}
+
+	PlanFileState struct {
+		MediaId      int                 `json:"mediaId"`
+		Episode      int                 `json:"episode"`
+		AniDBEpisode string              `json:"aniDBEpisode"`
+		Type         anime.LocalFileType `json:"type"`
+		Locked       bool                `json:"locked"`
+		Ignored      bool                `json:"ignored"`
+	}
+)
+
+// Plan runs the whole scan without side effects and returns what it would change.
+// Nothing is persisted, and unknown media are not added to the user's collection.
+func (scn *Scanner) Plan(ctx context.Context) (*ScanPlan, error) {
+	scn.DryRun = true
+
+	if scn.ScanSummaryLogger == nil {
+		scn.ScanSummaryLogger = summary.NewScanSummaryLogger()
+	}
+
+	lfs, err := scn.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := NewScanPlan(scn.ExistingLocalFiles, lfs, scn.ScanSummaryLogger)
+	ret.Summary = scn.ScanSummaryLogger.GenerateSummary()
+
+	return ret, nil
+}
+
+// NewScanPlan compares the scanned local files with the existing ones.
+func NewScanPlan(existingLfs []*anime.LocalFile, scannedLfs []*anime.LocalFile, summaryLogger *summary.ScanSummaryLogger) *ScanPlan {
+	ret := &ScanPlan{
+		LocalFiles: scannedLfs,
+		Changes:    make([]*ScanPlanChange, 0),
+		Counts:     make(map[PlanChangeType]int),
+	}
+
+	// Index the logs by file
+	logs := make(map[string][]*summary.ScanSummaryLog)
+	if summaryLogger != nil {
+		for _, log := range summaryLogger.Logs {
+			key := normalizeFingerprintPath(log.FilePath)
+			logs[key] = append(logs[key], log)
+		}
+	}
+
+	existing := make(map[string]*anime.LocalFile, len(existingLfs))
+	for _, lf := range existingLfs {
+		existing[normalizeFingerprintPath(lf.Path)] = lf
+	}
+
+	addChange := func(changeType PlanChangeType, path string, prev *anime.LocalFile, curr *anime.LocalFile) {
+		reasons := logs[normalizeFingerprintPath(path)]
+		if reasons == nil {
+			reasons = make([]*summary.ScanSummaryLog, 0)
+		}
+		ret.Changes = append(ret.Changes, &ScanPlanChange{
+			Type:     changeType,
+			Path:     path,
+			Previous: newPlanFileState(prev),
+			Current:  newPlanFileState(curr),
+			Reasons:  reasons,
+		})
+		ret.Counts[changeType]++
+	}
+
+	scanned := make(map[string]struct{}, len(scannedLfs))
+	for _, lf := range scannedLfs {
+		key := normalizeFingerprintPath(lf.Path)
+		scanned[key] = struct{}{}
+
+		prev, found := existing[key]
+		prevMediaId := 0
+		if found {
+			prevMediaId = prev.MediaId
+		}
+
+		switch {
+		case prevMediaId == 0 && lf.MediaId != 0:
+			addChange(PlanChangeMatched, lf.Path, prev, lf)
+		case prevMediaId != 0 && lf.MediaId == 0:
+			addChange(PlanChangeUnmatched, lf.Path, prev, lf)
+		case !found && lf.MediaId == 0:
+			addChange(PlanChangeUnmatched, lf.Path, nil, lf)
+		case prevMediaId != lf.MediaId:
+			addChange(PlanChangeRematched, lf.Path, prev, lf)
+		case lf.MediaId != 0 && episodeChanged(prev, lf):
+			addChange(PlanChangeEpisodeChanged, lf.Path, prev, lf)
+		default:
+			ret.Unchanged++
+		}
+	}
+
+	for key, lf := range existing {
+		if _, ok := scanned[key]; !ok {
+			addChange(PlanChangeRemoved, lf.Path, lf, nil)
+		}
+	}
+
+	slices.SortStableFunc(ret.Changes, func(a, b *ScanPlanChange) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return ret
+}
+
+func newPlanFileState(lf *anime.LocalFile) *PlanFileState {
+	if lf == nil {
+		return nil
+	}
+	ret := &PlanFileState{
+		MediaId: lf.MediaId,
+		Locked:  lf.Locked,
+		Ignored: lf.Ignored,
+	}
+	if lf.Metadata != nil {
+		ret.Episode = lf.Metadata.Episode
+		ret.AniDBEpisode = lf.Metadata.AniDBEpisode
+		ret.Type = lf.Metadata.Type
+	}
+	return ret
+}
+
+func episodeChanged(prev *anime.LocalFile, curr *anime.LocalFile) bool {
+	a, b := newPlanFileState(prev), newPlanFileState(curr)
+	return a.Episode != b.Episode || a.AniDBEpisode != b.AniDBEpisode || a.Type != b.Type
+}
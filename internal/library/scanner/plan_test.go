@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seanime/internal/library/anime"
+	"seanime/internal/library/summary"
+	"testing"
+)
+
+func TestNewScanPlan(t *testing.T) {
+	mock := func(path string, mediaId int, episode int) *anime.LocalFile {
+		return anime.MockHydratedLocalFile(anime.MockHydratedLocalFileOptions{
+			FilePath:             "/mnt/anime/" + path,
+			LibraryPath:          "/mnt/anime/",
+			MediaId:              mediaId,
+			MetadataEpisode:      episode,
+			MetadataAniDbEpisode: "1",
+			MetadataType:         anime.LocalFileTypeMain,
+		})
+	}
+
+	existing := []*anime.LocalFile{
+		mock("Frieren - 01.mkv", 154587, 1),
+		mock("Frieren - 02.mkv", 154587, 2),
+		mock("Frieren - 03.mkv", 0, 0),
+		mock("Bocchi - 01.mkv", 130003, 1),
+		mock("Bocchi - 02.mkv", 130003, 2),
+		mock("Mushishi - 01.mkv", 457, 1),
+	}
+	scanned := []*anime.LocalFile{
+		mock("Frieren - 01.mkv", 154587, 1),      // Unchanged
+		mock("Frieren - 02.mkv", 154587, 12),     // Episode changed
+		mock("Frieren - 03.mkv", 154587, 3),      // Matched
+		mock("Bocchi - 01.mkv", 21, 1),           // Rematched
+		mock("Bocchi - 02.mkv", 0, 0),            // Unmatched
+		mock("Made in Abyss - 01.mkv", 0, 0),     // New and unmatched
+		mock("Made in Abyss - 02.mkv", 97986, 2), // New and matched
+	}
+
+	summaryLogger := summary.NewScanSummaryLogger()
+	summaryLogger.LogSuccessfullyMatched(scanned[3], 21)
+	summaryLogger.LogUnmatched(scanned[4], "rating below threshold")
+
+	plan := NewScanPlan(existing, scanned, summaryLogger)
+
+	assert.Equal(t, 1, plan.Unchanged)
+	assert.Equal(t, map[PlanChangeType]int{
+		PlanChangeMatched:        2,
+		PlanChangeRematched:      1,
+		PlanChangeEpisodeChanged: 1,
+		PlanChangeUnmatched:      2,
+		PlanChangeRemoved:        1,
+	}, plan.Counts)
+
+	changes := make(map[string]*ScanPlanChange)
+	for _, change := range plan.Changes {
+		changes[change.Path] = change
+	}
+
+	rematched := changes["/mnt/anime/Bocchi - 01.mkv"]
+	require.NotNil(t, rematched)
+	assert.Equal(t, PlanChangeRematched, rematched.Type)
+	assert.Equal(t, 130003, rematched.Previous.MediaId)
+	assert.Equal(t, 21, rematched.Current.MediaId)
+	if assert.Len(t, rematched.Reasons, 1) {
+		assert.Equal(t, "Successfully matched to media 21", rematched.Reasons[0].Message)
+	}
+
+	unmatched := changes["/mnt/anime/Bocchi - 02.mkv"]
+	require.NotNil(t, unmatched)
+	assert.Equal(t, PlanChangeUnmatched, unmatched.Type)
+	assert.Len(t, unmatched.Reasons, 1)
+
+	episodeChanged := changes["/mnt/anime/Frieren - 02.mkv"]
+	require.NotNil(t, episodeChanged)
+	assert.Equal(t, 2, episodeChanged.Previous.Episode)
+	assert.Equal(t, 12, episodeChanged.Current.Episode)
+
+	newFile := changes["/mnt/anime/Made in Abyss - 02.mkv"]
+	require.NotNil(t, newFile)
+	assert.Equal(t, PlanChangeMatched, newFile.Type)
+	assert.Nil(t, newFile.Previous)
+
+	removed := changes["/mnt/anime/Mushishi - 01.mkv"]
+	require.NotNil(t, removed)
+	assert.Equal(t, PlanChangeRemoved, removed.Type)
+	assert.Nil(t, removed.Current)
+}
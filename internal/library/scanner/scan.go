@@ -1,13 +1,18 @@
 package scanner
 
 import (
+	"context"
 	"errors"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/rs/zerolog"
 	"github.com/samber/lo"
+	"runtime"
 	"seanime/internal/api/anilist"
 	"seanime/internal/api/anizip"
+	"seanime/internal/api/mappings"
+	"seanime/internal/database/models"
 	"seanime/internal/events"
+	"seanime/internal/extension"
 	"seanime/internal/library/anime"
 	"seanime/internal/library/filesystem"
 	"seanime/internal/library/summary"
@@ -18,6 +23,7 @@ import (
 
 type Scanner struct {
 	DirPath            string
+	Roots              []*models.LibraryRoot // Library roots to scan, DirPath is used if empty
 	Enhanced           bool
 	Platform           platform.Platform
 	Logger             *zerolog.Logger
@@ -27,10 +33,38 @@ type Scanner struct {
 	SkipIgnoredFiles   bool
 	ScanSummaryLogger  *summary.ScanSummaryLogger
 	ScanLogger         *ScanLogger
+	// Incremental scans only parse, match and hydrate files that are new or changed since the last scan.
+	// FingerprintIndex must be the index of the last scan, a full scan is done if it is missing.
+	Incremental      bool
+	FingerprintIndex *FingerprintIndex
+	UsePartialHash   bool // Hash the start and end of files to detect moved files more reliably
+	// DryRun prevents the scan from having side effects, such as adding unknown media to the collection.
+	DryRun bool
+	// Concurrency is the maximum number of files or media processed in parallel by each stage, defaults to the number of CPUs.
+	// The result of the scan does not depend on it.
+	Concurrency int
+	// DuplicatePolicy decides which release of an episode is preferred, the others are marked as alternates.
+	DuplicatePolicy *models.DuplicatePolicy
+	// Checkpoint is the last checkpoint of an interrupted scan, the scan resumes from it if it was created with the same options.
+	Checkpoint *ScanCheckpoint
+	// OnCheckpoint is called after each stage with a checkpoint that should be persisted until the scan completes.
+	OnCheckpoint func(cp *ScanCheckpoint)
+	// LibraryMatchers are used to match the files that could not be confidently matched by title comparison.
+	LibraryMatchers []extension.LibraryMatcherExtension
+	// FileIndex is used to identify files by their ED2K hash before matching them, it is skipped if empty.
+	FileIndex *AniDBFileIndex
+	// AnimeLists are used to map the IDs found in NFO files to AniList IDs, they are fetched if needed.
+	AnimeLists       *mappings.ReducedAnimeListResponse
+	fingerprintIndex *FingerprintIndex
+	folderOverrides  *FolderOverrides
+	nfoFiles         *NfoFiles
 }
 
 // Scan will scan the directory and return a list of anime.LocalFile.
-func (scn *Scanner) Scan() (lfs []*anime.LocalFile, err error) {
+//
+// The scan stops when the context is cancelled and returns ErrScanCancelled, the matching and hydration stages stop between files.
+// A checkpoint is passed to OnCheckpoint after each stage, and the scan resumes from Checkpoint if it was created with the same options.
+func (scn *Scanner) Scan(ctx context.Context) (lfs []*anime.LocalFile, err error) {
 
 	defer util.HandlePanicWithError(&err)
 
@@ -44,64 +78,37 @@ func (scn *Scanner) Scan() (lfs []*anime.LocalFile, err error) {
 		scn.ScanSummaryLogger = summary.NewScanSummaryLogger()
 	}
 
-	scn.Logger.Debug().Msg("scanner: Starting scan")
-	scn.WSEventManager.SendEvent(events.EventScanProgress, 10)
-	scn.WSEventManager.SendEvent(events.EventScanStatus, "Retrieving local files...")
-
-	// +---------------------+
-	// |     Local Files     |
-	// +---------------------+
-
-	// Get local files
-	localFiles, err := GetLocalFilesFromDir(scn.DirPath, scn.Logger)
-	if err != nil {
-		return nil, err
-	}
-
-	if scn.ScanLogger != nil {
-		scn.ScanLogger.logger.Info().
-			Any("count", len(localFiles)).
-			Msg("Retrieved and parsed local files")
-	}
-
-	for _, lf := range localFiles {
-		if scn.ScanLogger != nil {
-			scn.ScanLogger.logger.Trace().
-				Str("path", lf.Path).
-				Any("parsedData", spew.Sdump(lf.ParsedData)).
-				Any("parsedFolderData", spew.Sdump(lf.ParsedFolderData)).
-				Msg("Parsed local file")
+	cp := scn.getResumableCheckpoint()
+	if cp.Stage != "" {
+		scn.ScanSummaryLogger.Logs = append(scn.ScanSummaryLogger.Logs, cp.SummaryLogs...)
+		for _, media := range cp.AllMedia {
+			completeAnimeCache.Set(media.ID, media)
 		}
 	}
 
-	if scn.ScanLogger != nil {
-		scn.ScanLogger.logger.Debug().
-			Msg("===========================================================================================================")
+	scn.Logger.Debug().Msg("scanner: Starting scan")
+	scn.WSEventManager.SendEvent(events.EventScanProgress, 10)
+	if cp.Stage != "" {
+		scn.WSEventManager.SendEvent(events.EventScanStatus, "Resuming scan...")
+	} else {
+		scn.WSEventManager.SendEvent(events.EventScanStatus, "Retrieving local files...")
 	}
 
 	// +---------------------+
-	// | Filter local files  |
+	// |     Local Files     |
 	// +---------------------+
 
-	// Get skipped files depending on options
-	skippedLfs := make([]*anime.LocalFile, 0)
-	if (scn.SkipLockedFiles || scn.SkipIgnoredFiles) && scn.ExistingLocalFiles != nil {
-		// Retrieve skipped files from existing local files
-		for _, lf := range scn.ExistingLocalFiles {
-			if scn.SkipLockedFiles && lf.IsLocked() {
-				skippedLfs = append(skippedLfs, lf)
-			} else if scn.SkipIgnoredFiles && lf.IsIgnored() {
-				skippedLfs = append(skippedLfs, lf)
-			}
+	if !cp.HasCompleted(ScanStageFiles) {
+		if err = scn.retrieveLocalFiles(ctx, cp); err != nil {
+			return nil, err
 		}
-
-		// Remove skipped files from local files that will be hydrated
-		localFiles = lo.Filter(localFiles, func(lf *anime.LocalFile, _ int) bool {
-			if lf.IsIncluded(skippedLfs) {
-				return false
-			}
-			return true
-		})
+		scn.saveCheckpoint(cp, ScanStageFiles)
+	} else {
+		scn.fingerprintIndex = cp.FingerprintIndex
+		scn.loadSidecarFiles(scn.getMediaFilesFromLocalFiles(cp.LocalFiles))
+	}
+	if err = checkCancelled(ctx); err != nil {
+		return nil, err
 	}
 
 	// +---------------------+
@@ -109,17 +116,11 @@ func (scn *Scanner) Scan() (lfs []*anime.LocalFile, err error) {
 	// +---------------------+
 
 	// If there are no local files to scan (all files are skipped, or a file was deleted)
-	if len(localFiles) == 0 {
+	if len(cp.LocalFiles) == 0 {
 		scn.WSEventManager.SendEvent(events.EventScanProgress, 90)
 		scn.WSEventManager.SendEvent(events.EventScanStatus, "Verifying file integrity...")
-		// Add skipped files
-		if len(skippedLfs) > 0 {
-			for _, sf := range skippedLfs {
-				if filesystem.FileExists(sf.Path) { // Verify that the file still exists
-					localFiles = append(localFiles, sf)
-				}
-			}
-		}
+		localFiles := scn.mergeLocalFiles(cp, make([]*anime.LocalFile, 0))
+		MarkAlternateLocalFiles(localFiles, scn.DuplicatePolicy, scn.ScanSummaryLogger)
 		scn.Logger.Debug().Msg("scanner: Scan completed")
 		scn.WSEventManager.SendEvent(events.EventScanProgress, 100)
 		scn.WSEventManager.SendEvent(events.EventScanStatus, "Scan completed")
@@ -138,18 +139,29 @@ func (scn *Scanner) Scan() (lfs []*anime.LocalFile, err error) {
 	// |    MediaFetcher     |
 	// +---------------------+
 
-	// Fetch media needed for matching
-	mf, err := NewMediaFetcher(&MediaFetcherOptions{
-		Enhanced:           scn.Enhanced,
-		Platform:           scn.Platform,
-		LocalFiles:         localFiles,
-		CompleteAnimeCache: completeAnimeCache,
-		AnizipCache:        anizipCache,
-		Logger:             scn.Logger,
-		AnilistRateLimiter: anilistRateLimiter,
-		ScanLogger:         scn.ScanLogger,
-	})
-	if err != nil {
+	if !cp.HasCompleted(ScanStageMedia) {
+		// Fetch media needed for matching
+		mf, err := NewMediaFetcher(&MediaFetcherOptions{
+			Enhanced:           scn.Enhanced,
+			Concurrency:        scn.Concurrency,
+			Platform:           scn.Platform,
+			LocalFiles:         cp.LocalFiles,
+			CompleteAnimeCache: completeAnimeCache,
+			AnizipCache:        anizipCache,
+			Logger:             scn.Logger,
+			AnilistRateLimiter: anilistRateLimiter,
+			ScanLogger:         scn.ScanLogger,
+			ExtraMediaIds:      append(scn.getPinnedMediaIds(cp.LocalFiles), getMediaIds(cp.IdentifiedLocalFiles)...),
+		})
+		if err != nil {
+			return nil, err
+		}
+		cp.AllMedia = mf.AllMedia
+		cp.UnknownMediaIds = mf.UnknownMediaIds
+		cp.AnimeCollection = mf.AnimeCollectionWithRelations
+		scn.saveCheckpoint(cp, ScanStageMedia)
+	}
+	if err = checkCancelled(ctx); err != nil {
 		return nil, err
 	}
 
@@ -162,7 +174,7 @@ func (scn *Scanner) Scan() (lfs []*anime.LocalFile, err error) {
 
 	// Create a new container for media
 	mc := NewMediaContainer(&MediaContainerOptions{
-		AllMedia:   mf.AllMedia,
+		AllMedia:   cp.AllMedia,
 		ScanLogger: scn.ScanLogger,
 	})
 
@@ -174,26 +186,43 @@ func (scn *Scanner) Scan() (lfs []*anime.LocalFile, err error) {
 	// |      Matcher        |
 	// +---------------------+
 
-	// Create a new matcher
-	matcher := &Matcher{
-		LocalFiles:         localFiles,
-		MediaContainer:     mc,
-		CompleteAnimeCache: completeAnimeCache,
-		Logger:             scn.Logger,
-		ScanLogger:         scn.ScanLogger,
-		ScanSummaryLogger:  scn.ScanSummaryLogger,
-	}
-
 	scn.WSEventManager.SendEvent(events.EventScanProgress, 60)
 
-	err = matcher.MatchLocalFilesWithMedia()
-	if err != nil {
-		// If the matcher received no local files, return an error
-		if errors.Is(err, ErrNoLocalFiles) {
-			scn.Logger.Debug().Msg("scanner: Scan completed")
-			scn.WSEventManager.SendEvent(events.EventScanProgress, 100)
-			scn.WSEventManager.SendEvent(events.EventScanStatus, "Scan completed")
+	if !cp.HasCompleted(ScanStageMatching) {
+		// Match the files of roots that hold movies with movies first
+		unmatchedLfs := scn.matchMovieLocalFiles(cp.LocalFiles, cp.AllMedia, completeAnimeCache)
+
+		// Create a new matcher
+		matcher := &Matcher{
+			LocalFiles:         unmatchedLfs,
+			MediaContainer:     mc,
+			CompleteAnimeCache: completeAnimeCache,
+			Logger:             scn.Logger,
+			ScanLogger:         scn.ScanLogger,
+			ScanSummaryLogger:  scn.ScanSummaryLogger,
+			FolderOverrides:    scn.folderOverrides,
+			NfoFiles:           scn.nfoFiles,
+			LibraryMatchers:    scn.LibraryMatchers,
+			Concurrency:        scn.Concurrency,
+			Context:            ctx,
+		}
+
+		err = matcher.MatchLocalFilesWithMedia()
+		if err != nil && len(unmatchedLfs) == 0 && len(cp.LocalFiles) > 0 {
+			err = nil // All files were matched with movies
 		}
+		if err != nil {
+			// If the matcher received no local files, return an error
+			if errors.Is(err, ErrNoLocalFiles) {
+				scn.Logger.Debug().Msg("scanner: Scan completed")
+				scn.WSEventManager.SendEvent(events.EventScanProgress, 100)
+				scn.WSEventManager.SendEvent(events.EventScanStatus, "Scan completed")
+			}
+			return nil, err
+		}
+		scn.saveCheckpoint(cp, ScanStageMatching)
+	}
+	if err = checkCancelled(ctx); err != nil {
 		return nil, err
 	}
 
@@ -204,19 +233,32 @@ func (scn *Scanner) Scan() (lfs []*anime.LocalFile, err error) {
 	// |    FileHydrator     |
 	// +---------------------+
 
-	// Create a new hydrator
-	hydrator := &FileHydrator{
-		AllMedia:           mc.NormalizedMedia,
-		LocalFiles:         localFiles,
-		AnizipCache:        anizipCache,
-		Platform:           scn.Platform,
-		CompleteAnimeCache: completeAnimeCache,
-		AnilistRateLimiter: anilistRateLimiter,
-		Logger:             scn.Logger,
-		ScanLogger:         scn.ScanLogger,
-		ScanSummaryLogger:  scn.ScanSummaryLogger,
+	if !cp.HasCompleted(ScanStageHydration) {
+		// Create a new hydrator
+		hydrator := &FileHydrator{
+			AllMedia:           mc.NormalizedMedia,
+			LocalFiles:         cp.LocalFiles,
+			AnizipCache:        anizipCache,
+			Platform:           scn.Platform,
+			CompleteAnimeCache: completeAnimeCache,
+			AnilistRateLimiter: anilistRateLimiter,
+			Logger:             scn.Logger,
+			ScanLogger:         scn.ScanLogger,
+			ScanSummaryLogger:  scn.ScanSummaryLogger,
+			FolderOverrides:    scn.folderOverrides,
+			Concurrency:        scn.Concurrency,
+			Context:            ctx,
+		}
+		hydrator.HydrateMetadata()
+		// The files of a cancelled hydration are only partially hydrated
+		if err = checkCancelled(ctx); err != nil {
+			return nil, err
+		}
+		scn.saveCheckpoint(cp, ScanStageHydration)
+	}
+	if err = checkCancelled(ctx); err != nil {
+		return nil, err
 	}
-	hydrator.HydrateMetadata()
 
 	scn.WSEventManager.SendEvent(events.EventScanProgress, 80)
 
@@ -226,10 +268,10 @@ func (scn *Scanner) Scan() (lfs []*anime.LocalFile, err error) {
 
 	// Add non-added media entries to AniList collection
 	// Max of 4 to avoid rate limit issues
-	if len(mf.UnknownMediaIds) < 5 {
+	if len(cp.UnknownMediaIds) < 5 && !scn.DryRun {
 		scn.WSEventManager.SendEvent(events.EventScanStatus, "Adding missing media to AniList...")
 
-		if err = scn.Platform.AddMediaToCollection(mf.UnknownMediaIds); err != nil {
+		if err = scn.Platform.AddMediaToCollection(cp.UnknownMediaIds); err != nil {
 			scn.Logger.Warn().Msg("scanner: An error occurred while adding media to planning list: " + err.Error())
 		}
 	}
@@ -238,21 +280,21 @@ func (scn *Scanner) Scan() (lfs []*anime.LocalFile, err error) {
 	scn.WSEventManager.SendEvent(events.EventScanStatus, "Verifying file integrity...")
 
 	// Hydrate the summary logger before merging files
-	scn.ScanSummaryLogger.HydrateData(localFiles, mc.NormalizedMedia, mf.AnimeCollectionWithRelations)
+	scn.ScanSummaryLogger.HydrateData(cp.LocalFiles, mc.NormalizedMedia, cp.AnimeCollection)
 
 	// +---------------------+
 	// |    Merge files      |
 	// +---------------------+
 
-	// Merge skipped files with scanned files
-	// Only files that exist (this removes deleted/moved files)
-	if len(skippedLfs) > 0 {
-		for _, sf := range skippedLfs {
-			if filesystem.FileExists(sf.Path) {
-				localFiles = append(localFiles, sf)
-			}
-		}
-	}
+	localFiles := scn.mergeLocalFiles(cp, cp.LocalFiles)
+
+	// +---------------------+
+	// |     Duplicates      |
+	// +---------------------+
+
+	// Mark the alternate releases of episodes
+	// This is done on the merged files so that new files are compared with the files that were not scanned again
+	MarkAlternateLocalFiles(localFiles, scn.DuplicatePolicy, scn.ScanSummaryLogger)
 
 	scn.Logger.Info().Msg("scanner: Scan completed")
 	scn.WSEventManager.SendEvent(events.EventScanProgress, 100)
@@ -261,10 +303,293 @@ func (scn *Scanner) Scan() (lfs []*anime.LocalFile, err error) {
 	if scn.ScanLogger != nil {
 		scn.ScanLogger.logger.Info().
 			Int("scannedFileCount", len(localFiles)).
-			Int("skippedFileCount", len(skippedLfs)).
-			Int("unknownMediaCount", len(mf.UnknownMediaIds)).
+			Int("skippedFileCount", len(cp.SkippedLocalFiles)).
+			Int("reusedFileCount", len(cp.ReusedLocalFiles)).
+			Int("identifiedFileCount", len(cp.IdentifiedLocalFiles)).
+			Int("unknownMediaCount", len(cp.UnknownMediaIds)).
 			Msg("Scan completed")
 	}
 
 	return localFiles, nil
 }
+
+// retrieveLocalFiles retrieves, parses and filters the local files, and stores them in the checkpoint.
+func (scn *Scanner) retrieveLocalFiles(ctx context.Context, cp *ScanCheckpoint) error {
+	// Get media files from all roots
+	mediaFiles, unreachableRoots, err := getMediaFilesFromRoots(scn.getRoots(), scn.Logger)
+	if err != nil {
+		return err
+	}
+	cp.ContentSignature = getContentSignature(mediaFiles)
+
+	// Read the folder overrides and NFO files, and remove excluded files
+	mediaFiles = scn.loadSidecarFiles(mediaFiles)
+
+	// Create the local files, unchanged and moved files are reused during incremental scans
+	localFiles, reusedLfs := scn.getLocalFiles(mediaFiles)
+
+	// Keep the files of roots that could not be read (e.g. disconnected drive)
+	// so that they are not removed from the library
+	unreachableLfs := scn.getExistingLocalFilesInRoots(unreachableRoots)
+	for _, lf := range unreachableLfs {
+		if fp, found := scn.FingerprintIndex.Get(lf.Path); found {
+			scn.fingerprintIndex.Set(fp)
+		}
+	}
+
+	if scn.ScanLogger != nil {
+		scn.ScanLogger.logger.Info().
+			Any("count", len(localFiles)).
+			Msg("Retrieved and parsed local files")
+	}
+
+	for _, lf := range localFiles {
+		if scn.ScanLogger != nil {
+			scn.ScanLogger.logger.Trace().
+				Str("path", lf.Path).
+				Any("parsedData", spew.Sdump(lf.ParsedData)).
+				Any("parsedFolderData", spew.Sdump(lf.ParsedFolderData)).
+				Msg("Parsed local file")
+		}
+	}
+
+	if scn.ScanLogger != nil {
+		scn.ScanLogger.logger.Debug().
+			Msg("===========================================================================================================")
+	}
+
+	// +---------------------+
+	// | Filter local files  |
+	// +---------------------+
+
+	// Get skipped files depending on options
+	skippedLfs := make([]*anime.LocalFile, 0)
+	if (scn.SkipLockedFiles || scn.SkipIgnoredFiles) && scn.ExistingLocalFiles != nil {
+		// Retrieve skipped files from existing local files
+		for _, lf := range scn.ExistingLocalFiles {
+			if scn.SkipLockedFiles && lf.IsLocked() {
+				skippedLfs = append(skippedLfs, lf)
+			} else if scn.SkipIgnoredFiles && lf.IsIgnored() {
+				skippedLfs = append(skippedLfs, lf)
+			}
+		}
+
+		// Remove skipped files from local files that will be hydrated
+		localFiles = lo.Filter(localFiles, func(lf *anime.LocalFile, _ int) bool {
+			if lf.IsIncluded(skippedLfs) {
+				return false
+			}
+			return true
+		})
+	}
+
+	// Reused files are already merged, remove them from the skipped files
+	if len(reusedLfs) > 0 {
+		reusedPaths := make(map[string]struct{}, len(reusedLfs))
+		for _, lf := range reusedLfs {
+			reusedPaths[normalizeFingerprintPath(lf.Path)] = struct{}{}
+		}
+		skippedLfs = lo.Filter(skippedLfs, func(lf *anime.LocalFile, _ int) bool {
+			_, ok := reusedPaths[normalizeFingerprintPath(lf.Path)]
+			return !ok
+		})
+	}
+
+	// +---------------------+
+	// | Hash identification |
+	// +---------------------+
+
+	// Identify files using the AniDB file index, identified files skip the matcher and hydrator
+	localFiles, identifiedLfs, err := scn.identifyLocalFiles(ctx, localFiles)
+	if err != nil {
+		return err
+	}
+
+	cp.LocalFiles = localFiles
+	cp.ReusedLocalFiles = reusedLfs
+	cp.SkippedLocalFiles = skippedLfs
+	cp.IdentifiedLocalFiles = identifiedLfs
+	cp.UnreachableLocalFiles = unreachableLfs
+	cp.FingerprintIndex = scn.fingerprintIndex
+
+	return nil
+}
+
+// loadSidecarFiles reads the folder overrides and NFO files of the media files.
+// It returns the media files that are not excluded by a folder override.
+func (scn *Scanner) loadSidecarFiles(mediaFiles []*mediaFile) []*mediaFile {
+	// Read the folder overrides and remove excluded files
+	scn.folderOverrides = LoadFolderOverrides(mediaFiles, scn.Logger)
+	if !scn.folderOverrides.IsEmpty() {
+		mediaFiles = lo.Filter(mediaFiles, func(file *mediaFile, _ int) bool {
+			return !scn.folderOverrides.IsExcluded(file.Path)
+		})
+	}
+
+	// Read the NFO files left by other media servers
+	scn.nfoFiles = LoadNfoFiles(mediaFiles, scn.Logger)
+	scn.nfoFiles.ResolveMediaIds(scn.getAnimeLists)
+
+	return mediaFiles
+}
+
+// mergeLocalFiles adds the files that did not go through the matcher and hydrator to the scanned files.
+// Skipped files are only added if they still exist (this removes deleted/moved files).
+func (scn *Scanner) mergeLocalFiles(cp *ScanCheckpoint, scannedLfs []*anime.LocalFile) []*anime.LocalFile {
+	ret := make([]*anime.LocalFile, 0, len(scannedLfs)+len(cp.SkippedLocalFiles)+len(cp.ReusedLocalFiles)+len(cp.IdentifiedLocalFiles)+len(cp.UnreachableLocalFiles))
+	ret = append(ret, scannedLfs...)
+	for _, sf := range cp.SkippedLocalFiles {
+		if filesystem.FileExists(sf.Path) {
+			ret = append(ret, sf)
+		}
+	}
+	ret = append(ret, cp.ReusedLocalFiles...)
+	ret = append(ret, cp.IdentifiedLocalFiles...)
+	ret = append(ret, cp.UnreachableLocalFiles...)
+	return ret
+}
+
+// getMediaFilesFromLocalFiles returns the media files of local files, with the root they are in.
+func (scn *Scanner) getMediaFilesFromLocalFiles(lfs []*anime.LocalFile) []*mediaFile {
+	roots := scn.getRoots()
+	ret := make([]*mediaFile, 0, len(lfs))
+	for _, lf := range lfs {
+		file := &mediaFile{Path: lf.Path}
+		if root, found := lo.Find(roots, func(root *models.LibraryRoot) bool {
+			return util.IsSubdirectory(root.Path, lf.Path)
+		}); found {
+			file.RootPath = root.Path
+		}
+		ret = append(ret, file)
+	}
+	return ret
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetFingerprintIndex returns the fingerprint index built during the last scan.
+// It should be persisted and passed to the next incremental scan.
+func (scn *Scanner) GetFingerprintIndex() *FingerprintIndex {
+	return scn.fingerprintIndex
+}
+
+// getPinnedMediaIds returns the media IDs set by folder overrides and NFO files.
+func (scn *Scanner) getPinnedMediaIds(lfs []*anime.LocalFile) []int {
+	ret := make([]int, 0)
+	if scn.folderOverrides.IsEmpty() && scn.nfoFiles.IsEmpty() {
+		return ret
+	}
+	for _, lf := range lfs {
+		if override, ok := scn.folderOverrides.Get(lf.Path); ok && override.MediaId != 0 {
+			ret = append(ret, override.MediaId)
+		} else if nfo, ok := scn.nfoFiles.Get(lf.Path); ok && nfo.MediaId != 0 {
+			ret = append(ret, nfo.MediaId)
+		}
+	}
+	return lo.Uniq(ret)
+}
+
+// getAnimeLists returns the anime lists used to map IDs, fetching them if they were not provided.
+func (scn *Scanner) getAnimeLists() (*mappings.ReducedAnimeListResponse, error) {
+	if scn.AnimeLists != nil {
+		return scn.AnimeLists, nil
+	}
+	var err error
+	scn.AnimeLists, err = mappings.GetReducedAnimeLists()
+	return scn.AnimeLists, err
+}
+
+// getMediaIds returns the media IDs of the local files.
+func getMediaIds(lfs []*anime.LocalFile) []int {
+	return lo.Uniq(lo.FilterMap(lfs, func(lf *anime.LocalFile, _ int) (int, bool) {
+		return lf.MediaId, lf.MediaId != 0
+	}))
+}
+
+// getConcurrency returns the maximum number of goroutines a stage of the scan can use.
+func getConcurrency(concurrency int) int {
+	if concurrency > 0 {
+		return concurrency
+	}
+	return runtime.NumCPU()
+}
+
+// getRoots returns the enabled library roots.
+// If no roots are set, DirPath is the only root.
+func (scn *Scanner) getRoots() []*models.LibraryRoot {
+	if len(scn.Roots) == 0 {
+		return []*models.LibraryRoot{{Path: scn.DirPath, Enabled: true}}
+	}
+	return lo.Filter(scn.Roots, func(root *models.LibraryRoot, _ int) bool {
+		return root.Enabled && root.Path != ""
+	})
+}
+
+// getExistingLocalFilesInRoots returns the existing local files that are inside the roots.
+func (scn *Scanner) getExistingLocalFilesInRoots(roots []*models.LibraryRoot) []*anime.LocalFile {
+	if len(roots) == 0 {
+		return []*anime.LocalFile{}
+	}
+	return lo.Filter(scn.ExistingLocalFiles, func(lf *anime.LocalFile, _ int) bool {
+		return lo.ContainsBy(roots, func(root *models.LibraryRoot) bool {
+			return util.IsSubdirectory(root.Path, lf.Path)
+		})
+	})
+}
+
+// matchMovieLocalFiles matches the files of roots marked as containing movies with movies only.
+// It returns the files that should go through the regular matcher.
+func (scn *Scanner) matchMovieLocalFiles(lfs []*anime.LocalFile, allMedia []*anilist.CompleteAnime, completeAnimeCache *anilist.CompleteAnimeCache) []*anime.LocalFile {
+	movieRoots := lo.Filter(scn.getRoots(), func(root *models.LibraryRoot, _ int) bool {
+		return root.TreatAsMovies
+	})
+	if len(movieRoots) == 0 {
+		return lfs
+	}
+
+	movieLfs := make([]*anime.LocalFile, 0)
+	otherLfs := make([]*anime.LocalFile, 0)
+	for _, lf := range lfs {
+		if lo.ContainsBy(movieRoots, func(root *models.LibraryRoot) bool {
+			return util.IsSubdirectory(root.Path, lf.Path)
+		}) {
+			movieLfs = append(movieLfs, lf)
+		} else {
+			otherLfs = append(otherLfs, lf)
+		}
+	}
+
+	movies := lo.Filter(allMedia, func(media *anilist.CompleteAnime, _ int) bool {
+		return media.GetFormat() != nil && *media.GetFormat() == anilist.MediaFormatMovie
+	})
+	if len(movieLfs) == 0 || len(movies) == 0 {
+		return lfs
+	}
+
+	matcher := &Matcher{
+		LocalFiles: movieLfs,
+		MediaContainer: NewMediaContainer(&MediaContainerOptions{
+			AllMedia:   movies,
+			ScanLogger: scn.ScanLogger,
+		}),
+		CompleteAnimeCache: completeAnimeCache,
+		Logger:             scn.Logger,
+		ScanLogger:         scn.ScanLogger,
+		ScanSummaryLogger:  scn.ScanSummaryLogger,
+		FolderOverrides:    scn.folderOverrides,
+		NfoFiles:           scn.nfoFiles,
+	}
+	if err := matcher.MatchLocalFilesWithMedia(); err != nil {
+		scn.Logger.Warn().Err(err).Msg("scanner: Could not match files with movies")
+		return lfs
+	}
+
+	scn.Logger.Debug().
+		Int("count", lo.CountBy(movieLfs, func(lf *anime.LocalFile) bool { return lf.MediaId != 0 })).
+		Msg("scanner: Matched files with movies")
+
+	// Files that did not match a movie go through the regular matcher
+	return append(otherLfs, lo.Filter(movieLfs, func(lf *anime.LocalFile, _ int) bool {
+		return lf.MediaId == 0
+	})...)
+}
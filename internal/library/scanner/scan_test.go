@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"context"
 	"seanime/internal/api/anilist"
 	"seanime/internal/events"
 	"seanime/internal/library/anime"
@@ -62,7 +63,7 @@ func TestScanner_Scan(t *testing.T) {
 				ScanSummaryLogger:  nil,
 			}
 
-			lfs, err := scanner.Scan()
+			lfs, err := scanner.Scan(context.Background())
 			if err != nil {
 				t.Fatal("expected result, got error:", err.Error())
 			}
@@ -8,6 +8,14 @@ import (
 	"seanime/internal/events"
 )
 
+const (
+	FileActionAdded   FileAction = "added"
+	FileActionRemoved FileAction = "removed" // The file was deleted, or moved or renamed
+)
+
+// FileAction is the kind of change reported by the watcher.
+type FileAction string
+
 // Watcher is a custom file system event watcher
 type Watcher struct {
 	Watcher        *fsnotify.Watcher
@@ -38,10 +46,11 @@ func NewWatcher(opts *NewWatcherOptions) (*Watcher, error) {
 //----------------------------------------------------------------------------------------------------------------------
 
 type WatchLibraryFilesOptions struct {
-	LibraryPath string
+	LibraryPaths []string
 }
 
-// InitLibraryFileWatcher starts watching the specified directory and its subdirectories for file system events
+// InitLibraryFileWatcher starts watching the specified directories and their subdirectories for file system events.
+// Directories that cannot be watched are skipped, an error is returned only if none of them can be watched.
 func (w *Watcher) InitLibraryFileWatcher(opts *WatchLibraryFilesOptions) error {
 	// Define a function to add directories and their subdirectories to the watcher
 	watchDir := func(dir string) error {
@@ -57,18 +66,31 @@ func (w *Watcher) InitLibraryFileWatcher(opts *WatchLibraryFilesOptions) error {
 		return err
 	}
 
-	// Add the initial directory and its subdirectories to the watcher
-	if err := watchDir(opts.LibraryPath); err != nil {
-		return err
+	// Add the library directories and their subdirectories to the watcher
+	var err error
+	watched := 0
+	for _, path := range opts.LibraryPaths {
+		if dirErr := watchDir(path); dirErr != nil {
+			w.Logger.Warn().Err(dirErr).Msgf("watcher: Failed to watch directory: \"%s\"", path)
+			err = dirErr
+			continue
+		}
+		watched++
+		w.Logger.Info().Msgf("watcher: Watching directory: \"%s\"", path)
 	}
 
-	w.Logger.Info().Msgf("watcher: Watching directory: \"%s\"", opts.LibraryPath)
+	if watched == 0 && err != nil {
+		return err
+	}
 
 	return nil
 }
 
+// StartWatching starts handling file system events.
+// onFileAction is called with the path of the file that was added or removed.
+// When a directory is added, it is watched and onFileAction is called for each file it contains.
 func (w *Watcher) StartWatching(
-	onFileAction func(),
+	onFileAction func(action FileAction, path string),
 ) {
 	// Start a goroutine to handle file system events
 	go func() {
@@ -78,17 +100,16 @@ func (w *Watcher) StartWatching(
 				if !ok {
 					return
 				}
-				if event.Op&fsnotify.Write == fsnotify.Write {
-				}
 				if event.Op&fsnotify.Create == fsnotify.Create {
 					w.Logger.Debug().Msgf("watcher: File created: %s", event.Name)
 					w.WSEventManager.SendEvent(events.LibraryWatcherFileAdded, event.Name)
-					onFileAction()
+					w.handleCreated(event.Name, onFileAction)
 				}
-				if event.Op&fsnotify.Remove == fsnotify.Remove {
+				// A renamed file is reported with its old path, the new path is reported as created
+				if event.Op&fsnotify.Remove == fsnotify.Remove || event.Op&fsnotify.Rename == fsnotify.Rename {
 					w.Logger.Debug().Msgf("watcher: File removed: %s", event.Name)
 					w.WSEventManager.SendEvent(events.LibraryWatcherFileRemoved, event.Name)
-					onFileAction()
+					onFileAction(FileActionRemoved, event.Name)
 				}
 
 			case err, ok := <-w.Watcher.Errors:
@@ -101,6 +122,29 @@ func (w *Watcher) StartWatching(
 	}()
 }
 
+// handleCreated watches the new directories and reports the files they contain, e.g. a downloaded release folder.
+func (w *Watcher) handleCreated(path string, onFileAction func(action FileAction, path string)) {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		onFileAction(FileActionAdded, path)
+		return
+	}
+
+	_ = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if err := w.Watcher.Add(p); err != nil {
+				w.Logger.Warn().Err(err).Msgf("watcher: Failed to watch directory: \"%s\"", p)
+			}
+			return nil
+		}
+		onFileAction(FileActionAdded, p)
+		return nil
+	})
+}
+
 func (w *Watcher) StopWatching() {
 	err := w.Watcher.Close()
 	if err == nil {
@@ -0,0 +1,198 @@
+package summary
+
+import (
+	"path/filepath"
+	"seanime/internal/library/anime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// ScanFileState is the state of a file after a scan.
+	// The states of the files are saved with each scan summary to compare scans.
+	ScanFileState struct {
+		ScanSummaryId uint                `json:"scanSummaryId"`
+		CreatedAt     time.Time           `json:"createdAt"`
+		Path          string              `json:"path"`
+		MediaId       int                 `json:"mediaId"`
+		Episode       int                 `json:"episode"`
+		AniDBEpisode  string              `json:"aniDBEpisode"`
+		Type          anime.LocalFileType `json:"type"`
+		Locked        bool                `json:"locked"`
+		Ignored       bool                `json:"ignored"`
+		IsAlternate   bool                `json:"isAlternate"`
+		Scanned       bool                `json:"scanned"` // The file was matched and hydrated during the scan, its logs are in the summary
+	}
+
+	// ScanFileHistoryEntry is the state of a file after a scan, and what changed since the previous scan.
+	ScanFileHistoryEntry struct {
+		*ScanFileState
+		Changes []*ScanFileChange `json:"changes"`
+		Logs    []*ScanSummaryLog `json:"logs"`
+	}
+
+	ScanFileChange struct {
+		Field string `json:"field"` // e.g. "mediaId", "episode"
+		From  string `json:"from"`
+		To    string `json:"to"`
+	}
+
+	// MediaFlip is a file that was matched to another media between two scans.
+	MediaFlip struct {
+		Path          string    `json:"path"`
+		ScanSummaryId uint      `json:"scanSummaryId"` // Scan after which the file was matched to ToMediaId
+		CreatedAt     time.Time `json:"createdAt"`
+		FromMediaId   int       `json:"fromMediaId"`
+		ToMediaId     int       `json:"toMediaId"`
+		FromEpisode   int       `json:"fromEpisode"`
+		ToEpisode     int       `json:"toEpisode"`
+	}
+)
+
+// NewScanFileStates returns the state of each local file after the scan.
+func NewScanFileStates(lfs []*anime.LocalFile, summary *ScanSummary) []*ScanFileState {
+	scanned := make(map[string]struct{})
+	if summary != nil {
+		for _, file := range summary.GetFiles() {
+			if file.LocalFile != nil {
+				scanned[file.LocalFile.GetNormalizedPath()] = struct{}{}
+			}
+		}
+	}
+
+	ret := make([]*ScanFileState, 0, len(lfs))
+	for _, lf := range lfs {
+		_, isScanned := scanned[lf.GetNormalizedPath()]
+		state := &ScanFileState{
+			Path:        lf.Path,
+			MediaId:     lf.MediaId,
+			Locked:      lf.IsLocked(),
+			Ignored:     lf.IsIgnored(),
+			IsAlternate: lf.IsAlternate(),
+			Scanned:     isScanned,
+		}
+		if lf.Metadata != nil {
+			state.Episode = lf.Metadata.Episode
+			state.AniDBEpisode = lf.Metadata.AniDBEpisode
+			state.Type = lf.Metadata.Type
+		}
+		ret = append(ret, state)
+	}
+	return ret
+}
+
+// GetFiles returns the files of the summary, matched and unmatched.
+func (s *ScanSummary) GetFiles() []*ScanSummaryFile {
+	ret := make([]*ScanSummaryFile, 0)
+	if s == nil {
+		return ret
+	}
+	for _, group := range s.Groups {
+		ret = append(ret, group.Files...)
+	}
+	return append(ret, s.UnmatchedFiles...)
+}
+
+// GetFileLogs returns the logs of the file in the summary.
+func (s *ScanSummary) GetFileLogs(path string) []*ScanSummaryLog {
+	for _, file := range s.GetFiles() {
+		if file.LocalFile != nil && file.LocalFile.HasSamePath(path) {
+			return file.Logs
+		}
+	}
+	return make([]*ScanSummaryLog, 0)
+}
+
+// NewScanFileHistory returns the history of a file from its states, from newest to oldest.
+// Each entry holds the changes since the previous state. The states can be in any order.
+func NewScanFileHistory(states []*ScanFileState) []*ScanFileHistoryEntry {
+	states = sortScanFileStates(states)
+
+	ret := make([]*ScanFileHistoryEntry, 0, len(states))
+	for i, state := range states {
+		entry := &ScanFileHistoryEntry{
+			ScanFileState: state,
+			Changes:       make([]*ScanFileChange, 0),
+			Logs:          make([]*ScanSummaryLog, 0),
+		}
+		if i > 0 {
+			entry.Changes = compareScanFileStates(states[i-1], state)
+		}
+		ret = append(ret, entry)
+	}
+
+	slices.Reverse(ret)
+	return ret
+}
+
+// FindMediaFlips returns the files that were matched to another media between two of their states, from newest to oldest.
+// Files that were unmatched in one of the states are not included.
+func FindMediaFlips(states []*ScanFileState) []*MediaFlip {
+	byPath := make(map[string][]*ScanFileState)
+	for _, state := range states {
+		key := NormalizeScanFilePath(state.Path)
+		byPath[key] = append(byPath[key], state)
+	}
+
+	ret := make([]*MediaFlip, 0)
+	for _, fileStates := range byPath {
+		fileStates = sortScanFileStates(fileStates)
+		for i := 1; i < len(fileStates); i++ {
+			prev, curr := fileStates[i-1], fileStates[i]
+			if prev.MediaId == 0 || curr.MediaId == 0 || prev.MediaId == curr.MediaId {
+				continue
+			}
+			ret = append(ret, &MediaFlip{
+				Path:          curr.Path,
+				ScanSummaryId: curr.ScanSummaryId,
+				CreatedAt:     curr.CreatedAt,
+				FromMediaId:   prev.MediaId,
+				ToMediaId:     curr.MediaId,
+				FromEpisode:   prev.Episode,
+				ToEpisode:     curr.Episode,
+			})
+		}
+	}
+
+	slices.SortStableFunc(ret, func(a, b *MediaFlip) int {
+		if a.ScanSummaryId != b.ScanSummaryId {
+			return int(b.ScanSummaryId) - int(a.ScanSummaryId)
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return ret
+}
+
+// NormalizeScanFilePath returns the path used to find the states of a file.
+func NormalizeScanFilePath(path string) string {
+	return filepath.ToSlash(strings.ToLower(path))
+}
+
+// sortScanFileStates returns the states from oldest to newest.
+func sortScanFileStates(states []*ScanFileState) []*ScanFileState {
+	ret := slices.Clone(states)
+	slices.SortStableFunc(ret, func(a, b *ScanFileState) int {
+		return int(a.ScanSummaryId) - int(b.ScanSummaryId)
+	})
+	return ret
+}
+
+func compareScanFileStates(prev, curr *ScanFileState) []*ScanFileChange {
+	ret := make([]*ScanFileChange, 0)
+	add := func(field string, from string, to string) {
+		if from != to {
+			ret = append(ret, &ScanFileChange{Field: field, From: from, To: to})
+		}
+	}
+	add("mediaId", strconv.Itoa(prev.MediaId), strconv.Itoa(curr.MediaId))
+	add("episode", strconv.Itoa(prev.Episode), strconv.Itoa(curr.Episode))
+	add("aniDBEpisode", prev.AniDBEpisode, curr.AniDBEpisode)
+	add("type", string(prev.Type), string(curr.Type))
+	add("locked", strconv.FormatBool(prev.Locked), strconv.FormatBool(curr.Locked))
+	add("ignored", strconv.FormatBool(prev.Ignored), strconv.FormatBool(curr.Ignored))
+	add("isAlternate", strconv.FormatBool(prev.IsAlternate), strconv.FormatBool(curr.IsAlternate))
+	return ret
+}
@@ -0,0 +1,57 @@
+package summary
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewScanFileHistory(t *testing.T) {
+
+	states := []*ScanFileState{
+		{ScanSummaryId: 3, Path: "E:/Anime/Show/Show - 01.mkv", MediaId: 2, Episode: 1, Locked: true},
+		{ScanSummaryId: 1, Path: "E:/Anime/Show/Show - 01.mkv", MediaId: 1, Episode: 1},
+		{ScanSummaryId: 2, Path: "E:/Anime/Show/Show - 01.mkv", MediaId: 2, Episode: 1},
+	}
+
+	history := NewScanFileHistory(states)
+	require.Len(t, history, 3)
+
+	// Newest first
+	assert.Equal(t, uint(3), history[0].ScanSummaryId)
+	assert.Equal(t, uint(2), history[1].ScanSummaryId)
+	assert.Equal(t, uint(1), history[2].ScanSummaryId)
+
+	require.Len(t, history[0].Changes, 1)
+	assert.Equal(t, &ScanFileChange{Field: "locked", From: "false", To: "true"}, history[0].Changes[0])
+
+	require.Len(t, history[1].Changes, 1)
+	assert.Equal(t, &ScanFileChange{Field: "mediaId", From: "1", To: "2"}, history[1].Changes[0])
+
+	// The oldest state has nothing to compare to
+	assert.Empty(t, history[2].Changes)
+}
+
+func TestFindMediaFlips(t *testing.T) {
+
+	states := []*ScanFileState{
+		{ScanSummaryId: 1, Path: "E:/Anime/Show/Show - 01.mkv", MediaId: 1, Episode: 1},
+		{ScanSummaryId: 2, Path: "E:/Anime/Show/Show - 01.mkv", MediaId: 2, Episode: 13},
+		{ScanSummaryId: 3, Path: "e:/anime/show/show - 01.mkv", MediaId: 2, Episode: 13},
+		// Unmatched in between, not a flip
+		{ScanSummaryId: 1, Path: "E:/Anime/Other/Other - 01.mkv", MediaId: 3, Episode: 1},
+		{ScanSummaryId: 2, Path: "E:/Anime/Other/Other - 01.mkv", MediaId: 0},
+		{ScanSummaryId: 3, Path: "E:/Anime/Other/Other - 01.mkv", MediaId: 4, Episode: 1},
+		{ScanSummaryId: 3, Path: "E:/Anime/Movie/Movie.mkv", MediaId: 5, Episode: 1},
+	}
+
+	flips := FindMediaFlips(states)
+	require.Len(t, flips, 1)
+
+	assert.Equal(t, "E:/Anime/Show/Show - 01.mkv", flips[0].Path)
+	assert.Equal(t, uint(2), flips[0].ScanSummaryId)
+	assert.Equal(t, 1, flips[0].FromMediaId)
+	assert.Equal(t, 2, flips[0].ToMediaId)
+	assert.Equal(t, 1, flips[0].FromEpisode)
+	assert.Equal(t, 13, flips[0].ToEpisode)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/google/uuid"
 	"seanime/internal/api/anilist"
 	"seanime/internal/library/anime"
+	"sync"
 )
 
 const (
@@ -24,6 +25,12 @@ const (
 	LogMetadataMain
 	LogMetadataHydrated
 	LogPanic
+	LogFolderOverride
+	LogExtensionMatch
+	LogHashIdentified
+	LogNfo
+	LogAlternate
+	LogMetadataAltEpisode
 )
 
 type (
@@ -34,6 +41,7 @@ type (
 		LocalFiles      []*anime.LocalFile
 		AllMedia        []*anime.NormalizedMedia
 		AnimeCollection *anilist.AnimeCollectionWithRelations
+		mu              sync.Mutex // Logs are added by parallel stages of the scan
 	}
 
 	ScanSummaryLog struct { // Holds a log entry. The log entry will then be used to generate a ScanSummary.
@@ -280,6 +288,54 @@ func (l *ScanSummaryLogger) LogMetadataHydrated(lf *anime.LocalFile, mediaId int
 	l.logType(LogMetadataHydrated, lf, msg)
 }
 
+func (l *ScanSummaryLogger) LogFolderOverride(lf *anime.LocalFile, detail string) {
+	if l == nil {
+		return
+	}
+	msg := fmt.Sprintf("Folder override applied: %s", detail)
+	l.logType(LogFolderOverride, lf, msg)
+}
+
+func (l *ScanSummaryLogger) LogExtensionMatch(lf *anime.LocalFile, extensionId string, mediaId int, confidence float64) {
+	if l == nil {
+		return
+	}
+	msg := fmt.Sprintf("Matched to media %d by extension %s (confidence: %.2f)", mediaId, extensionId, confidence)
+	l.logType(LogExtensionMatch, lf, msg)
+}
+
+func (l *ScanSummaryLogger) LogHashIdentified(lf *anime.LocalFile, mediaId int, aniDBEpisode string) {
+	if l == nil {
+		return
+	}
+	msg := fmt.Sprintf("Identified by hash as episode %s of media %d", aniDBEpisode, mediaId)
+	l.logType(LogHashIdentified, lf, msg)
+}
+
+func (l *ScanSummaryLogger) LogNfo(lf *anime.LocalFile, detail string) {
+	if l == nil {
+		return
+	}
+	msg := fmt.Sprintf("NFO file applied: %s", detail)
+	l.logType(LogNfo, lf, msg)
+}
+
+func (l *ScanSummaryLogger) LogAlternate(lf *anime.LocalFile, preferred *anime.LocalFile) {
+	if l == nil {
+		return
+	}
+	msg := fmt.Sprintf("Alternate release of episode %s, \"%s\" is preferred", lf.GetAniDBEpisode(), preferred.Name)
+	l.logType(LogAlternate, lf, msg)
+}
+
+func (l *ScanSummaryLogger) LogMetadataAltEpisode(lf *anime.LocalFile, episode int, altEpisode int, aniDBEpisode string) {
+	if l == nil {
+		return
+	}
+	msg := fmt.Sprintf("Episode %d does not fit the media, used alternate episode number. Episode %d. AniDB episode %s", episode, altEpisode, aniDBEpisode)
+	l.logType(LogMetadataAltEpisode, lf, msg)
+}
+
 func (l *ScanSummaryLogger) logType(logType LogType, lf *anime.LocalFile, message string) {
 	if l == nil {
 		return
@@ -317,6 +373,18 @@ func (l *ScanSummaryLogger) logType(logType LogType, lf *anime.LocalFile, messag
 		l.log(lf, "warning", message)
 	case LogPanic:
 		l.log(lf, "error", message)
+	case LogFolderOverride:
+		l.log(lf, "info", message)
+	case LogExtensionMatch:
+		l.log(lf, "info", message)
+	case LogHashIdentified:
+		l.log(lf, "info", message)
+	case LogNfo:
+		l.log(lf, "info", message)
+	case LogAlternate:
+		l.log(lf, "warning", message)
+	case LogMetadataAltEpisode:
+		l.log(lf, "info", message)
 	}
 }
 
@@ -324,6 +392,8 @@ func (l *ScanSummaryLogger) log(lf *anime.LocalFile, level string, message strin
 	if l == nil {
 		return
 	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.Logs = append(l.Logs, &ScanSummaryLog{
 		ID:       uuid.NewString(),
 		FilePath: lf.Path,
@@ -0,0 +1,373 @@
+package listexport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/samber/lo"
+	"math"
+	"seanime/internal/api/anilist"
+	"strconv"
+	"strings"
+)
+
+func toJson(archive *Archive) ([]byte, error) {
+	return json.MarshalIndent(archive, "", "  ")
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type (
+	// entry is a list entry with its library facts, used by the flat formats.
+	entry struct {
+		mediaType   MediaType
+		mediaId     int
+		malId       int
+		title       string
+		format      string
+		total       int
+		status      anilist.MediaListStatus
+		score       int // 0-100
+		progress    int
+		repeat      int
+		startedAt   anilist.IFuzzyDate
+		completedAt anilist.IFuzzyDate
+		library     *LibraryEntry // nil if no files are on disk
+	}
+)
+
+// getEntries flattens the collections, skipping duplicate entries from custom lists.
+func getEntries(animeCollection *anilist.AnimeCollection, mangaCollection *anilist.MangaCollection, library map[int]*LibraryEntry) []*entry {
+	ret := make([]*entry, 0)
+
+	if animeCollection != nil && animeCollection.MediaListCollection != nil {
+		seen := make(map[int]struct{})
+		for _, list := range animeCollection.MediaListCollection.Lists {
+			for _, e := range list.GetEntries() {
+				media := e.GetMedia()
+				if media == nil {
+					continue
+				}
+				if _, ok := seen[media.GetID()]; ok {
+					continue
+				}
+				seen[media.GetID()] = struct{}{}
+				ret = append(ret, &entry{
+					mediaType:   MediaTypeAnime,
+					mediaId:     media.GetID(),
+					malId:       lo.FromPtr(media.GetIDMal()),
+					title:       media.GetPreferredTitle(),
+					format:      lo.FromPtr((*string)(media.GetFormat())),
+					total:       lo.FromPtr(media.GetEpisodes()),
+					status:      anilist.StatusOrPlanning(e.GetStatus()),
+					score:       anilist.RoundedScore(e.GetScore()),
+					progress:    lo.FromPtr(e.GetProgress()),
+					repeat:      lo.FromPtr(e.GetRepeat()),
+					startedAt:   e.GetStartedAt(),
+					completedAt: e.GetCompletedAt(),
+					library:     library[media.GetID()],
+				})
+			}
+		}
+	}
+
+	if mangaCollection != nil && mangaCollection.MediaListCollection != nil {
+		seen := make(map[int]struct{})
+		for _, list := range mangaCollection.MediaListCollection.Lists {
+			for _, e := range list.GetEntries() {
+				media := e.GetMedia()
+				if media == nil {
+					continue
+				}
+				if _, ok := seen[media.GetID()]; ok {
+					continue
+				}
+				seen[media.GetID()] = struct{}{}
+				ret = append(ret, &entry{
+					mediaType:   MediaTypeManga,
+					mediaId:     media.GetID(),
+					malId:       lo.FromPtr(media.GetIDMal()),
+					title:       media.GetPreferredTitle(),
+					format:      lo.FromPtr((*string)(media.GetFormat())),
+					total:       lo.FromPtr(media.GetChapters()),
+					status:      anilist.StatusOrPlanning(e.GetStatus()),
+					score:       anilist.RoundedScore(e.GetScore()),
+					progress:    lo.FromPtr(e.GetProgress()),
+					repeat:      lo.FromPtr(e.GetRepeat()),
+					startedAt:   e.GetStartedAt(),
+					completedAt: e.GetCompletedAt(),
+				})
+			}
+		}
+	}
+
+	return ret
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+var csvHeader = []string{
+	"type",
+	"anilist_id",
+	"mal_id",
+	"title",
+	"format",
+	"status",
+	"score",
+	"progress",
+	"total",
+	"repeat",
+	"started_at",
+	"completed_at",
+	"episodes_on_disk",
+	"file_count",
+	"release_groups",
+	"paths",
+}
+
+// toCsv returns one row per list entry.
+// Multiple values in a cell are separated by semicolons.
+func toCsv(animeCollection *anilist.AnimeCollection, mangaCollection *anilist.MangaCollection, library map[int]*LibraryEntry) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+
+	for _, e := range getEntries(animeCollection, mangaCollection, library) {
+		var episodesOnDisk, fileCount, releaseGroups, paths string
+		if e.library != nil {
+			episodesOnDisk = formatEpisodeRanges(e.library.Episodes)
+			fileCount = strconv.Itoa(len(e.library.Files))
+			releaseGroups = strings.Join(e.library.ReleaseGroups, ";")
+			filePaths := make([]string, 0, len(e.library.Files))
+			for _, f := range e.library.Files {
+				filePaths = append(filePaths, f.Path)
+			}
+			paths = strings.Join(filePaths, ";")
+		}
+
+		err := w.Write([]string{
+			string(e.mediaType),
+			strconv.Itoa(e.mediaId),
+			formatOptionalInt(e.malId),
+			e.title,
+			e.format,
+			string(e.status),
+			strconv.Itoa(e.score),
+			strconv.Itoa(e.progress),
+			formatOptionalInt(e.total),
+			strconv.Itoa(e.repeat),
+			formatDate(e.startedAt, ""),
+			formatDate(e.completedAt, ""),
+			episodesOnDisk,
+			fileCount,
+			releaseGroups,
+			paths,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// formatEpisodeRanges returns the sorted episode numbers as ranges, e.g. "1-12;14".
+func formatEpisodeRanges(episodes []int) string {
+	ranges := make([]string, 0)
+	for i := 0; i < len(episodes); {
+		j := i
+		for j+1 < len(episodes) && episodes[j+1] == episodes[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(episodes[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", episodes[i], episodes[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, ";")
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type (
+	malExport struct {
+		XMLName xml.Name          `xml:"myanimelist"`
+		MyInfo  *malExportMyInfo  `xml:"myinfo,omitempty"`
+		Anime   []*malExportAnime `xml:"anime"`
+		Manga   []*malExportManga `xml:"manga"`
+	}
+
+	malExportMyInfo struct {
+		ExportType int `xml:"user_export_type"` // 1 for anime, 2 for manga
+	}
+
+	malExportAnime struct {
+		ID              int    `xml:"series_animedb_id"`
+		Title           cdata  `xml:"series_title"`
+		Type            string `xml:"series_type"`
+		Episodes        int    `xml:"series_episodes"`
+		MyID            int    `xml:"my_id"`
+		WatchedEpisodes int    `xml:"my_watched_episodes"`
+		StartDate       string `xml:"my_start_date"`
+		FinishDate      string `xml:"my_finish_date"`
+		Score           int    `xml:"my_score"`
+		DownloadedEps   int    `xml:"my_downloaded_eps"`
+		Storage         string `xml:"my_storage"`
+		Status          string `xml:"my_status"`
+		Comments        cdata  `xml:"my_comments"`
+		TimesWatched    int    `xml:"my_times_watched"`
+		Tags            cdata  `xml:"my_tags"`
+		Rewatching      int    `xml:"my_rewatching"`
+		UpdateOnImport  int    `xml:"update_on_import"`
+	}
+
+	malExportManga struct {
+		ID             int    `xml:"manga_mangadb_id"`
+		Title          cdata  `xml:"manga_title"`
+		Chapters       int    `xml:"manga_chapters"`
+		MyID           int    `xml:"my_id"`
+		ReadChapters   int    `xml:"my_read_chapters"`
+		StartDate      string `xml:"my_start_date"`
+		FinishDate     string `xml:"my_finish_date"`
+		Score          int    `xml:"my_score"`
+		Status         string `xml:"my_status"`
+		TimesRead      int    `xml:"my_times_read"`
+		Rereading      int    `xml:"my_rereading"`
+		UpdateOnImport int    `xml:"update_on_import"`
+	}
+
+	cdata struct {
+		Value string `xml:",cdata"`
+	}
+)
+
+// toMalXml returns a MyAnimeList export.
+// Entries without a MyAnimeList ID are skipped. The number of episodes on disk is stored in "my_downloaded_eps"
+// and the release groups in "my_tags".
+func toMalXml(animeCollection *anilist.AnimeCollection, mangaCollection *anilist.MangaCollection, library map[int]*LibraryEntry) ([]byte, error) {
+	export := &malExport{
+		Anime: make([]*malExportAnime, 0),
+		Manga: make([]*malExportManga, 0),
+	}
+	switch {
+	case animeCollection != nil && mangaCollection == nil:
+		export.MyInfo = &malExportMyInfo{ExportType: 1}
+	case animeCollection == nil && mangaCollection != nil:
+		export.MyInfo = &malExportMyInfo{ExportType: 2}
+	}
+
+	for _, e := range getEntries(animeCollection, mangaCollection, library) {
+		if e.malId == 0 {
+			continue
+		}
+		status, isRepeating := toMalStatus(e.status, e.mediaType)
+		switch e.mediaType {
+		case MediaTypeAnime:
+			a := &malExportAnime{
+				ID:              e.malId,
+				Title:           cdata{e.title},
+				Type:            e.format,
+				Episodes:        e.total,
+				WatchedEpisodes: e.progress,
+				StartDate:       formatDate(e.startedAt, "0000-00-00"),
+				FinishDate:      formatDate(e.completedAt, "0000-00-00"),
+				Score:           toMalScore(e.score),
+				Status:          status,
+				TimesWatched:    e.repeat,
+				Rewatching:      boolToInt(isRepeating),
+				UpdateOnImport:  1,
+			}
+			if e.library != nil {
+				a.DownloadedEps = len(e.library.Episodes)
+				a.Storage = "Hard Drive"
+				a.Tags = cdata{strings.Join(e.library.ReleaseGroups, ", ")}
+			}
+			export.Anime = append(export.Anime, a)
+		case MediaTypeManga:
+			export.Manga = append(export.Manga, &malExportManga{
+				ID:             e.malId,
+				Title:          cdata{e.title},
+				Chapters:       e.total,
+				ReadChapters:   e.progress,
+				StartDate:      formatDate(e.startedAt, "0000-00-00"),
+				FinishDate:     formatDate(e.completedAt, "0000-00-00"),
+				Score:          toMalScore(e.score),
+				Status:         status,
+				TimesRead:      e.repeat,
+				Rereading:      boolToInt(isRepeating),
+				UpdateOnImport: 1,
+			})
+		}
+	}
+
+	data, err := xml.MarshalIndent(export, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
+
+// toMalStatus returns the status as displayed in MyAnimeList exports.
+func toMalStatus(status anilist.MediaListStatus, mediaType MediaType) (ret string, isRepeating bool) {
+	switch status {
+	case anilist.MediaListStatusCurrent, anilist.MediaListStatusRepeating:
+		ret = "Watching"
+		if mediaType == MediaTypeManga {
+			ret = "Reading"
+		}
+		return ret, status == anilist.MediaListStatusRepeating
+	case anilist.MediaListStatusCompleted:
+		return "Completed", false
+	case anilist.MediaListStatusPaused:
+		return "On-Hold", false
+	case anilist.MediaListStatusDropped:
+		return "Dropped", false
+	default:
+		if mediaType == MediaTypeManga {
+			return "Plan to Read", false
+		}
+		return "Plan to Watch", false
+	}
+}
+
+// toMalScore converts a raw AniList score (0-100) to a MyAnimeList score (0-10).
+func toMalScore(scoreRaw int) int {
+	return int(math.Round(float64(scoreRaw) / 10))
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// formatDate returns the date as "YYYY-MM-DD", where unknown parts are zeroes.
+func formatDate(d anilist.IFuzzyDate, empty string) string {
+	if d == nil || d.GetYear() == nil || *d.GetYear() <= 0 {
+		return empty
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", *d.GetYear(), lo.FromPtr(d.GetMonth()), lo.FromPtr(d.GetDay()))
+}
+
+func formatOptionalInt(i int) string {
+	if i <= 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
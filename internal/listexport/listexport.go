@@ -0,0 +1,200 @@
+package listexport
+
+import (
+	"errors"
+	"fmt"
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	FormatMalXml Format = "mal_xml" // Can be imported on MyAnimeList and by Seanime
+	FormatCsv    Format = "csv"     // One row per list entry, for spreadsheets
+	FormatJson   Format = "json"    // Full archive, can be imported by Seanime
+
+	MediaTypeAnime MediaType = "anime"
+	MediaTypeManga MediaType = "manga"
+
+	// archiveVersion is incremented when the JSON archive changes in a breaking way.
+	archiveVersion = 1
+)
+
+var (
+	ErrUnknownFormat = errors.New("list export: Unknown format")
+)
+
+type (
+	Format    string
+	MediaType string
+
+	ExportOptions struct {
+		Format          Format
+		MediaType       MediaType // Only export this media type, both are exported if empty
+		AnimeCollection *anilist.AnimeCollection
+		MangaCollection *anilist.MangaCollection
+		LocalFiles      []*anime.LocalFile
+	}
+
+	// Export is a serialized export file.
+	Export struct {
+		Data        []byte
+		ContentType string
+		Filename    string
+	}
+
+	// Archive is the content of the JSON export.
+	// The "anime" and "manga" collections can be imported with the list import.
+	Archive struct {
+		Version    int                      `json:"version"`
+		ExportedAt time.Time                `json:"exportedAt"`
+		Anime      *anilist.AnimeCollection `json:"anime,omitempty"`
+		Manga      *anilist.MangaCollection `json:"manga,omitempty"`
+		Library    []*LibraryEntry          `json:"library,omitempty"`
+	}
+
+	// LibraryEntry is what the local library holds for a media.
+	LibraryEntry struct {
+		MediaID       int            `json:"mediaId"`
+		Episodes      []int          `json:"episodes"` // Main episodes on disk, sorted
+		ReleaseGroups []string       `json:"releaseGroups"`
+		Files         []*LibraryFile `json:"files"`
+	}
+
+	LibraryFile struct {
+		Path         string              `json:"path"`
+		Episode      int                 `json:"episode"`
+		Type         anime.LocalFileType `json:"type"`
+		ReleaseGroup string              `json:"releaseGroup,omitempty"`
+	}
+)
+
+// ParseFormat returns the format, defaulting to JSON.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatMalXml, FormatCsv, FormatJson:
+		return Format(s), nil
+	case "":
+		return FormatJson, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// NewExport serializes the collections and the local library in the given format.
+func NewExport(opts *ExportOptions) (*Export, error) {
+	now := time.Now()
+
+	animeCollection, mangaCollection := opts.AnimeCollection, opts.MangaCollection
+	switch opts.MediaType {
+	case MediaTypeAnime:
+		mangaCollection = nil
+	case MediaTypeManga:
+		animeCollection = nil
+	}
+
+	library := make(map[int]*LibraryEntry)
+	if animeCollection != nil {
+		library = getLibraryEntries(opts.LocalFiles)
+	}
+
+	ret := &Export{}
+	var err error
+	switch opts.Format {
+	case FormatMalXml:
+		ret.Data, err = toMalXml(animeCollection, mangaCollection, library)
+		ret.ContentType = "application/xml"
+	case FormatCsv:
+		ret.Data, err = toCsv(animeCollection, mangaCollection, library)
+		ret.ContentType = "text/csv"
+	case FormatJson:
+		ret.Data, err = toJson(&Archive{
+			Version:    archiveVersion,
+			ExportedAt: now,
+			Anime:      animeCollection,
+			Manga:      mangaCollection,
+			Library:    sortedLibraryEntries(library),
+		})
+		ret.ContentType = "application/json"
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ext := string(opts.Format)
+	if opts.Format == FormatMalXml {
+		ext = "xml"
+	}
+	ret.Filename = fmt.Sprintf("seanime-export-%s.%s", now.Format("2006-01-02"), ext)
+
+	return ret, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// getLibraryEntries groups the matched local files by media.
+func getLibraryEntries(lfs []*anime.LocalFile) map[int]*LibraryEntry {
+	ret := make(map[int]*LibraryEntry)
+	for _, lf := range lfs {
+		if lf == nil || lf.MediaId == 0 || lf.Metadata == nil {
+			continue
+		}
+
+		entry, ok := ret[lf.MediaId]
+		if !ok {
+			entry = &LibraryEntry{
+				MediaID:       lf.MediaId,
+				Episodes:      make([]int, 0),
+				ReleaseGroups: make([]string, 0),
+				Files:         make([]*LibraryFile, 0),
+			}
+			ret[lf.MediaId] = entry
+		}
+
+		file := &LibraryFile{
+			Path:    lf.GetPath(),
+			Episode: lf.GetEpisodeNumber(),
+			Type:    lf.GetType(),
+		}
+		if lf.ParsedData != nil {
+			file.ReleaseGroup = lf.ParsedData.ReleaseGroup
+		}
+		entry.Files = append(entry.Files, file)
+
+		if file.Type == anime.LocalFileTypeMain && !slices.Contains(entry.Episodes, file.Episode) {
+			entry.Episodes = append(entry.Episodes, file.Episode)
+		}
+		if file.ReleaseGroup != "" && !slices.Contains(entry.ReleaseGroups, file.ReleaseGroup) {
+			entry.ReleaseGroups = append(entry.ReleaseGroups, file.ReleaseGroup)
+		}
+	}
+
+	for _, entry := range ret {
+		slices.Sort(entry.Episodes)
+		slices.SortFunc(entry.Files, func(a, b *LibraryFile) int {
+			if a.Type != b.Type {
+				return strings.Compare(string(a.Type), string(b.Type))
+			}
+			if a.Episode != b.Episode {
+				return a.Episode - b.Episode
+			}
+			return strings.Compare(a.Path, b.Path)
+		})
+	}
+
+	return ret
+}
+
+func sortedLibraryEntries(library map[int]*LibraryEntry) []*LibraryEntry {
+	ret := make([]*LibraryEntry, 0, len(library))
+	for _, entry := range library {
+		ret = append(ret, entry)
+	}
+	slices.SortFunc(ret, func(a, b *LibraryEntry) int {
+		return a.MediaID - b.MediaID
+	})
+	return ret
+}
@@ -0,0 +1,140 @@
+package listexport
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"github.com/goccy/go-json"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seanime/internal/api/anilist"
+	"seanime/internal/library/anime"
+	"strings"
+	"testing"
+)
+
+func newTestExportOptions(format Format) *ExportOptions {
+	return &ExportOptions{
+		Format: format,
+		AnimeCollection: &anilist.AnimeCollection{
+			MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+				Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{{
+					Entries: []*anilist.AnimeCollection_MediaListCollection_Lists_Entries{
+						{
+							Status:    lo.ToPtr(anilist.MediaListStatusRepeating),
+							Progress:  lo.ToPtr(3),
+							Score:     lo.ToPtr(86.0),
+							StartedAt: &anilist.AnimeCollection_MediaListCollection_Lists_Entries_StartedAt{Year: lo.ToPtr(2024), Month: lo.ToPtr(5)},
+							Media: &anilist.BaseAnime{
+								ID:       1,
+								IDMal:    lo.ToPtr(1),
+								Episodes: lo.ToPtr(26),
+								Format:   lo.ToPtr(anilist.MediaFormatTv),
+								Title:    &anilist.BaseAnime_Title{UserPreferred: lo.ToPtr("Cowboy Bebop")},
+							},
+						},
+						{
+							Status: lo.ToPtr(anilist.MediaListStatusPlanning),
+							Media:  &anilist.BaseAnime{ID: 2, Title: &anilist.BaseAnime_Title{UserPreferred: lo.ToPtr("Not on MAL")}},
+						},
+					},
+				}},
+			},
+		},
+		MangaCollection: &anilist.MangaCollection{
+			MediaListCollection: &anilist.MangaCollection_MediaListCollection{
+				Lists: []*anilist.MangaCollection_MediaListCollection_Lists{{
+					Entries: []*anilist.MangaCollection_MediaListCollection_Lists_Entries{{
+						Status:   lo.ToPtr(anilist.MediaListStatusCurrent),
+						Progress: lo.ToPtr(100),
+						Media:    &anilist.BaseManga{ID: 30002, IDMal: lo.ToPtr(2), Title: &anilist.BaseManga_Title{UserPreferred: lo.ToPtr("Berserk")}},
+					}},
+				}},
+			},
+		},
+		LocalFiles: []*anime.LocalFile{
+			newTestLocalFile("/anime/Bebop/[Group] Cowboy Bebop - 02.mkv", 1, 2, anime.LocalFileTypeMain, "Group"),
+			newTestLocalFile("/anime/Bebop/[Group] Cowboy Bebop - 01.mkv", 1, 1, anime.LocalFileTypeMain, "Group"),
+			newTestLocalFile("/anime/Bebop/[Other] Cowboy Bebop - 04.mkv", 1, 4, anime.LocalFileTypeMain, "Other"),
+			newTestLocalFile("/anime/Bebop/[Other] Cowboy Bebop - NCOP.mkv", 1, 1, anime.LocalFileTypeNC, "Other"),
+			newTestLocalFile("/anime/Unmatched.mkv", 0, 1, anime.LocalFileTypeMain, ""),
+		},
+	}
+}
+
+func newTestLocalFile(path string, mediaId int, episode int, lfType anime.LocalFileType, releaseGroup string) *anime.LocalFile {
+	return &anime.LocalFile{
+		Path:       path,
+		MediaId:    mediaId,
+		ParsedData: &anime.LocalFileParsedData{ReleaseGroup: releaseGroup},
+		Metadata:   &anime.LocalFileMetadata{Episode: episode, Type: lfType},
+	}
+}
+
+func TestNewExport_Json(t *testing.T) {
+	export, err := NewExport(newTestExportOptions(FormatJson))
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", export.ContentType)
+
+	var archive Archive
+	require.NoError(t, json.Unmarshal(export.Data, &archive))
+	assert.Equal(t, archiveVersion, archive.Version)
+	assert.Len(t, archive.Anime.GetMediaListCollection().GetLists()[0].GetEntries(), 2)
+
+	require.Len(t, archive.Library, 1)
+	assert.Equal(t, 1, archive.Library[0].MediaID)
+	assert.Equal(t, []int{1, 2, 4}, archive.Library[0].Episodes)
+	assert.Equal(t, []string{"Group", "Other"}, archive.Library[0].ReleaseGroups)
+	assert.Len(t, archive.Library[0].Files, 4)
+	assert.Equal(t, "/anime/Bebop/[Group] Cowboy Bebop - 01.mkv", archive.Library[0].Files[0].Path)
+}
+
+func TestNewExport_Csv(t *testing.T) {
+	export, err := NewExport(newTestExportOptions(FormatCsv))
+	require.NoError(t, err)
+
+	rows, err := csv.NewReader(strings.NewReader(string(export.Data))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	assert.Equal(t, csvHeader, rows[0])
+	assert.Equal(t, []string{
+		"anime", "1", "1", "Cowboy Bebop", "TV", "REPEATING", "86", "3", "26", "0", "2024-05-00", "",
+		"1-2;4", "4", "Group;Other",
+		"/anime/Bebop/[Group] Cowboy Bebop - 01.mkv;/anime/Bebop/[Group] Cowboy Bebop - 02.mkv;/anime/Bebop/[Other] Cowboy Bebop - 04.mkv;/anime/Bebop/[Other] Cowboy Bebop - NCOP.mkv",
+	}, rows[1])
+	assert.Equal(t, "manga", rows[3][0])
+	assert.Equal(t, "", rows[3][12]) // No library facts for manga
+}
+
+func TestNewExport_MalXml(t *testing.T) {
+	opts := newTestExportOptions(FormatMalXml)
+	opts.MediaType = MediaTypeAnime
+
+	export, err := NewExport(opts)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(export.Filename, "seanime-export-"))
+	assert.True(t, strings.HasSuffix(export.Filename, ".xml"))
+
+	var res malExport
+	require.NoError(t, xml.Unmarshal(export.Data, &res))
+	require.NotNil(t, res.MyInfo)
+	assert.Equal(t, 1, res.MyInfo.ExportType)
+	assert.Empty(t, res.Manga)
+
+	// The entry without a MyAnimeList ID is skipped
+	require.Len(t, res.Anime, 1)
+	a := res.Anime[0]
+	assert.Equal(t, "Cowboy Bebop", a.Title.Value)
+	assert.Equal(t, "Watching", a.Status)
+	assert.Equal(t, 1, a.Rewatching)
+	assert.Equal(t, 9, a.Score)
+	assert.Equal(t, "2024-05-00", a.StartDate)
+	assert.Equal(t, "0000-00-00", a.FinishDate)
+	assert.Equal(t, 3, a.DownloadedEps)
+}
+
+func TestFormatEpisodeRanges(t *testing.T) {
+	assert.Equal(t, "", formatEpisodeRanges(nil))
+	assert.Equal(t, "1", formatEpisodeRanges([]int{1}))
+	assert.Equal(t, "1-3;5;7-8", formatEpisodeRanges([]int{1, 2, 3, 5, 7, 8}))
+}
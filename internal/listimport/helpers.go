@@ -0,0 +1,7 @@
+package listimport
+
+import "fmt"
+
+func getKey(mediaType MediaType, mediaId int) string {
+	return fmt.Sprintf("%s-%d", mediaType, mediaId)
+}
@@ -0,0 +1,388 @@
+package listimport
+
+import (
+	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+	"seanime/internal/api/anilist"
+	"seanime/internal/api/mappings"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/util/limiter"
+	"slices"
+	"sync"
+)
+
+const (
+	ActionAdd        Action = "add"        // The entry is not in the list and will be added
+	ActionUpdate     Action = "update"     // The entry is in the list and will be overwritten
+	ActionConflict   Action = "conflict"   // The entry is in the list with different data and will be left untouched
+	ActionUnchanged  Action = "unchanged"  // The entry is already in the list with the same data
+	ActionUnresolved Action = "unresolved" // The entry could not be matched to an AniList media
+	ActionFailed     Action = "failed"     // The entry could not be written to the list
+
+	// compoundQueryChunkSize is the number of media resolved by a single AniList request.
+	compoundQueryChunkSize = 50
+)
+
+type (
+	Action string
+
+	// Importer imports list entries from export files into the platform used by the app.
+	// This allows users to migrate their history, e.g. from MyAnimeList to the local platform.
+	Importer struct {
+		logger                 *zerolog.Logger
+		platform               platform.Platform
+		refreshCollectionsFunc func()
+		animeLists             mo.Option[*mappings.ReducedAnimeListResponse] // Used to resolve MyAnimeList anime IDs without querying AniList
+		animeListsMu           sync.Mutex
+		anilistLimiter         *limiter.Limiter
+		mu                     sync.Mutex
+		// Overridden in tests
+		fetchAnimeListsFunc    func() (*mappings.ReducedAnimeListResponse, error)
+		fetchAnimeByMalIdsFunc func(malIds []int) (map[int]*anilist.BaseAnime, error)
+		fetchMangaByMalIdsFunc func(malIds []int) (map[int]*anilist.BaseManga, error)
+	}
+
+	NewImporterOptions struct {
+		Logger                 *zerolog.Logger
+		Platform               platform.Platform // Platform the entries are written to
+		RefreshCollectionsFunc func()            // This function is called after entries are imported
+	}
+
+	ImportOptions struct {
+		Format    Format // Detected from the content if empty
+		DryRun    bool   // Only return the report
+		Overwrite bool   // Overwrite entries that are already in the list with different data
+	}
+
+	// Report describes what an import did, or would do in dry-run mode.
+	Report struct {
+		Format  Format         `json:"format"`
+		DryRun  bool           `json:"dryRun"`
+		Counts  map[Action]int `json:"counts"`
+		Entries []*ReportEntry `json:"entries"`
+	}
+
+	ReportEntry struct {
+		Action  Action   `json:"action"`
+		Entry   *Entry   `json:"entry"`
+		Current *Entry   `json:"current,omitempty"` // Entry currently in the list
+		Fields  []string `json:"fields,omitempty"`  // Fields that differ from the current entry
+		Error   string   `json:"error,omitempty"`
+	}
+)
+
+func New(opts *NewImporterOptions) *Importer {
+	return &Importer{
+		logger:                 opts.Logger,
+		platform:               opts.Platform,
+		refreshCollectionsFunc: opts.RefreshCollectionsFunc,
+		animeLists:             mo.None[*mappings.ReducedAnimeListResponse](),
+		anilistLimiter:         limiter.NewAnilistLimiter(),
+		fetchAnimeListsFunc:    mappings.GetReducedAnimeLists,
+		fetchAnimeByMalIdsFunc: anilist.FetchBaseAnimeMapByMalIDs,
+		fetchMangaByMalIdsFunc: anilist.FetchBaseMangaMapByMalIDs,
+	}
+}
+
+// Import reads the export file and writes its entries to the platform.
+// Entries are matched to AniList media using their AniList ID or, for MyAnimeList exports, their MyAnimeList ID.
+func (i *Importer) Import(data []byte, opts *ImportOptions) (*Report, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	format, err := ParseFormat(string(opts.Format), data)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := parseEntries(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("list import: Failed to parse file: %w", err)
+	}
+
+	i.logger.Debug().Str("format", string(format)).Int("count", len(entries)).Msg("list import: Parsed file")
+
+	i.resolveEntries(entries)
+
+	current, err := i.getCurrentEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Format:  format,
+		DryRun:  opts.DryRun,
+		Counts:  make(map[Action]int),
+		Entries: make([]*ReportEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		report.Entries = append(report.Entries, getReportEntry(entry, current, opts.Overwrite))
+	}
+
+	if !opts.DryRun {
+		i.apply(report.Entries)
+	}
+
+	for _, re := range report.Entries {
+		report.Counts[re.Action]++
+	}
+
+	i.logger.Info().
+		Bool("dryRun", opts.DryRun).
+		Int("added", report.Counts[ActionAdd]).
+		Int("updated", report.Counts[ActionUpdate]).
+		Int("conflicts", report.Counts[ActionConflict]).
+		Int("unresolved", report.Counts[ActionUnresolved]).
+		Int("failed", report.Counts[ActionFailed]).
+		Msg("list import: Import completed")
+
+	return report, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// apply writes the entries that should be added or updated.
+// New planning entries without any data are added in bulk.
+func (i *Importer) apply(entries []*ReportEntry) {
+	planning := make([]int, 0)
+	written := 0
+
+	for _, re := range entries {
+		if re.Action != ActionAdd && re.Action != ActionUpdate {
+			continue
+		}
+
+		e := re.Entry
+		if re.Action == ActionAdd && isBlankPlanningEntry(e) {
+			planning = append(planning, e.MediaID)
+			continue
+		}
+
+		err := i.platform.UpdateEntry(e.MediaID, &e.Status, &e.Score, &e.Progress, e.StartedAt, e.CompletedAt)
+		if err != nil {
+			i.logger.Warn().Err(err).Int("mediaId", e.MediaID).Msg("list import: Failed to write entry")
+			re.Action = ActionFailed
+			re.Error = err.Error()
+			continue
+		}
+		written++
+	}
+
+	if len(planning) > 0 {
+		if err := i.platform.AddMediaToCollection(planning); err != nil {
+			i.logger.Warn().Err(err).Msg("list import: Failed to add media to planning list")
+			for _, re := range entries {
+				if re.Action == ActionAdd && slices.Contains(planning, re.Entry.MediaID) {
+					re.Action = ActionFailed
+					re.Error = err.Error()
+				}
+			}
+		} else {
+			written += len(planning)
+		}
+	}
+
+	if written > 0 && i.refreshCollectionsFunc != nil {
+		i.refreshCollectionsFunc()
+	}
+}
+
+// getReportEntry compares the imported entry with the current list entry.
+// Dates that are missing from the imported entry are not compared since they are never cleared.
+func getReportEntry(entry *Entry, current map[string]*Entry, overwrite bool) *ReportEntry {
+	ret := &ReportEntry{Entry: entry}
+
+	if entry.MediaID == 0 {
+		ret.Action = ActionUnresolved
+		return ret
+	}
+
+	cur, found := current[getKey(entry.MediaType, entry.MediaID)]
+	if !found {
+		ret.Action = ActionAdd
+		return ret
+	}
+	ret.Current = cur
+
+	if cur.Status != entry.Status {
+		ret.Fields = append(ret.Fields, "status")
+	}
+	if cur.Progress != entry.Progress {
+		ret.Fields = append(ret.Fields, "progress")
+	}
+	if cur.Score != entry.Score {
+		ret.Fields = append(ret.Fields, "score")
+	}
+	if entry.StartedAt != nil && anilist.FuzzyDateInputKey(cur.StartedAt) != anilist.FuzzyDateInputKey(entry.StartedAt) {
+		ret.Fields = append(ret.Fields, "startedAt")
+	}
+	if entry.CompletedAt != nil && anilist.FuzzyDateInputKey(cur.CompletedAt) != anilist.FuzzyDateInputKey(entry.CompletedAt) {
+		ret.Fields = append(ret.Fields, "completedAt")
+	}
+
+	switch {
+	case len(ret.Fields) == 0:
+		ret.Action = ActionUnchanged
+	case overwrite:
+		ret.Action = ActionUpdate
+	default:
+		ret.Action = ActionConflict
+	}
+	return ret
+}
+
+func isBlankPlanningEntry(e *Entry) bool {
+	return e.Status == anilist.MediaListStatusPlanning && e.Progress == 0 && e.Score == 0 && e.StartedAt == nil && e.CompletedAt == nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// resolveEntries sets the AniList ID of the entries that only have a MyAnimeList ID.
+// Anime are resolved with the anime-lists mappings first, then with AniList.
+func (i *Importer) resolveEntries(entries []*Entry) {
+	unresolvedAnime := make([]int, 0)
+	unresolvedManga := make([]int, 0)
+
+	for _, e := range entries {
+		if e.MediaID != 0 || e.MalID == 0 {
+			continue
+		}
+		switch e.MediaType {
+		case MediaTypeAnime:
+			if id, ok := i.getAnimeLists().FindAnilistIDFromMalID(e.MalID); ok {
+				e.MediaID = id
+				continue
+			}
+			unresolvedAnime = append(unresolvedAnime, e.MalID)
+		case MediaTypeManga:
+			unresolvedManga = append(unresolvedManga, e.MalID)
+		}
+	}
+
+	animeIdByMalId := make(map[int]int)
+	for _, chunk := range lo.Chunk(lo.Uniq(unresolvedAnime), compoundQueryChunkSize) {
+		i.anilistLimiter.Wait()
+		res, err := i.fetchAnimeByMalIdsFunc(chunk)
+		if err != nil {
+			// A single missing media fails the whole request, resolve the chunk one by one
+			i.logger.Debug().Err(err).Msg("list import: Failed to resolve anime in bulk, resolving one by one")
+			res = make(map[int]*anilist.BaseAnime)
+			for _, malId := range chunk {
+				i.anilistLimiter.Wait()
+				media, err := i.platform.GetAnimeByMalID(malId)
+				if err != nil || media == nil {
+					continue
+				}
+				res[malId] = media
+			}
+		}
+		for malId, media := range res {
+			animeIdByMalId[malId] = media.GetID()
+		}
+	}
+
+	mangaIdByMalId := make(map[int]int)
+	for _, chunk := range lo.Chunk(lo.Uniq(unresolvedManga), compoundQueryChunkSize) {
+		i.anilistLimiter.Wait()
+		res, err := i.fetchMangaByMalIdsFunc(chunk)
+		if err != nil {
+			i.logger.Warn().Err(err).Msg("list import: Failed to resolve manga")
+			continue
+		}
+		for malId, media := range res {
+			mangaIdByMalId[malId] = media.GetID()
+		}
+	}
+
+	for _, e := range entries {
+		if e.MediaID != 0 || e.MalID == 0 {
+			continue
+		}
+		var found bool
+		switch e.MediaType {
+		case MediaTypeAnime:
+			e.MediaID, found = animeIdByMalId[e.MalID]
+		case MediaTypeManga:
+			e.MediaID, found = mangaIdByMalId[e.MalID]
+		}
+		if !found {
+			i.logger.Debug().Int("malId", e.MalID).Str("type", string(e.MediaType)).Msg("list import: Could not resolve media")
+		}
+	}
+}
+
+// getAnimeLists returns the anime-lists mappings, fetching them once.
+// It returns nil if they cannot be fetched.
+func (i *Importer) getAnimeLists() *mappings.ReducedAnimeListResponse {
+	i.animeListsMu.Lock()
+	defer i.animeListsMu.Unlock()
+
+	if animeLists, ok := i.animeLists.Get(); ok {
+		return animeLists
+	}
+
+	animeLists, err := i.fetchAnimeListsFunc()
+	if err != nil {
+		i.logger.Warn().Err(err).Msg("list import: Failed to fetch anime mappings")
+		// Do not retry for every entry
+		i.animeLists = mo.Some[*mappings.ReducedAnimeListResponse](nil)
+		return nil
+	}
+	i.animeLists = mo.Some(animeLists)
+	return animeLists
+}
+
+// getCurrentEntries returns the entries of the platform's collections.
+func (i *Importer) getCurrentEntries() (map[string]*Entry, error) {
+	ret := make(map[string]*Entry)
+
+	animeCollection, err := i.platform.GetRawAnimeCollection(false)
+	if err != nil {
+		return nil, err
+	}
+	if animeCollection != nil && animeCollection.MediaListCollection != nil {
+		for _, list := range animeCollection.MediaListCollection.Lists {
+			for _, e := range list.GetEntries() {
+				if e.GetMedia() == nil {
+					continue
+				}
+				ret[getKey(MediaTypeAnime, e.GetMedia().GetID())] = &Entry{
+					MediaType:   MediaTypeAnime,
+					MediaID:     e.GetMedia().GetID(),
+					Status:      anilist.StatusOrPlanning(e.GetStatus()),
+					Score:       anilist.RoundedScore(e.GetScore()),
+					Progress:    lo.FromPtr(e.GetProgress()),
+					StartedAt:   anilist.ToFuzzyDateInput(e.GetStartedAt()),
+					CompletedAt: anilist.ToFuzzyDateInput(e.GetCompletedAt()),
+				}
+			}
+		}
+	}
+
+	mangaCollection, err := i.platform.GetRawMangaCollection(false)
+	if err != nil {
+		return nil, err
+	}
+	if mangaCollection != nil && mangaCollection.MediaListCollection != nil {
+		for _, list := range mangaCollection.MediaListCollection.Lists {
+			for _, e := range list.GetEntries() {
+				if e.GetMedia() == nil {
+					continue
+				}
+				ret[getKey(MediaTypeManga, e.GetMedia().GetID())] = &Entry{
+					MediaType:   MediaTypeManga,
+					MediaID:     e.GetMedia().GetID(),
+					Status:      anilist.StatusOrPlanning(e.GetStatus()),
+					Score:       anilist.RoundedScore(e.GetScore()),
+					Progress:    lo.FromPtr(e.GetProgress()),
+					StartedAt:   anilist.ToFuzzyDateInput(e.GetStartedAt()),
+					CompletedAt: anilist.ToFuzzyDateInput(e.GetCompletedAt()),
+				}
+			}
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,265 @@
+package listimport
+
+import (
+	"errors"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seanime/internal/api/anilist"
+	"seanime/internal/api/mappings"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/util"
+	"seanime/internal/util/limiter"
+	"testing"
+	"time"
+)
+
+const testMalXml = `<?xml version="1.0" encoding="UTF-8" ?>
+<myanimelist>
+	<myinfo>
+		<user_export_type>1</user_export_type>
+	</myinfo>
+	<anime>
+		<series_animedb_id>1</series_animedb_id>
+		<series_title><![CDATA[Cowboy Bebop]]></series_title>
+		<my_watched_episodes>26</my_watched_episodes>
+		<my_start_date>2020-04-00</my_start_date>
+		<my_finish_date>0000-00-00</my_finish_date>
+		<my_score>9</my_score>
+		<my_status>Completed</my_status>
+		<my_rewatching>0</my_rewatching>
+	</anime>
+	<anime>
+		<series_animedb_id>5</series_animedb_id>
+		<series_title><![CDATA[Cowboy Bebop: Tengoku no Tobira]]></series_title>
+		<my_watched_episodes>0</my_watched_episodes>
+		<my_start_date>0000-00-00</my_start_date>
+		<my_finish_date>0000-00-00</my_finish_date>
+		<my_score>0</my_score>
+		<my_status>Plan to Watch</my_status>
+		<my_rewatching>0</my_rewatching>
+	</anime>
+	<anime>
+		<series_animedb_id>6</series_animedb_id>
+		<series_title><![CDATA[Trigun]]></series_title>
+		<my_watched_episodes>3</my_watched_episodes>
+		<my_start_date>0000-00-00</my_start_date>
+		<my_finish_date>0000-00-00</my_finish_date>
+		<my_score>0</my_score>
+		<my_status>Watching</my_status>
+		<my_rewatching>0</my_rewatching>
+	</anime>
+	<anime>
+		<series_animedb_id>999999</series_animedb_id>
+		<series_title><![CDATA[Unknown]]></series_title>
+		<my_status>Dropped</my_status>
+	</anime>
+	<manga>
+		<manga_mangadb_id>2</manga_mangadb_id>
+		<manga_title><![CDATA[Berserk]]></manga_title>
+		<my_read_chapters>100</my_read_chapters>
+		<my_score>10</my_score>
+		<my_status>Reading</my_status>
+		<my_rereading>1</my_rereading>
+	</manga>
+</myanimelist>`
+
+const testAnilistJson = `{
+	"data": {
+		"MediaListCollection": {
+			"lists": [
+				{
+					"entries": [
+						{"status": "CURRENT", "score": 85, "progress": 4, "startedAt": {"year": 2021, "month": 2, "day": null}, "media": {"id": 21, "idMal": 21, "type": "ANIME", "title": {"userPreferred": "One Piece"}}},
+						{"status": "PLANNING", "score": 0, "progress": 0, "media": {"id": 30013, "type": "MANGA"}}
+					]
+				},
+				{
+					"entries": [
+						{"status": "CURRENT", "score": 85, "progress": 4, "media": {"id": 21, "type": "ANIME"}}
+					]
+				}
+			]
+		}
+	}
+}`
+
+func TestParseEntries(t *testing.T) {
+	format, err := ParseFormat("", []byte(testMalXml))
+	require.NoError(t, err)
+	assert.Equal(t, FormatMalXml, format)
+
+	entries, err := parseEntries(format, []byte(testMalXml))
+	require.NoError(t, err)
+	require.Len(t, entries, 5)
+
+	assert.Equal(t, &Entry{
+		MediaType: MediaTypeAnime,
+		MalID:     1,
+		Title:     "Cowboy Bebop",
+		Status:    anilist.MediaListStatusCompleted,
+		Score:     90,
+		Progress:  26,
+		StartedAt: &anilist.FuzzyDateInput{Year: lo.ToPtr(2020), Month: lo.ToPtr(4)},
+	}, entries[0])
+	assert.Equal(t, anilist.MediaListStatusPlanning, entries[1].Status)
+	assert.Equal(t, MediaTypeManga, entries[4].MediaType)
+	assert.Equal(t, anilist.MediaListStatusRepeating, entries[4].Status)
+
+	format, err = ParseFormat("", []byte(testAnilistJson))
+	require.NoError(t, err)
+	assert.Equal(t, FormatAnilistJson, format)
+
+	entries, err = parseEntries(format, []byte(testAnilistJson))
+	require.NoError(t, err)
+	require.Len(t, entries, 2) // The custom list entry is a duplicate
+
+	assert.Equal(t, 21, entries[0].MediaID)
+	assert.Equal(t, 21, entries[0].MalID)
+	assert.Equal(t, "One Piece", entries[0].Title)
+	assert.Equal(t, 85, entries[0].Score)
+	assert.Equal(t, MediaTypeManga, entries[1].MediaType)
+
+	_, err = ParseFormat("", []byte("name,status"))
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fakePlatform returns a fixed anime collection and records entry updates.
+type fakePlatform struct {
+	platform.Platform
+	animeCollection *anilist.AnimeCollection
+	animeByMalId    map[int]*anilist.BaseAnime
+	updates         map[int]anilist.MediaListStatus
+	added           []int
+}
+
+func (p *fakePlatform) GetRawAnimeCollection(bool) (*anilist.AnimeCollection, error) {
+	return p.animeCollection, nil
+}
+
+func (p *fakePlatform) GetRawMangaCollection(bool) (*anilist.MangaCollection, error) {
+	return &anilist.MangaCollection{}, nil
+}
+
+func (p *fakePlatform) GetAnimeByMalID(malID int) (*anilist.BaseAnime, error) {
+	if media, ok := p.animeByMalId[malID]; ok {
+		return media, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (p *fakePlatform) UpdateEntry(mediaID int, status *anilist.MediaListStatus, _ *int, _ *int, _ *anilist.FuzzyDateInput, _ *anilist.FuzzyDateInput) error {
+	p.updates[mediaID] = *status
+	return nil
+}
+
+func (p *fakePlatform) AddMediaToCollection(mIds []int) error {
+	p.added = append(p.added, mIds...)
+	return nil
+}
+
+func newTestImporter(p *fakePlatform) (*Importer, *int) {
+	refreshed := 0
+	i := New(&NewImporterOptions{
+		Logger:   util.NewLogger(),
+		Platform: p,
+		RefreshCollectionsFunc: func() {
+			refreshed++
+		},
+	})
+	i.fetchAnimeListsFunc = func() (*mappings.ReducedAnimeListResponse, error) {
+		return mappings.NewReducedAnimeListResponse([]*mappings.ReducedAnimeListItem{
+			{MalID: 1, AnilistID: 1},
+			{MalID: 5, AnilistID: 5},
+		}), nil
+	}
+	i.fetchAnimeByMalIdsFunc = func(malIds []int) (map[int]*anilist.BaseAnime, error) {
+		// Fail the bulk request to resolve the anime one by one
+		return nil, errors.New("not found")
+	}
+	i.fetchMangaByMalIdsFunc = func(malIds []int) (map[int]*anilist.BaseManga, error) {
+		return map[int]*anilist.BaseManga{2: {ID: 30002}}, nil
+	}
+	i.anilistLimiter = limiter.NewLimiter(time.Millisecond, 100)
+	return i, &refreshed
+}
+
+func TestImporter_Import(t *testing.T) {
+	p := &fakePlatform{
+		animeCollection: &anilist.AnimeCollection{
+			MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+				Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{{
+					Entries: []*anilist.AnimeCollection_MediaListCollection_Lists_Entries{{
+						Status:   lo.ToPtr(anilist.MediaListStatusCurrent),
+						Progress: lo.ToPtr(20),
+						Score:    lo.ToPtr(90.0),
+						Media:    &anilist.BaseAnime{ID: 1},
+					}},
+				}},
+			},
+		},
+		animeByMalId: map[int]*anilist.BaseAnime{6: {ID: 6}},
+		updates:      make(map[int]anilist.MediaListStatus),
+	}
+	importer, refreshed := newTestImporter(p)
+
+	// Dry run
+	report, err := importer.Import([]byte(testMalXml), &ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, FormatMalXml, report.Format)
+	assert.Equal(t, map[Action]int{ActionConflict: 1, ActionAdd: 3, ActionUnresolved: 1}, report.Counts)
+
+	conflict := report.Entries[0]
+	assert.Equal(t, ActionConflict, conflict.Action)
+	assert.Equal(t, []string{"status", "progress", "startedAt"}, conflict.Fields)
+	assert.Equal(t, 6, report.Entries[2].Entry.MediaID)     // Resolved with AniList
+	assert.Equal(t, 30002, report.Entries[4].Entry.MediaID) // Manga
+
+	assert.Empty(t, p.updates)
+	assert.Empty(t, p.added)
+	assert.Equal(t, 0, *refreshed)
+
+	// Import without overwriting
+	report, err = importer.Import([]byte(testMalXml), &ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Counts[ActionAdd])
+	assert.Equal(t, map[int]anilist.MediaListStatus{6: anilist.MediaListStatusCurrent, 30002: anilist.MediaListStatusRepeating}, p.updates)
+	assert.Equal(t, []int{5}, p.added) // Blank planning entries are added in bulk
+	assert.Equal(t, 1, *refreshed)
+
+	// Import with overwriting
+	report, err = importer.Import([]byte(testMalXml), &ImportOptions{Format: FormatMalXml, Overwrite: true})
+	require.NoError(t, err)
+	assert.Equal(t, ActionUpdate, report.Entries[0].Action)
+	assert.Equal(t, anilist.MediaListStatusCompleted, p.updates[1])
+}
+
+func TestImporter_ResolveEntriesInChunks(t *testing.T) {
+	importer, _ := newTestImporter(&fakePlatform{})
+
+	requests := 0
+	importer.fetchAnimeByMalIdsFunc = func(malIds []int) (map[int]*anilist.BaseAnime, error) {
+		requests++
+		ret := make(map[int]*anilist.BaseAnime)
+		for _, id := range malIds {
+			ret[id] = &anilist.BaseAnime{ID: 10000 + id}
+		}
+		return ret, nil
+	}
+
+	entries := make([]*Entry, 0)
+	for id := 100; id < 220; id++ {
+		entries = append(entries, &Entry{MediaType: MediaTypeAnime, MalID: id})
+	}
+	// Resolved with the anime-lists mappings
+	entries = append(entries, &Entry{MediaType: MediaTypeAnime, MalID: 5})
+
+	importer.resolveEntries(entries)
+
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, 10100, entries[0].MediaID)
+	assert.Equal(t, 10219, entries[119].MediaID)
+	assert.Equal(t, 5, entries[120].MediaID)
+}
@@ -0,0 +1,368 @@
+package listimport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"github.com/goccy/go-json"
+	"io"
+	"math"
+	"seanime/internal/api/anilist"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatMalXml      Format = "mal_xml"      // MyAnimeList export (https://myanimelist.net/panel.php?go=export), optionally gzipped
+	FormatAnilistJson Format = "anilist_json" // AniList MediaListCollection response, as returned by the API or exported by Seanime
+
+	MediaTypeAnime MediaType = "anime"
+	MediaTypeManga MediaType = "manga"
+)
+
+var (
+	ErrUnknownFormat = errors.New("list import: Unknown file format")
+	ErrNoEntries     = errors.New("list import: No entries found in the file")
+)
+
+type (
+	Format    string
+	MediaType string
+
+	// Entry is a list entry read from an export file.
+	Entry struct {
+		MediaType   MediaType               `json:"mediaType"`
+		MediaID     int                     `json:"mediaId"` // AniList ID, 0 until resolved
+		MalID       int                     `json:"malId"`   // 0 if unknown
+		Title       string                  `json:"title"`
+		Status      anilist.MediaListStatus `json:"status"`
+		Score       int                     `json:"score"` // 0-100
+		Progress    int                     `json:"progress"`
+		StartedAt   *anilist.FuzzyDateInput `json:"startedAt,omitempty"`
+		CompletedAt *anilist.FuzzyDateInput `json:"completedAt,omitempty"`
+	}
+)
+
+// ParseFormat returns the format, detecting it from the content if it is empty.
+func ParseFormat(s string, data []byte) (Format, error) {
+	switch Format(s) {
+	case FormatMalXml, FormatAnilistJson:
+		return Format(s), nil
+	case "":
+	default:
+		return "", ErrUnknownFormat
+	}
+
+	if isGzip(data) {
+		return FormatMalXml, nil
+	}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return FormatMalXml, nil
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return FormatAnilistJson, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// parseEntries reads the entries of an export file.
+func parseEntries(format Format, data []byte) ([]*Entry, error) {
+	var ret []*Entry
+	var err error
+
+	switch format {
+	case FormatMalXml:
+		ret, err = parseMalXml(data)
+	case FormatAnilistJson:
+		ret, err = parseAnilistJson(data)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ret) == 0 {
+		return nil, ErrNoEntries
+	}
+	return ret, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type (
+	malExport struct {
+		Anime []*malExportAnime `xml:"anime"`
+		Manga []*malExportManga `xml:"manga"`
+	}
+
+	malExportAnime struct {
+		ID              int    `xml:"series_animedb_id"`
+		Title           string `xml:"series_title"`
+		WatchedEpisodes int    `xml:"my_watched_episodes"`
+		StartDate       string `xml:"my_start_date"`
+		FinishDate      string `xml:"my_finish_date"`
+		Score           int    `xml:"my_score"`
+		Status          string `xml:"my_status"`
+		IsRewatching    string `xml:"my_rewatching"`
+	}
+
+	malExportManga struct {
+		ID           int    `xml:"manga_mangadb_id"`
+		Title        string `xml:"manga_title"`
+		ReadChapters int    `xml:"my_read_chapters"`
+		StartDate    string `xml:"my_start_date"`
+		FinishDate   string `xml:"my_finish_date"`
+		Score        int    `xml:"my_score"`
+		Status       string `xml:"my_status"`
+		IsRereading  string `xml:"my_rereading"`
+	}
+)
+
+func parseMalXml(data []byte) ([]*Entry, error) {
+	if isGzip(data) {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		data, err = io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var export malExport
+	if err := xml.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+
+	ret := make([]*Entry, 0, len(export.Anime)+len(export.Manga))
+	for _, a := range export.Anime {
+		if a.ID <= 0 {
+			continue
+		}
+		ret = append(ret, &Entry{
+			MediaType:   MediaTypeAnime,
+			MalID:       a.ID,
+			Title:       strings.TrimSpace(a.Title),
+			Status:      fromMalStatus(a.Status, a.IsRewatching),
+			Score:       a.Score * 10,
+			Progress:    a.WatchedEpisodes,
+			StartedAt:   parseMalDate(a.StartDate),
+			CompletedAt: parseMalDate(a.FinishDate),
+		})
+	}
+	for _, m := range export.Manga {
+		if m.ID <= 0 {
+			continue
+		}
+		ret = append(ret, &Entry{
+			MediaType:   MediaTypeManga,
+			MalID:       m.ID,
+			Title:       strings.TrimSpace(m.Title),
+			Status:      fromMalStatus(m.Status, m.IsRereading),
+			Score:       m.Score * 10,
+			Progress:    m.ReadChapters,
+			StartedAt:   parseMalDate(m.StartDate),
+			CompletedAt: parseMalDate(m.FinishDate),
+		})
+	}
+
+	return ret, nil
+}
+
+// fromMalStatus converts a status from a MyAnimeList export.
+// Exports use the display names of the statuses, e.g. "Plan to Watch".
+func fromMalStatus(status string, isRepeating string) anilist.MediaListStatus {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "watching", "reading", "1":
+		if isRepeating == "1" {
+			return anilist.MediaListStatusRepeating
+		}
+		return anilist.MediaListStatusCurrent
+	case "completed", "2":
+		if isRepeating == "1" {
+			return anilist.MediaListStatusRepeating
+		}
+		return anilist.MediaListStatusCompleted
+	case "on-hold", "3":
+		return anilist.MediaListStatusPaused
+	case "dropped", "4":
+		return anilist.MediaListStatusDropped
+	default:
+		return anilist.MediaListStatusPlanning
+	}
+}
+
+// parseMalDate parses a "YYYY-MM-DD" date, where unknown parts are zeroes.
+func parseMalDate(s string) *anilist.FuzzyDateInput {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 3 {
+		return nil
+	}
+
+	toPtr := func(s string) *int {
+		i, err := strconv.Atoi(s)
+		if err != nil || i <= 0 {
+			return nil
+		}
+		return &i
+	}
+
+	year := toPtr(parts[0])
+	if year == nil {
+		return nil
+	}
+	return &anilist.FuzzyDateInput{
+		Year:  year,
+		Month: toPtr(parts[1]),
+		Day:   toPtr(parts[2]),
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type (
+	// anilistExport accepts a MediaListCollection response, optionally wrapped in "data",
+	// or an object with both the "anime" and "manga" collections.
+	anilistExport struct {
+		Data                *anilistExport           `json:"data"`
+		MediaListCollection *anilistExportCollection `json:"MediaListCollection"`
+		Anime               *anilistExport           `json:"anime"`
+		Manga               *anilistExport           `json:"manga"`
+	}
+
+	anilistExportCollection struct {
+		Lists []*struct {
+			Entries []*anilistExportEntry `json:"entries"`
+		} `json:"lists"`
+	}
+
+	anilistExportEntry struct {
+		MediaID     int                      `json:"mediaId"`
+		Status      *anilist.MediaListStatus `json:"status"`
+		Score       *float64                 `json:"score"` // POINT_100
+		Progress    *int                     `json:"progress"`
+		StartedAt   *anilist.FuzzyDateInput  `json:"startedAt"`
+		CompletedAt *anilist.FuzzyDateInput  `json:"completedAt"`
+		Media       *struct {
+			ID    int                `json:"id"`
+			IDMal *int               `json:"idMal"`
+			Type  *anilist.MediaType `json:"type"`
+			Title *struct {
+				UserPreferred *string `json:"userPreferred"`
+				Romaji        *string `json:"romaji"`
+				English       *string `json:"english"`
+			} `json:"title"`
+		} `json:"media"`
+	}
+)
+
+func parseAnilistJson(data []byte) ([]*Entry, error) {
+	var export anilistExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+
+	ret := make([]*Entry, 0)
+	seen := make(map[string]struct{})
+
+	var collect func(e *anilistExport, defaultType MediaType)
+	collect = func(e *anilistExport, defaultType MediaType) {
+		if e == nil {
+			return
+		}
+		collect(e.Data, defaultType)
+		collect(e.Anime, MediaTypeAnime)
+		collect(e.Manga, MediaTypeManga)
+
+		if e.MediaListCollection == nil {
+			return
+		}
+		for _, list := range e.MediaListCollection.Lists {
+			if list == nil {
+				continue
+			}
+			for _, ae := range list.Entries {
+				entry, ok := fromAnilistExportEntry(ae, defaultType)
+				if !ok {
+					continue
+				}
+				// Custom lists contain entries that are already in a status list
+				key := string(entry.MediaType) + strconv.Itoa(entry.MediaID)
+				if _, found := seen[key]; found {
+					continue
+				}
+				seen[key] = struct{}{}
+				ret = append(ret, entry)
+			}
+		}
+	}
+	collect(&export, MediaTypeAnime)
+
+	return ret, nil
+}
+
+func fromAnilistExportEntry(ae *anilistExportEntry, defaultType MediaType) (*Entry, bool) {
+	if ae == nil {
+		return nil, false
+	}
+
+	ret := &Entry{
+		MediaType:   defaultType,
+		MediaID:     ae.MediaID,
+		Status:      anilist.MediaListStatusPlanning,
+		StartedAt:   normalizeDate(ae.StartedAt),
+		CompletedAt: normalizeDate(ae.CompletedAt),
+	}
+	if ae.Status != nil {
+		ret.Status = *ae.Status
+	}
+	if ae.Score != nil {
+		ret.Score = int(math.Round(*ae.Score))
+	}
+	if ae.Progress != nil {
+		ret.Progress = *ae.Progress
+	}
+
+	if media := ae.Media; media != nil {
+		if ret.MediaID == 0 {
+			ret.MediaID = media.ID
+		}
+		if media.IDMal != nil {
+			ret.MalID = *media.IDMal
+		}
+		if media.Type != nil && *media.Type == anilist.MediaTypeManga {
+			ret.MediaType = MediaTypeManga
+		} else if media.Type != nil {
+			ret.MediaType = MediaTypeAnime
+		}
+		if title := media.Title; title != nil {
+			for _, t := range []*string{title.UserPreferred, title.English, title.Romaji} {
+				if t != nil && *t != "" {
+					ret.Title = *t
+					break
+				}
+			}
+		}
+	}
+
+	if ret.MediaID == 0 && ret.MalID == 0 {
+		return nil, false
+	}
+	return ret, true
+}
+
+func normalizeDate(d *anilist.FuzzyDateInput) *anilist.FuzzyDateInput {
+	if d == nil || d.Year == nil || *d.Year <= 0 {
+		return nil
+	}
+	return d
+}
+
+func isGzip(data []byte) bool {
+	return len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b
+}
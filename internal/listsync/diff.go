@@ -0,0 +1,255 @@
+package listsync
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"hash/fnv"
+	"math"
+	"seanime/internal/api/anilist"
+	"slices"
+	"strings"
+)
+
+const (
+	DiffKindMissingInMal     DiffKind = "missing_in_mal"     // The entry only exists on AniList
+	DiffKindMissingInAnilist DiffKind = "missing_in_anilist" // The entry only exists on MyAnimeList
+	DiffKindMismatch         DiffKind = "mismatch"           // The entry exists on both lists but some fields differ
+
+	DiffFieldStatus      DiffField = "status"
+	DiffFieldProgress    DiffField = "progress"
+	DiffFieldScore       DiffField = "score"
+	DiffFieldStartedAt   DiffField = "startedAt"
+	DiffFieldCompletedAt DiffField = "completedAt"
+
+	MediaTypeAnime MediaType = "anime"
+	MediaTypeManga MediaType = "manga"
+)
+
+type (
+	DiffKind  string
+	DiffField string
+	MediaType string
+
+	// EntryData is the normalized list entry data compared by the sync engine.
+	EntryData struct {
+		Status      anilist.MediaListStatus `json:"status"`
+		Progress    int                     `json:"progress"`
+		Score       int                     `json:"score"` // 0-100
+		StartedAt   *anilist.FuzzyDateInput `json:"startedAt,omitempty"`
+		CompletedAt *anilist.FuzzyDateInput `json:"completedAt,omitempty"`
+	}
+
+	// Diff is a difference between the AniList and MyAnimeList entries of a media.
+	Diff struct {
+		ID        string      `json:"id"` // e.g. "anime-21"
+		MediaType MediaType   `json:"mediaType"`
+		MediaID   int         `json:"mediaId"`
+		Title     string      `json:"title"`
+		Image     string      `json:"image"`
+		Kind      DiffKind    `json:"kind"`
+		Fields    []DiffField `json:"fields"`
+		Anilist   *EntryData  `json:"anilist,omitempty"`
+		Mal       *EntryData  `json:"mal,omitempty"`
+		// Hash identifies the content of the diff.
+		// An ignored diff shows up again once its hash changes.
+		Hash    string `json:"hash"`
+		Ignored bool   `json:"ignored"`
+	}
+)
+
+// diffAnimeCollections compares the AniList and MyAnimeList anime collections.
+// Media without a MyAnimeList ID are skipped since they cannot be added to MyAnimeList.
+func diffAnimeCollections(anilistCollection *anilist.AnimeCollection, malCollection *anilist.AnimeCollection) []*Diff {
+	anilistEntries := make(map[int]*EntryData)
+	malEntries := make(map[int]*EntryData)
+	media := make(map[int]*anilist.BaseAnime)
+
+	collect := func(collection *anilist.AnimeCollection, entries map[int]*EntryData) {
+		if collection == nil || collection.MediaListCollection == nil {
+			return
+		}
+		for _, list := range collection.MediaListCollection.Lists {
+			for _, entry := range list.GetEntries() {
+				if entry.GetMedia() == nil || entry.GetMedia().GetIDMal() == nil {
+					continue
+				}
+				entries[entry.GetMedia().GetID()] = &EntryData{
+					Status:      anilist.StatusOrPlanning(entry.GetStatus()),
+					Progress:    lo.FromPtr(entry.GetProgress()),
+					Score:       anilist.RoundedScore(entry.GetScore()),
+					StartedAt:   anilist.ToFuzzyDateInput(entry.GetStartedAt()),
+					CompletedAt: anilist.ToFuzzyDateInput(entry.GetCompletedAt()),
+				}
+				media[entry.GetMedia().GetID()] = entry.GetMedia()
+			}
+		}
+	}
+	collect(anilistCollection, anilistEntries)
+	collect(malCollection, malEntries)
+
+	return diffEntries(MediaTypeAnime, anilistEntries, malEntries, func(mediaId int) (string, string) {
+		return media[mediaId].GetPreferredTitle(), media[mediaId].GetCoverImageSafe()
+	})
+}
+
+// diffMangaCollections compares the AniList and MyAnimeList manga collections.
+func diffMangaCollections(anilistCollection *anilist.MangaCollection, malCollection *anilist.MangaCollection) []*Diff {
+	anilistEntries := make(map[int]*EntryData)
+	malEntries := make(map[int]*EntryData)
+	media := make(map[int]*anilist.BaseManga)
+
+	collect := func(collection *anilist.MangaCollection, entries map[int]*EntryData) {
+		if collection == nil || collection.MediaListCollection == nil {
+			return
+		}
+		for _, list := range collection.MediaListCollection.Lists {
+			for _, entry := range list.GetEntries() {
+				if entry.GetMedia() == nil || entry.GetMedia().GetIDMal() == nil {
+					continue
+				}
+				entries[entry.GetMedia().GetID()] = &EntryData{
+					Status:      anilist.StatusOrPlanning(entry.GetStatus()),
+					Progress:    lo.FromPtr(entry.GetProgress()),
+					Score:       anilist.RoundedScore(entry.GetScore()),
+					StartedAt:   anilist.ToFuzzyDateInput(entry.GetStartedAt()),
+					CompletedAt: anilist.ToFuzzyDateInput(entry.GetCompletedAt()),
+				}
+				media[entry.GetMedia().GetID()] = entry.GetMedia()
+			}
+		}
+	}
+	collect(anilistCollection, anilistEntries)
+	collect(malCollection, malEntries)
+
+	return diffEntries(MediaTypeManga, anilistEntries, malEntries, func(mediaId int) (string, string) {
+		return media[mediaId].GetPreferredTitle(), media[mediaId].GetCoverImageSafe()
+	})
+}
+
+// diffEntries returns the diffs between the AniList and MyAnimeList entries, sorted by media ID.
+func diffEntries(
+	mediaType MediaType,
+	anilistEntries map[int]*EntryData,
+	malEntries map[int]*EntryData,
+	getMediaInfo func(mediaId int) (title string, image string),
+) []*Diff {
+	mediaIds := make([]int, 0, len(anilistEntries)+len(malEntries))
+	for id := range anilistEntries {
+		mediaIds = append(mediaIds, id)
+	}
+	for id := range malEntries {
+		if _, ok := anilistEntries[id]; !ok {
+			mediaIds = append(mediaIds, id)
+		}
+	}
+	slices.Sort(mediaIds)
+
+	ret := make([]*Diff, 0)
+	for _, id := range mediaIds {
+		anilistEntry, malEntry := anilistEntries[id], malEntries[id]
+
+		diff := &Diff{
+			ID:        getDiffId(mediaType, id),
+			MediaType: mediaType,
+			MediaID:   id,
+			Anilist:   anilistEntry,
+			Mal:       malEntry,
+		}
+
+		switch {
+		case malEntry == nil:
+			diff.Kind = DiffKindMissingInMal
+		case anilistEntry == nil:
+			diff.Kind = DiffKindMissingInAnilist
+		default:
+			diff.Fields = compareEntries(anilistEntry, malEntry)
+			if len(diff.Fields) == 0 {
+				continue
+			}
+			diff.Kind = DiffKindMismatch
+		}
+
+		diff.Title, diff.Image = getMediaInfo(id)
+		diff.Hash = hashDiff(diff)
+		ret = append(ret, diff)
+	}
+
+	return ret
+}
+
+// compareEntries returns the fields that differ between two entries.
+// Scores are compared with MyAnimeList's precision (0-10).
+func compareEntries(a *EntryData, b *EntryData) []DiffField {
+	ret := make([]DiffField, 0)
+	if a.Status != b.Status {
+		ret = append(ret, DiffFieldStatus)
+	}
+	if a.Progress != b.Progress {
+		ret = append(ret, DiffFieldProgress)
+	}
+	if roundScore(a.Score) != roundScore(b.Score) {
+		ret = append(ret, DiffFieldScore)
+	}
+	if anilist.FuzzyDateInputKey(a.StartedAt) != anilist.FuzzyDateInputKey(b.StartedAt) {
+		ret = append(ret, DiffFieldStartedAt)
+	}
+	if anilist.FuzzyDateInputKey(a.CompletedAt) != anilist.FuzzyDateInputKey(b.CompletedAt) {
+		ret = append(ret, DiffFieldCompletedAt)
+	}
+	return ret
+}
+
+// getSource returns the entry data of the given origin.
+func (d *Diff) getSource(origin Origin) *EntryData {
+	if origin == OriginMal {
+		return d.Mal
+	}
+	return d.Anilist
+}
+
+// isApplicable returns true if applying the diff from the origin would change the other list.
+// Dates cannot be cleared, so a diff that only consists of dates missing from the origin is not applicable.
+func (d *Diff) isApplicable(origin Origin) bool {
+	source := d.getSource(origin)
+	if source == nil {
+		return false
+	}
+	if d.Kind != DiffKindMismatch {
+		return true
+	}
+	for _, field := range d.Fields {
+		switch field {
+		case DiffFieldStartedAt:
+			if source.StartedAt != nil {
+				return true
+			}
+		case DiffFieldCompletedAt:
+			if source.CompletedAt != nil {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func getDiffId(mediaType MediaType, mediaId int) string {
+	return fmt.Sprintf("%s-%d", mediaType, mediaId)
+}
+
+func hashDiff(d *Diff) string {
+	entryKey := func(e *EntryData) string {
+		if e == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%s|%d|%d|%s|%s", e.Status, e.Progress, roundScore(e.Score), anilist.FuzzyDateInputKey(e.StartedAt), anilist.FuzzyDateInputKey(e.CompletedAt))
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strings.Join([]string{d.ID, entryKey(d.Anilist), entryKey(d.Mal)}, "#")))
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+func roundScore(score int) int {
+	return int(math.Round(float64(score) / 10))
+}
@@ -0,0 +1,359 @@
+package listsync
+
+import (
+	"errors"
+	"github.com/rs/zerolog"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/util"
+	"sync"
+	"time"
+)
+
+const (
+	OriginAnilist Origin = "anilist"
+	OriginMal     Origin = "mal"
+
+	// automaticSyncDelay is used to debounce automatic syncs triggered by consecutive progress updates.
+	automaticSyncDelay = 10 * time.Second
+)
+
+var (
+	ErrAnilistNotConnected = errors.New("list sync: AniList account not connected")
+	ErrMalNotConnected     = errors.New("list sync: MyAnimeList account not connected")
+	ErrDiffNotFound        = errors.New("list sync: Diff not found, refresh the preview")
+	ErrNotApplicable       = errors.New("list sync: Diff cannot be applied from this origin")
+)
+
+type (
+	// Origin is the list that is used as the source of truth.
+	Origin string
+
+	// ListSync compares the user's AniList and MyAnimeList lists and applies the differences in either direction.
+	//
+	// Both lists are read and written through a platform.Platform, so entries are always identified by their AniList media ID.
+	// Entries missing from the origin are never deleted from the other list.
+	ListSync struct {
+		logger                 *zerolog.Logger
+		database               *db.Database
+		anilistPlatform        platform.Platform
+		malPlatform            platform.Platform
+		refreshCollectionsFunc func()
+		settings               *models.ListSyncSettings
+		settingsMu             sync.RWMutex
+		diffs                  []*Diff // Diffs from the last preview, used to apply or ignore individual diffs
+		mu                     sync.Mutex
+		automaticSyncTimer     *time.Timer
+		timerMu                sync.Mutex
+	}
+
+	NewListSyncOptions struct {
+		Logger                 *zerolog.Logger
+		Database               *db.Database
+		AnilistPlatform        platform.Platform // Platform used to read and write the AniList list
+		MalPlatform            platform.Platform // Platform used to read and write the MyAnimeList list
+		RefreshCollectionsFunc func()            // This function is called after diffs are applied
+	}
+
+	// Preview is the reviewable set of diffs between the two lists.
+	Preview struct {
+		Origin Origin  `json:"origin"`
+		Diffs  []*Diff `json:"diffs"`
+	}
+
+	ApplyResult struct {
+		Applied []string          `json:"applied"`
+		Errors  map[string]string `json:"errors"` // Diff ID -> error message
+	}
+)
+
+func New(opts *NewListSyncOptions) *ListSync {
+	return &ListSync{
+		logger:                 opts.Logger,
+		database:               opts.Database,
+		anilistPlatform:        opts.AnilistPlatform,
+		malPlatform:            opts.MalPlatform,
+		refreshCollectionsFunc: opts.RefreshCollectionsFunc,
+		settings:               &models.ListSyncSettings{},
+		diffs:                  make([]*Diff, 0),
+	}
+}
+
+// ParseOrigin returns the origin, defaulting to AniList.
+func ParseOrigin(s string) Origin {
+	if Origin(s) == OriginMal {
+		return OriginMal
+	}
+	return OriginAnilist
+}
+
+func (ls *ListSync) SetSettings(settings *models.ListSyncSettings) {
+	ls.settingsMu.Lock()
+	defer ls.settingsMu.Unlock()
+	if settings == nil {
+		settings = &models.ListSyncSettings{}
+	}
+	ls.settings = settings
+}
+
+// GetOrigin returns the origin set in the settings.
+func (ls *ListSync) GetOrigin() Origin {
+	ls.settingsMu.RLock()
+	defer ls.settingsMu.RUnlock()
+	return ParseOrigin(ls.settings.Origin)
+}
+
+func (ls *ListSync) isAutomatic() bool {
+	ls.settingsMu.RLock()
+	defer ls.settingsMu.RUnlock()
+	return ls.settings.Automatic
+}
+
+// SetAnilistClient updates the AniList client of both platforms.
+// This should be called when the user logs in.
+func (ls *ListSync) SetAnilistClient(client anilist.AnilistClient) {
+	ls.anilistPlatform.SetAnilistClient(client)
+	ls.malPlatform.SetAnilistClient(client)
+}
+
+// SetUsername sets the username used to fetch the AniList list.
+func (ls *ListSync) SetUsername(username string) {
+	ls.anilistPlatform.SetUsername(username)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetPreview fetches both lists and returns the diffs between them.
+func (ls *ListSync) GetPreview() (*Preview, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	diffs, err := ls.fetchDiffs()
+	if err != nil {
+		return nil, err
+	}
+
+	ls.diffs = diffs
+
+	return &Preview{
+		Origin: ls.GetOrigin(),
+		Diffs:  diffs,
+	}, nil
+}
+
+// Apply applies the diffs from the last preview, using the given list as the source of truth.
+func (ls *ListSync) Apply(diffIds []string, origin Origin) (*ApplyResult, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ret := &ApplyResult{
+		Applied: make([]string, 0),
+		Errors:  make(map[string]string),
+	}
+
+	for _, id := range diffIds {
+		diff, found := ls.getDiff(id)
+		if !found {
+			ret.Errors[id] = ErrDiffNotFound.Error()
+			continue
+		}
+
+		if err := ls.applyDiff(diff, origin); err != nil {
+			ret.Errors[id] = err.Error()
+			continue
+		}
+		ret.Applied = append(ret.Applied, id)
+	}
+
+	ls.logger.Info().Int("applied", len(ret.Applied)).Int("errors", len(ret.Errors)).Str("origin", string(origin)).Msg("list sync: Applied diffs")
+
+	if len(ret.Applied) > 0 {
+		// The preview is outdated
+		ls.diffs = make([]*Diff, 0)
+		ls.refreshCollectionsFunc()
+	}
+
+	return ret, nil
+}
+
+// Ignore ignores or un-ignores a diff from the last preview.
+// An ignored diff is skipped by the automatic sync until either list entry changes.
+func (ls *ListSync) Ignore(diffId string, ignored bool) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if !ignored {
+		if diff, found := ls.getDiff(diffId); found {
+			diff.Ignored = false
+		}
+		return ls.database.DeleteListSyncIgnoredDiff(diffId)
+	}
+
+	diff, found := ls.getDiff(diffId)
+	if !found {
+		return ErrDiffNotFound
+	}
+
+	if err := ls.database.UpsertListSyncIgnoredDiff(diff.ID, diff.Hash); err != nil {
+		return err
+	}
+	diff.Ignored = true
+
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TriggerAutomaticSync schedules an automatic sync if it is enabled.
+// This should be called after every progress update, consecutive calls are debounced.
+func (ls *ListSync) TriggerAutomaticSync() {
+	if !ls.isAutomatic() {
+		return
+	}
+
+	ls.timerMu.Lock()
+	defer ls.timerMu.Unlock()
+
+	if ls.automaticSyncTimer != nil {
+		ls.automaticSyncTimer.Stop()
+	}
+	ls.automaticSyncTimer = time.AfterFunc(automaticSyncDelay, func() {
+		defer util.HandlePanicInModuleThen("listsync/TriggerAutomaticSync", func() {})
+		if err := ls.RunAutomaticSync(); err != nil {
+			ls.logger.Error().Err(err).Msg("list sync: Automatic sync failed")
+		}
+	})
+}
+
+// RunAutomaticSync applies all the diffs that are not ignored, using the origin from the settings.
+// It does nothing if the automatic sync is disabled or if MyAnimeList is not connected.
+func (ls *ListSync) RunAutomaticSync() error {
+	if !ls.isAutomatic() {
+		return nil
+	}
+
+	if _, err := ls.database.GetMalInfo(); err != nil {
+		return nil
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	diffs, err := ls.fetchDiffs()
+	if err != nil {
+		return err
+	}
+	ls.diffs = diffs
+
+	origin := ls.GetOrigin()
+
+	applied := 0
+	for _, diff := range diffs {
+		if diff.Ignored || !diff.isApplicable(origin) {
+			continue
+		}
+		if err := ls.applyDiff(diff, origin); err != nil {
+			ls.logger.Warn().Err(err).Str("diff", diff.ID).Msg("list sync: Failed to apply diff")
+			continue
+		}
+		applied++
+	}
+
+	if applied == 0 {
+		ls.logger.Debug().Msg("list sync: Lists are in sync")
+		return nil
+	}
+
+	ls.logger.Info().Int("applied", applied).Str("origin", string(origin)).Msg("list sync: Automatic sync completed")
+
+	ls.diffs = make([]*Diff, 0)
+	ls.refreshCollectionsFunc()
+
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fetchDiffs fetches both lists and returns the diffs, marking the ones that were ignored.
+func (ls *ListSync) fetchDiffs() ([]*Diff, error) {
+	if _, err := ls.database.GetMalInfo(); err != nil {
+		return nil, ErrMalNotConnected
+	}
+
+	anilistAnimeCollection, err := ls.anilistPlatform.GetAnimeCollection(true)
+	if err != nil {
+		return nil, err
+	}
+	if anilistAnimeCollection == nil {
+		return nil, ErrAnilistNotConnected
+	}
+
+	malAnimeCollection, err := ls.malPlatform.GetAnimeCollection(true)
+	if err != nil {
+		return nil, err
+	}
+
+	anilistMangaCollection, err := ls.anilistPlatform.GetMangaCollection(true)
+	if err != nil {
+		return nil, err
+	}
+
+	malMangaCollection, err := ls.malPlatform.GetMangaCollection(true)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := diffAnimeCollections(anilistAnimeCollection, malAnimeCollection)
+	diffs = append(diffs, diffMangaCollections(anilistMangaCollection, malMangaCollection)...)
+
+	ignoredDiffs, err := ls.database.GetListSyncIgnoredDiffs()
+	if err != nil {
+		ls.logger.Warn().Err(err).Msg("list sync: Failed to get ignored diffs")
+	}
+	ignoredHashes := make(map[string]string, len(ignoredDiffs))
+	for _, ignored := range ignoredDiffs {
+		ignoredHashes[ignored.DiffID] = ignored.Hash
+	}
+	for _, diff := range diffs {
+		if hash, ok := ignoredHashes[diff.ID]; ok && hash == diff.Hash {
+			diff.Ignored = true
+		}
+	}
+
+	ls.logger.Debug().Int("count", len(diffs)).Msg("list sync: Fetched diffs")
+
+	return diffs, nil
+}
+
+func (ls *ListSync) getDiff(id string) (*Diff, bool) {
+	for _, diff := range ls.diffs {
+		if diff.ID == id {
+			return diff, true
+		}
+	}
+	return nil, false
+}
+
+// applyDiff copies the entry of the origin to the other list.
+func (ls *ListSync) applyDiff(diff *Diff, origin Origin) error {
+	if !diff.isApplicable(origin) {
+		return ErrNotApplicable
+	}
+
+	source := diff.getSource(origin)
+	target := ls.malPlatform
+	if origin == OriginMal {
+		target = ls.anilistPlatform
+	}
+
+	return target.UpdateEntry(
+		diff.MediaID,
+		&source.Status,
+		&source.Score,
+		&source.Progress,
+		source.StartedAt,
+		source.CompletedAt,
+	)
+}
@@ -0,0 +1,232 @@
+package listsync
+
+import (
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/db"
+	"seanime/internal/database/models"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/util"
+	"testing"
+)
+
+type testEntry struct {
+	mediaId   int
+	malId     *int
+	status    anilist.MediaListStatus
+	progress  int
+	score     float64
+	startedAt *int // Year
+}
+
+func newTestAnimeCollection(entries ...testEntry) *anilist.AnimeCollection {
+	list := &anilist.AnimeCollection_MediaListCollection_Lists{}
+	for _, e := range entries {
+		entry := &anilist.AnimeCollection_MediaListCollection_Lists_Entries{
+			Status:   lo.ToPtr(e.status),
+			Progress: lo.ToPtr(e.progress),
+			Score:    lo.ToPtr(e.score),
+			Media:    &anilist.BaseAnime{ID: e.mediaId, IDMal: e.malId},
+		}
+		if e.startedAt != nil {
+			entry.StartedAt = &anilist.AnimeCollection_MediaListCollection_Lists_Entries_StartedAt{Year: e.startedAt, Month: lo.ToPtr(1), Day: lo.ToPtr(1)}
+		}
+		list.Entries = append(list.Entries, entry)
+	}
+	return &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+			Lists: []*anilist.AnimeCollection_MediaListCollection_Lists{list},
+		},
+	}
+}
+
+func TestDiffAnimeCollections(t *testing.T) {
+	anilistCollection := newTestAnimeCollection(
+		testEntry{mediaId: 1, malId: lo.ToPtr(11), status: anilist.MediaListStatusCurrent, progress: 3, score: 80},
+		testEntry{mediaId: 2, malId: lo.ToPtr(12), status: anilist.MediaListStatusCompleted, progress: 12, score: 76, startedAt: lo.ToPtr(2023)},
+		testEntry{mediaId: 3, malId: lo.ToPtr(13), status: anilist.MediaListStatusPlanning},
+		testEntry{mediaId: 4, malId: nil, status: anilist.MediaListStatusPlanning}, // Not on MyAnimeList
+	)
+	malCollection := newTestAnimeCollection(
+		testEntry{mediaId: 1, malId: lo.ToPtr(11), status: anilist.MediaListStatusCurrent, progress: 5, score: 80},
+		testEntry{mediaId: 2, malId: lo.ToPtr(12), status: anilist.MediaListStatusCompleted, progress: 12, score: 80, startedAt: lo.ToPtr(2023)}, // Same score with MAL precision
+		testEntry{mediaId: 5, malId: lo.ToPtr(15), status: anilist.MediaListStatusDropped, progress: 1},
+	)
+
+	diffs := diffAnimeCollections(anilistCollection, malCollection)
+	require.Len(t, diffs, 3)
+
+	assert.Equal(t, "anime-1", diffs[0].ID)
+	assert.Equal(t, DiffKindMismatch, diffs[0].Kind)
+	assert.Equal(t, []DiffField{DiffFieldProgress}, diffs[0].Fields)
+
+	assert.Equal(t, "anime-3", diffs[1].ID)
+	assert.Equal(t, DiffKindMissingInMal, diffs[1].Kind)
+	assert.Nil(t, diffs[1].Mal)
+
+	assert.Equal(t, "anime-5", diffs[2].ID)
+	assert.Equal(t, DiffKindMissingInAnilist, diffs[2].Kind)
+	assert.Nil(t, diffs[2].Anilist)
+
+	// The hash only depends on the content of the diff
+	assert.Equal(t, diffs[0].Hash, diffAnimeCollections(anilistCollection, malCollection)[0].Hash)
+	assert.NotEqual(t, diffs[0].Hash, diffs[1].Hash)
+}
+
+func TestDiff_IsApplicable(t *testing.T) {
+	anilistCollection := newTestAnimeCollection(
+		testEntry{mediaId: 1, malId: lo.ToPtr(11), status: anilist.MediaListStatusCurrent, progress: 3},
+		testEntry{mediaId: 2, malId: lo.ToPtr(12), status: anilist.MediaListStatusCurrent, progress: 3},
+	)
+	malCollection := newTestAnimeCollection(
+		testEntry{mediaId: 1, malId: lo.ToPtr(11), status: anilist.MediaListStatusCurrent, progress: 3, startedAt: lo.ToPtr(2023)},
+	)
+
+	diffs := diffAnimeCollections(anilistCollection, malCollection)
+	require.Len(t, diffs, 2)
+
+	// Only the start date differs, and it is missing from AniList
+	assert.Equal(t, []DiffField{DiffFieldStartedAt}, diffs[0].Fields)
+	assert.False(t, diffs[0].isApplicable(OriginAnilist))
+	assert.True(t, diffs[0].isApplicable(OriginMal))
+
+	// The entry is missing from MyAnimeList
+	assert.True(t, diffs[1].isApplicable(OriginAnilist))
+	assert.False(t, diffs[1].isApplicable(OriginMal))
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type updatedEntry struct {
+	status   anilist.MediaListStatus
+	progress int
+	score    int
+}
+
+// fakePlatform returns fixed collections and records entry updates.
+type fakePlatform struct {
+	platform.Platform
+	animeCollection *anilist.AnimeCollection
+	updates         map[int]updatedEntry
+}
+
+func (p *fakePlatform) GetAnimeCollection(bool) (*anilist.AnimeCollection, error) {
+	return p.animeCollection, nil
+}
+
+func (p *fakePlatform) GetMangaCollection(bool) (*anilist.MangaCollection, error) {
+	return &anilist.MangaCollection{}, nil
+}
+
+func (p *fakePlatform) UpdateEntry(mediaID int, status *anilist.MediaListStatus, scoreRaw *int, progress *int, _ *anilist.FuzzyDateInput, _ *anilist.FuzzyDateInput) error {
+	p.updates[mediaID] = updatedEntry{status: *status, progress: *progress, score: *scoreRaw}
+	return nil
+}
+
+func newTestListSync(t *testing.T, anilistPlatform *fakePlatform, malPlatform *fakePlatform) (*ListSync, *int) {
+	logger := util.NewLogger()
+	database, err := db.NewDatabase(t.TempDir(), "test", logger)
+	require.NoError(t, err)
+
+	_, err = database.UpsertMalInfo(&models.Mal{BaseModel: models.BaseModel{ID: 1}, Username: "test"})
+	require.NoError(t, err)
+
+	refreshed := 0
+	return New(&NewListSyncOptions{
+		Logger:          logger,
+		Database:        database,
+		AnilistPlatform: anilistPlatform,
+		MalPlatform:     malPlatform,
+		RefreshCollectionsFunc: func() {
+			refreshed++
+		},
+	}), &refreshed
+}
+
+func TestListSync_Apply(t *testing.T) {
+	anilistPlatform := &fakePlatform{
+		animeCollection: newTestAnimeCollection(
+			testEntry{mediaId: 1, malId: lo.ToPtr(11), status: anilist.MediaListStatusCurrent, progress: 3, score: 70},
+		),
+		updates: make(map[int]updatedEntry),
+	}
+	malPlatform := &fakePlatform{
+		animeCollection: newTestAnimeCollection(
+			testEntry{mediaId: 1, malId: lo.ToPtr(11), status: anilist.MediaListStatusCurrent, progress: 5, score: 70},
+			testEntry{mediaId: 2, malId: lo.ToPtr(12), status: anilist.MediaListStatusPlanning},
+		),
+		updates: make(map[int]updatedEntry),
+	}
+
+	ls, refreshed := newTestListSync(t, anilistPlatform, malPlatform)
+
+	preview, err := ls.GetPreview()
+	require.NoError(t, err)
+	require.Len(t, preview.Diffs, 2)
+	assert.Equal(t, OriginAnilist, preview.Origin)
+
+	// "anime-2" does not exist on AniList, it cannot be applied from AniList
+	res, err := ls.Apply([]string{"anime-1", "anime-2", "anime-3"}, OriginAnilist)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"anime-1"}, res.Applied)
+	assert.Equal(t, ErrNotApplicable.Error(), res.Errors["anime-2"])
+	assert.Equal(t, ErrDiffNotFound.Error(), res.Errors["anime-3"])
+	assert.Equal(t, 1, *refreshed)
+
+	assert.Equal(t, updatedEntry{status: anilist.MediaListStatusCurrent, progress: 3, score: 70}, malPlatform.updates[1])
+	assert.Empty(t, anilistPlatform.updates)
+
+	// The preview is outdated after applying diffs
+	res, err = ls.Apply([]string{"anime-2"}, OriginMal)
+	require.NoError(t, err)
+	assert.Empty(t, res.Applied)
+
+	_, err = ls.GetPreview()
+	require.NoError(t, err)
+	res, err = ls.Apply([]string{"anime-2"}, OriginMal)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"anime-2"}, res.Applied)
+	assert.Equal(t, anilist.MediaListStatusPlanning, anilistPlatform.updates[2].status)
+}
+
+func TestListSync_AutomaticSync(t *testing.T) {
+	anilistPlatform := &fakePlatform{
+		animeCollection: newTestAnimeCollection(
+			testEntry{mediaId: 1, malId: lo.ToPtr(11), status: anilist.MediaListStatusCurrent, progress: 3},
+			testEntry{mediaId: 2, malId: lo.ToPtr(12), status: anilist.MediaListStatusCompleted, progress: 12},
+		),
+		updates: make(map[int]updatedEntry),
+	}
+	malPlatform := &fakePlatform{
+		animeCollection: newTestAnimeCollection(),
+		updates:         make(map[int]updatedEntry),
+	}
+
+	ls, refreshed := newTestListSync(t, anilistPlatform, malPlatform)
+
+	// Disabled
+	require.NoError(t, ls.RunAutomaticSync())
+	assert.Empty(t, malPlatform.updates)
+
+	ls.SetSettings(&models.ListSyncSettings{Automatic: true, Origin: string(OriginAnilist)})
+
+	// Ignore the first diff
+	_, err := ls.GetPreview()
+	require.NoError(t, err)
+	require.NoError(t, ls.Ignore("anime-1", true))
+
+	require.NoError(t, ls.RunAutomaticSync())
+	assert.Len(t, malPlatform.updates, 1)
+	assert.Contains(t, malPlatform.updates, 2)
+	assert.Equal(t, 1, *refreshed)
+
+	// The ignored diff shows up again once it changes
+	anilistPlatform.animeCollection.MediaListCollection.Lists[0].Entries[0].Progress = lo.ToPtr(4)
+	preview, err := ls.GetPreview()
+	require.NoError(t, err)
+	diff, found := lo.Find(preview.Diffs, func(d *Diff) bool { return d.ID == "anime-1" })
+	require.True(t, found)
+	assert.False(t, diff.Ignored)
+}
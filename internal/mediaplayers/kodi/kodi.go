@@ -0,0 +1,171 @@
+package kodi
+
+// https://kodi.wiki/view/JSON-RPC_API/v13
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultHost = "127.0.0.1"
+	DefaultPort = 8080
+)
+
+var ErrNoActivePlayer = errors.New("kodi: no video is playing")
+
+// Kodi controls a Kodi instance through its JSON-RPC API over HTTP.
+// "Allow remote control via HTTP" should be enabled in the Kodi settings.
+type Kodi struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Logger   *zerolog.Logger
+	lastId   atomic.Int64
+}
+
+type (
+	request struct {
+		JsonRPC string      `json:"jsonrpc"`
+		Method  string      `json:"method"`
+		Params  interface{} `json:"params,omitempty"`
+		ID      int64       `json:"id"`
+	}
+
+	response struct {
+		ID     int64           `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *Error          `json:"error"`
+	}
+
+	// Error is an error returned by Kodi.
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+)
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("kodi: %s (%d)", e.Message, e.Code)
+}
+
+func (k *Kodi) url() string {
+	host := k.Host
+	if host == "" {
+		host = DefaultHost
+	}
+	port := k.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	return fmt.Sprintf("http://%s:%d/jsonrpc", host, port)
+}
+
+// Call calls the JSON-RPC method and decodes its result into ret, if not nil.
+func (k *Kodi) Call(method string, params interface{}, ret interface{}) error {
+	body, err := json.Marshal(&request{
+		JsonRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      k.lastId.Add(1),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, k.url(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if k.Username != "" || k.Password != "" {
+		req.SetBasicAuth(k.Username, k.Password)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errors.New("kodi: invalid username or password")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("kodi: http error code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var res response
+	if err = json.Unmarshal(data, &res); err != nil {
+		return fmt.Errorf("kodi: invalid response, %w", err)
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if ret == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Result, ret)
+}
+
+// Ping returns an error if Kodi cannot be reached.
+func (k *Kodi) Ping() error {
+	return k.Call("JSONRPC.Ping", nil, nil)
+}
+
+// OpenAndPlay plays the file or stream URL, replacing the current video if any.
+// The path should be reachable by Kodi, e.g. a network share or a stream URL if Kodi runs on another device.
+func (k *Kodi) OpenAndPlay(path string) error {
+	err := k.Call("Player.Open", map[string]interface{}{
+		"item": map[string]interface{}{"file": path},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	k.Logger.Debug().Str("path", path).Msg("kodi: Opened file")
+	return nil
+}
+
+// Stop stops the video player, if a video is playing.
+func (k *Kodi) Stop() error {
+	playerId, err := k.getVideoPlayerId()
+	if err != nil {
+		if errors.Is(err, ErrNoActivePlayer) {
+			return nil
+		}
+		return err
+	}
+	return k.Call("Player.Stop", map[string]interface{}{"playerid": playerId}, nil)
+}
+
+// getVideoPlayerId returns the ID of the active video player.
+func (k *Kodi) getVideoPlayerId() (int, error) {
+	var players []struct {
+		PlayerId int    `json:"playerid"`
+		Type     string `json:"type"`
+	}
+	if err := k.Call("Player.GetActivePlayers", nil, &players); err != nil {
+		return 0, err
+	}
+	for _, p := range players {
+		if p.Type == "video" {
+			return p.PlayerId, nil
+		}
+	}
+	return 0, ErrNoActivePlayer
+}
@@ -0,0 +1,152 @@
+package kodi
+
+import (
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"seanime/internal/util"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// fakeKodi is a local stand-in for the JSON-RPC API of Kodi.
+type fakeKodi struct {
+	mu      sync.Mutex
+	file    string
+	seconds int
+	paused  bool
+	calls   []string
+}
+
+func (f *fakeKodi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, _ := r.BasicAuth(); user != "kodi" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Method string                 `json:"method"`
+		Params map[string]interface{} `json:"params"`
+		ID     int64                  `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, req.Method)
+
+	var result interface{} = "OK"
+	switch req.Method {
+	case "JSONRPC.Ping":
+		result = "pong"
+	case "Player.Open":
+		f.file = req.Params["item"].(map[string]interface{})["file"].(string)
+		f.seconds = 0
+	case "Player.Stop":
+		f.file = ""
+	case "Player.GetActivePlayers":
+		players := make([]interface{}, 0)
+		if f.file != "" {
+			players = append(players, map[string]interface{}{"playerid": 1, "type": "video"})
+		}
+		result = players
+	case "Player.GetProperties":
+		f.seconds += 600
+		speed := 1
+		if f.paused {
+			speed = 0
+		}
+		result = map[string]interface{}{
+			"time":      map[string]int{"hours": 0, "minutes": f.seconds / 60, "seconds": f.seconds % 60, "milliseconds": 0},
+			"totaltime": map[string]int{"hours": 0, "minutes": 24, "seconds": 0, "milliseconds": 0},
+			"speed":     speed,
+		}
+	case "Player.GetItem":
+		result = map[string]interface{}{"item": map[string]interface{}{"file": f.file, "label": "Episode 1"}}
+	default:
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id": req.ID, "jsonrpc": "2.0",
+			"error": map[string]interface{}{"code": -32601, "message": "Method not found."},
+		})
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "jsonrpc": "2.0", "result": result})
+}
+
+func newTestKodi(t *testing.T, fake *fakeKodi) *Kodi {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	portInt, _ := strconv.Atoi(port)
+
+	return &Kodi{
+		Host:     host,
+		Port:     portInt,
+		Username: "kodi",
+		Password: "secret",
+		Logger:   util.NewLogger(),
+	}
+}
+
+func TestKodi_Playback(t *testing.T) {
+	fake := &fakeKodi{}
+	k := newTestKodi(t, fake)
+
+	require.NoError(t, k.Ping())
+
+	// Nothing is playing
+	_, err := k.GetPlaybackStatus()
+	assert.ErrorIs(t, err, ErrNoActivePlayer)
+
+	err = k.OpenAndPlay("smb://nas/anime/Sousou no Frieren/[SubsPlease] Sousou no Frieren - 01 (1080p) [F02B9CEE].mkv")
+	require.NoError(t, err)
+
+	status, err := k.GetPlaybackStatus()
+	require.NoError(t, err)
+	assert.Equal(t, "[SubsPlease] Sousou no Frieren - 01 (1080p) [F02B9CEE].mkv", status.Filename)
+	assert.Equal(t, "smb://nas/anime/Sousou no Frieren/[SubsPlease] Sousou no Frieren - 01 (1080p) [F02B9CEE].mkv", status.Filepath)
+	assert.Equal(t, float64(600), status.Position)
+	assert.Equal(t, float64(1440), status.Duration)
+	assert.False(t, status.Paused)
+
+	fake.mu.Lock()
+	fake.paused = true
+	fake.mu.Unlock()
+
+	status, err = k.GetPlaybackStatus()
+	require.NoError(t, err)
+	assert.Equal(t, float64(1200), status.Position)
+	assert.True(t, status.Paused)
+
+	require.NoError(t, k.Stop())
+	_, err = k.GetPlaybackStatus()
+	assert.ErrorIs(t, err, ErrNoActivePlayer)
+
+	// Stopping when nothing is playing is not an error
+	require.NoError(t, k.Stop())
+}
+
+func TestKodi_Errors(t *testing.T) {
+	fake := &fakeKodi{}
+	k := newTestKodi(t, fake)
+
+	// JSON-RPC errors are returned
+	err := k.Call("Player.Unknown", nil, nil)
+	var kodiErr *Error
+	require.ErrorAs(t, err, &kodiErr)
+	assert.Equal(t, -32601, kodiErr.Code)
+
+	// Wrong credentials
+	k.Password = "wrong"
+	assert.Error(t, k.Ping())
+}
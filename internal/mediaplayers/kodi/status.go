@@ -0,0 +1,79 @@
+package kodi
+
+import (
+	"path"
+	"strings"
+)
+
+type (
+	// Playback is the status of the video player.
+	Playback struct {
+		Filename string
+		Filepath string
+		Position float64 // in seconds
+		Duration float64 // in seconds
+		Paused   bool
+	}
+
+	globalTime struct {
+		Hours        int `json:"hours"`
+		Minutes      int `json:"minutes"`
+		Seconds      int `json:"seconds"`
+		Milliseconds int `json:"milliseconds"`
+	}
+)
+
+func (t globalTime) seconds() float64 {
+	return float64(t.Hours*3600+t.Minutes*60+t.Seconds) + float64(t.Milliseconds)/1000
+}
+
+// GetPlaybackStatus returns the status of the video that is playing.
+// ErrNoActivePlayer is returned if nothing is playing.
+func (k *Kodi) GetPlaybackStatus() (*Playback, error) {
+	playerId, err := k.getVideoPlayerId()
+	if err != nil {
+		return nil, err
+	}
+
+	var props struct {
+		Time      globalTime `json:"time"`
+		TotalTime globalTime `json:"totaltime"`
+		Speed     int        `json:"speed"`
+	}
+	err = k.Call("Player.GetProperties", map[string]interface{}{
+		"playerid":   playerId,
+		"properties": []string{"time", "totaltime", "speed"},
+	}, &props)
+	if err != nil {
+		return nil, err
+	}
+
+	var item struct {
+		Item struct {
+			File  string `json:"file"`
+			Label string `json:"label"`
+		} `json:"item"`
+	}
+	err = k.Call("Player.GetItem", map[string]interface{}{
+		"playerid":   playerId,
+		"properties": []string{"file"},
+	}, &item)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &Playback{
+		Filepath: item.Item.File,
+		Position: props.Time.seconds(),
+		Duration: props.TotalTime.seconds(),
+		Paused:   props.Speed == 0,
+	}
+
+	// Kodi uses forward slashes for network shares and URLs, the label is used if there is no file name
+	ret.Filename = path.Base(strings.ReplaceAll(item.Item.File, "\\", "/"))
+	if ret.Filename == "." || ret.Filename == "/" {
+		ret.Filename = item.Item.Label
+	}
+
+	return ret, nil
+}
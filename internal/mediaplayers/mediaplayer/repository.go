@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"github.com/rs/zerolog"
 	"seanime/internal/events"
+	"seanime/internal/extension"
+	"seanime/internal/mediaplayers/kodi"
 	mpchc2 "seanime/internal/mediaplayers/mpchc"
 	"seanime/internal/mediaplayers/mpv"
+	"seanime/internal/mediaplayers/upnp"
 	vlc2 "seanime/internal/mediaplayers/vlc"
 	"seanime/internal/util/result"
 	"sync"
@@ -22,6 +25,9 @@ type (
 		VLC                   *vlc2.VLC
 		MpcHc                 *mpchc2.MpcHc
 		Mpv                   *mpv.Mpv
+		Kodi                  *kodi.Kodi
+		Upnp                  *upnp.Renderer
+		extensionBank         *extension.UnifiedBank // Media player extensions, used if Default is the ID of one
 		wsEventManager        events.WSEventManagerInterface
 		playerInUse           string
 		completionThreshold   float64
@@ -39,8 +45,11 @@ type (
 		VLC            *vlc2.VLC
 		MpcHc          *mpchc2.MpcHc
 		Mpv            *mpv.Mpv
+		Kodi           *kodi.Kodi
+		Upnp           *upnp.Renderer
 		MpvType        string
 		WSEventManager events.WSEventManagerInterface
+		ExtensionBank  *extension.UnifiedBank
 	}
 
 	RepositorySubscriber struct {
@@ -75,6 +84,9 @@ func NewRepository(opts *NewRepositoryOptions) *Repository {
 		VLC:                   opts.VLC,
 		MpcHc:                 opts.MpcHc,
 		Mpv:                   opts.Mpv,
+		Kodi:                  opts.Kodi,
+		Upnp:                  opts.Upnp,
+		extensionBank:         opts.ExtensionBank,
 		wsEventManager:        opts.WSEventManager,
 		completionThreshold:   0.8,
 		subscribers:           result.NewResultMap[string, *RepositorySubscriber](),
@@ -151,8 +163,32 @@ func (m *Repository) Play(path string) error {
 		}
 		//m.exitedCh = m.Mpv.Exited()
 		return nil
+	case "kodi":
+		err := m.Kodi.OpenAndPlay(path)
+		if err != nil {
+			return fmt.Errorf("could not open and play video, make sure Kodi is running and remote control via HTTP is enabled, %s", err.Error())
+		}
+		return nil
+	case "upnp":
+		// The renderer fetches the file from the server
+		err := m.Upnp.OpenAndPlay(path)
+		if errors.Is(err, upnp.ErrServerNotReachable) {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("could not open and play video, make sure the renderer is turned on and reachable, %s", err.Error())
+		}
+		return nil
 	default:
-		return errors.New("no default media player set")
+		player, found := m.getExtensionMediaPlayer()
+		if !found {
+			return errors.New("no default media player set")
+		}
+		err := player.Play(&extension.MediaPlayerPlayRequest{Path: path})
+		if err != nil {
+			return fmt.Errorf("could not open and play video, %s", err.Error())
+		}
+		return nil
 	}
 
 }
@@ -162,14 +198,22 @@ func (m *Repository) Stream(streamUrl string) error {
 	m.Logger.Debug().Str("streamUrl", streamUrl).Msg("media player: Stream requested")
 	var err error
 
+	if player, found := m.getExtensionMediaPlayer(); found {
+		err = player.Stream(&extension.MediaPlayerPlayRequest{Path: streamUrl})
+		if err != nil {
+			return fmt.Errorf("could not open and play video, %s", err.Error())
+		}
+		return nil
+	}
+
 	switch m.Default {
 	case "vlc":
 		err = m.VLC.Start()
 	case "mpc-hc":
 		err = m.MpcHc.Start()
 		_, err = m.MpcHc.OpenAndPlay(streamUrl)
-	case "mpv":
-		// MPV does not need to be started
+	case "mpv", "kodi", "upnp":
+		// MPV, Kodi and UPnP renderers do not need to be started
 	default:
 		return errors.New("no default media player set")
 	}
@@ -188,6 +232,10 @@ func (m *Repository) Stream(streamUrl string) error {
 	case "mpv":
 		err = m.Mpv.OpenAndPlay(streamUrl, "--force-window")
 		//m.exitedCh = m.Mpv.Exited()
+	case "kodi":
+		err = m.Kodi.OpenAndPlay(streamUrl)
+	case "upnp":
+		err = m.Upnp.OpenAndPlay(streamUrl)
 	}
 
 	if err != nil {
@@ -211,6 +259,9 @@ func (m *Repository) Cancel() {
 	if m.Default == "mpv" {
 		m.Mpv.CloseAll()
 	}
+	m.stopKodi()
+	m.stopUpnp()
+	m.stopExtensionMediaPlayer()
 	m.mu.Unlock()
 }
 
@@ -226,6 +277,9 @@ func (m *Repository) Stop() {
 	if m.Default == "mpv" {
 		m.Mpv.CloseAll()
 	}
+	m.stopKodi()
+	m.stopUpnp()
+	m.stopExtensionMediaPlayer()
 	m.mu.Unlock()
 }
 
@@ -545,6 +599,13 @@ func (m *Repository) getStatus() (interface{}, error) {
 		return m.MpcHc.GetVariables()
 	case "mpv":
 		return m.Mpv.GetPlaybackStatus()
+	case "kodi":
+		return m.Kodi.GetPlaybackStatus()
+	case "upnp":
+		return m.Upnp.GetPlaybackStatus()
+	}
+	if player, found := m.getExtensionMediaPlayer(); found {
+		return player.GetPlaybackStatus()
 	}
 	return nil, errors.New("unsupported media player")
 }
@@ -593,8 +654,15 @@ func (m *Repository) processStatus(player string, status interface{}) bool {
 		m.currentPlaybackStatus.Filepath = st.Filepath
 
 		return true
+	case "kodi":
+		// Process Kodi status
+		return m.processKodiStatus(status)
+	case "upnp":
+		// Process UPnP renderer status
+		return m.processUpnpStatus(status)
 	default:
-		return false
+		// Process media player extension status
+		return m.processExtensionStatus(status)
 	}
 }
 
@@ -642,13 +710,112 @@ func (m *Repository) processStreamStatus(player string, status interface{}) bool
 		m.currentPlaybackStatus.Filepath = st.Filepath
 
 		return true
+	case "kodi":
+		// Process Kodi status
+		return m.processKodiStatus(status)
+	case "upnp":
+		// Process UPnP renderer status
+		return m.processUpnpStatus(status)
 	default:
+		// Process media player extension status
+		return m.processExtensionStatus(status)
+	}
+}
+
+func (m *Repository) processKodiStatus(status interface{}) bool {
+	st, ok := status.(*kodi.Playback)
+	if !ok || st == nil || st.Duration <= 0 {
 		return false
 	}
+
+	m.currentPlaybackStatus.CompletionPercentage = st.Position / st.Duration
+	m.currentPlaybackStatus.Playing = !st.Paused
+	m.currentPlaybackStatus.Filename = st.Filename
+	m.currentPlaybackStatus.Duration = int(st.Duration * 1000)
+	m.currentPlaybackStatus.Filepath = st.Filepath
+
+	return true
+}
+
+func (m *Repository) processUpnpStatus(status interface{}) bool {
+	st, ok := status.(*upnp.Playback)
+	if !ok || st == nil || st.Duration <= 0 {
+		return false
+	}
+
+	m.currentPlaybackStatus.CompletionPercentage = st.Position / st.Duration
+	m.currentPlaybackStatus.Playing = !st.Paused
+	m.currentPlaybackStatus.Filename = st.Filename
+	m.currentPlaybackStatus.Duration = int(st.Duration * 1000)
+	m.currentPlaybackStatus.Filepath = "" // The renderer only knows the URL
+
+	return true
+}
+
+func (m *Repository) processExtensionStatus(status interface{}) bool {
+	st, ok := status.(*extension.MediaPlayerPlaybackStatus)
+	if !ok || st == nil || st.Duration <= 0 {
+		return false
+	}
+
+	m.currentPlaybackStatus.CompletionPercentage = st.Position / st.Duration
+	m.currentPlaybackStatus.Playing = !st.Paused
+	m.currentPlaybackStatus.Filename = st.Filename
+	m.currentPlaybackStatus.Duration = int(st.Duration * 1000)
+	m.currentPlaybackStatus.Filepath = st.Filepath
+
+	return true
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// getExtensionMediaPlayer returns the media player extension whose ID is the default media player, if any.
+func (m *Repository) getExtensionMediaPlayer() (extension.MediaPlayer, bool) {
+	switch m.Default {
+	case "", "vlc", "mpc-hc", "mpv", "kodi", "upnp":
+		return nil, false
+	}
+	if m.extensionBank == nil {
+		return nil, false
+	}
+	ext, found := extension.GetExtension[extension.MediaPlayerExtension](m.extensionBank, m.Default)
+	if !found {
+		return nil, false
+	}
+	return ext.GetMediaPlayer(), true
+}
+
+// stopKodi stops the playback if the default media player is Kodi.
+func (m *Repository) stopKodi() {
+	if m.Default != "kodi" || m.Kodi == nil {
+		return
+	}
+	if err := m.Kodi.Stop(); err != nil {
+		m.Logger.Warn().Err(err).Msg("media player: Failed to stop Kodi")
+	}
+}
+
+// stopUpnp stops the renderer if the default media player is a UPnP renderer.
+func (m *Repository) stopUpnp() {
+	if m.Default != "upnp" || m.Upnp == nil {
+		return
+	}
+	if err := m.Upnp.Stop(); err != nil {
+		m.Logger.Warn().Err(err).Msg("media player: Failed to stop UPnP renderer")
+	}
+}
+
+// stopExtensionMediaPlayer stops the playback if the default media player is an extension.
+func (m *Repository) stopExtensionMediaPlayer() {
+	player, found := m.getExtensionMediaPlayer()
+	if !found {
+		return
+	}
+	if err := player.Stop(); err != nil {
+		m.Logger.Warn().Err(err).Str("player", m.Default).Msg("media player: Failed to stop media player extension")
+	}
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 //type PlayMediaOptions struct {
@@ -1,8 +1,14 @@
 package mediaplayer
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"seanime/internal/events"
+	"seanime/internal/extension"
 	"seanime/internal/test_utils"
+	"seanime/internal/util"
 	"testing"
 	"time"
 )
@@ -22,3 +28,79 @@ func TestRepository_StartTracking(t *testing.T) {
 		repo.Stop()
 	}()
 }
+
+type fakeMediaPlayer struct {
+	path    string
+	stopped bool
+}
+
+func (f *fakeMediaPlayer) Play(req *extension.MediaPlayerPlayRequest) error {
+	f.path = req.Path
+	return nil
+}
+
+func (f *fakeMediaPlayer) Stream(req *extension.MediaPlayerPlayRequest) error {
+	f.path = req.Path
+	return nil
+}
+
+func (f *fakeMediaPlayer) GetPlaybackStatus() (*extension.MediaPlayerPlaybackStatus, error) {
+	if f.path == "" {
+		return nil, errors.New("nothing is playing")
+	}
+	return &extension.MediaPlayerPlaybackStatus{
+		Filename: filepath.Base(f.path),
+		Filepath: f.path,
+		Position: 1200,
+		Duration: 1440,
+	}, nil
+}
+
+func (f *fakeMediaPlayer) Stop() error {
+	f.path = ""
+	f.stopped = true
+	return nil
+}
+
+func TestRepository_ExtensionMediaPlayer(t *testing.T) {
+	logger := util.NewLogger()
+
+	player := &fakeMediaPlayer{}
+	bank := extension.NewUnifiedBank()
+	bank.Set("my-player", extension.NewMediaPlayerExtension(&extension.Extension{
+		ID:   "my-player",
+		Name: "My Player",
+		Type: extension.TypeMediaPlayer,
+	}, player))
+
+	repo := NewRepository(&NewRepositoryOptions{
+		Logger:         logger,
+		Default:        "my-player",
+		WSEventManager: events.NewMockWSEventManager(logger),
+		ExtensionBank:  bank,
+	})
+
+	err := repo.Play("/anime/Sousou no Frieren/[SubsPlease] Sousou no Frieren - 01 (1080p) [F02B9CEE].mkv")
+	require.NoError(t, err)
+	assert.Equal(t, "/anime/Sousou no Frieren/[SubsPlease] Sousou no Frieren - 01 (1080p) [F02B9CEE].mkv", player.path)
+
+	status, err := repo.getStatus()
+	require.NoError(t, err)
+	require.True(t, repo.processStatus(repo.Default, status))
+
+	assert.Equal(t, "[SubsPlease] Sousou no Frieren - 01 (1080p) [F02B9CEE].mkv", repo.GetStatus().Filename)
+	assert.InDelta(t, 0.833, repo.GetStatus().CompletionPercentage, 0.001)
+	assert.Equal(t, 1440000, repo.GetStatus().Duration)
+	assert.True(t, repo.GetStatus().Playing)
+
+	err = repo.Stream("http://127.0.0.1:43211/api/v1/torrentstream/stream/episode.mkv")
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:43211/api/v1/torrentstream/stream/episode.mkv", player.path)
+
+	repo.Stop()
+	assert.True(t, player.stopped)
+
+	// Unknown players are not routed to extensions
+	repo.Default = "unknown-player"
+	assert.Error(t, repo.Play("/anime/episode.mkv"))
+}
@@ -0,0 +1,180 @@
+package upnp
+
+// https://upnp.org/specs/av/UPnP-av-AVTransport-v1-Service.pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// arg is an argument of a SOAP action, some renderers expect them in the order of the specification.
+	arg struct {
+		Name  string
+		Value string
+	}
+
+	soapEnvelope struct {
+		Body struct {
+			Inner []byte `xml:",innerxml"`
+		} `xml:"Body"`
+	}
+
+	soapResponse struct {
+		Fields []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	}
+
+	soapFault struct {
+		ErrorCode        int    `xml:"detail>UPnPError>errorCode"`
+		ErrorDescription string `xml:"detail>UPnPError>errorDescription"`
+	}
+)
+
+// call calls the AVTransport action and returns the values of the response.
+func call(controlURL string, action string, args ...arg) (map[string]string, error) {
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?>`)
+	body.WriteString(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	body.WriteString(fmt.Sprintf(`<u:%s xmlns:u="%s">`, action, AVTransportType))
+	for _, a := range args {
+		body.WriteString("<" + a.Name + ">")
+		_ = xml.EscapeText(&body, []byte(a.Value))
+		body.WriteString("</" + a.Name + ">")
+	}
+	body.WriteString(fmt.Sprintf(`</u:%s>`, action))
+	body.WriteString(`</s:Body></s:Envelope>`)
+
+	req, err := http.NewRequest(http.MethodPost, controlURL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s#%s"`, AVTransportType, action))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope soapEnvelope
+	if err = xml.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("upnp: %s failed, http error code: %d", action, resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var fault soapFault
+		if err = xml.Unmarshal(envelope.Body.Inner, &fault); err == nil && fault.ErrorDescription != "" {
+			return nil, fmt.Errorf("upnp: %s failed, %s (%d)", action, fault.ErrorDescription, fault.ErrorCode)
+		}
+		return nil, fmt.Errorf("upnp: %s failed, http error code: %d", action, resp.StatusCode)
+	}
+
+	var res soapResponse
+	if err = xml.Unmarshal(envelope.Body.Inner, &res); err != nil {
+		return nil, fmt.Errorf("upnp: invalid %s response, %w", action, err)
+	}
+
+	ret := make(map[string]string, len(res.Fields))
+	for _, field := range res.Fields {
+		ret[field.XMLName.Local] = field.Value
+	}
+	return ret, nil
+}
+
+func setAVTransportURI(controlURL string, uri string, metadata string) error {
+	_, err := call(controlURL, "SetAVTransportURI",
+		arg{"InstanceID", "0"},
+		arg{"CurrentURI", uri},
+		arg{"CurrentURIMetaData", metadata},
+	)
+	return err
+}
+
+func play(controlURL string) error {
+	_, err := call(controlURL, "Play", arg{"InstanceID", "0"}, arg{"Speed", "1"})
+	return err
+}
+
+func stop(controlURL string) error {
+	_, err := call(controlURL, "Stop", arg{"InstanceID", "0"})
+	return err
+}
+
+// getTransportState returns the state of the renderer, e.g. "PLAYING", "PAUSED_PLAYBACK" or "STOPPED".
+func getTransportState(controlURL string) (string, error) {
+	res, err := call(controlURL, "GetTransportInfo", arg{"InstanceID", "0"})
+	if err != nil {
+		return "", err
+	}
+	return res["CurrentTransportState"], nil
+}
+
+type positionInfo struct {
+	TrackURI string
+	Position float64 // in seconds
+	Duration float64 // in seconds
+}
+
+func getPositionInfo(controlURL string) (*positionInfo, error) {
+	res, err := call(controlURL, "GetPositionInfo", arg{"InstanceID", "0"})
+	if err != nil {
+		return nil, err
+	}
+	return &positionInfo{
+		TrackURI: res["TrackURI"],
+		Position: parseTime(res["RelTime"]),
+		Duration: parseTime(res["TrackDuration"]),
+	}, nil
+}
+
+// parseTime parses a duration in the "H+:MM:SS[.F+]" format.
+// 0 is returned if the value is invalid or "NOT_IMPLEMENTED".
+func parseTime(s string) float64 {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 3 {
+		return 0
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0
+	}
+	// The fraction can be "F+" or "F0/F1", only the former is handled
+	seconds, err := strconv.ParseFloat(strings.SplitN(parts[2], "/", 2)[0], 64)
+	if err != nil {
+		return 0
+	}
+	return float64(hours*3600+minutes*60) + seconds
+}
+
+// didlMetadata returns the minimal DIDL-Lite metadata most TVs require to play a video.
+func didlMetadata(uri string, title string, mimeType string) string {
+	var buf bytes.Buffer
+	buf.WriteString(`<DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/">`)
+	buf.WriteString(`<item id="0" parentID="-1" restricted="1"><dc:title>`)
+	_ = xml.EscapeText(&buf, []byte(title))
+	buf.WriteString(`</dc:title><upnp:class>object.item.videoItem</upnp:class>`)
+	buf.WriteString(fmt.Sprintf(`<res protocolInfo="http-get:*:%s:*">`, mimeType))
+	_ = xml.EscapeText(&buf, []byte(uri))
+	buf.WriteString(`</res></item></DIDL-Lite>`)
+	return buf.String()
+}
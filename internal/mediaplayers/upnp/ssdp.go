@@ -0,0 +1,195 @@
+package upnp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ssdpAddr = "239.255.255.250:1900"
+
+	MediaRendererType = "urn:schemas-upnp-org:device:MediaRenderer:1"
+	AVTransportType   = "urn:schemas-upnp-org:service:AVTransport:1"
+)
+
+type (
+	// Device is a UPnP MediaRenderer that exposes the AVTransport service.
+	Device struct {
+		UDN      string `json:"udn"`
+		Name     string `json:"name"`
+		Location string `json:"location"` // URL of the device description
+		// ControlURL is the absolute URL of the AVTransport control endpoint
+		ControlURL string `json:"-"`
+	}
+
+	deviceDescription struct {
+		URLBase string            `xml:"URLBase"`
+		Device  deviceDescElement `xml:"device"`
+	}
+
+	deviceDescElement struct {
+		DeviceType   string `xml:"deviceType"`
+		FriendlyName string `xml:"friendlyName"`
+		UDN          string `xml:"UDN"`
+		Services     []struct {
+			ServiceType string `xml:"serviceType"`
+			ControlURL  string `xml:"controlURL"`
+		} `xml:"serviceList>service"`
+		Devices []deviceDescElement `xml:"deviceList>device"`
+	}
+)
+
+// Discover sends an SSDP search for the target and returns the renderers that answered before the timeout.
+// The target is usually MediaRendererType, or "uuid:<udn>" to look for a specific device.
+func Discover(target string, timeout time.Duration) ([]*Device, error) {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	dst, err := net.ResolveUDPAddr("udp4", ssdpAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	mx := int(timeout.Seconds())
+	if mx < 1 {
+		mx = 1
+	}
+	msg := fmt.Sprintf("M-SEARCH * HTTP/1.1\r\nHOST: %s\r\nMAN: \"ssdp:discover\"\r\nMX: %d\r\nST: %s\r\n\r\n", ssdpAddr, mx, target)
+	if _, err = conn.WriteTo([]byte(msg), dst); err != nil {
+		return nil, err
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+
+	locations := make([]string, 0)
+	seen := make(map[string]struct{})
+	buf := make([]byte, 4096)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			// The deadline has been reached
+			break
+		}
+		location, ok := parseSearchResponse(buf[:n])
+		if !ok {
+			continue
+		}
+		if _, found := seen[location]; found {
+			continue
+		}
+		seen[location] = struct{}{}
+		locations = append(locations, location)
+	}
+
+	ret := make([]*Device, 0, len(locations))
+	udns := make(map[string]struct{})
+	for _, location := range locations {
+		device, err := FetchDevice(location)
+		if err != nil {
+			continue
+		}
+		if _, found := udns[device.UDN]; found {
+			continue
+		}
+		udns[device.UDN] = struct{}{}
+		ret = append(ret, device)
+	}
+
+	return ret, nil
+}
+
+// parseSearchResponse returns the location of the device description from an SSDP search response.
+func parseSearchResponse(data []byte) (string, bool) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", false
+	}
+	return location, true
+}
+
+// FetchDevice fetches the device description at the location and returns the renderer it describes.
+// An error is returned if the device, or one of its embedded devices, does not have an AVTransport service.
+func FetchDevice(location string) (*Device, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("upnp: http error code: %d", resp.StatusCode)
+	}
+
+	var desc deviceDescription
+	if err = xml.NewDecoder(resp.Body).Decode(&desc); err != nil {
+		return nil, fmt.Errorf("upnp: invalid device description, %w", err)
+	}
+
+	base := location
+	if desc.URLBase != "" {
+		base = desc.URLBase
+	}
+
+	return newDevice(location, base, &desc.Device)
+}
+
+func newDevice(location string, base string, desc *deviceDescElement) (*Device, error) {
+	for _, service := range desc.Services {
+		if !strings.HasPrefix(service.ServiceType, "urn:schemas-upnp-org:service:AVTransport:") {
+			continue
+		}
+		controlURL, err := resolveURL(base, service.ControlURL)
+		if err != nil {
+			return nil, err
+		}
+		return &Device{
+			UDN:        desc.UDN,
+			Name:       desc.FriendlyName,
+			Location:   location,
+			ControlURL: controlURL,
+		}, nil
+	}
+
+	// The renderer can be an embedded device, e.g. for TVs that also expose a media server
+	for _, embedded := range desc.Devices {
+		if device, err := newDevice(location, base, &embedded); err == nil {
+			return device, nil
+		}
+	}
+
+	return nil, errors.New("upnp: device is not a media renderer")
+}
+
+func resolveURL(base string, ref string) (string, error) {
+	baseUrl, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	refUrl, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return baseUrl.ResolveReference(refUrl).String(), nil
+}
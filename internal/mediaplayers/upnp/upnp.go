@@ -0,0 +1,256 @@
+package upnp
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNothingPlaying = errors.New("upnp: no video is playing")
+	// ErrServerNotReachable is returned when the server only accepts connections from this device, so the renderer cannot fetch the video.
+	ErrServerNotReachable = errors.New("upnp: the server is bound to a loopback address and cannot be reached by the renderer, set the server host to 0.0.0.0 or to the address of this device")
+)
+
+// Renderer controls a UPnP/DLNA MediaRenderer, e.g. a smart TV, through its AVTransport service.
+// The renderer fetches the video from Seanime, local files are served by the mediastream file endpoint.
+type Renderer struct {
+	// Device is the UDN of the renderer, found via SSDP, or the URL of its device description
+	Device string
+	// ServerHost is the address the Seanime server is bound to
+	ServerHost string
+	// ServerPort is the port of the Seanime server
+	ServerPort int
+	Logger     *zerolog.Logger
+	mu         sync.Mutex
+	device     *Device
+}
+
+type (
+	// Playback is the status of the renderer.
+	Playback struct {
+		Filename string
+		URI      string
+		Position float64 // in seconds
+		Duration float64 // in seconds
+		Paused   bool
+	}
+)
+
+// GetDevice returns the renderer, looking it up if it has not been found yet.
+func (r *Renderer) GetDevice() (*Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.device != nil {
+		return r.device, nil
+	}
+
+	if r.Device == "" {
+		return nil, errors.New("upnp: no renderer selected")
+	}
+
+	if strings.HasPrefix(r.Device, "http://") || strings.HasPrefix(r.Device, "https://") {
+		device, err := FetchDevice(r.Device)
+		if err != nil {
+			return nil, err
+		}
+		r.device = device
+		return device, nil
+	}
+
+	udn := r.Device
+	if !strings.HasPrefix(udn, "uuid:") {
+		udn = "uuid:" + udn
+	}
+	devices, err := Discover(udn, 3*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		if strings.EqualFold(device.UDN, udn) {
+			r.device = device
+			return device, nil
+		}
+	}
+
+	return nil, fmt.Errorf("upnp: renderer %s not found", r.Device)
+}
+
+// OpenAndPlay plays the local file or stream URL, replacing the current video if any.
+func (r *Renderer) OpenAndPlay(p string) error {
+	device, err := r.GetDevice()
+	if err != nil {
+		return err
+	}
+
+	uri, err := r.getMediaURL(device, p)
+	if err != nil {
+		return err
+	}
+
+	title := getFilename(uri)
+	err = setAVTransportURI(device.ControlURL, uri, didlMetadata(uri, title, getMimeType(title)))
+	if err != nil {
+		r.resetDevice(err)
+		return err
+	}
+
+	if err = play(device.ControlURL); err != nil {
+		return err
+	}
+
+	r.Logger.Debug().Str("uri", uri).Str("renderer", device.Name).Msg("upnp: Playing video")
+	return nil
+}
+
+// GetPlaybackStatus returns the status of the video that is playing.
+// ErrNothingPlaying is returned if the renderer is stopped.
+func (r *Renderer) GetPlaybackStatus() (*Playback, error) {
+	device, err := r.GetDevice()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := getTransportState(device.ControlURL)
+	if err != nil {
+		r.resetDevice(err)
+		return nil, err
+	}
+
+	switch state {
+	case "STOPPED", "NO_MEDIA_PRESENT":
+		return nil, ErrNothingPlaying
+	}
+
+	info, err := getPositionInfo(device.ControlURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Playback{
+		Filename: getFilename(info.TrackURI),
+		URI:      info.TrackURI,
+		Position: info.Position,
+		Duration: info.Duration,
+		Paused:   state != "PLAYING",
+	}, nil
+}
+
+// Stop stops the renderer.
+func (r *Renderer) Stop() error {
+	device, err := r.GetDevice()
+	if err != nil {
+		return err
+	}
+	return stop(device.ControlURL)
+}
+
+// resetDevice forgets the renderer if it cannot be reached, its address might have changed.
+func (r *Renderer) resetDevice(err error) {
+	var netErr net.Error
+	if !errors.As(err, &netErr) {
+		return
+	}
+	r.mu.Lock()
+	r.device = nil
+	r.mu.Unlock()
+}
+
+// getMediaURL returns a URL the renderer can fetch the video from.
+//   - Local files are served by the mediastream file endpoint.
+//   - Loopback stream URLs, e.g. from the torrent streaming server, are rewritten to use the LAN address.
+//
+// ErrServerNotReachable is returned if the video is served by the server and the server is bound to a loopback address.
+func (r *Renderer) getMediaURL(device *Device, p string) (string, error) {
+	isStreamURL := strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://")
+	if !isStreamURL && isLoopbackHost(r.ServerHost) {
+		return "", ErrServerNotReachable
+	}
+
+	localIP, err := getLocalIP(device.Location)
+	if err != nil {
+		return "", fmt.Errorf("upnp: could not find the address of the server on the network, %w", err)
+	}
+
+	if isStreamURL {
+		u, err := url.Parse(p)
+		if err != nil {
+			return "", err
+		}
+		switch u.Hostname() {
+		case "127.0.0.1", "localhost", "0.0.0.0", "::1":
+			if isLoopbackHost(r.ServerHost) {
+				return "", ErrServerNotReachable
+			}
+			if port := u.Port(); port != "" {
+				u.Host = net.JoinHostPort(localIP, port)
+			} else {
+				u.Host = localIP
+			}
+		}
+		return u.String(), nil
+	}
+
+	return fmt.Sprintf("http://%s/api/v1/mediastream/file/%s", net.JoinHostPort(localIP, fmt.Sprint(r.ServerPort)), url.PathEscape(p)), nil
+}
+
+// isLoopbackHost returns true if the host only accepts connections from this device.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// getLocalIP returns the address of the interface used to reach the device.
+func getLocalIP(location string) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+	}
+	// No packet is sent, dialing UDP only selects the route
+	conn, err := net.Dial("udp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
+
+func getFilename(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	// The mediastream file endpoint escapes the whole path, including the separators
+	name := path.Base(strings.ReplaceAll(u.Path, "\\", "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+func getMimeType(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".mp4", ".m4v":
+		return "video/mp4"
+	case ".webm":
+		return "video/webm"
+	case ".avi":
+		return "video/x-msvideo"
+	default:
+		return "video/x-matroska"
+	}
+}
@@ -0,0 +1,221 @@
+package upnp
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"seanime/internal/util"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const fakeDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:MediaRenderer:1</deviceType>
+    <friendlyName>Living Room TV</friendlyName>
+    <UDN>uuid:1234-5678</UDN>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:RenderingControl:1</serviceType>
+        <controlURL>/upnp/control/RenderingControl</controlURL>
+      </service>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:AVTransport:1</serviceType>
+        <controlURL>/upnp/control/AVTransport</controlURL>
+      </service>
+    </serviceList>
+  </device>
+</root>`
+
+// fakeRenderer is a minimal AVTransport implementation.
+type fakeRenderer struct {
+	mu    sync.Mutex
+	uri   string
+	state string
+}
+
+func (f *fakeRenderer) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/description.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fakeDescription))
+	})
+	mux.HandleFunc("/upnp/control/AVTransport", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		action := strings.TrimSuffix(strings.SplitN(r.Header.Get("SOAPAction"), "#", 2)[1], `"`)
+		body, _ := io.ReadAll(r.Body)
+
+		var req struct {
+			Body struct {
+				Action struct {
+					CurrentURI string `xml:"CurrentURI"`
+					Metadata   string `xml:"CurrentURIMetaData"`
+				} `xml:",any"`
+			} `xml:"Body"`
+		}
+		assert.NoError(t, xml.Unmarshal(body, &req))
+
+		values := ""
+		switch action {
+		case "SetAVTransportURI":
+			assert.Contains(t, req.Body.Action.Metadata, "<DIDL-Lite")
+			f.uri = req.Body.Action.CurrentURI
+			f.state = "STOPPED"
+		case "Play":
+			if f.uri == "" {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = fmt.Fprintf(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault><detail><UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>701</errorCode><errorDescription>Transition not available</errorDescription></UPnPError></detail></s:Fault></s:Body></s:Envelope>`)
+				return
+			}
+			f.state = "PLAYING"
+		case "Stop":
+			f.state = "STOPPED"
+		case "GetTransportInfo":
+			state := f.state
+			if state == "" {
+				state = "NO_MEDIA_PRESENT"
+			}
+			values = "<CurrentTransportState>" + state + "</CurrentTransportState><CurrentTransportStatus>OK</CurrentTransportStatus>"
+		case "GetPositionInfo":
+			var uri strings.Builder
+			_ = xml.EscapeText(&uri, []byte(f.uri))
+			values = "<Track>1</Track><TrackDuration>0:24:00</TrackDuration><TrackURI>" + uri.String() + "</TrackURI><RelTime>0:05:00.500</RelTime>"
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:%sResponse xmlns:u="%s">%s</u:%sResponse></s:Body></s:Envelope>`,
+			action, AVTransportType, values, action)
+	})
+	return mux
+}
+
+func TestRenderer_Playback(t *testing.T) {
+	fake := &fakeRenderer{}
+	server := httptest.NewServer(fake.handler(t))
+	defer server.Close()
+
+	r := &Renderer{
+		Device:     server.URL + "/description.xml",
+		ServerPort: 43211,
+		Logger:     util.NewLogger(),
+	}
+
+	device, err := r.GetDevice()
+	require.NoError(t, err)
+	assert.Equal(t, "Living Room TV", device.Name)
+	assert.Equal(t, "uuid:1234-5678", device.UDN)
+	assert.Equal(t, server.URL+"/upnp/control/AVTransport", device.ControlURL)
+
+	// Nothing is playing
+	_, err = r.GetPlaybackStatus()
+	assert.ErrorIs(t, err, ErrNothingPlaying)
+
+	// Local files are served by the mediastream file endpoint
+	filepath := "/anime/Sousou no Frieren/[SubsPlease] Sousou no Frieren - 01 (1080p) [F02B9CEE].mkv"
+	require.NoError(t, r.OpenAndPlay(filepath))
+
+	u, err := url.Parse(fake.uri)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:43211", u.Host)
+	assert.Equal(t, "/api/v1/mediastream/file/"+filepath, u.Path)
+
+	status, err := r.GetPlaybackStatus()
+	require.NoError(t, err)
+	assert.Equal(t, "[SubsPlease] Sousou no Frieren - 01 (1080p) [F02B9CEE].mkv", status.Filename)
+	assert.Equal(t, 300.5, status.Position)
+	assert.Equal(t, float64(1440), status.Duration)
+	assert.False(t, status.Paused)
+
+	require.NoError(t, r.Stop())
+
+	_, err = r.GetPlaybackStatus()
+	assert.ErrorIs(t, err, ErrNothingPlaying)
+}
+
+func TestRenderer_Errors(t *testing.T) {
+	fake := &fakeRenderer{}
+	server := httptest.NewServer(fake.handler(t))
+	defer server.Close()
+
+	device, err := FetchDevice(server.URL + "/description.xml")
+	require.NoError(t, err)
+
+	// The fault description is returned
+	err = play(device.ControlURL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Transition not available")
+
+	// No renderer selected
+	_, err = (&Renderer{Logger: util.NewLogger()}).GetDevice()
+	assert.Error(t, err)
+}
+
+func TestGetMediaURL(t *testing.T) {
+	r := &Renderer{ServerPort: 43211}
+	device := &Device{Location: "http://127.0.0.1:1400/description.xml"}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{
+			path:     "/anime/Frieren/01.mkv",
+			expected: "http://127.0.0.1:43211/api/v1/mediastream/file/%2Fanime%2FFrieren%2F01.mkv",
+		},
+		{
+			// Torrent streaming server bound to all interfaces
+			path:     "http://0.0.0.0:43214/stream/01.mkv",
+			expected: "http://127.0.0.1:43214/stream/01.mkv",
+		},
+		{
+			path:     "http://192.168.1.20:43214/stream/01.mkv",
+			expected: "http://192.168.1.20:43214/stream/01.mkv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			ret, err := r.getMediaURL(device, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ret)
+		})
+	}
+
+	// The renderer cannot reach a server bound to a loopback address
+	r.ServerHost = "127.0.0.1"
+	_, err := r.getMediaURL(device, "/anime/Frieren/01.mkv")
+	assert.ErrorIs(t, err, ErrServerNotReachable)
+	_, err = r.getMediaURL(device, "http://127.0.0.1:43211/api/v1/torrentstream/stream")
+	assert.ErrorIs(t, err, ErrServerNotReachable)
+	ret, err := r.getMediaURL(device, "http://192.168.1.20:43214/stream/01.mkv")
+	require.NoError(t, err)
+	assert.Equal(t, "http://192.168.1.20:43214/stream/01.mkv", ret)
+}
+
+func TestParseTime(t *testing.T) {
+	assert.Equal(t, float64(0), parseTime("NOT_IMPLEMENTED"))
+	assert.Equal(t, float64(0), parseTime(""))
+	assert.Equal(t, float64(1440), parseTime("0:24:00"))
+	assert.Equal(t, 3723.25, parseTime("01:02:03.25"))
+}
+
+func TestParseSearchResponse(t *testing.T) {
+	data := "HTTP/1.1 200 OK\r\nCACHE-CONTROL: max-age=1800\r\nLOCATION: http://192.168.1.30:1400/xml/device_description.xml\r\nST: urn:schemas-upnp-org:device:MediaRenderer:1\r\nUSN: uuid:1234-5678::urn:schemas-upnp-org:device:MediaRenderer:1\r\n\r\n"
+
+	location, ok := parseSearchResponse([]byte(data))
+	require.True(t, ok)
+	assert.Equal(t, "http://192.168.1.30:1400/xml/device_description.xml", location)
+
+	_, ok = parseSearchResponse([]byte("NOTIFY * HTTP/1.1\r\n\r\n"))
+	assert.False(t, ok)
+}
@@ -24,6 +24,7 @@ type (
 		rawMangaCollection   mo.Option[*anilist.MangaCollection]
 		isOffline            bool
 		localPlatformEnabled bool
+		mutationQueue        mo.Option[*MutationQueue] // List mutations go through the queue if present
 	}
 )
 
@@ -36,11 +37,20 @@ func NewAnilistPlatform(anilistClient anilist.AnilistClient, logger *zerolog.Log
 		rawAnimeCollection: mo.None[*anilist.AnimeCollection](),
 		mangaCollection:    mo.None[*anilist.MangaCollection](),
 		rawMangaCollection: mo.None[*anilist.MangaCollection](),
+		mutationQueue:      mo.None[*MutationQueue](),
 	}
 
 	return ap
 }
 
+// NewAnilistPlatformWithMutationQueue is the same as NewAnilistPlatform but list mutations are sent through the queue.
+func NewAnilistPlatformWithMutationQueue(anilistClient anilist.AnilistClient, queue *MutationQueue, logger *zerolog.Logger) platform.Platform {
+	ap := NewAnilistPlatform(anilistClient, logger).(*AnilistPlatform)
+	queue.sendFunc = ap.sendMutation
+	ap.mutationQueue = mo.Some(queue)
+	return ap
+}
+
 func (ap *AnilistPlatform) SetUsername(username string) {
 	// Set the username for the AnilistPlatform
 	if username == "" {
@@ -58,6 +68,22 @@ func (ap *AnilistPlatform) SetAnilistClient(client anilist.AnilistClient) {
 }
 
 func (ap *AnilistPlatform) UpdateEntry(mediaID int, status *anilist.MediaListStatus, scoreRaw *int, progress *int, startedAt *anilist.FuzzyDateInput, completedAt *anilist.FuzzyDateInput) error {
+	if queue, ok := ap.mutationQueue.Get(); ok {
+		return queue.Push(&QueuedMutation{
+			MediaID: mediaID,
+			Entry: &EntryMutation{
+				Status:      status,
+				ScoreRaw:    scoreRaw,
+				Progress:    progress,
+				StartedAt:   startedAt,
+				CompletedAt: completedAt,
+			},
+		})
+	}
+	return ap.updateEntry(mediaID, status, scoreRaw, progress, startedAt, completedAt)
+}
+
+func (ap *AnilistPlatform) updateEntry(mediaID int, status *anilist.MediaListStatus, scoreRaw *int, progress *int, startedAt *anilist.FuzzyDateInput, completedAt *anilist.FuzzyDateInput) error {
 	ap.logger.Trace().Msg("anilist platform: Updating entry")
 	_, err := ap.anilistClient.UpdateMediaListEntry(context.Background(), &mediaID, status, scoreRaw, progress, startedAt, completedAt)
 	if err != nil {
@@ -67,6 +93,19 @@ func (ap *AnilistPlatform) UpdateEntry(mediaID int, status *anilist.MediaListSta
 }
 
 func (ap *AnilistPlatform) UpdateEntryProgress(mediaID int, progress int, totalEpisodes *int) error {
+	if queue, ok := ap.mutationQueue.Get(); ok {
+		return queue.Push(&QueuedMutation{
+			MediaID: mediaID,
+			Progress: &ProgressMutation{
+				Progress:      progress,
+				TotalEpisodes: totalEpisodes,
+			},
+		})
+	}
+	return ap.updateEntryProgress(mediaID, progress, totalEpisodes)
+}
+
+func (ap *AnilistPlatform) updateEntryProgress(mediaID int, progress int, totalEpisodes *int) error {
 	ap.logger.Trace().Msg("anilist platform: Updating entry progress")
 
 	totalEp := 0
@@ -112,15 +151,64 @@ func (ap *AnilistPlatform) UpdateEntryProgress(mediaID int, progress int, totalE
 	return nil
 }
 
+// sendMutation sends a queued mutation.
+// If it fails, it returns the part of the mutation that has not been sent.
+func (ap *AnilistPlatform) sendMutation(m *QueuedMutation) (*QueuedMutation, error) {
+	if m.Entry != nil {
+		err := ap.updateEntry(m.MediaID, m.Entry.Status, m.Entry.ScoreRaw, m.Entry.Progress, m.Entry.StartedAt, m.Entry.CompletedAt)
+		if err != nil {
+			return m, err
+		}
+	}
+	if m.Progress != nil {
+		err := ap.updateEntryProgress(m.MediaID, m.Progress.Progress, m.Progress.TotalEpisodes)
+		if err != nil {
+			return &QueuedMutation{MediaID: m.MediaID, Progress: m.Progress}, err
+		}
+	}
+	return nil, nil
+}
+
 func (ap *AnilistPlatform) DeleteEntry(mediaID int) error {
 	ap.logger.Trace().Msg("anilist platform: Deleting entry")
 	_, err := ap.anilistClient.DeleteEntry(context.Background(), &mediaID)
 	if err != nil {
 		return err
 	}
+
+	// Drop the pending mutation so that it does not add the entry back
+	if queue, ok := ap.mutationQueue.Get(); ok {
+		if id, found := ap.getMediaIdFromEntryId(mediaID); found {
+			_ = queue.Remove(id)
+		}
+	}
+
 	return nil
 }
 
+// getMediaIdFromEntryId returns the media ID of a list entry from the cached collections.
+func (ap *AnilistPlatform) getMediaIdFromEntryId(entryId int) (int, bool) {
+	if collection, ok := ap.rawAnimeCollection.Get(); ok && collection.MediaListCollection != nil {
+		for _, list := range collection.MediaListCollection.Lists {
+			for _, entry := range list.GetEntries() {
+				if entry.GetID() == entryId {
+					return entry.GetMedia().GetID(), true
+				}
+			}
+		}
+	}
+	if collection, ok := ap.rawMangaCollection.Get(); ok && collection.MediaListCollection != nil {
+		for _, list := range collection.MediaListCollection.Lists {
+			for _, entry := range list.GetEntries() {
+				if entry.GetID() == entryId {
+					return entry.GetMedia().GetID(), true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
 func (ap *AnilistPlatform) GetAnime(mediaID int) (*anilist.BaseAnime, error) {
 	ap.logger.Trace().Msg("anilist platform: Fetching anime")
 	ret, err := ap.anilistClient.BaseAnimeByID(context.Background(), &mediaID)
@@ -0,0 +1,407 @@
+package anilist_platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Yamashou/gqlgenc/clientv2"
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+	"net/http"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/models"
+	"seanime/internal/events"
+	"seanime/internal/util"
+	"sync"
+	"time"
+)
+
+const (
+	mutationQueueInterval    = 10 * time.Second
+	mutationQueueBaseBackoff = 30 * time.Second
+	mutationQueueMaxBackoff  = 1 * time.Hour
+)
+
+type (
+	// MutationQueue is a persistent queue of AniList list mutations.
+	//
+	// Mutations are saved in the database before being sent, so they survive restarts.
+	// Pending mutations for the same media are coalesced into one.
+	// Mutations that fail because of the network or rate limiting are retried with backoff until they succeed,
+	// other errors are returned to the caller and the mutation is dropped.
+	MutationQueue struct {
+		logger                 *zerolog.Logger
+		database               MutationStore
+		wsEventManager         events.WSEventManagerInterface
+		sendFunc               func(m *QueuedMutation) (*QueuedMutation, error) // Set by the AnilistPlatform
+		refreshCollectionsFunc func()
+		nowFunc                func() time.Time
+		mu                     sync.Mutex // Only one mutation is sent at a time
+		stopCh                 chan struct{}
+		started                bool
+	}
+
+	NewMutationQueueOptions struct {
+		Logger         *zerolog.Logger
+		Database       MutationStore
+		WSEventManager events.WSEventManagerInterface
+	}
+
+	// MutationStore persists the queued mutations, it is implemented by db.Database.
+	// The package cannot import db directly, as that would create an import cycle through library/anime.
+	MutationStore interface {
+		GetAnilistMutations() ([]*models.AnilistMutation, error)
+		GetAnilistMutation(mediaId int) (*models.AnilistMutation, bool)
+		SaveAnilistMutation(mutation *models.AnilistMutation) error
+		DeleteAnilistMutation(mediaId int) error
+	}
+
+	// QueuedMutation is a pending mutation for a media.
+	// If both parts are set, the entry update is sent before the progress update.
+	QueuedMutation struct {
+		MediaID       int               `json:"mediaId"`
+		Entry         *EntryMutation    `json:"entry,omitempty"`
+		Progress      *ProgressMutation `json:"progress,omitempty"`
+		Attempts      int               `json:"attempts"`
+		NextAttemptAt time.Time         `json:"nextAttemptAt"`
+		LastError     string            `json:"lastError"`
+		CreatedAt     time.Time         `json:"createdAt"`
+		UpdatedAt     time.Time         `json:"updatedAt"`
+	}
+
+	// EntryMutation is an AnilistPlatform.UpdateEntry call.
+	EntryMutation struct {
+		Status      *anilist.MediaListStatus `json:"status,omitempty"`
+		ScoreRaw    *int                     `json:"scoreRaw,omitempty"`
+		Progress    *int                     `json:"progress,omitempty"`
+		StartedAt   *anilist.FuzzyDateInput  `json:"startedAt,omitempty"`
+		CompletedAt *anilist.FuzzyDateInput  `json:"completedAt,omitempty"`
+	}
+
+	// ProgressMutation is an AnilistPlatform.UpdateEntryProgress call.
+	ProgressMutation struct {
+		Progress      int  `json:"progress"`
+		TotalEpisodes *int `json:"totalEpisodes,omitempty"`
+	}
+
+	mutationData struct {
+		Entry    *EntryMutation    `json:"entry,omitempty"`
+		Progress *ProgressMutation `json:"progress,omitempty"`
+	}
+)
+
+func NewMutationQueue(opts *NewMutationQueueOptions) *MutationQueue {
+	return &MutationQueue{
+		logger:                 opts.Logger,
+		database:               opts.Database,
+		wsEventManager:         opts.WSEventManager,
+		refreshCollectionsFunc: func() {},
+		nowFunc:                time.Now,
+		stopCh:                 make(chan struct{}),
+	}
+}
+
+// SetRefreshCollectionsFunc sets the function called after queued mutations are sent in the background.
+func (q *MutationQueue) SetRefreshCollectionsFunc(f func()) {
+	q.refreshCollectionsFunc = f
+}
+
+// Start processes the pending mutations periodically.
+// This is run in a goroutine.
+func (q *MutationQueue) Start() {
+	q.mu.Lock()
+	if q.started {
+		q.mu.Unlock()
+		return
+	}
+	q.started = true
+	q.mu.Unlock()
+
+	go func() {
+		defer util.HandlePanicInModuleThen("anilist_platform/MutationQueue", func() {})
+
+		// Send the mutations left from the previous session
+		q.processDue(false)
+
+		ticker := time.NewTicker(mutationQueueInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-q.stopCh:
+				return
+			case <-ticker.C:
+				q.processDue(false)
+			}
+		}
+	}()
+}
+
+func (q *MutationQueue) Stop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.started {
+		close(q.stopCh)
+		q.started = false
+		q.stopCh = make(chan struct{})
+	}
+}
+
+// GetMutations returns the pending mutations, oldest first.
+func (q *MutationQueue) GetMutations() ([]*QueuedMutation, error) {
+	rows, err := q.database.GetAnilistMutations()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*QueuedMutation, 0, len(rows))
+	for _, row := range rows {
+		m, err := fromMutationModel(row)
+		if err != nil {
+			q.logger.Warn().Err(err).Int("mediaId", row.MediaID).Msg("anilist platform: Invalid queued mutation")
+			continue
+		}
+		ret = append(ret, m)
+	}
+	return ret, nil
+}
+
+// RetryNow sends all the pending mutations, ignoring their backoff.
+func (q *MutationQueue) RetryNow() {
+	q.processDue(true)
+}
+
+// Remove drops the pending mutation of a media.
+func (q *MutationQueue) Remove(mediaId int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.database.DeleteAnilistMutation(mediaId)
+}
+
+// Push saves the mutation, coalescing it with a pending mutation for the same media, and tries to send it.
+//
+// It returns nil if the mutation was sent or if it could not be sent because of a temporary error,
+// in which case it stays in the queue and the user is warned.
+func (q *MutationQueue) Push(m *QueuedMutation) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if prev, found := q.getMutation(m.MediaID); found {
+		m = coalesceMutations(prev, m)
+		q.logger.Debug().Int("mediaId", m.MediaID).Msg("anilist platform: Coalesced queued mutation")
+	}
+
+	if err := q.saveMutation(m); err != nil {
+		// Fall back to sending the mutation without the queue
+		q.logger.Error().Err(err).Msg("anilist platform: Failed to save mutation")
+		_, err = q.sendFunc(m)
+		return err
+	}
+
+	err := q.send(m)
+	if err != nil && isRetryableError(err) {
+		q.wsEventManager.SendEvent(events.WarningToast, "Could not reach AniList, the update will be sent later")
+		return nil
+	}
+
+	return err
+}
+
+// processDue sends the mutations whose next attempt is due.
+func (q *MutationQueue) processDue(force bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// The queue is not used by the platform
+	if q.sendFunc == nil {
+		return
+	}
+
+	rows, err := q.database.GetAnilistMutations()
+	if err != nil {
+		q.logger.Error().Err(err).Msg("anilist platform: Failed to get queued mutations")
+		return
+	}
+
+	sent := 0
+	for _, row := range rows {
+		if !force && row.NextAttemptAt.After(q.nowFunc()) {
+			continue
+		}
+		m, err := fromMutationModel(row)
+		if err != nil {
+			q.logger.Warn().Err(err).Int("mediaId", row.MediaID).Msg("anilist platform: Dropping invalid queued mutation")
+			_ = q.database.DeleteAnilistMutation(row.MediaID)
+			continue
+		}
+		err = q.send(m)
+		if err == nil {
+			sent++
+		} else if !isRetryableError(err) {
+			q.wsEventManager.SendEvent(events.ErrorToast, fmt.Sprintf("Failed to update AniList entry: %s", err.Error()))
+		}
+	}
+
+	if sent > 0 {
+		q.logger.Info().Int("count", sent).Msg("anilist platform: Sent queued mutations")
+		q.refreshCollectionsFunc()
+	}
+}
+
+// send sends a saved mutation and updates the queue accordingly.
+func (q *MutationQueue) send(m *QueuedMutation) error {
+	rest, err := q.sendFunc(m)
+	if err == nil {
+		_ = q.database.DeleteAnilistMutation(m.MediaID)
+		return nil
+	}
+
+	if !isRetryableError(err) {
+		q.logger.Error().Err(err).Int("mediaId", m.MediaID).Msg("anilist platform: Dropping mutation")
+		_ = q.database.DeleteAnilistMutation(m.MediaID)
+		return err
+	}
+
+	// Only keep the part that has not been sent yet
+	if rest != nil {
+		m.Entry, m.Progress = rest.Entry, rest.Progress
+	}
+	m.Attempts++
+	m.LastError = err.Error()
+	m.NextAttemptAt = q.nowFunc().Add(getBackoff(m.Attempts))
+	q.logger.Warn().Err(err).Int("mediaId", m.MediaID).Int("attempts", m.Attempts).Time("nextAttempt", m.NextAttemptAt).Msg("anilist platform: Mutation will be retried")
+	if saveErr := q.saveMutation(m); saveErr != nil {
+		q.logger.Error().Err(saveErr).Msg("anilist platform: Failed to save mutation")
+	}
+	return err
+}
+
+func (q *MutationQueue) getMutation(mediaId int) (*QueuedMutation, bool) {
+	row, found := q.database.GetAnilistMutation(mediaId)
+	if !found {
+		return nil, false
+	}
+	m, err := fromMutationModel(row)
+	if err != nil {
+		return nil, false
+	}
+	return m, true
+}
+
+func (q *MutationQueue) saveMutation(m *QueuedMutation) error {
+	data, err := json.Marshal(&mutationData{Entry: m.Entry, Progress: m.Progress})
+	if err != nil {
+		return err
+	}
+
+	row := &models.AnilistMutation{
+		MediaID:       m.MediaID,
+		Data:          data,
+		Attempts:      m.Attempts,
+		NextAttemptAt: m.NextAttemptAt,
+		LastError:     m.LastError,
+	}
+	if prev, found := q.database.GetAnilistMutation(m.MediaID); found {
+		row.ID = prev.ID
+		row.CreatedAt = prev.CreatedAt
+	}
+
+	return q.database.SaveAnilistMutation(row)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func fromMutationModel(row *models.AnilistMutation) (*QueuedMutation, error) {
+	var data mutationData
+	if err := json.Unmarshal(row.Data, &data); err != nil {
+		return nil, err
+	}
+	return &QueuedMutation{
+		MediaID:       row.MediaID,
+		Entry:         data.Entry,
+		Progress:      data.Progress,
+		Attempts:      row.Attempts,
+		NextAttemptAt: row.NextAttemptAt,
+		LastError:     row.LastError,
+		CreatedAt:     row.CreatedAt,
+		UpdatedAt:     row.UpdatedAt,
+	}, nil
+}
+
+// coalesceMutations merges a new mutation into a pending one.
+// The attempts of the pending mutation are reset since the new mutation should be sent right away.
+func coalesceMutations(prev *QueuedMutation, next *QueuedMutation) *QueuedMutation {
+	ret := &QueuedMutation{
+		MediaID:   next.MediaID,
+		Entry:     prev.Entry,
+		Progress:  prev.Progress,
+		CreatedAt: prev.CreatedAt,
+	}
+
+	if next.Entry != nil {
+		entry := next.Entry
+		if prev.Entry != nil {
+			entry = &EntryMutation{
+				Status:      coalesce(next.Entry.Status, prev.Entry.Status),
+				ScoreRaw:    coalesce(next.Entry.ScoreRaw, prev.Entry.ScoreRaw),
+				Progress:    coalesce(next.Entry.Progress, prev.Entry.Progress),
+				StartedAt:   coalesce(next.Entry.StartedAt, prev.Entry.StartedAt),
+				CompletedAt: coalesce(next.Entry.CompletedAt, prev.Entry.CompletedAt),
+			}
+		}
+		// A pending progress update happened before the entry update, fold it into the entry update
+		if prev.Progress != nil && entry.Progress == nil {
+			progress := prev.Progress.Progress
+			entry.Progress = &progress
+		}
+		ret.Entry = entry
+		ret.Progress = nil
+	}
+
+	if next.Progress != nil {
+		ret.Progress = next.Progress
+	}
+
+	return ret
+}
+
+func coalesce[T any](a *T, b *T) *T {
+	if a != nil {
+		return a
+	}
+	return b
+}
+
+func getBackoff(attempts int) time.Duration {
+	backoff := mutationQueueBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= mutationQueueMaxBackoff {
+			return mutationQueueMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// isRetryableError returns true if the request could succeed later,
+// i.e. it failed because of the network, rate limiting or a server error.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var errResponse *clientv2.ErrorResponse
+	if errors.As(err, &errResponse) {
+		if errResponse.NetworkError != nil {
+			code := errResponse.NetworkError.Code
+			return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+		}
+		// GraphQL errors, e.g. invalid input
+		return false
+	}
+
+	// The request did not go through
+	return true
+}
@@ -0,0 +1,174 @@
+package anilist_platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Yamashou/gqlgenc/clientv2"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seanime/internal/api/anilist"
+	"seanime/internal/database/db"
+	"seanime/internal/events"
+	"seanime/internal/util"
+	"testing"
+	"time"
+)
+
+// fakeMutationClient records list mutations and fails with err if set.
+type fakeMutationClient struct {
+	anilist.AnilistClient
+	err         error
+	progressErr error // Only used by UpdateMediaListEntryProgress
+	progress    map[int]int
+	status      map[int]anilist.MediaListStatus
+}
+
+func (c *fakeMutationClient) UpdateMediaListEntry(_ context.Context, mediaID *int, status *anilist.MediaListStatus, _ *int, progress *int, _ *anilist.FuzzyDateInput, _ *anilist.FuzzyDateInput, _ ...clientv2.RequestInterceptor) (*anilist.UpdateMediaListEntry, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if status != nil {
+		c.status[*mediaID] = *status
+	}
+	if progress != nil {
+		c.progress[*mediaID] = *progress
+	}
+	return &anilist.UpdateMediaListEntry{}, nil
+}
+
+func (c *fakeMutationClient) UpdateMediaListEntryProgress(_ context.Context, mediaID *int, progress *int, status *anilist.MediaListStatus, _ ...clientv2.RequestInterceptor) (*anilist.UpdateMediaListEntryProgress, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.progressErr != nil {
+		return nil, c.progressErr
+	}
+	c.status[*mediaID] = *status
+	c.progress[*mediaID] = *progress
+	return &anilist.UpdateMediaListEntryProgress{}, nil
+}
+
+func newTestMutationQueue(t *testing.T, database *db.Database, client *fakeMutationClient) (*AnilistPlatform, *MutationQueue) {
+	logger := util.NewLogger()
+	queue := NewMutationQueue(&NewMutationQueueOptions{
+		Logger:         logger,
+		Database:       database,
+		WSEventManager: events.NewMockWSEventManager(logger),
+	})
+	p := NewAnilistPlatformWithMutationQueue(client, queue, logger)
+	return p.(*AnilistPlatform), queue
+}
+
+func newTestMutationClient(err error) *fakeMutationClient {
+	return &fakeMutationClient{
+		err:      err,
+		progress: make(map[int]int),
+		status:   make(map[int]anilist.MediaListStatus),
+	}
+}
+
+func TestMutationQueue(t *testing.T) {
+	database, err := db.NewDatabase(t.TempDir(), "test", util.NewLogger())
+	require.NoError(t, err)
+
+	networkErr := fmt.Errorf("request failed: %w", errors.New("dial tcp: no such host"))
+	client := newTestMutationClient(networkErr)
+	p, queue := newTestMutationQueue(t, database, client)
+
+	// The mutation is kept when AniList cannot be reached
+	require.NoError(t, p.UpdateEntryProgress(1, 5, lo.ToPtr(12)))
+
+	mutations, err := queue.GetMutations()
+	require.NoError(t, err)
+	require.Len(t, mutations, 1)
+	assert.Equal(t, 1, mutations[0].Attempts)
+	assert.Equal(t, 5, mutations[0].Progress.Progress)
+	assert.NotEmpty(t, mutations[0].LastError)
+
+	// Updates to the same media are coalesced
+	require.NoError(t, p.UpdateEntry(1, lo.ToPtr(anilist.MediaListStatusPaused), nil, nil, nil, nil))
+	require.NoError(t, p.UpdateEntryProgress(1, 6, lo.ToPtr(12)))
+
+	mutations, err = queue.GetMutations()
+	require.NoError(t, err)
+	require.Len(t, mutations, 1)
+	assert.Equal(t, anilist.MediaListStatusPaused, *mutations[0].Entry.Status)
+	assert.Equal(t, 5, *mutations[0].Entry.Progress)
+	assert.Equal(t, 6, mutations[0].Progress.Progress)
+
+	// Not due yet
+	client.err = nil
+	queue.processDue(false)
+	mutations, _ = queue.GetMutations()
+	require.Len(t, mutations, 1)
+
+	// The queue survives a restart
+	client2 := newTestMutationClient(nil)
+	_, queue2 := newTestMutationQueue(t, database, client2)
+	queue2.RetryNow()
+
+	mutations, err = queue2.GetMutations()
+	require.NoError(t, err)
+	assert.Empty(t, mutations)
+	assert.Equal(t, 6, client2.progress[1])
+	assert.Equal(t, anilist.MediaListStatusCurrent, client2.status[1]) // The progress update is sent last
+}
+
+func TestMutationQueue_PermanentError(t *testing.T) {
+	database, err := db.NewDatabase(t.TempDir(), "test", util.NewLogger())
+	require.NoError(t, err)
+
+	client := newTestMutationClient(&clientv2.ErrorResponse{
+		NetworkError: &clientv2.HTTPError{Code: 400, Message: "validation"},
+	})
+	p, queue := newTestMutationQueue(t, database, client)
+
+	require.Error(t, p.UpdateEntryProgress(1, 5, nil))
+
+	mutations, err := queue.GetMutations()
+	require.NoError(t, err)
+	assert.Empty(t, mutations)
+}
+
+func TestMutationQueue_PartialSend(t *testing.T) {
+	database, err := db.NewDatabase(t.TempDir(), "test", util.NewLogger())
+	require.NoError(t, err)
+
+	client := newTestMutationClient(nil)
+	client.progressErr = &clientv2.ErrorResponse{NetworkError: &clientv2.HTTPError{Code: 502}}
+	_, queue := newTestMutationQueue(t, database, client)
+
+	// The entry update is sent but the progress update fails
+	require.NoError(t, queue.Push(&QueuedMutation{
+		MediaID:  1,
+		Entry:    &EntryMutation{Status: lo.ToPtr(anilist.MediaListStatusPlanning)},
+		Progress: &ProgressMutation{Progress: 1},
+	}))
+	assert.Equal(t, anilist.MediaListStatusPlanning, client.status[1])
+
+	// Only the progress update is left
+	mutations, err := queue.GetMutations()
+	require.NoError(t, err)
+	require.Len(t, mutations, 1)
+	assert.Nil(t, mutations[0].Entry)
+	assert.Equal(t, 1, mutations[0].Progress.Progress)
+}
+
+func TestIsRetryableError(t *testing.T) {
+	assert.False(t, isRetryableError(nil))
+	assert.True(t, isRetryableError(errors.New("request failed: EOF")))
+	assert.True(t, isRetryableError(&clientv2.ErrorResponse{NetworkError: &clientv2.HTTPError{Code: 429}}))
+	assert.True(t, isRetryableError(&clientv2.ErrorResponse{NetworkError: &clientv2.HTTPError{Code: 503}}))
+	assert.False(t, isRetryableError(&clientv2.ErrorResponse{NetworkError: &clientv2.HTTPError{Code: 400}}))
+	assert.False(t, isRetryableError(&clientv2.ErrorResponse{}))
+	assert.False(t, isRetryableError(context.Canceled))
+}
+
+func TestGetBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, getBackoff(1))
+	assert.Equal(t, 60*time.Second, getBackoff(2))
+	assert.Equal(t, 4*time.Minute, getBackoff(4))
+	assert.Equal(t, time.Hour, getBackoff(20))
+}
@@ -1,23 +1,48 @@
 package local_platform
 
 import (
+	"context"
+	"errors"
 	"github.com/rs/zerolog"
+	"github.com/samber/lo"
 	"github.com/samber/mo"
 	"seanime/internal/api/anilist"
 	"seanime/internal/platforms/platform"
+	"seanime/internal/util/limiter"
 	"sync"
+	"time"
 )
 
+const (
+	// metadataRefreshInterval is the minimum interval between two refreshes of the cached media metadata.
+	metadataRefreshInterval = 6 * time.Hour
+)
+
+var (
+	ErrMediaNotFound = errors.New("local platform: Media not found")
+)
+
+// LocalPlatform is a platform.Platform implementation that keeps the user's lists on disk.
+// List data (status, score, progress, dates) never leaves the local database.
+// The AniList client is only used to fetch media metadata, which is cached in the local collections.
 type LocalPlatform struct {
-	logger             *zerolog.Logger
-	anilistClient      anilist.AnilistClient
-	animeCollection    mo.Option[*anilist.AnimeCollection]
-	rawAnimeCollection mo.Option[*anilist.AnimeCollection]
-	mangaCollection    mo.Option[*anilist.MangaCollection]
-	rawMangaCollection mo.Option[*anilist.MangaCollection]
-	mangaMu            sync.RWMutex
-	animeMu            sync.RWMutex
-	localDb            *LocalPlatformDatabase
+	logger                  *zerolog.Logger
+	username                mo.Option[string]
+	anilistClient           anilist.AnilistClient
+	animeCollection         mo.Option[*anilist.AnimeCollection]
+	rawAnimeCollection      mo.Option[*anilist.AnimeCollection]
+	mangaCollection         mo.Option[*anilist.MangaCollection]
+	rawMangaCollection      mo.Option[*anilist.MangaCollection]
+	mangaMu                 sync.RWMutex
+	animeMu                 sync.RWMutex
+	writeMu                 sync.Mutex // Serializes writes to the local database
+	localDb                 *LocalPlatformDatabase
+	lastMetadataRefresh     time.Time
+	lastMetadataRefreshMu   sync.Mutex
+	collectionWithRelations *anilist.AnimeCollectionWithRelationsBuilder
+	metadataRefreshLimiter  *limiter.Limiter
+	addToCollectionLimiter  *limiter.Limiter
+	isMetadataRefreshActive bool
 }
 
 func NewLocalPlatform(dataDir string, anilistClient anilist.AnilistClient, logger *zerolog.Logger) (platform.Platform, error) {
@@ -28,16 +53,20 @@ func NewLocalPlatform(dataDir string, anilistClient anilist.AnilistClient, logge
 	}
 
 	ap := &LocalPlatform{
-		localDb:            localDb,
-		anilistClient:      anilistClient,
-		logger:             logger,
-		animeCollection:    mo.None[*anilist.AnimeCollection](),
-		rawAnimeCollection: mo.None[*anilist.AnimeCollection](),
-		mangaCollection:    mo.None[*anilist.MangaCollection](),
-		rawMangaCollection: mo.None[*anilist.MangaCollection](),
-		mangaMu:            sync.RWMutex{},
-		animeMu:            sync.RWMutex{},
+		localDb:                localDb,
+		anilistClient:          anilistClient,
+		logger:                 logger,
+		username:               mo.None[string](),
+		animeCollection:        mo.None[*anilist.AnimeCollection](),
+		rawAnimeCollection:     mo.None[*anilist.AnimeCollection](),
+		mangaCollection:        mo.None[*anilist.MangaCollection](),
+		rawMangaCollection:     mo.None[*anilist.MangaCollection](),
+		mangaMu:                sync.RWMutex{},
+		animeMu:                sync.RWMutex{},
+		metadataRefreshLimiter: limiter.NewAnilistLimiter(),
+		addToCollectionLimiter: limiter.NewLimiter(1*time.Second, 1),
 	}
+	ap.collectionWithRelations = anilist.NewAnimeCollectionWithRelationsBuilder(ap.metadataRefreshLimiter, logger)
 
 	go ap.loadAnimeCollection()
 	go ap.loadMangaCollection()
@@ -48,93 +77,471 @@ func NewLocalPlatform(dataDir string, anilistClient anilist.AnilistClient, logge
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 func (pm *LocalPlatform) SetUsername(username string) {
-	panic("todo")
+	// The username is not used to fetch the collections, they are stored locally
+	pm.username = mo.Some(username)
 }
 
 func (pm *LocalPlatform) SetAnilistClient(client anilist.AnilistClient) {
-	panic("todo")
+	// Set the AnilistClient used to fetch media metadata
+	pm.anilistClient = client
 }
 
+// UpdateEntry creates or updates the local list entry of the media.
+// If the media is not in the local collections, its metadata is fetched from AniList.
 func (pm *LocalPlatform) UpdateEntry(mediaID int, status *anilist.MediaListStatus, scoreRaw *int, progress *int, startedAt *anilist.FuzzyDateInput, completedAt *anilist.FuzzyDateInput) error {
-	panic("todo")
+	pm.logger.Trace().Int("mediaId", mediaID).Msg("local platform: Updating entry")
+
+	return pm.updateEntry(mediaID, &entryUpdate{
+		status:      status,
+		score:       scoreRaw,
+		progress:    progress,
+		startedAt:   startedAt,
+		completedAt: completedAt,
+	})
 }
 
 func (pm *LocalPlatform) UpdateEntryProgress(mediaID int, progress int, totalEpisodes *int) error {
-	panic("todo")
+	pm.logger.Trace().Int("mediaId", mediaID).Msg("local platform: Updating entry progress")
+
+	totalEp := 0
+	if totalEpisodes != nil && *totalEpisodes > 0 {
+		totalEp = *totalEpisodes
+	}
+
+	status := anilist.MediaListStatusCurrent
+	var startedAt, completedAt *anilist.FuzzyDateInput
+
+	// Check if the media is being repeated
+	// If it is, keep the repeating status
+	animeEntry, isAnime := findAnimeEntry(pm.getRawAnimeCollectionOrEmpty(), mediaID)
+	mangaEntry, isManga := findMangaEntry(pm.getRawMangaCollectionOrEmpty(), mediaID)
+	switch {
+	case isAnime:
+		if animeEntry.Status != nil && *animeEntry.Status == anilist.MediaListStatusRepeating {
+			status = anilist.MediaListStatusRepeating
+		}
+		if animeEntry.StartedAt == nil || animeEntry.StartedAt.Year == nil {
+			startedAt = todayFuzzyDate()
+		}
+	case isManga:
+		if mangaEntry.Status != nil && *mangaEntry.Status == anilist.MediaListStatusRepeating {
+			status = anilist.MediaListStatusRepeating
+		}
+		if mangaEntry.StartedAt == nil || mangaEntry.StartedAt.Year == nil {
+			startedAt = todayFuzzyDate()
+		}
+	default:
+		startedAt = todayFuzzyDate()
+	}
+
+	if totalEp > 0 && progress >= totalEp {
+		status = anilist.MediaListStatusCompleted
+		completedAt = todayFuzzyDate()
+	}
+
+	if totalEp > 0 && progress > totalEp {
+		progress = totalEp
+	}
+
+	return pm.updateEntry(mediaID, &entryUpdate{
+		status:      &status,
+		progress:    &progress,
+		startedAt:   startedAt,
+		completedAt: completedAt,
+	})
 }
 
+// DeleteEntry removes the entry from the local collections.
+// Local list entries share their ID with the media, so both IDs are accepted.
 func (pm *LocalPlatform) DeleteEntry(mediaID int) error {
-	panic("todo")
+	pm.logger.Trace().Int("id", mediaID).Msg("local platform: Deleting entry")
+
+	pm.writeMu.Lock()
+	defer pm.writeMu.Unlock()
+
+	animeCollection := pm.readLocalAnimeCollection()
+	if removeAnimeEntry(animeCollection, mediaID) {
+		return pm.saveAnimeCollection(animeCollection)
+	}
+
+	mangaCollection := pm.readLocalMangaCollection()
+	if removeMangaEntry(mangaCollection, mediaID) {
+		return pm.saveMangaCollection(mangaCollection)
+	}
+
+	return errors.New("local platform: Entry not found")
 }
 
+// GetAnime returns the media from the local collection if it is there, otherwise it fetches it from AniList.
 func (pm *LocalPlatform) GetAnime(mediaID int) (*anilist.BaseAnime, error) {
-	panic("todo")
+	pm.logger.Trace().Msg("local platform: Fetching anime")
+	if entry, found := findAnimeEntry(pm.getRawAnimeCollectionOrEmpty(), mediaID); found && entry.Media != nil {
+		return entry.Media, nil
+	}
+	ret, err := pm.anilistClient.BaseAnimeByID(context.Background(), &mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return ret.GetMedia(), nil
 }
 
 func (pm *LocalPlatform) GetAnimeByMalID(malID int) (*anilist.BaseAnime, error) {
-	panic("todo")
+	pm.logger.Trace().Msg("local platform: Fetching anime by MAL ID")
+	for _, media := range pm.getRawAnimeCollectionOrEmpty().GetAllAnime() {
+		if media.GetIDMal() != nil && *media.GetIDMal() == malID {
+			return media, nil
+		}
+	}
+	ret, err := pm.anilistClient.BaseAnimeByMalID(context.Background(), &malID)
+	if err != nil {
+		return nil, err
+	}
+	return ret.GetMedia(), nil
 }
 
 func (pm *LocalPlatform) GetAnimeDetails(mediaID int) (*anilist.AnimeDetailsById_Media, error) {
-	panic("todo")
+	pm.logger.Trace().Msg("local platform: Fetching anime details")
+	ret, err := pm.anilistClient.AnimeDetailsByID(context.Background(), &mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return ret.GetMedia(), nil
 }
 
 func (pm *LocalPlatform) GetAnimeWithRelations(mediaID int) (*anilist.CompleteAnime, error) {
-	panic("todo")
+	pm.logger.Trace().Msg("local platform: Fetching anime with relations")
+	ret, err := pm.anilistClient.CompleteAnimeByID(context.Background(), &mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return ret.GetMedia(), nil
 }
 
+// GetManga returns the media from the local collection if it is there, otherwise it fetches it from AniList.
 func (pm *LocalPlatform) GetManga(mediaID int) (*anilist.BaseManga, error) {
-	panic("todo")
+	pm.logger.Trace().Msg("local platform: Fetching manga")
+	if entry, found := findMangaEntry(pm.getRawMangaCollectionOrEmpty(), mediaID); found && entry.Media != nil {
+		return entry.Media, nil
+	}
+	ret, err := pm.anilistClient.BaseMangaByID(context.Background(), &mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return ret.GetMedia(), nil
 }
 
 func (pm *LocalPlatform) GetMangaDetails(mediaID int) (*anilist.MangaDetailsById_Media, error) {
-	panic("todo")
+	pm.logger.Trace().Msg("local platform: Fetching manga details")
+	ret, err := pm.anilistClient.MangaDetailsByID(context.Background(), &mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return ret.GetMedia(), nil
 }
 
 func (pm *LocalPlatform) GetAnimeCollection(bypassCache bool) (*anilist.AnimeCollection, error) {
-	panic("todo")
+	ret, ok := pm.getAnimeCollection(bypassCache)
+	if !ok {
+		return nil, errors.New("local platform: Could not load anime collection")
+	}
+	return ret, nil
 }
 
 func (pm *LocalPlatform) GetRawAnimeCollection(bypassCache bool) (*anilist.AnimeCollection, error) {
-	panic("todo")
+	ret, ok := pm.getRawAnimeCollection(bypassCache)
+	if !ok {
+		return nil, errors.New("local platform: Could not load anime collection")
+	}
+	return ret, nil
 }
 
+// RefreshAnimeCollection reloads the anime collection from the local database.
+// Media metadata is refreshed from AniList in the background if it is stale.
 func (pm *LocalPlatform) RefreshAnimeCollection() (*anilist.AnimeCollection, error) {
-	panic("todo")
+	err := pm.refreshAnimeCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	go pm.refreshMetadataIfStale()
+
+	return pm.GetAnimeCollection(false)
 }
 
 func (pm *LocalPlatform) refreshAnimeCollection() error {
-	panic("todo")
+	pm.loadAnimeCollection()
+	if _, ok := pm.getAnimeCollection(false); !ok {
+		return errors.New("local platform: Could not load anime collection")
+	}
+	return nil
 }
 
+// GetAnimeCollectionWithRelations builds the collection with relations from the local anime collection.
+// Complete media are fetched from AniList in batches and cached in memory.
 func (pm *LocalPlatform) GetAnimeCollectionWithRelations() (*anilist.AnimeCollectionWithRelations, error) {
-	panic("todo")
+	pm.logger.Trace().Msg("local platform: Fetching anime collection with relations")
+
+	collection := pm.getRawAnimeCollectionOrEmpty()
+
+	return pm.collectionWithRelations.Build(pm.anilistClient, collection), nil
 }
 
 func (pm *LocalPlatform) GetMangaCollection(bypassCache bool) (*anilist.MangaCollection, error) {
-	panic("todo")
+	ret, ok := pm.getMangaCollection(bypassCache)
+	if !ok {
+		return nil, errors.New("local platform: Could not load manga collection")
+	}
+	return ret, nil
 }
 
 func (pm *LocalPlatform) GetRawMangaCollection(bypassCache bool) (*anilist.MangaCollection, error) {
-	panic("todo")
+	ret, ok := pm.getRawMangaCollection(bypassCache)
+	if !ok {
+		return nil, errors.New("local platform: Could not load manga collection")
+	}
+	return ret, nil
 }
 
+// RefreshMangaCollection reloads the manga collection from the local database.
 func (pm *LocalPlatform) RefreshMangaCollection() (*anilist.MangaCollection, error) {
-	panic("todo")
+	err := pm.refreshMangaCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	go pm.refreshMetadataIfStale()
+
+	return pm.GetMangaCollection(false)
 }
 
 func (pm *LocalPlatform) refreshMangaCollection() error {
-	panic("todo")
+	pm.loadMangaCollection()
+	if _, ok := pm.getMangaCollection(false); !ok {
+		return errors.New("local platform: Could not load manga collection")
+	}
+	return nil
 }
 
+// AddMediaToCollection adds the media to the local planning list.
+// Media that are already in the collection are left untouched.
 func (pm *LocalPlatform) AddMediaToCollection(mIds []int) error {
-	panic("todo")
+	pm.logger.Trace().Msg("local platform: Adding media to collection")
+	if len(mIds) == 0 {
+		pm.logger.Debug().Msg("local platform: No media added to planning list")
+		return nil
+	}
+
+	count := 0
+	for _, id := range mIds {
+		if _, found := findAnimeEntry(pm.getRawAnimeCollectionOrEmpty(), id); found {
+			continue
+		}
+		if _, found := findMangaEntry(pm.getRawMangaCollectionOrEmpty(), id); found {
+			continue
+		}
+		pm.addToCollectionLimiter.Wait()
+		err := pm.updateEntry(id, &entryUpdate{
+			status:   lo.ToPtr(anilist.MediaListStatusPlanning),
+			score:    lo.ToPtr(0),
+			progress: lo.ToPtr(0),
+		})
+		if err != nil {
+			pm.logger.Error().Err(err).Int("mediaId", id).Msg("local platform: An error occurred while adding media to planning list")
+			continue
+		}
+		count++
+	}
+
+	pm.logger.Debug().Any("count", count).Msg("local platform: Media added to planning list")
+	return nil
 }
 
 func (pm *LocalPlatform) GetStudioDetails(studioID int) (*anilist.StudioDetails, error) {
-	panic("todo")
+	pm.logger.Trace().Msg("local platform: Fetching studio details")
+	ret, err := pm.anilistClient.StudioDetails(context.Background(), &studioID)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
 }
 
 func (pm *LocalPlatform) GetAnilistClient() anilist.AnilistClient {
-	panic("todo")
+	return pm.anilistClient
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// updateEntry applies the update to the local entry of the media.
+// If the media is not in the local collections, it is fetched from AniList (first as an anime, then as a manga) and added to them.
+func (pm *LocalPlatform) updateEntry(mediaID int, u *entryUpdate) error {
+	pm.writeMu.Lock()
+	defer pm.writeMu.Unlock()
+
+	// Anime
+	animeCollection := pm.readLocalAnimeCollection()
+	if entry, found := findAnimeEntry(animeCollection, mediaID); found {
+		applyAnimeEntryUpdate(entry, u)
+		putAnimeEntry(animeCollection, entry)
+		return pm.saveAnimeCollection(animeCollection)
+	}
+
+	// Manga
+	mangaCollection := pm.readLocalMangaCollection()
+	if entry, found := findMangaEntry(mangaCollection, mediaID); found {
+		applyMangaEntryUpdate(entry, u)
+		putMangaEntry(mangaCollection, entry)
+		return pm.saveMangaCollection(mangaCollection)
+	}
+
+	// New entry, fetch the media from AniList
+	if animeRes, err := pm.anilistClient.BaseAnimeByID(context.Background(), &mediaID); err == nil && animeRes.GetMedia() != nil {
+		entry := &anilist.AnimeCollection_MediaListCollection_Lists_Entries{
+			ID:       mediaID,
+			Score:    lo.ToPtr(0.0),
+			Progress: lo.ToPtr(0),
+			Repeat:   lo.ToPtr(0),
+			Private:  lo.ToPtr(false),
+			Media:    animeRes.GetMedia(),
+		}
+		applyAnimeEntryUpdate(entry, u)
+		putAnimeEntry(animeCollection, entry)
+		return pm.saveAnimeCollection(animeCollection)
+	}
+
+	if mangaRes, err := pm.anilistClient.BaseMangaByID(context.Background(), &mediaID); err == nil && mangaRes.GetMedia() != nil {
+		entry := &anilist.MangaCollection_MediaListCollection_Lists_Entries{
+			ID:       mediaID,
+			Score:    lo.ToPtr(0.0),
+			Progress: lo.ToPtr(0),
+			Repeat:   lo.ToPtr(0),
+			Private:  lo.ToPtr(false),
+			Media:    mangaRes.GetMedia(),
+		}
+		applyMangaEntryUpdate(entry, u)
+		putMangaEntry(mangaCollection, entry)
+		return pm.saveMangaCollection(mangaCollection)
+	}
+
+	return ErrMediaNotFound
+}
+
+func (pm *LocalPlatform) saveAnimeCollection(collection *anilist.AnimeCollection) error {
+	if err := pm.localDb.saveLocalCollection("anime", collection); err != nil {
+		return err
+	}
+	pm.loadAnimeCollection()
+	return nil
+}
+
+func (pm *LocalPlatform) saveMangaCollection(collection *anilist.MangaCollection) error {
+	if err := pm.localDb.saveLocalCollection("manga", collection); err != nil {
+		return err
+	}
+	pm.loadMangaCollection()
+	return nil
+}
+
+// refreshMetadataIfStale refreshes the cached metadata of media that are still airing or publishing.
+// It does nothing if the metadata was refreshed recently.
+func (pm *LocalPlatform) refreshMetadataIfStale() {
+	pm.lastMetadataRefreshMu.Lock()
+	if pm.isMetadataRefreshActive || time.Since(pm.lastMetadataRefresh) < metadataRefreshInterval {
+		pm.lastMetadataRefreshMu.Unlock()
+		return
+	}
+	pm.isMetadataRefreshActive = true
+	pm.lastMetadataRefreshMu.Unlock()
+
+	defer func() {
+		pm.lastMetadataRefreshMu.Lock()
+		pm.isMetadataRefreshActive = false
+		pm.lastMetadataRefresh = time.Now()
+		pm.lastMetadataRefreshMu.Unlock()
+	}()
+
+	if err := pm.RefreshMetadata(); err != nil {
+		pm.logger.Error().Err(err).Msg("local platform: Failed to refresh media metadata")
+	}
+}
+
+// RefreshMetadata re-fetches the metadata of every media in the local collections that has not finished releasing.
+// List data is never sent to AniList.
+func (pm *LocalPlatform) RefreshMetadata() error {
+	pm.logger.Debug().Msg("local platform: Refreshing media metadata")
+
+	isStale := func(status *anilist.MediaStatus) bool {
+		return status == nil || (*status != anilist.MediaStatusFinished && *status != anilist.MediaStatusCancelled)
+	}
+
+	// Collect the media to refresh without holding the write lock
+	animeIds := make([]int, 0)
+	for _, media := range pm.getRawAnimeCollectionOrEmpty().GetAllAnime() {
+		if isStale(media.GetStatus()) {
+			animeIds = append(animeIds, media.GetID())
+		}
+	}
+	mangaIds := make([]int, 0)
+	for _, list := range pm.getRawMangaCollectionOrEmpty().MediaListCollection.Lists {
+		for _, entry := range list.GetEntries() {
+			if isStale(entry.GetMedia().GetStatus()) {
+				mangaIds = append(mangaIds, entry.GetMedia().GetID())
+			}
+		}
+	}
+
+	animeMedia := make(map[int]*anilist.BaseAnime)
+	for _, id := range animeIds {
+		pm.metadataRefreshLimiter.Wait()
+		res, err := pm.anilistClient.BaseAnimeByID(context.Background(), &id)
+		if err != nil {
+			pm.logger.Warn().Err(err).Int("mediaId", id).Msg("local platform: Failed to refresh anime metadata")
+			continue
+		}
+		animeMedia[id] = res.GetMedia()
+	}
+	mangaMedia := make(map[int]*anilist.BaseManga)
+	for _, id := range mangaIds {
+		pm.metadataRefreshLimiter.Wait()
+		res, err := pm.anilistClient.BaseMangaByID(context.Background(), &id)
+		if err != nil {
+			pm.logger.Warn().Err(err).Int("mediaId", id).Msg("local platform: Failed to refresh manga metadata")
+			continue
+		}
+		mangaMedia[id] = res.GetMedia()
+	}
+
+	pm.writeMu.Lock()
+	defer pm.writeMu.Unlock()
+
+	if len(animeMedia) > 0 {
+		animeCollection := pm.readLocalAnimeCollection()
+		for _, list := range animeCollection.MediaListCollection.Lists {
+			for _, entry := range list.GetEntries() {
+				if media, ok := animeMedia[entry.GetMedia().GetID()]; ok && media != nil {
+					entry.Media = media
+				}
+			}
+		}
+		if err := pm.saveAnimeCollection(animeCollection); err != nil {
+			return err
+		}
+	}
+
+	if len(mangaMedia) > 0 {
+		mangaCollection := pm.readLocalMangaCollection()
+		for _, list := range mangaCollection.MediaListCollection.Lists {
+			for _, entry := range list.GetEntries() {
+				if media, ok := mangaMedia[entry.GetMedia().GetID()]; ok && media != nil {
+					entry.Media = media
+				}
+			}
+		}
+		if err := pm.saveMangaCollection(mangaCollection); err != nil {
+			return err
+		}
+	}
+
+	pm.logger.Debug().Int("anime", len(animeMedia)).Int("manga", len(mangaMedia)).Msg("local platform: Refreshed media metadata")
+
+	return nil
 }
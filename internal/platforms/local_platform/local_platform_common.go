@@ -8,49 +8,70 @@ import (
 
 func (pm *LocalPlatform) getAnimeCollection(bypassCache bool) (ret *anilist.AnimeCollection, ok bool) {
 	if !bypassCache {
-		if ret, ok = pm.animeCollection.Get(); ok {
+		pm.animeMu.RLock()
+		ret, ok = pm.animeCollection.Get()
+		pm.animeMu.RUnlock()
+		if ok {
 			return ret, true
 		}
 	}
 	pm.loadAnimeCollection()
+	pm.animeMu.RLock()
+	defer pm.animeMu.RUnlock()
 	return pm.animeCollection.Get()
 }
 
 func (pm *LocalPlatform) getRawAnimeCollection(bypassCache bool) (ret *anilist.AnimeCollection, ok bool) {
 	if !bypassCache {
-		if ret, ok = pm.rawAnimeCollection.Get(); ok {
+		pm.animeMu.RLock()
+		ret, ok = pm.rawAnimeCollection.Get()
+		pm.animeMu.RUnlock()
+		if ok {
 			return ret, true
 		}
 	}
 	pm.loadAnimeCollection()
+	pm.animeMu.RLock()
+	defer pm.animeMu.RUnlock()
 	return pm.rawAnimeCollection.Get()
 }
 
 func (pm *LocalPlatform) getMangaCollection(bypassCache bool) (ret *anilist.MangaCollection, ok bool) {
 	if !bypassCache {
-		if ret, ok = pm.mangaCollection.Get(); ok {
+		pm.mangaMu.RLock()
+		ret, ok = pm.mangaCollection.Get()
+		pm.mangaMu.RUnlock()
+		if ok {
 			return ret, true
 		}
 	}
 	pm.loadMangaCollection()
+	pm.mangaMu.RLock()
+	defer pm.mangaMu.RUnlock()
 	return pm.mangaCollection.Get()
 }
 
 func (pm *LocalPlatform) getRawMangaCollection(bypassCache bool) (ret *anilist.MangaCollection, ok bool) {
 	if !bypassCache {
-		if ret, ok = pm.rawMangaCollection.Get(); ok {
+		pm.mangaMu.RLock()
+		ret, ok = pm.rawMangaCollection.Get()
+		pm.mangaMu.RUnlock()
+		if ok {
 			return ret, true
 		}
 	}
 	pm.loadMangaCollection()
+	pm.mangaMu.RLock()
+	defer pm.mangaMu.RUnlock()
 	return pm.rawMangaCollection.Get()
 }
 
 func (pm *LocalPlatform) loadAnimeCollection() {
 	// Load the anime collection from the local database
 	collection, ok := pm.localDb.getLocalAnimeCollection()
-	if !ok {
-		return
+	if !ok || collection.MediaListCollection == nil {
+		// Nothing has been saved yet, start with an empty collection
+		collection = newEmptyAnimeCollection()
 	}
 
 	pm.animeMu.Lock()
@@ -75,8 +96,9 @@ func (pm *LocalPlatform) loadAnimeCollection() {
 func (pm *LocalPlatform) loadMangaCollection() {
 	// Load the manga collection from the local database
 	collection, ok := pm.localDb.getLocalMangaCollection()
-	if !ok {
-		return
+	if !ok || collection.MediaListCollection == nil {
+		// Nothing has been saved yet, start with an empty collection
+		collection = newEmptyMangaCollection()
 	}
 	pm.mangaMu.Lock()
 	// Save the raw collection to App (retains the lists with no status)
@@ -97,6 +119,42 @@ func (pm *LocalPlatform) loadMangaCollection() {
 	return
 }
 
+// getRawAnimeCollectionOrEmpty returns the cached raw anime collection.
+// The returned collection should not be modified.
+func (pm *LocalPlatform) getRawAnimeCollectionOrEmpty() *anilist.AnimeCollection {
+	if ret, ok := pm.getRawAnimeCollection(false); ok && ret.MediaListCollection != nil {
+		return ret
+	}
+	return newEmptyAnimeCollection()
+}
+
+// getRawMangaCollectionOrEmpty returns the cached raw manga collection.
+// The returned collection should not be modified.
+func (pm *LocalPlatform) getRawMangaCollectionOrEmpty() *anilist.MangaCollection {
+	if ret, ok := pm.getRawMangaCollection(false); ok && ret.MediaListCollection != nil {
+		return ret
+	}
+	return newEmptyMangaCollection()
+}
+
+// readLocalAnimeCollection reads a fresh copy of the anime collection from the local database.
+// Unlike the cached collection, it can be modified before being saved.
+func (pm *LocalPlatform) readLocalAnimeCollection() *anilist.AnimeCollection {
+	if ret, ok := pm.localDb.getLocalAnimeCollection(); ok && ret.MediaListCollection != nil {
+		return ret
+	}
+	return newEmptyAnimeCollection()
+}
+
+// readLocalMangaCollection reads a fresh copy of the manga collection from the local database.
+// Unlike the cached collection, it can be modified before being saved.
+func (pm *LocalPlatform) readLocalMangaCollection() *anilist.MangaCollection {
+	if ret, ok := pm.localDb.getLocalMangaCollection(); ok && ret.MediaListCollection != nil {
+		return ret
+	}
+	return newEmptyMangaCollection()
+}
+
 // UpdateLocalAnimeCollection updates the local anime collection with the current collection from Anilist.
 func (pm *LocalPlatform) UpdateLocalAnimeCollection(current *anilist.AnimeCollection) error {
 	err := pm.localDb.saveLocalCollection("anime", current)
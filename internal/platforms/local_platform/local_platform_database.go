@@ -61,7 +61,9 @@ func newLocalPlatformDatabase(appDataDir, dbName string, logger *zerolog.Logger)
 
 // MigrateTables performs auto migration on the database
 func migrateTables(db *gorm.DB) error {
-	err := db.AutoMigrate()
+	err := db.AutoMigrate(
+		&LocalCollection{},
+	)
 	if err != nil {
 
 		return err
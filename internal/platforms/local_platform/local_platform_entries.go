@@ -0,0 +1,250 @@
+package local_platform
+
+import (
+	"github.com/samber/lo"
+	"seanime/internal/api/anilist"
+	"time"
+)
+
+// localListNames are the names given to the status lists of a local collection.
+// They mirror the default list names used by AniList.
+var (
+	localAnimeListNames = map[anilist.MediaListStatus]string{
+		anilist.MediaListStatusCurrent:   "Watching",
+		anilist.MediaListStatusPlanning:  "Planning",
+		anilist.MediaListStatusCompleted: "Completed",
+		anilist.MediaListStatusDropped:   "Dropped",
+		anilist.MediaListStatusPaused:    "Paused",
+		anilist.MediaListStatusRepeating: "Rewatching",
+	}
+	localMangaListNames = map[anilist.MediaListStatus]string{
+		anilist.MediaListStatusCurrent:   "Reading",
+		anilist.MediaListStatusPlanning:  "Planning",
+		anilist.MediaListStatusCompleted: "Completed",
+		anilist.MediaListStatusDropped:   "Dropped",
+		anilist.MediaListStatusPaused:    "Paused",
+		anilist.MediaListStatusRepeating: "Rereading",
+	}
+)
+
+// entryUpdate holds the list data that should be written to a local entry.
+// Nil fields are left untouched.
+type entryUpdate struct {
+	status      *anilist.MediaListStatus
+	score       *int
+	progress    *int
+	startedAt   *anilist.FuzzyDateInput
+	completedAt *anilist.FuzzyDateInput
+}
+
+func newEmptyAnimeCollection() *anilist.AnimeCollection {
+	return &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+			Lists: make([]*anilist.AnimeCollection_MediaListCollection_Lists, 0),
+		},
+	}
+}
+
+func newEmptyMangaCollection() *anilist.MangaCollection {
+	return &anilist.MangaCollection{
+		MediaListCollection: &anilist.MangaCollection_MediaListCollection{
+			Lists: make([]*anilist.MangaCollection_MediaListCollection_Lists, 0),
+		},
+	}
+}
+
+// todayFuzzyDate returns the current date as a fuzzy date input.
+func todayFuzzyDate() *anilist.FuzzyDateInput {
+	now := time.Now()
+	return &anilist.FuzzyDateInput{
+		Year:  lo.ToPtr(now.Year()),
+		Month: lo.ToPtr(int(now.Month())),
+		Day:   lo.ToPtr(now.Day()),
+	}
+}
+
+func isEmptyFuzzyDate(d *anilist.FuzzyDateInput) bool {
+	return d == nil || (d.Year == nil && d.Month == nil && d.Day == nil)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// findAnimeEntry returns the list entry for the given media ID (or list entry ID) from the local anime collection.
+func findAnimeEntry(collection *anilist.AnimeCollection, id int) (*anilist.AnimeCollection_MediaListCollection_Lists_Entries, bool) {
+	if collection == nil || collection.MediaListCollection == nil {
+		return nil, false
+	}
+	for _, list := range collection.MediaListCollection.Lists {
+		for _, entry := range list.GetEntries() {
+			if entry.GetID() == id || entry.GetMedia().GetID() == id {
+				return entry, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// removeAnimeEntry removes every entry matching the media ID (or list entry ID) from the local anime collection.
+func removeAnimeEntry(collection *anilist.AnimeCollection, id int) (removed bool) {
+	if collection == nil || collection.MediaListCollection == nil {
+		return false
+	}
+	for _, list := range collection.MediaListCollection.Lists {
+		before := len(list.Entries)
+		list.Entries = lo.Filter(list.Entries, func(entry *anilist.AnimeCollection_MediaListCollection_Lists_Entries, _ int) bool {
+			return entry.GetID() != id && entry.GetMedia().GetID() != id
+		})
+		if len(list.Entries) != before {
+			removed = true
+		}
+	}
+	// Remove empty lists
+	collection.MediaListCollection.Lists = lo.Filter(collection.MediaListCollection.Lists, func(list *anilist.AnimeCollection_MediaListCollection_Lists, _ int) bool {
+		return len(list.Entries) > 0
+	})
+	return
+}
+
+// putAnimeEntry adds the entry to the list matching its status, creating the list if needed.
+// The entry is removed from any other list.
+func putAnimeEntry(collection *anilist.AnimeCollection, entry *anilist.AnimeCollection_MediaListCollection_Lists_Entries) {
+	removeAnimeEntry(collection, entry.GetMedia().GetID())
+
+	status := anilist.MediaListStatusPlanning
+	if entry.Status != nil {
+		status = *entry.Status
+	}
+	entry.Status = &status
+
+	list, found := lo.Find(collection.MediaListCollection.Lists, func(list *anilist.AnimeCollection_MediaListCollection_Lists) bool {
+		return list.Status != nil && *list.Status == status
+	})
+	if !found {
+		list = &anilist.AnimeCollection_MediaListCollection_Lists{
+			Status:       lo.ToPtr(status),
+			Name:         lo.ToPtr(localAnimeListNames[status]),
+			IsCustomList: lo.ToPtr(false),
+			Entries:      make([]*anilist.AnimeCollection_MediaListCollection_Lists_Entries, 0),
+		}
+		collection.MediaListCollection.Lists = append(collection.MediaListCollection.Lists, list)
+	}
+	list.Entries = append(list.Entries, entry)
+}
+
+// applyAnimeEntryUpdate writes the update to the entry.
+func applyAnimeEntryUpdate(entry *anilist.AnimeCollection_MediaListCollection_Lists_Entries, u *entryUpdate) {
+	if u.status != nil {
+		entry.Status = lo.ToPtr(*u.status)
+	}
+	if u.score != nil {
+		entry.Score = lo.ToPtr(float64(*u.score))
+	}
+	if u.progress != nil {
+		entry.Progress = lo.ToPtr(*u.progress)
+	}
+	if !isEmptyFuzzyDate(u.startedAt) {
+		entry.StartedAt = &anilist.AnimeCollection_MediaListCollection_Lists_Entries_StartedAt{
+			Year:  u.startedAt.Year,
+			Month: u.startedAt.Month,
+			Day:   u.startedAt.Day,
+		}
+	}
+	if !isEmptyFuzzyDate(u.completedAt) {
+		entry.CompletedAt = &anilist.AnimeCollection_MediaListCollection_Lists_Entries_CompletedAt{
+			Year:  u.completedAt.Year,
+			Month: u.completedAt.Month,
+			Day:   u.completedAt.Day,
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// findMangaEntry returns the list entry for the given media ID (or list entry ID) from the local manga collection.
+func findMangaEntry(collection *anilist.MangaCollection, id int) (*anilist.MangaCollection_MediaListCollection_Lists_Entries, bool) {
+	if collection == nil || collection.MediaListCollection == nil {
+		return nil, false
+	}
+	for _, list := range collection.MediaListCollection.Lists {
+		for _, entry := range list.GetEntries() {
+			if entry.GetID() == id || entry.GetMedia().GetID() == id {
+				return entry, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// removeMangaEntry removes every entry matching the media ID (or list entry ID) from the local manga collection.
+func removeMangaEntry(collection *anilist.MangaCollection, id int) (removed bool) {
+	if collection == nil || collection.MediaListCollection == nil {
+		return false
+	}
+	for _, list := range collection.MediaListCollection.Lists {
+		before := len(list.Entries)
+		list.Entries = lo.Filter(list.Entries, func(entry *anilist.MangaCollection_MediaListCollection_Lists_Entries, _ int) bool {
+			return entry.GetID() != id && entry.GetMedia().GetID() != id
+		})
+		if len(list.Entries) != before {
+			removed = true
+		}
+	}
+	// Remove empty lists
+	collection.MediaListCollection.Lists = lo.Filter(collection.MediaListCollection.Lists, func(list *anilist.MangaCollection_MediaListCollection_Lists, _ int) bool {
+		return len(list.Entries) > 0
+	})
+	return
+}
+
+// putMangaEntry adds the entry to the list matching its status, creating the list if needed.
+// The entry is removed from any other list.
+func putMangaEntry(collection *anilist.MangaCollection, entry *anilist.MangaCollection_MediaListCollection_Lists_Entries) {
+	removeMangaEntry(collection, entry.GetMedia().GetID())
+
+	status := anilist.MediaListStatusPlanning
+	if entry.Status != nil {
+		status = *entry.Status
+	}
+	entry.Status = &status
+
+	list, found := lo.Find(collection.MediaListCollection.Lists, func(list *anilist.MangaCollection_MediaListCollection_Lists) bool {
+		return list.Status != nil && *list.Status == status
+	})
+	if !found {
+		list = &anilist.MangaCollection_MediaListCollection_Lists{
+			Status:       lo.ToPtr(status),
+			Name:         lo.ToPtr(localMangaListNames[status]),
+			IsCustomList: lo.ToPtr(false),
+			Entries:      make([]*anilist.MangaCollection_MediaListCollection_Lists_Entries, 0),
+		}
+		collection.MediaListCollection.Lists = append(collection.MediaListCollection.Lists, list)
+	}
+	list.Entries = append(list.Entries, entry)
+}
+
+// applyMangaEntryUpdate writes the update to the entry.
+func applyMangaEntryUpdate(entry *anilist.MangaCollection_MediaListCollection_Lists_Entries, u *entryUpdate) {
+	if u.status != nil {
+		entry.Status = lo.ToPtr(*u.status)
+	}
+	if u.score != nil {
+		entry.Score = lo.ToPtr(float64(*u.score))
+	}
+	if u.progress != nil {
+		entry.Progress = lo.ToPtr(*u.progress)
+	}
+	if !isEmptyFuzzyDate(u.startedAt) {
+		entry.StartedAt = &anilist.MangaCollection_MediaListCollection_Lists_Entries_StartedAt{
+			Year:  u.startedAt.Year,
+			Month: u.startedAt.Month,
+			Day:   u.startedAt.Day,
+		}
+	}
+	if !isEmptyFuzzyDate(u.completedAt) {
+		entry.CompletedAt = &anilist.MangaCollection_MediaListCollection_Lists_Entries_CompletedAt{
+			Year:  u.completedAt.Year,
+			Month: u.completedAt.Month,
+			Day:   u.completedAt.Day,
+		}
+	}
+}
@@ -31,15 +31,16 @@ func (ldb *LocalPlatformDatabase) saveLocalCollection(collectionType string, val
 		return err
 	}
 
-	lc := LocalCollection{
-		BaseModel: BaseModel{
-			ID: 1,
-		},
-		Type:  collectionType,
-		Value: marshalledValue,
+	// Each collection type has its own row, reuse it if it exists
+	lc, found := ldb.getLocalCollection(collectionType)
+	if !found {
+		lc = &LocalCollection{
+			Type: collectionType,
+		}
 	}
+	lc.Value = marshalledValue
 
-	return ldb.gormdb.Save(&lc).Error
+	return ldb.gormdb.Save(lc).Error
 }
 
 func (ldb *LocalPlatformDatabase) getLocalAnimeCollection() (*anilist.AnimeCollection, bool) {
@@ -0,0 +1,147 @@
+package local_platform
+
+import (
+	"context"
+	"errors"
+	"github.com/Yamashou/gqlgenc/clientv2"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seanime/internal/api/anilist"
+	"seanime/internal/util"
+	"testing"
+)
+
+// fakeAnilistClient only answers media queries, any list mutation would panic.
+type fakeAnilistClient struct {
+	anilist.AnilistClient
+	anime map[int]*anilist.BaseAnime
+	manga map[int]*anilist.BaseManga
+}
+
+func (c *fakeAnilistClient) BaseAnimeByID(_ context.Context, id *int, _ ...clientv2.RequestInterceptor) (*anilist.BaseAnimeByID, error) {
+	if m, ok := c.anime[*id]; ok {
+		return &anilist.BaseAnimeByID{Media: m}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (c *fakeAnilistClient) BaseMangaByID(_ context.Context, id *int, _ ...clientv2.RequestInterceptor) (*anilist.BaseMangaByID, error) {
+	if m, ok := c.manga[*id]; ok {
+		return &anilist.BaseMangaByID{Media: m}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func newTestLocalPlatform(t *testing.T) *LocalPlatform {
+	t.Setenv("TEST_ENV", "true")
+
+	client := &fakeAnilistClient{
+		anime: map[int]*anilist.BaseAnime{
+			1: {ID: 1, Episodes: lo.ToPtr(12), Status: lo.ToPtr(anilist.MediaStatusFinished)},
+			2: {ID: 2, Episodes: lo.ToPtr(24), Status: lo.ToPtr(anilist.MediaStatusFinished)},
+		},
+		manga: map[int]*anilist.BaseManga{
+			3: {ID: 3, Status: lo.ToPtr(anilist.MediaStatusReleasing)},
+		},
+	}
+
+	p, err := NewLocalPlatform(t.TempDir(), client, util.NewLogger())
+	require.NoError(t, err)
+
+	return p.(*LocalPlatform)
+}
+
+func TestLocalPlatform_UpdateEntry(t *testing.T) {
+	p := newTestLocalPlatform(t)
+
+	// Add new anime entry
+	err := p.UpdateEntry(1, lo.ToPtr(anilist.MediaListStatusCurrent), lo.ToPtr(80), lo.ToPtr(3), nil, nil)
+	require.NoError(t, err)
+
+	collection, err := p.GetAnimeCollection(false)
+	require.NoError(t, err)
+
+	entry, found := collection.GetListEntryFromAnimeId(1)
+	require.True(t, found)
+	assert.Equal(t, anilist.MediaListStatusCurrent, *entry.GetStatus())
+	assert.Equal(t, 3, *entry.GetProgress())
+	assert.Equal(t, 80.0, *entry.GetScore())
+
+	// Move the entry to another list
+	err = p.UpdateEntry(1, lo.ToPtr(anilist.MediaListStatusPaused), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	collection, err = p.GetAnimeCollection(false)
+	require.NoError(t, err)
+	require.Len(t, collection.MediaListCollection.Lists, 1)
+	assert.Equal(t, anilist.MediaListStatusPaused, *collection.MediaListCollection.Lists[0].Status)
+	assert.Equal(t, 3, *collection.MediaListCollection.Lists[0].Entries[0].GetProgress())
+
+	// Manga entry
+	err = p.UpdateEntry(3, lo.ToPtr(anilist.MediaListStatusPlanning), nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	mangaCollection, err := p.GetMangaCollection(false)
+	require.NoError(t, err)
+	_, found = mangaCollection.GetListEntryFromMediaId(3)
+	assert.True(t, found)
+
+	// Unknown media
+	err = p.UpdateEntry(4, lo.ToPtr(anilist.MediaListStatusPlanning), nil, nil, nil, nil)
+	assert.ErrorIs(t, err, ErrMediaNotFound)
+}
+
+func TestLocalPlatform_UpdateEntryProgress(t *testing.T) {
+	p := newTestLocalPlatform(t)
+
+	err := p.UpdateEntryProgress(1, 5, lo.ToPtr(12))
+	require.NoError(t, err)
+
+	collection, err := p.GetAnimeCollection(false)
+	require.NoError(t, err)
+	entry, found := collection.GetListEntryFromAnimeId(1)
+	require.True(t, found)
+	assert.Equal(t, anilist.MediaListStatusCurrent, *entry.GetStatus())
+	assert.NotNil(t, entry.GetStartedAt().GetYear())
+	assert.Nil(t, entry.GetCompletedAt())
+
+	// Repeating status is kept
+	err = p.UpdateEntry(1, lo.ToPtr(anilist.MediaListStatusRepeating), nil, nil, nil, nil)
+	require.NoError(t, err)
+	err = p.UpdateEntryProgress(1, 6, lo.ToPtr(12))
+	require.NoError(t, err)
+	collection, _ = p.GetAnimeCollection(false)
+	entry, _ = collection.GetListEntryFromAnimeId(1)
+	assert.Equal(t, anilist.MediaListStatusRepeating, *entry.GetStatus())
+
+	// Progress is capped and the entry is completed
+	err = p.UpdateEntryProgress(1, 15, lo.ToPtr(12))
+	require.NoError(t, err)
+	collection, _ = p.GetAnimeCollection(false)
+	entry, _ = collection.GetListEntryFromAnimeId(1)
+	assert.Equal(t, anilist.MediaListStatusCompleted, *entry.GetStatus())
+	assert.Equal(t, 12, *entry.GetProgress())
+	assert.NotNil(t, entry.GetCompletedAt().GetYear())
+}
+
+func TestLocalPlatform_DeleteEntry(t *testing.T) {
+	p := newTestLocalPlatform(t)
+
+	require.NoError(t, p.AddMediaToCollection([]int{1, 2}))
+
+	collection, err := p.GetAnimeCollection(false)
+	require.NoError(t, err)
+	assert.Len(t, collection.GetAllAnime(), 2)
+
+	require.NoError(t, p.DeleteEntry(1))
+
+	collection, err = p.GetAnimeCollection(true)
+	require.NoError(t, err)
+	_, found := collection.GetListEntryFromAnimeId(1)
+	assert.False(t, found)
+	_, found = collection.GetListEntryFromAnimeId(2)
+	assert.True(t, found)
+
+	assert.Error(t, p.DeleteEntry(1))
+}
@@ -0,0 +1,629 @@
+package mal_platform
+
+import (
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"github.com/samber/lo"
+	"github.com/samber/mo"
+	"seanime/internal/api/anilist"
+	"seanime/internal/api/mal"
+	"seanime/internal/database/db"
+	"seanime/internal/platforms/platform"
+	"seanime/internal/util/limiter"
+	"sync"
+	"time"
+)
+
+const (
+	// compoundQueryChunkSize is the number of media resolved by a single AniList request.
+	compoundQueryChunkSize = 50
+)
+
+var (
+	ErrMediaNotFound = errors.New("mal platform: Media not found")
+	ErrNoMalId       = errors.New("mal platform: Media is not on MyAnimeList")
+)
+
+type (
+	// MalPlatform is a platform.Platform implementation that uses MyAnimeList for the user's lists.
+	// MyAnimeList entries are translated to AniList media so that the rest of the app keeps working with AniList IDs.
+	// The AniList client is only used to fetch media metadata.
+	MalPlatform struct {
+		logger                  *zerolog.Logger
+		db                      *db.Database
+		username                mo.Option[string]
+		anilistClient           anilist.AnilistClient
+		animeCollection         mo.Option[*anilist.AnimeCollection]
+		mangaCollection         mo.Option[*anilist.MangaCollection]
+		animeMu                 sync.RWMutex
+		mangaMu                 sync.RWMutex
+		animeByMalId            map[int]*anilist.BaseAnime // Cache of resolved MyAnimeList IDs
+		mangaByMalId            map[int]*anilist.BaseManga // Cache of resolved MyAnimeList IDs
+		resolveMu               sync.Mutex
+		collectionWithRelations *anilist.AnimeCollectionWithRelationsBuilder
+		anilistLimiter          *limiter.Limiter
+		malLimiter              *limiter.Limiter
+	}
+)
+
+func NewMalPlatform(database *db.Database, anilistClient anilist.AnilistClient, logger *zerolog.Logger) platform.Platform {
+	mp := &MalPlatform{
+		logger:          logger,
+		db:              database,
+		username:        mo.None[string](),
+		anilistClient:   anilistClient,
+		animeCollection: mo.None[*anilist.AnimeCollection](),
+		mangaCollection: mo.None[*anilist.MangaCollection](),
+		animeByMalId:    make(map[int]*anilist.BaseAnime),
+		mangaByMalId:    make(map[int]*anilist.BaseManga),
+		anilistLimiter:  limiter.NewAnilistLimiter(),
+		malLimiter:      limiter.NewLimiter(1*time.Second, 1),
+	}
+	mp.collectionWithRelations = anilist.NewAnimeCollectionWithRelationsBuilder(mp.anilistLimiter, logger)
+
+	return mp
+}
+
+func (mp *MalPlatform) SetUsername(username string) {
+	// The MyAnimeList list is fetched with the stored token, the username is not needed
+	mp.username = mo.Some(username)
+}
+
+func (mp *MalPlatform) SetAnilistClient(client anilist.AnilistClient) {
+	// Set the AnilistClient used to fetch media metadata
+	mp.anilistClient = client
+}
+
+func (mp *MalPlatform) UpdateEntry(mediaID int, status *anilist.MediaListStatus, scoreRaw *int, progress *int, startedAt *anilist.FuzzyDateInput, completedAt *anilist.FuzzyDateInput) error {
+	mp.logger.Trace().Int("mediaId", mediaID).Msg("mal platform: Updating entry")
+
+	malId, isManga, err := mp.getMalId(mediaID)
+	if err != nil {
+		return err
+	}
+
+	wrapper, err := mp.getWrapper()
+	if err != nil {
+		return err
+	}
+
+	var score *int
+	if scoreRaw != nil {
+		score = lo.ToPtr(toMalScore(*scoreRaw))
+	}
+
+	if isManga {
+		params := &mal.MangaListStatusParams{
+			NumChaptersRead: progress,
+			Score:           score,
+			StartDate:       toMalDate(startedAt),
+			FinishDate:      toMalDate(completedAt),
+		}
+		if status != nil {
+			malStatus, isRereading := toMalMangaStatus(*status)
+			params.Status = &malStatus
+			params.IsRereading = &isRereading
+		}
+		return wrapper.UpdateMangaListStatus(params, malId)
+	}
+
+	params := &mal.AnimeListStatusParams{
+		NumEpisodesWatched: progress,
+		Score:              score,
+		StartDate:          toMalDate(startedAt),
+		FinishDate:         toMalDate(completedAt),
+	}
+	if status != nil {
+		malStatus, isRewatching := toMalAnimeStatus(*status)
+		params.Status = &malStatus
+		params.IsRewatching = &isRewatching
+	}
+	return wrapper.UpdateAnimeListStatus(params, malId)
+}
+
+func (mp *MalPlatform) UpdateEntryProgress(mediaID int, progress int, totalEpisodes *int) error {
+	mp.logger.Trace().Int("mediaId", mediaID).Msg("mal platform: Updating entry progress")
+
+	totalEp := 0
+	if totalEpisodes != nil && *totalEpisodes > 0 {
+		totalEp = *totalEpisodes
+	}
+
+	status := anilist.MediaListStatusCurrent
+	// Check if the media is being repeated
+	// If it is, keep the repeating status
+	mp.animeMu.RLock()
+	animeCollection, _ := mp.animeCollection.Get()
+	mp.animeMu.RUnlock()
+	if animeCollection != nil {
+		if entry, found := animeCollection.GetListEntryFromAnimeId(mediaID); found && entry.GetStatus() != nil && *entry.GetStatus() == anilist.MediaListStatusRepeating {
+			status = anilist.MediaListStatusRepeating
+		}
+	}
+	mp.mangaMu.RLock()
+	mangaCollection, _ := mp.mangaCollection.Get()
+	mp.mangaMu.RUnlock()
+	if mangaCollection != nil {
+		if entry, found := mangaCollection.GetListEntryFromMediaId(mediaID); found && entry.GetStatus() != nil && *entry.GetStatus() == anilist.MediaListStatusRepeating {
+			status = anilist.MediaListStatusRepeating
+		}
+	}
+	if totalEp > 0 && progress >= totalEp {
+		status = anilist.MediaListStatusCompleted
+	}
+
+	if totalEp > 0 && progress > totalEp {
+		progress = totalEp
+	}
+
+	return mp.UpdateEntry(mediaID, &status, nil, &progress, nil, nil)
+}
+
+// DeleteEntry removes the entry from the MyAnimeList list.
+// MyAnimeList entries are identified by their media, so the list entry ID is the AniList media ID.
+func (mp *MalPlatform) DeleteEntry(mediaID int) error {
+	mp.logger.Trace().Int("mediaId", mediaID).Msg("mal platform: Deleting entry")
+
+	malId, isManga, err := mp.getMalId(mediaID)
+	if err != nil {
+		return err
+	}
+
+	wrapper, err := mp.getWrapper()
+	if err != nil {
+		return err
+	}
+
+	if isManga {
+		return wrapper.DeleteMangaListItem(malId)
+	}
+	return wrapper.DeleteAnimeListItem(malId)
+}
+
+func (mp *MalPlatform) GetAnime(mediaID int) (*anilist.BaseAnime, error) {
+	mp.logger.Trace().Msg("mal platform: Fetching anime")
+	ret, err := mp.anilistClient.BaseAnimeByID(context.Background(), &mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return ret.GetMedia(), nil
+}
+
+func (mp *MalPlatform) GetAnimeByMalID(malID int) (*anilist.BaseAnime, error) {
+	mp.logger.Trace().Msg("mal platform: Fetching anime by MAL ID")
+	ret, err := mp.anilistClient.BaseAnimeByMalID(context.Background(), &malID)
+	if err != nil {
+		return nil, err
+	}
+	return ret.GetMedia(), nil
+}
+
+func (mp *MalPlatform) GetAnimeDetails(mediaID int) (*anilist.AnimeDetailsById_Media, error) {
+	mp.logger.Trace().Msg("mal platform: Fetching anime details")
+	ret, err := mp.anilistClient.AnimeDetailsByID(context.Background(), &mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return ret.GetMedia(), nil
+}
+
+func (mp *MalPlatform) GetAnimeWithRelations(mediaID int) (*anilist.CompleteAnime, error) {
+	mp.logger.Trace().Msg("mal platform: Fetching anime with relations")
+	ret, err := mp.anilistClient.CompleteAnimeByID(context.Background(), &mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return ret.GetMedia(), nil
+}
+
+func (mp *MalPlatform) GetManga(mediaID int) (*anilist.BaseManga, error) {
+	mp.logger.Trace().Msg("mal platform: Fetching manga")
+	ret, err := mp.anilistClient.BaseMangaByID(context.Background(), &mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return ret.GetMedia(), nil
+}
+
+func (mp *MalPlatform) GetMangaDetails(mediaID int) (*anilist.MangaDetailsById_Media, error) {
+	mp.logger.Trace().Msg("mal platform: Fetching manga details")
+	ret, err := mp.anilistClient.MangaDetailsByID(context.Background(), &mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return ret.GetMedia(), nil
+}
+
+func (mp *MalPlatform) GetAnimeCollection(bypassCache bool) (*anilist.AnimeCollection, error) {
+	if !bypassCache {
+		mp.animeMu.RLock()
+		ret, ok := mp.animeCollection.Get()
+		mp.animeMu.RUnlock()
+		if ok {
+			return ret, nil
+		}
+	}
+
+	return mp.RefreshAnimeCollection()
+}
+
+// GetRawAnimeCollection is the same as GetAnimeCollection, MyAnimeList does not have custom lists.
+func (mp *MalPlatform) GetRawAnimeCollection(bypassCache bool) (*anilist.AnimeCollection, error) {
+	return mp.GetAnimeCollection(bypassCache)
+}
+
+func (mp *MalPlatform) RefreshAnimeCollection() (*anilist.AnimeCollection, error) {
+	err := mp.refreshAnimeCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	mp.animeMu.RLock()
+	defer mp.animeMu.RUnlock()
+	return mp.animeCollection.MustGet(), nil
+}
+
+func (mp *MalPlatform) refreshAnimeCollection() error {
+	wrapper, err := mp.getWrapper()
+	if err != nil {
+		return err
+	}
+
+	entries, err := wrapper.GetAnimeCollection()
+	if err != nil {
+		return err
+	}
+
+	media := mp.resolveAnimeMalIds(lo.Map(entries, func(e *mal.AnimeListEntry, _ int) int {
+		return e.Node.ID
+	}))
+
+	collection := buildAnimeCollection(entries, media)
+
+	mp.animeMu.Lock()
+	mp.animeCollection = mo.Some(collection)
+	mp.animeMu.Unlock()
+
+	if skipped := len(entries) - len(collection.GetAllAnime()); skipped > 0 {
+		mp.logger.Warn().Int("count", skipped).Msg("mal platform: Some anime could not be found on AniList")
+	}
+
+	return nil
+}
+
+// GetAnimeCollectionWithRelations builds the collection with relations from the anime collection.
+// Complete media are fetched from AniList in batches and cached in memory.
+func (mp *MalPlatform) GetAnimeCollectionWithRelations() (*anilist.AnimeCollectionWithRelations, error) {
+	mp.logger.Trace().Msg("mal platform: Fetching anime collection with relations")
+
+	collection, err := mp.GetAnimeCollection(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return mp.collectionWithRelations.Build(mp.anilistClient, collection), nil
+}
+
+func (mp *MalPlatform) GetMangaCollection(bypassCache bool) (*anilist.MangaCollection, error) {
+	if !bypassCache {
+		mp.mangaMu.RLock()
+		ret, ok := mp.mangaCollection.Get()
+		mp.mangaMu.RUnlock()
+		if ok {
+			return ret, nil
+		}
+	}
+
+	return mp.RefreshMangaCollection()
+}
+
+// GetRawMangaCollection is the same as GetMangaCollection, MyAnimeList does not have custom lists.
+func (mp *MalPlatform) GetRawMangaCollection(bypassCache bool) (*anilist.MangaCollection, error) {
+	return mp.GetMangaCollection(bypassCache)
+}
+
+func (mp *MalPlatform) RefreshMangaCollection() (*anilist.MangaCollection, error) {
+	err := mp.refreshMangaCollection()
+	if err != nil {
+		return nil, err
+	}
+
+	mp.mangaMu.RLock()
+	defer mp.mangaMu.RUnlock()
+	return mp.mangaCollection.MustGet(), nil
+}
+
+func (mp *MalPlatform) refreshMangaCollection() error {
+	wrapper, err := mp.getWrapper()
+	if err != nil {
+		return err
+	}
+
+	entries, err := wrapper.GetMangaCollection()
+	if err != nil {
+		return err
+	}
+
+	media := mp.resolveMangaMalIds(lo.Map(entries, func(e *mal.MangaListEntry, _ int) int {
+		return e.Node.ID
+	}))
+
+	collection := buildMangaCollection(entries, media)
+
+	mp.mangaMu.Lock()
+	mp.mangaCollection = mo.Some(collection)
+	mp.mangaMu.Unlock()
+
+	return nil
+}
+
+// AddMediaToCollection adds the media to the MyAnimeList planning list.
+func (mp *MalPlatform) AddMediaToCollection(mIds []int) error {
+	mp.logger.Trace().Msg("mal platform: Adding media to collection")
+	if len(mIds) == 0 {
+		mp.logger.Debug().Msg("mal platform: No media added to planning list")
+		return nil
+	}
+
+	for _, id := range mIds {
+		mp.malLimiter.Wait()
+		err := mp.UpdateEntry(id, lo.ToPtr(anilist.MediaListStatusPlanning), nil, lo.ToPtr(0), nil, nil)
+		if err != nil {
+			mp.logger.Error().Err(err).Int("mediaId", id).Msg("mal platform: An error occurred while adding media to planning list")
+		}
+	}
+
+	mp.logger.Debug().Any("count", len(mIds)).Msg("mal platform: Media added to planning list")
+	return nil
+}
+
+func (mp *MalPlatform) GetStudioDetails(studioID int) (*anilist.StudioDetails, error) {
+	mp.logger.Trace().Msg("mal platform: Fetching studio details")
+	ret, err := mp.anilistClient.StudioDetails(context.Background(), &studioID)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (mp *MalPlatform) GetAnilistClient() anilist.AnilistClient {
+	return mp.anilistClient
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// getWrapper returns a MyAnimeList wrapper with a valid access token.
+func (mp *MalPlatform) getWrapper() (*mal.Wrapper, error) {
+	malInfo, err := mp.db.GetMalInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	malInfo, err = mal.VerifyMALAuth(malInfo, mp.db, mp.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return mal.NewWrapper(malInfo.AccessToken, mp.logger), nil
+}
+
+// getMalId returns the MyAnimeList ID of the AniList media.
+// The media is first looked up in the collections, then fetched from AniList as an anime and as a manga.
+func (mp *MalPlatform) getMalId(mediaID int) (malId int, isManga bool, err error) {
+	mp.animeMu.RLock()
+	animeCollection, _ := mp.animeCollection.Get()
+	mp.animeMu.RUnlock()
+	if entry, found := animeCollection.GetListEntryFromAnimeId(mediaID); found && entry.GetMedia().GetIDMal() != nil {
+		return *entry.GetMedia().GetIDMal(), false, nil
+	}
+
+	mp.mangaMu.RLock()
+	mangaCollection, _ := mp.mangaCollection.Get()
+	mp.mangaMu.RUnlock()
+	if entry, found := mangaCollection.GetListEntryFromMediaId(mediaID); found && entry.GetMedia().GetIDMal() != nil {
+		return *entry.GetMedia().GetIDMal(), true, nil
+	}
+
+	if res, err := mp.anilistClient.BaseAnimeByID(context.Background(), &mediaID); err == nil && res.GetMedia() != nil {
+		if res.GetMedia().GetIDMal() == nil {
+			return 0, false, ErrNoMalId
+		}
+		return *res.GetMedia().GetIDMal(), false, nil
+	}
+
+	if res, err := mp.anilistClient.BaseMangaByID(context.Background(), &mediaID); err == nil && res.GetMedia() != nil {
+		if res.GetMedia().GetIDMal() == nil {
+			return 0, true, ErrNoMalId
+		}
+		return *res.GetMedia().GetIDMal(), true, nil
+	}
+
+	return 0, false, ErrMediaNotFound
+}
+
+// resolveAnimeMalIds returns the AniList media matching the MyAnimeList IDs, keyed by MyAnimeList ID.
+// Media that have finished airing are cached, the others are fetched again to keep their metadata up to date.
+func (mp *MalPlatform) resolveAnimeMalIds(malIds []int) map[int]*anilist.BaseAnime {
+	mp.resolveMu.Lock()
+	defer mp.resolveMu.Unlock()
+
+	toFetch := lo.Filter(lo.Uniq(malIds), func(id int, _ int) bool {
+		media, found := mp.animeByMalId[id]
+		return !found || !isFinished(media.GetStatus())
+	})
+
+	for _, chunk := range lo.Chunk(toFetch, compoundQueryChunkSize) {
+		mp.anilistLimiter.Wait()
+		res, err := anilist.FetchBaseAnimeMapByMalIDs(chunk)
+		if err != nil {
+			// A single missing media fails the whole request, resolve the chunk one by one
+			mp.logger.Debug().Err(err).Msg("mal platform: Failed to resolve anime in bulk, resolving one by one")
+			res = make(map[int]*anilist.BaseAnime)
+			for _, id := range chunk {
+				mp.anilistLimiter.Wait()
+				media, err := mp.anilistClient.BaseAnimeByMalID(context.Background(), &id)
+				if err != nil || media.GetMedia() == nil {
+					continue
+				}
+				res[id] = media.GetMedia()
+			}
+		}
+		for id, media := range res {
+			mp.animeByMalId[id] = media
+		}
+	}
+
+	ret := make(map[int]*anilist.BaseAnime, len(malIds))
+	for _, id := range malIds {
+		if media, found := mp.animeByMalId[id]; found {
+			ret[id] = media
+		}
+	}
+	return ret
+}
+
+// resolveMangaMalIds returns the AniList media matching the MyAnimeList IDs, keyed by MyAnimeList ID.
+// Media that have finished publishing are cached, the others are fetched again to keep their metadata up to date.
+func (mp *MalPlatform) resolveMangaMalIds(malIds []int) map[int]*anilist.BaseManga {
+	mp.resolveMu.Lock()
+	defer mp.resolveMu.Unlock()
+
+	toFetch := lo.Filter(lo.Uniq(malIds), func(id int, _ int) bool {
+		media, found := mp.mangaByMalId[id]
+		return !found || !isFinished(media.GetStatus())
+	})
+
+	for _, chunk := range lo.Chunk(toFetch, compoundQueryChunkSize) {
+		mp.anilistLimiter.Wait()
+		res, err := anilist.FetchBaseMangaMapByMalIDs(chunk)
+		if err != nil {
+			// A single missing media fails the whole request, resolve the chunk one by one
+			mp.logger.Debug().Err(err).Msg("mal platform: Failed to resolve manga in bulk, resolving one by one")
+			res = make(map[int]*anilist.BaseManga)
+			for _, id := range chunk {
+				mp.anilistLimiter.Wait()
+				single, err := anilist.FetchBaseMangaMapByMalIDs([]int{id})
+				if err != nil {
+					continue
+				}
+				if media, found := single[id]; found {
+					res[id] = media
+				}
+			}
+		}
+		for id, media := range res {
+			mp.mangaByMalId[id] = media
+		}
+	}
+
+	ret := make(map[int]*anilist.BaseManga, len(malIds))
+	for _, id := range malIds {
+		if media, found := mp.mangaByMalId[id]; found {
+			ret[id] = media
+		}
+	}
+	return ret
+}
+
+func isFinished(status *anilist.MediaStatus) bool {
+	return status != nil && (*status == anilist.MediaStatusFinished || *status == anilist.MediaStatusCancelled)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// buildAnimeCollection converts the MyAnimeList anime list to an AniList collection.
+// Entries whose media could not be resolved are skipped.
+func buildAnimeCollection(entries []*mal.AnimeListEntry, media map[int]*anilist.BaseAnime) *anilist.AnimeCollection {
+	lists := make(map[anilist.MediaListStatus]*anilist.AnimeCollection_MediaListCollection_Lists)
+
+	for _, e := range entries {
+		m, found := media[e.Node.ID]
+		if !found || m == nil {
+			continue
+		}
+		status := toAnilistStatus(e.ListStatus.Status, e.ListStatus.IsRewatching)
+		list, found := lists[status]
+		if !found {
+			list = &anilist.AnimeCollection_MediaListCollection_Lists{
+				Status:       lo.ToPtr(status),
+				Name:         lo.ToPtr(listName(status, false)),
+				IsCustomList: lo.ToPtr(false),
+				Entries:      make([]*anilist.AnimeCollection_MediaListCollection_Lists_Entries, 0),
+			}
+			lists[status] = list
+		}
+		list.Entries = append(list.Entries, &anilist.AnimeCollection_MediaListCollection_Lists_Entries{
+			ID:          m.ID,
+			Score:       lo.ToPtr(fromMalScore(e.ListStatus.Score)),
+			Progress:    lo.ToPtr(e.ListStatus.NumEpisodesWatched),
+			Status:      lo.ToPtr(status),
+			Repeat:      lo.ToPtr(e.ListStatus.NumTimesRewatched),
+			Private:     lo.ToPtr(false),
+			StartedAt:   toAnimeStartedAt(e.ListStatus.StartDate),
+			CompletedAt: toAnimeCompletedAt(e.ListStatus.FinishDate),
+			Media:       m,
+		})
+	}
+
+	ret := &anilist.AnimeCollection{
+		MediaListCollection: &anilist.AnimeCollection_MediaListCollection{
+			Lists: make([]*anilist.AnimeCollection_MediaListCollection_Lists, 0, len(lists)),
+		},
+	}
+	// Keep the order of the lists stable
+	for _, status := range anilist.AllMediaListStatus {
+		if list, found := lists[status]; found {
+			ret.MediaListCollection.Lists = append(ret.MediaListCollection.Lists, list)
+		}
+	}
+	return ret
+}
+
+// buildMangaCollection converts the MyAnimeList manga list to an AniList collection.
+// Entries whose media could not be resolved are skipped.
+func buildMangaCollection(entries []*mal.MangaListEntry, media map[int]*anilist.BaseManga) *anilist.MangaCollection {
+	lists := make(map[anilist.MediaListStatus]*anilist.MangaCollection_MediaListCollection_Lists)
+
+	for _, e := range entries {
+		m, found := media[e.Node.ID]
+		if !found || m == nil {
+			continue
+		}
+		status := toAnilistStatus(e.ListStatus.Status, e.ListStatus.IsRereading)
+		list, found := lists[status]
+		if !found {
+			list = &anilist.MangaCollection_MediaListCollection_Lists{
+				Status:       lo.ToPtr(status),
+				Name:         lo.ToPtr(listName(status, true)),
+				IsCustomList: lo.ToPtr(false),
+				Entries:      make([]*anilist.MangaCollection_MediaListCollection_Lists_Entries, 0),
+			}
+			lists[status] = list
+		}
+		list.Entries = append(list.Entries, &anilist.MangaCollection_MediaListCollection_Lists_Entries{
+			ID:          m.ID,
+			Score:       lo.ToPtr(fromMalScore(e.ListStatus.Score)),
+			Progress:    lo.ToPtr(e.ListStatus.NumChaptersRead),
+			Status:      lo.ToPtr(status),
+			Repeat:      lo.ToPtr(e.ListStatus.NumTimesReread),
+			Private:     lo.ToPtr(false),
+			StartedAt:   toMangaStartedAt(e.ListStatus.StartDate),
+			CompletedAt: toMangaCompletedAt(e.ListStatus.FinishDate),
+			Media:       m,
+		})
+	}
+
+	ret := &anilist.MangaCollection{
+		MediaListCollection: &anilist.MangaCollection_MediaListCollection{
+			Lists: make([]*anilist.MangaCollection_MediaListCollection_Lists, 0, len(lists)),
+		},
+	}
+	// Keep the order of the lists stable
+	for _, status := range anilist.AllMediaListStatus {
+		if list, found := lists[status]; found {
+			ret.MediaListCollection.Lists = append(ret.MediaListCollection.Lists, list)
+		}
+	}
+	return ret
+}
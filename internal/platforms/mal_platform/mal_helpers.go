@@ -0,0 +1,167 @@
+package mal_platform
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"math"
+	"seanime/internal/api/anilist"
+	"seanime/internal/api/mal"
+	"strconv"
+	"strings"
+)
+
+// toAnilistStatus converts a MyAnimeList list status to an AniList list status.
+// MyAnimeList has no "repeating" status, it is a flag on the entry.
+func toAnilistStatus(status mal.MediaListStatus, isRepeating bool) anilist.MediaListStatus {
+	if isRepeating {
+		return anilist.MediaListStatusRepeating
+	}
+	switch status {
+	case mal.MediaListStatusWatching, mal.MediaListStatusReading:
+		return anilist.MediaListStatusCurrent
+	case mal.MediaListStatusCompleted:
+		return anilist.MediaListStatusCompleted
+	case mal.MediaListStatusOnHold:
+		return anilist.MediaListStatusPaused
+	case mal.MediaListStatusDropped:
+		return anilist.MediaListStatusDropped
+	default:
+		return anilist.MediaListStatusPlanning
+	}
+}
+
+// toMalAnimeStatus converts an AniList list status to a MyAnimeList anime list status.
+func toMalAnimeStatus(status anilist.MediaListStatus) (ret mal.MediaListStatus, isRepeating bool) {
+	switch status {
+	case anilist.MediaListStatusCurrent:
+		return mal.MediaListStatusWatching, false
+	case anilist.MediaListStatusRepeating:
+		return mal.MediaListStatusWatching, true
+	case anilist.MediaListStatusCompleted:
+		return mal.MediaListStatusCompleted, false
+	case anilist.MediaListStatusPaused:
+		return mal.MediaListStatusOnHold, false
+	case anilist.MediaListStatusDropped:
+		return mal.MediaListStatusDropped, false
+	default:
+		return mal.MediaListStatusPlanToWatch, false
+	}
+}
+
+// toMalMangaStatus converts an AniList list status to a MyAnimeList manga list status.
+func toMalMangaStatus(status anilist.MediaListStatus) (ret mal.MediaListStatus, isRepeating bool) {
+	switch status {
+	case anilist.MediaListStatusCurrent:
+		return mal.MediaListStatusReading, false
+	case anilist.MediaListStatusRepeating:
+		return mal.MediaListStatusReading, true
+	case anilist.MediaListStatusCompleted:
+		return mal.MediaListStatusCompleted, false
+	case anilist.MediaListStatusPaused:
+		return mal.MediaListStatusOnHold, false
+	case anilist.MediaListStatusDropped:
+		return mal.MediaListStatusDropped, false
+	default:
+		return mal.MediaListStatusPlanToRead, false
+	}
+}
+
+// toMalScore converts a raw AniList score (0-100) to a MyAnimeList score (0-10).
+func toMalScore(scoreRaw int) int {
+	return int(math.Round(float64(lo.Clamp(scoreRaw, 0, 100)) / 10))
+}
+
+// fromMalScore converts a MyAnimeList score (0-10) to a raw AniList score (0-100).
+func fromMalScore(score int) float64 {
+	return float64(lo.Clamp(score, 0, 10) * 10)
+}
+
+// toMalDate converts a fuzzy date to the format used by MyAnimeList (YYYY-MM-DD, YYYY-MM or YYYY).
+// It returns nil if the date has no year.
+func toMalDate(d *anilist.FuzzyDateInput) *string {
+	if d == nil || d.Year == nil || *d.Year <= 0 {
+		return nil
+	}
+	ret := fmt.Sprintf("%04d", *d.Year)
+	if d.Month != nil && *d.Month > 0 {
+		ret += fmt.Sprintf("-%02d", *d.Month)
+		if d.Day != nil && *d.Day > 0 {
+			ret += fmt.Sprintf("-%02d", *d.Day)
+		}
+	}
+	return &ret
+}
+
+// parseMalDate parses a MyAnimeList date (YYYY-MM-DD, YYYY-MM or YYYY).
+// Missing parts are returned as nil.
+func parseMalDate(s string) (year, month, day *int) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	values := make([]*int, 3)
+	for i, part := range parts {
+		if i > 2 {
+			break
+		}
+		v, err := strconv.Atoi(part)
+		if err != nil || v <= 0 {
+			break
+		}
+		values[i] = lo.ToPtr(v)
+	}
+	return values[0], values[1], values[2]
+}
+
+func toAnimeStartedAt(s string) *anilist.AnimeCollection_MediaListCollection_Lists_Entries_StartedAt {
+	year, month, day := parseMalDate(s)
+	if year == nil {
+		return nil
+	}
+	return &anilist.AnimeCollection_MediaListCollection_Lists_Entries_StartedAt{Year: year, Month: month, Day: day}
+}
+
+func toAnimeCompletedAt(s string) *anilist.AnimeCollection_MediaListCollection_Lists_Entries_CompletedAt {
+	year, month, day := parseMalDate(s)
+	if year == nil {
+		return nil
+	}
+	return &anilist.AnimeCollection_MediaListCollection_Lists_Entries_CompletedAt{Year: year, Month: month, Day: day}
+}
+
+func toMangaStartedAt(s string) *anilist.MangaCollection_MediaListCollection_Lists_Entries_StartedAt {
+	year, month, day := parseMalDate(s)
+	if year == nil {
+		return nil
+	}
+	return &anilist.MangaCollection_MediaListCollection_Lists_Entries_StartedAt{Year: year, Month: month, Day: day}
+}
+
+func toMangaCompletedAt(s string) *anilist.MangaCollection_MediaListCollection_Lists_Entries_CompletedAt {
+	year, month, day := parseMalDate(s)
+	if year == nil {
+		return nil
+	}
+	return &anilist.MangaCollection_MediaListCollection_Lists_Entries_CompletedAt{Year: year, Month: month, Day: day}
+}
+
+// listName returns the name of the list, mirroring the default list names used by AniList.
+func listName(status anilist.MediaListStatus, isManga bool) string {
+	switch status {
+	case anilist.MediaListStatusCurrent:
+		if isManga {
+			return "Reading"
+		}
+		return "Watching"
+	case anilist.MediaListStatusRepeating:
+		if isManga {
+			return "Rereading"
+		}
+		return "Rewatching"
+	case anilist.MediaListStatusCompleted:
+		return "Completed"
+	case anilist.MediaListStatusPaused:
+		return "Paused"
+	case anilist.MediaListStatusDropped:
+		return "Dropped"
+	default:
+		return "Planning"
+	}
+}
@@ -0,0 +1,94 @@
+package mal_platform
+
+import (
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seanime/internal/api/anilist"
+	"seanime/internal/api/mal"
+	"testing"
+)
+
+func TestStatusConversion(t *testing.T) {
+	for _, status := range anilist.AllMediaListStatus {
+		malStatus, isRepeating := toMalAnimeStatus(status)
+		assert.Equal(t, status, toAnilistStatus(malStatus, isRepeating), "anime status %s", status)
+
+		malStatus, isRepeating = toMalMangaStatus(status)
+		assert.Equal(t, status, toAnilistStatus(malStatus, isRepeating), "manga status %s", status)
+	}
+}
+
+func TestScoreConversion(t *testing.T) {
+	assert.Equal(t, 0, toMalScore(0))
+	assert.Equal(t, 8, toMalScore(75))
+	assert.Equal(t, 10, toMalScore(120))
+	assert.Equal(t, 70.0, fromMalScore(7))
+}
+
+func TestDateConversion(t *testing.T) {
+	tests := []struct {
+		input    *anilist.FuzzyDateInput
+		expected *string
+	}{
+		{input: nil, expected: nil},
+		{input: &anilist.FuzzyDateInput{}, expected: nil},
+		{input: &anilist.FuzzyDateInput{Year: lo.ToPtr(2023)}, expected: lo.ToPtr("2023")},
+		{input: &anilist.FuzzyDateInput{Year: lo.ToPtr(2023), Month: lo.ToPtr(4)}, expected: lo.ToPtr("2023-04")},
+		{input: &anilist.FuzzyDateInput{Year: lo.ToPtr(2023), Month: lo.ToPtr(4), Day: lo.ToPtr(9)}, expected: lo.ToPtr("2023-04-09")},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, toMalDate(tt.input))
+	}
+
+	year, month, day := parseMalDate("2023-04-09")
+	assert.Equal(t, 2023, *year)
+	assert.Equal(t, 4, *month)
+	assert.Equal(t, 9, *day)
+
+	year, month, day = parseMalDate("2023")
+	assert.Equal(t, 2023, *year)
+	assert.Nil(t, month)
+	assert.Nil(t, day)
+
+	assert.Nil(t, toAnimeStartedAt(""))
+}
+
+func TestBuildAnimeCollection(t *testing.T) {
+	entry := func(malId int, status mal.MediaListStatus, progress int, isRewatching bool) *mal.AnimeListEntry {
+		e := &mal.AnimeListEntry{}
+		e.Node.ID = malId
+		e.ListStatus.Status = status
+		e.ListStatus.NumEpisodesWatched = progress
+		e.ListStatus.IsRewatching = isRewatching
+		e.ListStatus.Score = 9
+		e.ListStatus.StartDate = "2024-01-02"
+		return e
+	}
+
+	entries := []*mal.AnimeListEntry{
+		entry(1, mal.MediaListStatusWatching, 3, false),
+		entry(2, mal.MediaListStatusCompleted, 12, true),
+		entry(3, mal.MediaListStatusPlanToWatch, 0, false), // Not resolved
+	}
+	media := map[int]*anilist.BaseAnime{
+		1: {ID: 101, IDMal: lo.ToPtr(1)},
+		2: {ID: 102, IDMal: lo.ToPtr(2)},
+	}
+
+	collection := buildAnimeCollection(entries, media)
+	require.Len(t, collection.MediaListCollection.Lists, 2)
+	assert.Len(t, collection.GetAllAnime(), 2)
+
+	e, found := collection.GetListEntryFromAnimeId(101)
+	require.True(t, found)
+	assert.Equal(t, anilist.MediaListStatusCurrent, *e.GetStatus())
+	assert.Equal(t, 3, *e.GetProgress())
+	assert.Equal(t, 90.0, *e.GetScore())
+	assert.Equal(t, 2024, *e.GetStartedAt().GetYear())
+
+	e, found = collection.GetListEntryFromAnimeId(102)
+	require.True(t, found)
+	assert.Equal(t, anilist.MediaListStatusRepeating, *e.GetStatus())
+}
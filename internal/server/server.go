@@ -7,6 +7,7 @@ import (
 	golog "log"
 	"os"
 	"path/filepath"
+	"seanime/internal/cli"
 	"seanime/internal/core"
 	"seanime/internal/cron"
 	"seanime/internal/handlers"
@@ -16,13 +17,31 @@ import (
 	"time"
 )
 
-func startApp(embeddedLogo []byte) (*core.App, core.SeanimeFlags, *updater.SelfUpdater) {
+// runCommand runs the command given on the command line, if any, and returns its exit code.
+// It returns false if no command was given, in which case the server should be started.
+func runCommand(flags core.SeanimeFlags, embeddedLogo []byte) (int, bool) {
+	if len(flags.Command) == 0 {
+		return 0, false
+	}
+
+	code := cli.Run(&cli.Options{
+		Args: flags.Command,
+		NewApp: func() *core.App {
+			return core.NewApp(&core.ConfigOptions{
+				DataDir:      flags.DataDir,
+				EmbeddedLogo: embeddedLogo,
+				Headless:     true,
+			}, updater.NewSelfUpdater())
+		},
+	})
+
+	return code, true
+}
+
+func startApp(flags core.SeanimeFlags, embeddedLogo []byte) (*core.App, *updater.SelfUpdater) {
 	// Print the header
 	core.PrintHeader()
 
-	// Get the flags
-	flags := core.GetSeanimeFlags()
-
 	selfupdater := updater.NewSelfUpdater()
 
 	// Create the app instance
@@ -54,7 +73,7 @@ func startApp(embeddedLogo []byte) (*core.App, core.SeanimeFlags, *updater.SelfU
 		}()
 	}
 
-	return app, flags, selfupdater
+	return app, selfupdater
 }
 
 func startAppLoop(webFS *embed.FS, app *core.App, flags core.SeanimeFlags, selfupdater *updater.SelfUpdater) {
@@ -4,11 +4,24 @@ package server
 
 import (
 	"embed"
+	"seanime/internal/cli"
+	"seanime/internal/core"
 )
 
-func StartServer(webFS embed.FS, embeddedLogo []byte) {
+// StartServer starts the server, or runs the command given on the command line, and returns the exit code.
+func StartServer(webFS embed.FS, embeddedLogo []byte) int {
 
-	app, flags, selfupdater := startApp(embeddedLogo)
+	// Get the flags
+	flags := core.GetSeanimeFlags(cli.PrintUsage)
+
+	// Run the command instead of the server if one is given
+	if code, ok := runCommand(flags, embeddedLogo); ok {
+		return code
+	}
+
+	app, selfupdater := startApp(flags, embeddedLogo)
 
 	startAppLoop(&webFS, app, flags, selfupdater)
+
+	return 0
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/cli/browser"
 	"github.com/gonutz/w32/v2"
 	"github.com/rs/zerolog/log"
+	"seanime/internal/cli"
 	"seanime/internal/constants"
 	"seanime/internal/core"
 	"seanime/internal/handlers"
@@ -16,14 +17,26 @@ import (
 	"seanime/internal/updater"
 )
 
-func StartServer(webFS embed.FS, embeddedLogo []byte) {
+// StartServer starts the server, or runs the command given on the command line, and returns the exit code.
+func StartServer(webFS embed.FS, embeddedLogo []byte) int {
 	onExit := func() {}
+
+	// Get the flags
+	flags := core.GetSeanimeFlags(cli.PrintUsage)
+
+	// Run the command instead of the server if one is given, the console is kept for its output
+	if code, ok := runCommand(flags, embeddedLogo); ok {
+		return code
+	}
+
 	hideConsole()
 
-	app, flags, selfupdater := startApp(embeddedLogo)
+	app, selfupdater := startApp(flags, embeddedLogo)
 
 	// Blocks until systray.Quit() is called
 	systray.Run(onReady(&webFS, app, flags, selfupdater), onExit)
+
+	return 0
 }
 
 func addQuitItem() {
@@ -0,0 +1,72 @@
+package tracker
+
+import (
+	"seanime/internal/platforms/platform"
+)
+
+type (
+	// PlatformTracker is a tracker backed by a platform.Platform.
+	// This is used for AniList, MyAnimeList and the local platform, which all use AniList IDs.
+	PlatformTracker struct {
+		name      string
+		logo      string
+		kind      Kind
+		platform  platform.Platform
+		isEnabled func() bool
+	}
+
+	NewPlatformTrackerOptions struct {
+		Name      string
+		Logo      string
+		Kind      Kind // Defaults to AnimeAndMangaKind
+		Platform  platform.Platform
+		IsEnabled func() bool // Defaults to always enabled
+	}
+)
+
+func NewPlatformTracker(opts *NewPlatformTrackerOptions) *PlatformTracker {
+	ret := &PlatformTracker{
+		name:      opts.Name,
+		logo:      opts.Logo,
+		kind:      opts.Kind,
+		platform:  opts.Platform,
+		isEnabled: opts.IsEnabled,
+	}
+	if ret.kind == "" {
+		ret.kind = AnimeAndMangaKind
+	}
+	if ret.isEnabled == nil {
+		ret.isEnabled = func() bool { return true }
+	}
+	return ret
+}
+
+func (t *PlatformTracker) GetName() string {
+	return t.name
+}
+
+func (t *PlatformTracker) GetLogo() string {
+	return t.logo
+}
+
+// GetUsername returns an empty string, platforms handle authentication themselves.
+func (t *PlatformTracker) GetUsername() string {
+	return ""
+}
+
+// GetPassword returns an empty string, platforms handle authentication themselves.
+func (t *PlatformTracker) GetPassword() string {
+	return ""
+}
+
+func (t *PlatformTracker) GetKind() Kind {
+	return t.kind
+}
+
+func (t *PlatformTracker) IsEnabled() bool {
+	return t.isEnabled()
+}
+
+func (t *PlatformTracker) UpdateProgress(mediaId int, _ Kind, progress int, total *int) error {
+	return t.platform.UpdateEntryProgress(mediaId, progress, total)
+}
@@ -0,0 +1,147 @@
+package tracker
+
+import (
+	"fmt"
+	"github.com/rs/zerolog"
+	"seanime/internal/events"
+	"sync"
+)
+
+type (
+	// Repository pushes progress updates to every enabled tracker.
+	//
+	// The primary tracker is the platform that holds the user's lists, its error is returned to the caller.
+	// Secondary trackers are updated concurrently in the background, their failures are reported but never block the primary update.
+	Repository struct {
+		logger         *zerolog.Logger
+		wsEventManager events.WSEventManagerInterface
+		primary        BaseTracker
+		secondary      []BaseTracker
+		mu             sync.RWMutex
+		pending        sync.WaitGroup // Background updates of the secondary trackers
+	}
+
+	NewRepositoryOptions struct {
+		Logger         *zerolog.Logger
+		WSEventManager events.WSEventManagerInterface // Optional, used to notify the user of failed updates
+		Primary        BaseTracker
+		Secondary      []BaseTracker
+	}
+
+	// UpdateResult is the outcome of a progress update for a single tracker.
+	UpdateResult struct {
+		Tracker string `json:"tracker"`
+		Primary bool   `json:"primary"`
+		Success bool   `json:"success"`
+		Error   string `json:"error,omitempty"`
+	}
+)
+
+func NewRepository(opts *NewRepositoryOptions) *Repository {
+	secondary := opts.Secondary
+	if secondary == nil {
+		secondary = make([]BaseTracker, 0)
+	}
+	return &Repository{
+		logger:         opts.Logger,
+		wsEventManager: opts.WSEventManager,
+		primary:        opts.Primary,
+		secondary:      secondary,
+	}
+}
+
+// AddTracker registers a secondary tracker.
+func (r *Repository) AddTracker(t BaseTracker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secondary = append(r.secondary, t)
+}
+
+// GetTrackers returns the primary tracker followed by the secondary trackers.
+func (r *Repository) GetTrackers() []BaseTracker {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]BaseTracker{r.primary}, r.secondary...)
+}
+
+// UpdateProgress updates the progress of an entry on every enabled tracker that supports the kind of media.
+// It returns once the primary tracker has been updated, with its result and error.
+// The secondary trackers are updated in the background, their results are sent to the client when they are all done.
+func (r *Repository) UpdateProgress(mediaId int, kind Kind, progress int, total *int) (*UpdateResult, error) {
+	r.mu.RLock()
+	secondary := make([]BaseTracker, 0, len(r.secondary))
+	for _, t := range r.secondary {
+		if t.IsEnabled() && SupportsKind(t, kind) {
+			secondary = append(secondary, t)
+		}
+	}
+	r.mu.RUnlock()
+
+	if len(secondary) > 0 {
+		r.pending.Add(1)
+		go func() {
+			defer r.pending.Done()
+			r.updateSecondaryTrackers(secondary, mediaId, kind, progress, total)
+		}()
+	}
+
+	var err error
+	ret := r.updateTracker(r.primary, true, mediaId, kind, progress, total)
+	if !ret.Success {
+		err = fmt.Errorf("%s: %s", ret.Tracker, ret.Error)
+	}
+
+	return ret, err
+}
+
+// WaitForPendingUpdates blocks until the secondary trackers of previous updates are done.
+func (r *Repository) WaitForPendingUpdates() {
+	r.pending.Wait()
+}
+
+func (r *Repository) updateSecondaryTrackers(secondary []BaseTracker, mediaId int, kind Kind, progress int, total *int) {
+	results := make([]*UpdateResult, len(secondary))
+	wg := sync.WaitGroup{}
+	wg.Add(len(secondary))
+	for i, t := range secondary {
+		go func(i int, t BaseTracker) {
+			defer wg.Done()
+			results[i] = r.updateTracker(t, false, mediaId, kind, progress, total)
+		}(i, t)
+	}
+	wg.Wait()
+
+	if r.wsEventManager == nil {
+		return
+	}
+	for _, res := range results {
+		if !res.Success {
+			r.wsEventManager.SendEvent(events.WarningToast, fmt.Sprintf("Failed to update progress on %s", res.Tracker))
+		}
+	}
+	r.wsEventManager.SendEvent(events.TrackerProgressUpdated, results)
+}
+
+func (r *Repository) updateTracker(t BaseTracker, primary bool, mediaId int, kind Kind, progress int, total *int) (ret *UpdateResult) {
+	ret = &UpdateResult{
+		Tracker: t.GetName(),
+		Primary: primary,
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			ret.Success = false
+			ret.Error = fmt.Sprintf("panic: %v", rec)
+		}
+	}()
+
+	if err := t.UpdateProgress(mediaId, kind, progress, total); err != nil {
+		r.logger.Warn().Err(err).Str("tracker", t.GetName()).Int("mediaId", mediaId).Msg("tracker: Failed to update progress")
+		ret.Error = err.Error()
+		return
+	}
+
+	r.logger.Debug().Str("tracker", t.GetName()).Int("mediaId", mediaId).Int("progress", progress).Msg("tracker: Updated progress")
+	ret.Success = true
+	return
+}
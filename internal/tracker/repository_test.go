@@ -0,0 +1,118 @@
+package tracker
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seanime/internal/util"
+	"sync"
+	"testing"
+)
+
+type fakeTracker struct {
+	name     string
+	kind     Kind
+	enabled  bool
+	err      error
+	mu       sync.Mutex
+	progress map[int]int
+	block    chan struct{} // If set, updates wait until it is closed
+}
+
+func newFakeTracker(name string, kind Kind, enabled bool, err error) *fakeTracker {
+	return &fakeTracker{name: name, kind: kind, enabled: enabled, err: err, progress: make(map[int]int)}
+}
+
+func (t *fakeTracker) GetName() string     { return t.name }
+func (t *fakeTracker) GetLogo() string     { return "" }
+func (t *fakeTracker) GetUsername() string { return "" }
+func (t *fakeTracker) GetPassword() string { return "" }
+func (t *fakeTracker) GetKind() Kind       { return t.kind }
+func (t *fakeTracker) IsEnabled() bool     { return t.enabled }
+
+func (t *fakeTracker) UpdateProgress(mediaId int, _ Kind, progress int, _ *int) error {
+	if t.block != nil {
+		<-t.block
+	}
+	if t.err != nil {
+		return t.err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress[mediaId] = progress
+	return nil
+}
+
+func TestRepository_UpdateProgress(t *testing.T) {
+	primary := newFakeTracker("AniList", AnimeAndMangaKind, true, nil)
+	mal := newFakeTracker("MyAnimeList", AnimeAndMangaKind, true, errors.New("unauthorized"))
+	animeOnly := newFakeTracker("Anime only", AnimeKind, true, nil)
+	disabled := newFakeTracker("Disabled", AnimeAndMangaKind, false, nil)
+
+	repo := NewRepository(&NewRepositoryOptions{
+		Logger:    util.NewLogger(),
+		Primary:   primary,
+		Secondary: []BaseTracker{mal, animeOnly, disabled},
+	})
+
+	// A failing secondary tracker does not affect the primary tracker
+	result, err := repo.UpdateProgress(1, AnimeKind, 5, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &UpdateResult{Tracker: "AniList", Primary: true, Success: true}, result)
+
+	repo.WaitForPendingUpdates()
+
+	assert.Equal(t, 5, primary.progress[1])
+	assert.Equal(t, 5, animeOnly.progress[1])
+	assert.Empty(t, disabled.progress)
+
+	// Manga is not sent to anime-only trackers
+	_, err = repo.UpdateProgress(2, MangaKind, 10, nil)
+	require.NoError(t, err)
+	repo.WaitForPendingUpdates()
+	assert.NotContains(t, animeOnly.progress, 2)
+}
+
+func TestRepository_UpdateProgressPrimaryFailure(t *testing.T) {
+	primary := newFakeTracker("AniList", AnimeAndMangaKind, true, errors.New("rate limited"))
+	secondary := newFakeTracker("MyAnimeList", AnimeAndMangaKind, true, nil)
+
+	repo := NewRepository(&NewRepositoryOptions{
+		Logger:  util.NewLogger(),
+		Primary: primary,
+	})
+	repo.AddTracker(secondary)
+
+	result, err := repo.UpdateProgress(1, AnimeKind, 3, nil)
+	require.Error(t, err)
+	assert.False(t, result.Success)
+
+	repo.WaitForPendingUpdates()
+	assert.Equal(t, 3, secondary.progress[1])
+}
+
+func TestRepository_UpdateProgressDoesNotWaitForSecondary(t *testing.T) {
+	primary := newFakeTracker("AniList", AnimeAndMangaKind, true, nil)
+	slow := newFakeTracker("MyAnimeList", AnimeAndMangaKind, true, nil)
+	slow.block = make(chan struct{})
+
+	repo := NewRepository(&NewRepositoryOptions{
+		Logger:    util.NewLogger(),
+		Primary:   primary,
+		Secondary: []BaseTracker{slow},
+	})
+
+	// The primary update returns while the secondary tracker is still being updated
+	result, err := repo.UpdateProgress(1, AnimeKind, 7, nil)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 7, primary.progress[1])
+
+	slow.mu.Lock()
+	assert.Empty(t, slow.progress)
+	slow.mu.Unlock()
+
+	close(slow.block)
+	repo.WaitForPendingUpdates()
+	assert.Equal(t, 7, slow.progress[1])
+}
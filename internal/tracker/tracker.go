@@ -1,9 +1,9 @@
 package tracker
 
 const (
-	AnimeKind         = "anime"
-	MangaKind         = "manga"
-	AnimeAndMangaKind = "anime_and_manga"
+	AnimeKind         Kind = "anime"
+	MangaKind         Kind = "manga"
+	AnimeAndMangaKind Kind = "anime_and_manga"
 )
 
 type (
@@ -16,5 +16,14 @@ type (
 		GetUsername() string
 		GetPassword() string
 		GetKind() Kind
+		// IsEnabled returns true if progress can be pushed to the tracker, e.g. the user is logged in
+		IsEnabled() bool
+		// UpdateProgress updates the progress of an entry, mediaId is the AniList ID of the media
+		UpdateProgress(mediaId int, kind Kind, progress int, total *int) error
 	}
 )
+
+// SupportsKind returns true if the tracker can track the given kind of media.
+func SupportsKind(t BaseTracker, kind Kind) bool {
+	return t.GetKind() == AnimeAndMangaKind || t.GetKind() == kind
+}
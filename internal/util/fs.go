@@ -3,6 +3,7 @@ package util
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 )
 
@@ -30,3 +31,23 @@ func IsValidVideoExtension(ext string) bool {
 	_, exists := validExtensions[ext]
 	return exists
 }
+
+// FoldPathCase returns the path with forward slashes, in lower case on Windows and macOS.
+// Case is only folded on Windows and macOS, whose file systems are case-insensitive by default,
+// on other systems two files can have paths that only differ in case.
+func FoldPathCase(path string) string {
+	path = filepath.ToSlash(path)
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+// IsSubdirectory returns true if the path is the directory itself or is inside it.
+// The comparison is case-insensitive and ignores the separator style.
+func IsSubdirectory(dir string, path string) bool {
+	dir = strings.ToLower(strings.TrimSuffix(filepath.ToSlash(filepath.Clean(dir)), "/"))
+	path = strings.ToLower(filepath.ToSlash(filepath.Clean(path)))
+	return path == dir || strings.HasPrefix(path, dir+"/")
+}
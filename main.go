@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"os"
 	"seanime/internal/server"
 )
 
@@ -12,5 +13,5 @@ var WebFS embed.FS
 var embeddedLogo []byte
 
 func main() {
-	server.StartServer(WebFS, embeddedLogo)
+	os.Exit(server.StartServer(WebFS, embeddedLogo))
 }
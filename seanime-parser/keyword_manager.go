@@ -332,7 +332,7 @@ func newKeywordManager() *keywordManager {
 		[]string{"BD", "ASF", "BDRIP", "BLURAY", "BLU-RAY", "DVD", "DVD5", "DVD9",
 			"DVD-R2J", "DVDRIP", "DVD-RIP", "R2DVD", "R2J", "R2JDVD",
 			"R2JDVDRIP", "HDTV", "HDTVRIP", "TVRIP", "TV-RIP",
-			"WEBCAST", "WEBRIP"},
+			"WEBCAST", "WEBRIP", "WEBDL", "WEB-DL", "SDTV"},
 	)
 
 	km.addGroupParts(
@@ -345,6 +345,8 @@ func newKeywordManager() *keywordManager {
 			{prefix: "DVD", seqParts: []string{" ", "RIP"}},
 			{prefix: "TV", seqParts: []string{"-", "RIP"}},
 			{prefix: "TV", seqParts: []string{" ", "RIP"}},
+			{prefix: "WEB", seqParts: []string{"-", "DL"}},
+			{prefix: "WEB", seqParts: []string{"-", "RIP"}},
 		},
 	)
 
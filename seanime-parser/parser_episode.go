@@ -8,6 +8,10 @@ import (
 
 func (p *parser) parseEpisode() {
 
+	// Check absolute episode number next to a season and episode number
+	// e.g. 105 (S05E01), S05E01 - 105 - Episode Title
+	p.parseDualEpisodeNumber()
+
 	// Check alt episode number or range
 	// e.g. 01 (12)
 	if found := p.parseKnownEpisodeAltNumber(); found {
@@ -136,6 +140,83 @@ func (p *parser) parseKnownEpisodeAltNumber() (foundEpisode bool) {
 	return
 }
 
+// +---------------------+
+// |   Dual numbering    |
+// +---------------------+
+// e.g. 105 (S05E01), S05E01 - 105 - Episode Title
+
+// parseDualEpisodeNumber parses the absolute episode number written next to a season and episode number (e.g. Plex, Sonarr).
+//   - "Title - 105 (S05E01)": The absolute number is the episode number, the season episode number becomes the alt number.
+//   - "Title - S05E01 - 105 - Episode Title": The absolute number becomes the alt number.
+func (p *parser) parseDualEpisodeNumber() bool {
+	found, epTkns := p.tokenManager.tokens.findWithMetadataCategory(metadataEpisodeNumber)
+	if !found {
+		return false
+	}
+
+	first, last := epTkns[0], epTkns[len(epTkns)-1]
+
+	// Make sure the episode number comes from a season and episode pattern
+	// e.g. S05E01, 05x01
+	prefixTkn, found := p.tokenManager.tokens.getTokenBefore(first)
+	if !found || !prefixTkn.isKeywordCategory(keywordCatEpisodePrefix) {
+		return false
+	}
+	startTkn, found := p.tokenManager.tokens.getTokenBefore(prefixTkn)
+	if !found || !startTkn.isMetadataCategory(metadataSeason) {
+		return false
+	}
+	if seasonPrefixTkn, found := p.tokenManager.tokens.getTokenBefore(startTkn); found && seasonPrefixTkn.isKeywordCategory(keywordCatSeasonPrefix) {
+		startTkn = seasonPrefixTkn
+	}
+
+	// e.g. 105 (S05E01)
+	if openingBracketTkn, found := p.tokenManager.tokens.getTokenBefore(startTkn); found && openingBracketTkn.getValue() == "(" {
+		if numTkn, found, _ := p.tokenManager.tokens.getTokenBeforeSD(openingBracketTkn); found && isAbsoluteEpisodeNumber(numTkn, first) {
+			for _, tkn := range epTkns {
+				tkn.setMetadataCategory(metadataEpisodeNumberAlt)
+			}
+			numTkn.setMetadataCategory(metadataEpisodeNumber)
+			return true
+		}
+		return false
+	}
+
+	// e.g. S05E01 - 105 - Episode Title, S05E01 - 105 [1080p]
+	// The dash should be surrounded by delimiters, "S05E01-05" is a range
+	nextTkns, found, dlSkipped := p.tokenManager.tokens.getCategorySequenceAfter(p.tokenManager.tokens.getIndexOf(last), []tokenCategory{
+		tokenCatSeparator, // -
+		tokenCatUnknown,   // 105
+	}, true)
+	if !found || dlSkipped == 0 || !nextTkns[0].isDashSeparator() || !isAbsoluteEpisodeNumber(nextTkns[1], last) {
+		return false
+	}
+	// The number should not be the start of the episode title, e.g. "S01E01 - 3 Days Later"
+	if afterTkn, found, _ := p.tokenManager.tokens.getTokenAfterSD(nextTkns[1]); found &&
+		!afterTkn.isDashSeparator() && !afterTkn.isOpeningBracket() && !afterTkn.isKeyword() && !afterTkn.isMetadataCategory(metadataFileExtension) {
+		return false
+	}
+
+	nextTkns[1].setMetadataCategory(metadataEpisodeNumberAlt)
+	return true
+}
+
+// isAbsoluteEpisodeNumber checks if the token can be the absolute number of the episode number token.
+// The absolute number cannot be lower than the season episode number.
+func isAbsoluteEpisodeNumber(tkn *token, episodeTkn *token) bool {
+	if !tkn.isUnknown() || !tkn.isNumberKind() || tkn.isYear() {
+		return false
+	}
+	absolute, err := strconv.Atoi(tkn.getValue())
+	if err != nil {
+		return false
+	}
+	if episode, err := strconv.Atoi(episodeTkn.getValue()); err == nil && absolute < episode {
+		return false
+	}
+	return true
+}
+
 // ---------------------------------------------------------------------------------------------------------------------
 // Searching by patterns
 // ---------------------------------------------------------------------------------------------------------------------
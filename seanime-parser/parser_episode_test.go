@@ -82,3 +82,38 @@ func TestOtherEpisodes(t *testing.T) {
 	}
 
 }
+
+func TestDualEpisodeNumbers(t *testing.T) {
+
+	tests := []struct {
+		input       string
+		episodes    []string
+		altEpisodes []string
+		debug       bool
+	}{
+		{"Show - 105 (S05E01)", []string{"105"}, []string{"01"}, false},
+		{"Show - 105 (S05E01) - Episode Title [HDTV-720p].mkv", []string{"105"}, []string{"01"}, false},
+		{"Show - S05E01 (105) - Episode Title.mkv", []string{"01"}, []string{"105"}, false},
+		{"Show - S05E01 - 105 - Episode Title.mkv", []string{"01"}, []string{"105"}, false},
+		{"Show - S05E01 - 105 [Bluray-1080p].mkv", []string{"01"}, []string{"105"}, false},
+		{"Show - S05E01 - 105.mkv", []string{"01"}, []string{"105"}, false},
+		{"Show - S01E01 - 3 Days Later.mkv", []string{"01"}, []string{""}, false},
+		{"Show - S02E05 - 01 [1080p].mkv", []string{"05"}, []string{""}, false},
+		{"[Trix] Shingeki no Kyojin - S04E29-31 (Part 3) [Multi Subs] (1080p AV1 E-AC3)", []string{"29", "31"}, []string{""}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			p := newParser(tt.input)
+			p.parse()
+
+			assertMetadataExists(t, p, metadataEpisodeNumber, tt.episodes)
+			assertMetadataExists(t, p, metadataEpisodeNumberAlt, tt.altEpisodes)
+
+			if tt.debug {
+				t.Log(p.tokenManager.tokens.Sdump())
+			}
+		})
+	}
+
+}
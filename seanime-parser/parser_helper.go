@@ -37,3 +37,16 @@ func extractSeasonAndEpisode(input string) (season string, separator string, epi
 
 	return
 }
+
+// extractPrefixedEpisode extracts the episode number from a string like "E02" or "EP02".
+func extractPrefixedEpisode(input string) (prefix string, episode string, ok bool) {
+	re := regexp.MustCompile(`(?i)^(ep?)(\d+)$`)
+
+	captures := re.FindStringSubmatch(input)
+
+	if captures == nil {
+		return "", "", false
+	}
+
+	return captures[1], captures[2], true
+}
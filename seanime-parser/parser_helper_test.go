@@ -30,3 +30,27 @@ func TestExtractSeasonAndEpisode(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractPrefixedEpisode(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedPrefix  string
+		expectedEpisode string
+		expectedOK      bool
+	}{
+		{"E02", "E", "02", true},
+		{"ep12", "ep", "12", true},
+		{"E", "", "", false},
+		{"Episode", "", "", false},
+		{"E02v2", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			prefix, episode, ok := extractPrefixedEpisode(tt.input)
+			if prefix != tt.expectedPrefix || episode != tt.expectedEpisode || ok != tt.expectedOK {
+				t.Errorf("got %v %v %v, want %v %v %v", prefix, episode, ok, tt.expectedPrefix, tt.expectedEpisode, tt.expectedOK)
+			}
+		})
+	}
+}
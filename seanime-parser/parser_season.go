@@ -42,6 +42,12 @@ func (p *parser) parseSeason() {
 
 				p.tokenManager.tokens.overwriteAndInsertManyAt(p.tokenManager.tokens.getIndexOf(tkn), []*token{seasonPrefixTkn, seasonTkn, sepTkn, episodeTkn})
 
+				// Check multi-episode
+				// e.g. S01E01-E02
+				if p.parseEpisodeRangeWithPrefix(episodeTkn) {
+					continue // Skip to next token
+				}
+
 				episodeIsZeroPadded := isNumberZeroPadded(episodeTkn.getValue())
 
 				// Check range
@@ -241,6 +247,35 @@ func (p *parser) parseSeason() {
 
 }
 
+// parseEpisodeRangeWithPrefix checks if the episode number is followed by a prefixed episode number.
+// e.g. S01E01-E02, S01E01-EP02
+func (p *parser) parseEpisodeRangeWithPrefix(episodeTkn *token) bool {
+
+	rangeTkns, found, _ := p.tokenManager.tokens.getCategorySequenceAfter(p.tokenManager.tokens.getIndexOf(episodeTkn), []tokenCategory{
+		tokenCatSeparator, // -
+		tokenCatUnknown,   // E02
+	}, false)
+	if !found || !rangeTkns[0].isDashSeparator() {
+		return false
+	}
+
+	prefix, episode, ok := extractPrefixedEpisode(rangeTkns[1].getValue())
+	if !ok {
+		return false
+	}
+
+	prefixTkn := newToken(prefix)
+	prefixTkn.setIdentifiedKeywordCategory(keywordCatEpisodePrefix, keywordKindCombinedWithNumber)
+	prefixTkn.setKind(tokenKindCharacter)
+
+	nextEpisodeTkn := newToken(episode)
+	nextEpisodeTkn.setMetadataCategory(metadataEpisodeNumber)
+	nextEpisodeTkn.setKind(tokenKindNumber)
+
+	p.tokenManager.tokens.overwriteAndInsertManyAt(p.tokenManager.tokens.getIndexOf(rangeTkns[1]), []*token{prefixTkn, nextEpisodeTkn})
+	return true
+}
+
 func checkNumberRangeAfterToken(p *parser, tkn *token, prevNumberIsPadded bool) (*token, bool, int) {
 
 	var nextNumTkn *token
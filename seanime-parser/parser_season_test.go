@@ -36,6 +36,10 @@ func TestSeasonAndEpisode(t *testing.T) {
 
 		{"[Seanime] Jujutsu Kaisen Season 01 - 12.mkv", &[]string{"01"}, &[]string{"12"}, false},
 
+		// Multi-episode
+		{"Show (2019) - S02E05-E06 - Episode Title [Bluray-1080p].mkv", &[]string{"02"}, &[]string{"05", "06"}, false},
+		{"Show (2019) - S02E05-EP06.mkv", &[]string{"02"}, &[]string{"05", "06"}, false},
+
 		// Season 1 to 3
 		{"[Seanime] Jujutsu Kaisen Seasons 1 ~ 3.mkv", &[]string{"1", "3"}, nil, false},
 		{"[Seanime] Jujutsu Kaisen Seasons 01-03.mkv", &[]string{"01", "03"}, nil, false},
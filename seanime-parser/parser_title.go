@@ -24,6 +24,14 @@ func (p *parser) parseEpisodeTitle() {
 		lastEpTkn = epTkns[len(epTkns)-1]
 	}
 
+	// Start after the alt episode number if it comes after the episode number
+	// e.g. "105 (S05E01) - Episode Title"
+	if found, altTkns := p.tokenManager.tokens.findWithMetadataCategory(metadataEpisodeNumberAlt); found {
+		if lastAltTkn := altTkns[len(altTkns)-1]; p.tokenManager.tokens.getIndexOf(lastAltTkn) > p.tokenManager.tokens.getIndexOf(lastEpTkn) {
+			lastEpTkn = lastAltTkn
+		}
+	}
+
 	// Get all unknown tokens between the last episode number token and an opening bracket/file info metadata/EOF
 	// e.g. "... `01` -> "episode title" -| `[` ... ]
 	tkns, found := p.tokenManager.tokens.walkAndCollecIf(
@@ -323,6 +331,37 @@ func (p *parser) parseReleaseGroup() {
 		return
 	}
 
+	// Handle release group after a dash at the end of the filename (e.g. Sonarr, Radarr)
+	// e.g. "... [1080p][x264]-RlsGrp.mkv"
+	for {
+		lastTkn, found := p.tokenManager.tokens.getAtSafe(len(*p.tokenManager.tokens) - 1)
+		if !found {
+			break // Next try
+		}
+		if lastTkn.isMetadataCategory(metadataFileExtension) {
+			if lastTkn, found, _ = p.tokenManager.tokens.getTokenBeforeSD(lastTkn); !found {
+				break // Next try
+			}
+		}
+		if !lastTkn.isUnknown() || lastTkn.isKeyword() || lastTkn.isSeparator() || lastTkn.isEnclosed() {
+			break // Next try
+		}
+
+		// e.g. "]-RlsGrp"
+		dashTkn, found := p.tokenManager.tokens.getTokenBefore(lastTkn)
+		if !found || !dashTkn.isDashSeparator() {
+			break // Next try
+		}
+		closingBracketTkn, found := p.tokenManager.tokens.getTokenBefore(dashTkn)
+		if !found || !closingBracketTkn.isClosingBracket() {
+			break // Next try
+		}
+
+		// Found release group
+		lastTkn.setMetadataCategory(metadataReleaseGroup)
+		return
+	}
+
 	// If we still haven't found a release group, try to find:
 	// - the first enclosed group of unknown tokens going backwards
 	for {